@@ -244,7 +244,7 @@ version = "$fullVersion"
     Write-Host "Building filesystem-mcp version $fullVersion..." -ForegroundColor Yellow
     
     # Build the MCP server with version info
-    go build -ldflags "-X main.Version=$fullVersion" -o $output .
+    go build -ldflags "-X github.com/bobmcallan/mcp-filesystem-server/filesystemserver.Version=$fullVersion" -o $output .
     
     if ($LASTEXITCODE -ne 0) {
         Write-Error "Build failed with exit code $LASTEXITCODE"
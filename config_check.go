@@ -0,0 +1,572 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bobmcallan/mcp-filesystem-server/filesystemserver/handler"
+	"github.com/gobwas/glob"
+)
+
+// configReport collects the findings of a configuration check
+type configReport struct {
+	w        io.Writer
+	problems int
+}
+
+func (r *configReport) ok(format string, args ...any) {
+	fmt.Fprintf(r.w, "[ OK ]  "+format+"\n", args...)
+}
+
+func (r *configReport) warn(format string, args ...any) {
+	fmt.Fprintf(r.w, "[WARN]  "+format+"\n", args...)
+}
+
+func (r *configReport) fail(format string, args ...any) {
+	r.problems++
+	fmt.Fprintf(r.w, "[FAIL]  "+format+"\n", args...)
+}
+
+// runConfigCheck loads and validates config.toml, with the command-line
+// overrides applied, without starting the server, writing a report to w. It
+// returns the process exit code: 0 when the configuration is valid and 1
+// when any problem was found.
+func runConfigCheck(w io.Writer, overrides configOverrides) int {
+	report := &configReport{w: w}
+
+	configPath, err := configFilePath(overrides)
+	if err != nil {
+		report.fail("%v", err)
+		return 1
+	}
+	fmt.Fprintf(w, "Checking configuration: %s\n\n", configPath)
+
+	config, err := loadConfig(overrides)
+	if err != nil {
+		report.fail("%v", err)
+		fmt.Fprintf(w, "\nConfiguration is invalid (%d problem(s))\n", report.problems)
+		return 1
+	}
+
+	if _, err := os.Stat(configPath); errors.Is(err, fs.ErrNotExist) {
+		report.warn("config file not found, built-in defaults would be used")
+	} else {
+		report.ok("config file parsed")
+
+		// Report keys that were not recognised, which usually indicates a typo
+		md, _ := toml.DecodeFile(configPath, &Config{})
+		for _, key := range md.Undecoded() {
+			report.warn("unknown key %q is ignored", key.String())
+		}
+	}
+
+	checkDirectories(report, config.Directories)
+	checkLogging(report, config)
+	checkCache(report, config.Cache)
+	checkCompression(report, config.Compression)
+	checkLimits(report, config.Limits)
+	checkAudit(report, config)
+	checkHealth(report, config.Health)
+	checkIndex(report, config)
+	checkServer(report, config.Server)
+	checkReload(report, config.Reload)
+	checkTools(report, config.Tools)
+
+	fmt.Fprintln(w)
+	if report.problems > 0 {
+		fmt.Fprintf(w, "Configuration is invalid (%d problem(s))\n", report.problems)
+		return 1
+	}
+	fmt.Fprintln(w, "Configuration is valid")
+	return 0
+}
+
+// checkDirectories verifies that the allowed directories exist and are directories
+func checkDirectories(report *configReport, dirs DirectoriesConfig) {
+	if len(dirs.Allowed) == 0 {
+		report.fail("directories.allowed is empty")
+		return
+	}
+
+	accepted := 0
+	for _, dir := range dirs.Allowed {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			report.fail("allowed directory %s: %v", dir, err)
+			continue
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			// The server skips inaccessible directories rather than failing
+			report.warn("allowed directory %s will be skipped: %v", abs, err)
+			continue
+		}
+		if !info.IsDir() {
+			report.fail("allowed directory %s is not a directory", abs)
+			continue
+		}
+		report.ok("allowed directory %s", abs)
+		accepted++
+	}
+	if accepted == 0 {
+		report.fail("none of the allowed directories is accessible")
+	}
+
+	if dirs.Templates != "" {
+		checkConfiguredDir(report, dirs, "templates", dirs.Templates, true)
+	}
+	if dirs.Trash != "" {
+		checkConfiguredDir(report, dirs, "trash", dirs.Trash, false)
+	}
+	if dirs.Backups != "" {
+		checkConfiguredDir(report, dirs, "backups", dirs.Backups, false)
+	}
+	if len(dirs.Aliases) > 0 {
+		checkAliases(report, dirs)
+	}
+	if len(dirs.Quotas) > 0 {
+		checkQuotas(report, dirs)
+	}
+	if len(dirs.Access) > 0 {
+		checkAccessModes(report, dirs)
+	}
+
+	if dirs.RootRelativePaths {
+		if len(dirs.Allowed) != 1 {
+			report.fail("directories.root_relative_paths requires exactly one allowed directory, got %d", len(dirs.Allowed))
+		} else {
+			report.ok("root-relative paths enabled")
+		}
+	}
+	if dirs.RelativePaths && dirs.RootRelativePaths {
+		report.warn("directories.relative_paths has no effect together with root_relative_paths")
+	}
+	if dirs.ReadOnly {
+		report.ok("read-only mode: tools that change the filesystem are turned off")
+	}
+	if dirs.RespectGitignore {
+		report.ok("walks leave out files excluded by .gitignore and .ignore files")
+	}
+	for _, pattern := range dirs.Denied {
+		if _, err := glob.Compile(pattern, '/'); err != nil {
+			report.fail("directories.denied: invalid pattern %q: %v", pattern, err)
+		} else {
+			report.ok("denied: %s", pattern)
+		}
+	}
+	switch handler.SymlinkPolicy(dirs.SymlinkPolicy) {
+	case "", handler.SymlinkMetadata:
+		report.ok("symlinks leading outside the allowed directories can be inspected but are not followed")
+	case handler.SymlinkReject:
+		report.ok("symlinks leading outside the allowed directories are hidden")
+	case handler.SymlinkFollow:
+		report.warn("symlinks leading outside the allowed directories are followed, exposing their targets")
+	default:
+		report.fail("directories.symlink_policy: unknown policy %q (expected metadata, reject or follow)", dirs.SymlinkPolicy)
+	}
+}
+
+// checkConfiguredDir verifies that a directory named in the configuration
+// lies within one of the allowed directories. A directory that must not exist
+// yet is only checked to not be something else.
+func checkConfiguredDir(report *configReport, dirs DirectoriesConfig, name, path string, mustExist bool) {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		if real, evalErr := filepath.EvalSymlinks(abs); evalErr == nil {
+			abs = real
+		} else if mustExist || !os.IsNotExist(evalErr) {
+			err = evalErr
+		}
+	}
+	if err != nil {
+		report.fail("%s directory %s: %v", name, path, err)
+		return
+	}
+	if info, err := os.Stat(abs); (err != nil && mustExist) || (err == nil && !info.IsDir()) {
+		report.fail("%s directory %s is not a directory", name, abs)
+		return
+	}
+	for _, dir := range dirs.Allowed {
+		allowed, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if real, err := filepath.EvalSymlinks(allowed); err == nil {
+			allowed = real
+		}
+		if rel, err := filepath.Rel(allowed, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			report.ok("%s directory %s", name, abs)
+			return
+		}
+	}
+	report.fail("%s directory %s is outside the allowed directories", name, abs)
+}
+
+// checkAliases verifies that every alias has a valid name and names one of
+// the allowed directories
+func checkAliases(report *configReport, dirs DirectoriesConfig) {
+	allowed := make(map[string]bool, len(dirs.Allowed))
+	for _, dir := range dirs.Allowed {
+		if abs, err := filepath.Abs(dir); err == nil {
+			allowed[filepath.Clean(abs)] = true
+		}
+	}
+	for name, dir := range dirs.Aliases {
+		abs, err := filepath.Abs(dir)
+		switch {
+		case name == "" || strings.ContainsAny(name, `/\@`):
+			report.fail("alias %q must be non-empty and not contain /, \\ or @", name)
+		case err != nil || !allowed[filepath.Clean(abs)]:
+			report.fail("alias @%s names %s, which is not an allowed directory", name, dir)
+		default:
+			report.ok("alias @%s for %s", name, abs)
+		}
+	}
+}
+
+// checkQuotas verifies that every quota is positive and applies to an
+// allowed directory or an alias
+func checkQuotas(report *configReport, dirs DirectoriesConfig) {
+	allowed := make(map[string]bool, len(dirs.Allowed))
+	for _, dir := range dirs.Allowed {
+		if abs, err := filepath.Abs(dir); err == nil {
+			allowed[filepath.Clean(abs)] = true
+		}
+	}
+	for key, limit := range dirs.Quotas {
+		abs, err := filepath.Abs(key)
+		_, isAlias := dirs.Aliases[strings.TrimPrefix(key, "@")]
+		switch {
+		case limit <= 0:
+			report.fail("quota for %s must be positive, got %d", key, limit)
+		case strings.HasPrefix(key, "@") && !isAlias:
+			report.fail("quota for unknown alias %s", key)
+		case !strings.HasPrefix(key, "@") && (err != nil || !allowed[filepath.Clean(abs)]):
+			report.fail("quota for %s, which is not an allowed directory", key)
+		default:
+			report.ok("quota of %d bytes for %s", limit, key)
+		}
+	}
+}
+
+// checkAccessModes verifies that the access modes name allowed directories
+// or aliases and are either ro or rw
+func checkAccessModes(report *configReport, dirs DirectoriesConfig) {
+	allowed := make(map[string]bool, len(dirs.Allowed))
+	for _, dir := range dirs.Allowed {
+		if abs, err := filepath.Abs(dir); err == nil {
+			allowed[filepath.Clean(abs)] = true
+		}
+	}
+	for key, mode := range dirs.Access {
+		abs, err := filepath.Abs(key)
+		_, isAlias := dirs.Aliases[strings.TrimPrefix(key, "@")]
+		switch {
+		case mode != "ro" && mode != "rw":
+			report.fail("access mode for %s must be \"ro\" or \"rw\", got %q", key, mode)
+		case strings.HasPrefix(key, "@") && !isAlias:
+			report.fail("access mode for unknown alias %s", key)
+		case !strings.HasPrefix(key, "@") && (err != nil || !allowed[filepath.Clean(abs)]):
+			report.fail("access mode for %s, which is not an allowed directory", key)
+		case mode == "rw" && dirs.ReadOnly:
+			report.warn("%s is marked rw, but read_only makes every directory read-only", key)
+		case mode == "ro":
+			report.ok("%s is read-only", key)
+		default:
+			report.ok("%s is writable", key)
+		}
+	}
+}
+
+// checkCache verifies the read cache settings
+func checkCache(report *configReport, cache CacheConfig) {
+	switch {
+	case cache.MaxBytes < 0:
+		report.fail("cache.max_bytes must not be negative, got %d", cache.MaxBytes)
+	case cache.MaxBytes == 0:
+		report.ok("read cache disabled")
+	default:
+		report.ok("read cache of %d bytes", cache.MaxBytes)
+	}
+}
+
+// checkCompression verifies the result compression threshold
+func checkCompression(report *configReport, compression CompressionConfig) {
+	switch {
+	case compression.MinBytes < 0:
+		report.fail("compression.min_bytes must not be negative, got %d", compression.MinBytes)
+	case compression.MinBytes == 0:
+		report.ok("result compression disabled")
+	default:
+		report.ok("results of %d bytes or more compressed on request", compression.MinBytes)
+	}
+}
+
+// checkLimits verifies the write rate limits
+func checkLimits(report *configReport, limits LimitsConfig) {
+	switch {
+	case limits.WritesPerMinute < 0:
+		report.fail("limits.writes_per_minute must not be negative, got %d", limits.WritesPerMinute)
+	case limits.WritesPerMinute == 0:
+		report.ok("write operations unlimited")
+	default:
+		report.ok("at most %d write operations per minute", limits.WritesPerMinute)
+	}
+
+	switch {
+	case limits.WriteBytesPerMinute < 0:
+		report.fail("limits.write_bytes_per_minute must not be negative, got %d", limits.WriteBytesPerMinute)
+	case limits.WriteBytesPerMinute == 0:
+		report.ok("bytes written unlimited")
+	default:
+		report.ok("at most %d bytes written per minute", limits.WriteBytesPerMinute)
+	}
+
+	var timeout time.Duration
+	var err error
+	if limits.OperationTimeout != "" {
+		timeout, err = time.ParseDuration(limits.OperationTimeout)
+	}
+	switch {
+	case err != nil || timeout < 0:
+		report.fail("limits.operation_timeout must be a duration such as \"10s\", got %q", limits.OperationTimeout)
+	case timeout == 0:
+		report.ok("filesystem operations have no timeout")
+	default:
+		report.ok("filesystem operations time out after %v", timeout)
+	}
+
+	var callLimit time.Duration
+	err = nil
+	if limits.MaxCallTimeout != "" {
+		callLimit, err = time.ParseDuration(limits.MaxCallTimeout)
+	}
+	switch {
+	case err != nil || callLimit < 0:
+		report.fail("limits.max_call_timeout must be a duration such as \"5m\", got %q", limits.MaxCallTimeout)
+	case callLimit == 0:
+		report.ok("tool calls may ask for a timeout_ms of up to the default %v", handler.DEFAULT_MAX_CALL_TIMEOUT)
+	default:
+		report.ok("tool calls may ask for a timeout_ms of up to %v", callLimit)
+	}
+
+	var ttl time.Duration
+	err = nil
+	if limits.ConfirmationTTL != "" {
+		ttl, err = time.ParseDuration(limits.ConfirmationTTL)
+	}
+	switch {
+	case err != nil || ttl < 0:
+		report.fail("limits.confirmation_ttl must be a duration such as \"5m\", got %q", limits.ConfirmationTTL)
+	case !limits.ConfirmDestructive:
+		report.ok("destructive tools run without confirmation")
+	case ttl == 0:
+		report.ok("destructive tools need confirmation within the default %v", handler.DEFAULT_CONFIRMATION_TTL)
+	default:
+		report.ok("destructive tools need confirmation within %v", ttl)
+	}
+
+	for _, setting := range []struct {
+		name     string
+		value    int
+		fallback int
+	}{
+		{"max_walk_depth", limits.MaxWalkDepth, handler.DEFAULT_MAX_WALK_DEPTH},
+		{"max_walk_entries", limits.MaxWalkEntries, handler.DEFAULT_MAX_WALK_ENTRIES},
+		{"max_concurrency", limits.MaxConcurrency, runtime.GOMAXPROCS(0)},
+		{"max_list_entries", limits.MaxListEntries, handler.MAX_SEARCH_RESULTS},
+		{"resource_budget", limits.ResourceBudget, handler.DEFAULT_RESOURCE_BUDGET},
+	} {
+		switch {
+		case setting.value < 0:
+			report.fail("limits.%s must not be negative, got %d", setting.name, setting.value)
+		case setting.value == 0:
+			report.ok("%s: default %d", setting.name, setting.fallback)
+		default:
+			report.ok("%s: %d", setting.name, setting.value)
+		}
+	}
+
+	for _, setting := range []struct{ name, value, fallback string }{
+		{"file_mode", limits.FileMode, "0644"},
+		{"dir_mode", limits.DirMode, "0755"},
+	} {
+		mode, err := parseFileMode(setting.value)
+		switch {
+		case err != nil:
+			report.fail("limits.%s: %v", setting.name, err)
+		case mode == 0:
+			report.ok("%s: default %s", setting.name, setting.fallback)
+		default:
+			report.ok("%s: %04o", setting.name, mode)
+		}
+	}
+}
+
+// checkAudit verifies the audit log settings and that its file is writable
+func checkAudit(report *configReport, config Config) {
+	if config.Audit.FilePath == "" {
+		report.ok("audit log disabled")
+		return
+	}
+
+	switch config.Audit.Format {
+	case "", "jsonl":
+		report.ok("audit log format \"jsonl\"")
+	default:
+		report.fail("unknown audit log format %q (expected jsonl)", config.Audit.Format)
+	}
+
+	auditPath, err := auditFilePath(config)
+	if err != nil {
+		report.fail("audit log: %v", err)
+		return
+	}
+	if err := checkWritable(auditPath); err != nil {
+		report.fail("audit log %s is not writable: %v", auditPath, err)
+		return
+	}
+	report.ok("audit log %s is writable", auditPath)
+}
+
+// checkHealth verifies the interval of the root health check
+func checkHealth(report *configReport, health HealthConfig) {
+	if health.CheckInterval == "" {
+		report.ok("root health check disabled")
+		return
+	}
+	interval, err := time.ParseDuration(health.CheckInterval)
+	if err != nil || interval <= 0 {
+		report.fail("health.check_interval must be a positive duration such as \"30s\", got %q", health.CheckInterval)
+		return
+	}
+	report.ok("allowed directories are checked every %v", interval)
+}
+
+// checkIndex verifies the content index settings and that its file is
+// writable
+func checkIndex(report *configReport, config Config) {
+	if config.Index.FilePath == "" {
+		report.ok("content index disabled")
+		return
+	}
+	if config.Index.RefreshInterval != "" {
+		interval, err := time.ParseDuration(config.Index.RefreshInterval)
+		if err != nil || interval <= 0 {
+			report.fail("index.refresh_interval must be a positive duration such as \"5m\", got %q", config.Index.RefreshInterval)
+		}
+	}
+	indexPath, err := indexFilePath(config)
+	if err != nil {
+		report.fail("content index: %v", err)
+		return
+	}
+	if err := checkWritable(indexPath); err != nil {
+		report.fail("content index %s is not writable: %v", indexPath, err)
+		return
+	}
+	report.ok("content index %s is writable", indexPath)
+}
+
+// checkServer verifies the transport settings
+func checkServer(report *configReport, server ServerConfig) {
+	server = withTransportDefaults(server)
+	if err := validateTransport(server); err != nil {
+		report.fail("%v", err)
+		return
+	}
+	if server.Transport != "stdio" && !isLoopbackAddress(server.Address) {
+		report.warn("server.address %s accepts remote clients, who can use every allowed directory; the transport has no authentication of its own", server.Address)
+	}
+	if slices.Contains(server.AllowedOrigins, "*") {
+		report.warn("server.allowed_origins lets web pages on any site call the server")
+	}
+	switch server.Transport {
+	case "http":
+		report.ok("serving Streamable HTTP at %s%s", server.Address, server.BasePath)
+		return
+	case "sse":
+		report.ok("serving SSE at %s%s/sse", server.Address, strings.TrimSuffix(server.BasePath, "/"))
+		return
+	}
+	report.ok("serving over stdio")
+}
+
+// checkReload verifies the interval of the config file watch
+func checkReload(report *configReport, reload ReloadConfig) {
+	if reload.WatchInterval == "" {
+		report.ok("config file is reloaded on SIGHUP and reload_config only")
+		return
+	}
+	interval, err := time.ParseDuration(reload.WatchInterval)
+	if err != nil || interval <= 0 {
+		report.fail("reload.watch_interval must be a positive duration such as \"5s\", got %q", reload.WatchInterval)
+		return
+	}
+	report.ok("config file is checked for changes every %v", interval)
+}
+
+// checkTools reports the disabled tools
+func checkTools(report *configReport, tools ToolsConfig) {
+	if len(tools.Disabled) == 0 {
+		report.ok("all tools enabled")
+		return
+	}
+	report.ok("disabled tools: %s", strings.Join(tools.Disabled, ", "))
+}
+
+// checkLogging verifies the logging settings and that the log file is writable
+func checkLogging(report *configReport, config Config) {
+	switch config.Logging.Level {
+	case "debug", "info", "warn", "error":
+		report.ok("log level %q", config.Logging.Level)
+	default:
+		report.fail("unknown log level %q (expected debug, info, warn or error)", config.Logging.Level)
+	}
+
+	switch config.Logging.Format {
+	case "json", "text", "logfmt":
+		report.ok("log format %q", config.Logging.Format)
+	default:
+		report.fail("unknown log format %q (expected json, text or logfmt)", config.Logging.Format)
+	}
+
+	logPath, err := logFilePath(config)
+	if err != nil {
+		report.fail("log file: %v", err)
+		return
+	}
+	if err := checkWritable(logPath); err != nil {
+		report.fail("log file %s is not writable: %v", logPath, err)
+		return
+	}
+	report.ok("log file %s is writable", logPath)
+}
+
+// checkWritable verifies that path can be opened for appending, without
+// leaving a new file behind if it does not exist yet
+func checkWritable(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
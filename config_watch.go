@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// watchConfigFile calls reload whenever the file at path changes, comparing
+// its size and modification time every interval until ctx is done. While
+// the file is missing nothing is reloaded; it counts as changed once it is
+// back.
+func watchConfigFile(ctx context.Context, path string, interval time.Duration, reload func()) {
+	last, _ := os.Stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			last = nil
+			continue
+		}
+		if last != nil && info.Size() == last.Size() && info.ModTime().Equal(last.ModTime()) {
+			continue
+		}
+		last = info
+		reload()
+	}
+}
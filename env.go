@@ -0,0 +1,43 @@
+package main
+
+import "path/filepath"
+
+// overridesFromEnv reads the MCP_FS_* environment variables, which override
+// config.toml like the matching flags do, for containers and clients that
+// cannot place a config file beside the binary. MCP_FS_ALLOWED_DIRS lists
+// directories separated like PATH, by ':' (';' on Windows).
+func overridesFromEnv(getenv func(string) string) configOverrides {
+	return configOverrides{
+		configPath:  getenv("MCP_FS_CONFIG"),
+		allowedDirs: filepath.SplitList(getenv("MCP_FS_ALLOWED_DIRS")),
+		logLevel:    getenv("MCP_FS_LOG_LEVEL"),
+		logFormat:   getenv("MCP_FS_LOG_FORMAT"),
+		transport:   getenv("MCP_FS_TRANSPORT"),
+		address:     getenv("MCP_FS_ADDRESS"),
+		basePath:    getenv("MCP_FS_BASE_PATH"),
+	}
+}
+
+// over returns the settings of o, falling back to those of base where o
+// leaves one unset. Flags are laid over the environment this way.
+func (o configOverrides) over(base configOverrides) configOverrides {
+	pick := func(value, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+	merged := configOverrides{
+		configPath:  pick(o.configPath, base.configPath),
+		allowedDirs: o.allowedDirs,
+		logLevel:    pick(o.logLevel, base.logLevel),
+		logFormat:   pick(o.logFormat, base.logFormat),
+		transport:   pick(o.transport, base.transport),
+		address:     pick(o.address, base.address),
+		basePath:    pick(o.basePath, base.basePath),
+	}
+	if len(merged.allowedDirs) == 0 {
+		merged.allowedDirs = base.allowedDirs
+	}
+	return merged
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// WithRootAliases names allowed directories, so that tools accept paths such
+// as @docs/guide.md in place of the absolute path of the directory. Each
+// alias maps a name to one of the allowed directories.
+func WithRootAliases(aliases map[string]string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.aliases = aliases
+	}
+}
+
+// resolveAliases checks that every alias names one of the configured allowed
+// directories and replaces the directories with their normalized form. An
+// alias of an allowed directory that was skipped at startup is dropped with a
+// warning.
+func (fs *FilesystemHandler) resolveAliases(configured []string) error {
+	resolved := make(map[string]string, len(fs.aliases))
+	for name, dir := range fs.aliases {
+		if name == "" || strings.ContainsAny(name, `/\@`) {
+			return fmt.Errorf("invalid alias name %q: must be non-empty and not contain /, \\ or @", name)
+		}
+		root, err := fs.allowedDirFor(configured, dir)
+		if err != nil {
+			return fmt.Errorf("alias @%s: %w", name, err)
+		}
+		if root == "" {
+			fs.logger.Warn("Skipping alias of an inaccessible allowed directory", "alias", name, "path", dir)
+			continue
+		}
+		resolved[name] = root
+	}
+	fs.aliases = resolved
+	return nil
+}
+
+// allowedDirFor returns the normalized allowed directory, with its trailing
+// separator, that a configuration entry names. It returns an empty string
+// for one of the configured directories that was skipped as inaccessible,
+// and an error for a directory that is not allowed at all.
+func (fs *FilesystemHandler) allowedDirFor(configured []string, dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.Clean(abs) + string(filepath.Separator)
+
+	if slices.Contains(fs.allowedDirs, abs) {
+		return abs, nil
+	}
+	for _, allowed := range configured {
+		if allowedAbs, err := filepath.Abs(allowed); err == nil && filepath.Clean(allowedAbs)+string(filepath.Separator) == abs {
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("%s is not an allowed directory", dir)
+}
+
+// expandAlias replaces a leading @name of a client supplied path with the
+// allowed directory it names. Paths without a known alias are returned
+// unchanged and validated as usual.
+func (fs *FilesystemHandler) expandAlias(path string) string {
+	if !strings.HasPrefix(path, "@") || len(fs.aliases) == 0 {
+		return path
+	}
+	name, rest, _ := strings.Cut(path[1:], "/")
+	if i := strings.IndexByte(name, filepath.Separator); i >= 0 {
+		name, rest = name[:i], name[i+1:]+"/"+rest
+	}
+	dir, ok := fs.aliases[name]
+	if !ok {
+		return path
+	}
+	// Joining cleans the remainder, and validation rejects a result that
+	// escapes the allowed directories with ".."
+	return filepath.Join(dir, filepath.FromSlash(rest))
+}
+
+// aliasesFor returns the aliases of an allowed directory, sorted by name
+func (fs *FilesystemHandler) aliasesFor(dir string) []string {
+	dir = filepath.Clean(dir) + string(filepath.Separator)
+	var names []string
+	for name, aliased := range fs.aliases {
+		if aliased == dir {
+			names = append(names, "@"+name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
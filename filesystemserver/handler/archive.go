@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ARCHIVE_ENTRY_SEPARATOR joins the path of an archive and the name of an
+// entry inside it, as in docs.zip::guide/intro.md
+const ARCHIVE_ENTRY_SEPARATOR = "::"
+
+// isZipArchive reports whether name is a zip archive, judging by its
+// extension
+func isZipArchive(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".zip")
+}
+
+// archiveEntryPath returns the path reported for an entry of an archive
+func archiveEntryPath(archive, entry string) string {
+	return archive + ARCHIVE_ENTRY_SEPARATOR + entry
+}
+
+// archiveOf returns the archive part of a path made by archiveEntryPath, or
+// path itself if it does not name an archive entry
+func archiveOf(path string) string {
+	archive, _, _ := strings.Cut(path, ARCHIVE_ENTRY_SEPARATOR)
+	return archive
+}
+
+// walkZipArchive opens the zip archive at validPath read-only and calls fn
+// for each of its entries in archive order until fn returns false. Archives
+// nested inside it are passed to fn like any other entry and never opened,
+// so scanning stops one level down.
+func (fs *FilesystemHandler) walkZipArchive(validPath string, fn func(entry *zip.File) bool) error {
+	f, err := fs.fsys.Open(validPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	archive, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return fmt.Errorf("reading zip archive %s: %w", validPath, err)
+	}
+	for _, entry := range archive.File {
+		if !fn(entry) {
+			break
+		}
+	}
+	return nil
+}
+
+// readZipEntry returns the uncompressed contents of a zip entry. Entries
+// larger than limit are refused, whether their header says so or they only
+// turn out larger while being decompressed.
+func readZipEntry(entry *zip.File, limit int64) ([]byte, error) {
+	if entry.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%s is too large (%d bytes, maximum is %d bytes)", entry.Name, entry.UncompressedSize64, limit)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is too large (maximum is %d bytes)", entry.Name, limit)
+	}
+	return data, nil
+}
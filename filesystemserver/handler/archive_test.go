@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeZip creates a zip archive holding the given entries
+func writeZip(t *testing.T, path string, entries map[string][]byte) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestSearchArchives(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	nested := filepath.Join(t.TempDir(), "nested.zip")
+	writeZip(t, nested, map[string][]byte{"deep.txt": []byte("needle in the deep")})
+	nestedData, err := os.ReadFile(nested)
+	require.NoError(t, err)
+
+	archive := filepath.Join(dir, "data.zip")
+	writeZip(t, archive, map[string][]byte{
+		"docs/notes.txt": []byte("first line\nneedle here\n"),
+		"image.png":      []byte("\x89PNG\r\n\x1a\nneedle"),
+		"nested.zip":     nestedData,
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.txt"), []byte("needle on disk\n"), 0644))
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		args["path"] = dir
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text
+	}
+
+	t.Run("search_files", func(t *testing.T) {
+		text := call(handler.HandleSearchFiles, map[string]any{"pattern": "*.txt"})
+		assert.NotContains(t, text, "::")
+
+		text = call(handler.HandleSearchFiles, map[string]any{"pattern": "*.txt", "search_archives": true})
+		assert.Contains(t, text, "data.zip::docs/notes.txt")
+		assert.Contains(t, text, "plain.txt")
+		assert.NotContains(t, text, "deep.txt")
+
+		text = call(handler.HandleSearchFiles, map[string]any{"pattern": "*.zip", "search_archives": true})
+		assert.Contains(t, text, "data.zip::nested.zip")
+	})
+
+	t.Run("search_within_files", func(t *testing.T) {
+		text := call(handler.HandleSearchWithinFiles, map[string]any{"substring": "needle"})
+		assert.Contains(t, text, "Found 1 occurrences")
+
+		text = call(handler.HandleSearchWithinFiles, map[string]any{"substring": "needle", "search_archives": true})
+		assert.Contains(t, text, "Found 2 occurrences")
+		assert.Contains(t, text, "data.zip::docs/notes.txt")
+		assert.Contains(t, text, "  Line 2: needle here")
+		assert.NotContains(t, text, "image.png")
+		assert.NotContains(t, text, "deep")
+	})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditPathArgs are the tool arguments that name the files a call touches
+var auditPathArgs = []string{"path", "source", "destination", "link_path"}
+
+// WithAuditLog records every call of a mutating tool to w as one JSON object
+// per line, regardless of the application's log level
+func WithAuditLog(w io.Writer) Option {
+	return func(fs *FilesystemHandler) {
+		if w != nil {
+			fs.audit = &auditLog{w: w}
+		}
+	}
+}
+
+// auditRecord is a single line of the audit log
+type auditRecord struct {
+	Time    time.Time `json:"time"`
+	Tool    string    `json:"tool"`
+	Paths   []string  `json:"paths,omitempty"`
+	Bytes   *int64    `json:"bytes,omitempty"` // size of the content argument, if any
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Outcome string    `json:"outcome"` // "success" or "error"
+	Error   string    `json:"error,omitempty"`
+}
+
+// auditLog serializes audit records to an append-only writer
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *auditLog) write(record auditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(line)
+	return err
+}
+
+// AuditWrites wraps the handler of a mutating tool so that every call, and
+// its outcome, is recorded in the audit log. A call answered with a
+// confirmation_token changes nothing and is recorded once it is confirmed.
+func (fs *FilesystemHandler) AuditWrites(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if fs.audit == nil || fs.awaitsConfirmation(request) {
+			return next(ctx, request)
+		}
+
+		record := auditRecord{
+			Time:   time.Now().UTC(),
+			Tool:   request.Params.Name,
+			DryRun: isDryRun(request),
+		}
+		args := request.GetArguments()
+		for _, key := range auditPathArgs {
+			if p, ok := args[key].(string); ok && p != "" {
+				record.Paths = append(record.Paths, p)
+			}
+		}
+		if files, ok := args["files"].([]any); ok {
+			for _, file := range files {
+				if fields, ok := file.(map[string]any); ok {
+					if p, ok := fields["path"].(string); ok && p != "" {
+						record.Paths = append(record.Paths, p)
+					}
+				}
+			}
+		}
+		if size, ok := contentSize(args); ok {
+			record.Bytes = &size
+		}
+
+		result, err := next(ctx, request)
+
+		record.Outcome = "success"
+		switch {
+		case err != nil:
+			record.Outcome, record.Error = "error", err.Error()
+		case result != nil && result.IsError:
+			record.Outcome = "error"
+			if len(result.Content) > 0 {
+				if text, ok := result.Content[0].(mcp.TextContent); ok {
+					record.Error = text.Text
+				}
+			}
+		}
+		// A failing audit sink must not change the result of the call
+		_ = fs.audit.write(record)
+
+		return result, err
+	}
+}
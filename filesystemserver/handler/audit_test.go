@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditWrites(t *testing.T) {
+	dir := t.TempDir()
+	var log bytes.Buffer
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithAuditLog(&log))
+	require.NoError(t, err)
+
+	call := func(name string, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		_, err := handler.AuditWrites(handle)(context.Background(), request)
+		require.NoError(t, err)
+	}
+
+	src := filepath.Join(dir, "a.txt")
+	dst := filepath.Join(dir, "b.txt")
+	call("write_file", handler.HandleWriteFile, map[string]any{"path": src, "content": "hello"})
+	call("copy_file", handler.HandleCopyFile, map[string]any{"source": src, "destination": dst})
+	call("delete_file", handler.HandleDeleteFile, map[string]any{"path": filepath.Join(dir, "missing.txt")})
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var records []auditRecord
+	for _, line := range lines {
+		var record auditRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+
+	assert.Equal(t, "write_file", records[0].Tool)
+	assert.Equal(t, []string{src}, records[0].Paths)
+	require.NotNil(t, records[0].Bytes)
+	assert.Equal(t, int64(5), *records[0].Bytes)
+	assert.Equal(t, "success", records[0].Outcome)
+	assert.False(t, records[0].Time.IsZero())
+
+	assert.Equal(t, "copy_file", records[1].Tool)
+	assert.Equal(t, []string{src, dst}, records[1].Paths)
+	assert.Nil(t, records[1].Bytes)
+	assert.Equal(t, "success", records[1].Outcome)
+
+	assert.Equal(t, "delete_file", records[2].Tool)
+	assert.Equal(t, "error", records[2].Outcome)
+	assert.NotEmpty(t, records[2].Error)
+}
+
+func TestAuditWrites_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	var log bytes.Buffer
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithAuditLog(&log))
+	require.NoError(t, err)
+
+	call := func(name string, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) auditRecord {
+		log.Reset()
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		_, err := handler.AuditWrites(handle)(context.Background(), request)
+		require.NoError(t, err)
+		var record auditRecord
+		require.NoError(t, json.Unmarshal(log.Bytes(), &record))
+		return record
+	}
+
+	src := filepath.Join(dir, "a.txt")
+	// write_file ignores dry_run, so the write is not recorded as one
+	record := call("write_file", handler.HandleWriteFile, map[string]any{"path": src, "content": "hello", "dry_run": true})
+	assert.False(t, record.DryRun)
+
+	record = call("move_file", handler.HandleMoveFile, map[string]any{"source": src, "destination": filepath.Join(dir, "b.txt"), "dry_run": true})
+	assert.True(t, record.DryRun)
+}
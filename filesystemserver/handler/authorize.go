@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Operation is the kind of access a tool needs to a path, as passed to an
+// Authorizer
+type Operation string
+
+const (
+	// OpRead covers every path a tool resolves, including paths that are
+	// only listed, stat'ed or searched
+	OpRead Operation = "read"
+	// OpWrite covers paths that a tool creates or changes
+	OpWrite Operation = "write"
+	// OpDelete covers paths that a tool removes or moves away
+	OpDelete Operation = "delete"
+)
+
+// Authorizer decides whether a path may be accessed, on top of the
+// confinement to the allowed directories, which always applies first. The
+// path is absolute, with symbolic links resolved. Authorize returns nil to
+// allow the access; its error is reported to the client as the reason the
+// access was denied.
+type Authorizer interface {
+	Authorize(path string, op Operation) error
+}
+
+// AuthorizerFunc adapts an ordinary function to the Authorizer interface
+type AuthorizerFunc func(path string, op Operation) error
+
+// Authorize calls f(path, op)
+func (f AuthorizerFunc) Authorize(path string, op Operation) error {
+	return f(path, op)
+}
+
+// WithAuthorizer consults authorizer for every path the tools access, so
+// that embedders can enforce their own policies, such as keeping clients
+// away from files that follow a secrets naming convention. Every path is
+// authorized for OpRead when it is resolved, and paths that are about to be
+// changed or removed are authorized again for OpWrite or OpDelete. Without
+// an authorizer only the allowed directories are enforced.
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(fs *FilesystemHandler) {
+		fs.authorizer = authorizer
+	}
+}
+
+// authorize applies the deny list, the access modes of the allowed
+// directories and the configured Authorizer to a confined path
+func (fs *FilesystemHandler) authorize(path string, op Operation) error {
+	if err := fs.authorizeDenied(path); err != nil {
+		return err
+	}
+	if err := fs.authorizeRootAccess(path, op); err != nil {
+		return err
+	}
+	if fs.authorizer == nil {
+		return nil
+	}
+	if err := fs.authorizer.Authorize(path, op); err != nil {
+		return fmt.Errorf("access denied - %s: %w", fs.displayPath(path), err)
+	}
+	return nil
+}
+
+// authorizeTree authorizes every entry below dir for op and, if dest is set,
+// the path each entry takes below dest for OpWrite. Tools that copy, move or
+// remove a whole directory call it so that they cannot carry off or destroy
+// what the deny list, a nested read-only directory or the authorizer keeps
+// from clients. dir itself is authorized by the caller; a file has no
+// entries.
+func (fs *FilesystemHandler) authorizeTree(dir string, op Operation, dest string) error {
+	if len(fs.denied) == 0 && len(fs.readOnlyRoots) == 0 && fs.authorizer == nil {
+		return nil
+	}
+	if info, err := fs.fsys.Lstat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+	return walk(fs.fsys, dir, func(path string, info os.FileInfo, err error) error {
+		if path == dir {
+			return nil
+		}
+		if err := fs.authorize(path, op); err != nil {
+			return err
+		}
+		if dest != "" {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			return fs.authorize(filepath.Join(dest, rel), OpWrite)
+		}
+		return nil
+	})
+}
+
+// validatePath confines a requested path to the allowed directories, see
+// confinePath, and authorizes it for reading
+func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error) {
+	return fs.authorized(fs.confinePath(requestedPath))
+}
+
+// validatePathWithParents confines a path whose parent directories may not
+// exist yet, see confinePathWithParents, and authorizes it for reading
+func (fs *FilesystemHandler) validatePathWithParents(requestedPath string) (string, error) {
+	return fs.authorized(fs.confinePathWithParents(requestedPath))
+}
+
+// validateLinkPath confines the path of a symbolic link without following
+// it, see confineLinkPath, and authorizes it for reading
+func (fs *FilesystemHandler) validateLinkPath(requestedPath string) (string, error) {
+	return fs.authorized(fs.confineLinkPath(requestedPath))
+}
+
+// authorized authorizes the result of a confine function for reading
+func (fs *FilesystemHandler) authorized(path string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if err := fs.authorize(path, OpRead); err != nil {
+		return "", err
+	}
+	return path, nil
+}
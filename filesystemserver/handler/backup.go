@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// backupStampLayout is appended to the name of a file backed up into the
+// backup directory, so that successive backups of a file sort by time
+const backupStampLayout = "20060102T150405.000000000Z"
+
+// WithBackupDir sets the directory that the backup option of write_file and
+// modify_file copies files into. It must lie within the allowed directories
+// and is created when first used. Without it, a backup is kept next to the
+// file as path~.
+func WithBackupDir(dir string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.backupDir = dir
+	}
+}
+
+// resolveBackupDir validates the configured backup directory and replaces
+// it with its real path
+func (fs *FilesystemHandler) resolveBackupDir() error {
+	if fs.backupDir == "" {
+		return nil
+	}
+	validPath, err := fs.confinePathWithParents(fs.backupDir)
+	if err != nil {
+		return fmt.Errorf("backup directory: %w", err)
+	}
+	if info, err := fs.fsys.Stat(validPath); err == nil && !info.IsDir() {
+		return fmt.Errorf("backup directory is not a directory: %s", fs.backupDir)
+	}
+	if fs.rootForPath(validPath) == validPath {
+		return fmt.Errorf("backup directory cannot be an allowed directory itself: %s", fs.backupDir)
+	}
+	fs.backupDir = validPath
+	return nil
+}
+
+// backupPath returns where a file at path is backed up at time t: path~, or
+// with a backup directory, its original absolute path below that directory
+// with t appended to the name
+func (fs *FilesystemHandler) backupPath(path string, t time.Time) string {
+	if fs.backupDir == "" {
+		return path + "~"
+	}
+	volume := filepath.VolumeName(path)
+	return filepath.Join(fs.backupDir, strings.TrimSuffix(volume, ":"), path[len(volume):]) + "." + t.UTC().Format(backupStampLayout)
+}
+
+// backupFile copies the regular file at validPath to its backup path before
+// it is overwritten and returns the backup path. Nothing is copied, and ""
+// is returned, when the file does not exist yet.
+func (fs *FilesystemHandler) backupFile(validPath string) (string, error) {
+	info, err := fs.fsys.Stat(validPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("only regular files can be backed up")
+	}
+
+	backup, err := fs.validatePathWithParents(fs.backupPath(validPath, time.Now()))
+	if err == nil {
+		err = fs.authorize(backup, OpWrite)
+	}
+	if err != nil {
+		return "", err
+	}
+	if result := fs.checkQuota(backup, info.Size(), true); result != nil {
+		return "", fmt.Errorf("%s", strings.TrimPrefix(result.Content[0].(mcp.TextContent).Text, "Error: "))
+	}
+	if _, err := fs.makeDirs(filepath.Dir(backup), fs.dirPerm); err != nil {
+		return "", err
+	}
+	defer fs.invalidateCache(backup)
+	if err := fs.copyFile(validPath, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
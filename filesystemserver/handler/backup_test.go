@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileBackup(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	path := filepath.Join(dir, "notes.txt")
+
+	write := func(handler *FilesystemHandler, content string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "content": content, "backup": true}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text
+	}
+
+	t.Run("next to the file", func(t *testing.T) {
+		handler, err := NewFilesystemHandler([]string{dir})
+		require.NoError(t, err)
+
+		text := write(handler, "first")
+		assert.NotContains(t, text, "Backup:")
+
+		text = write(handler, "second")
+		assert.Contains(t, text, "Backup: "+path+"~")
+		content, err := os.ReadFile(path + "~")
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(content))
+	})
+
+	t.Run("in the backup directory", func(t *testing.T) {
+		backups := filepath.Join(dir, ".backups")
+		handler, err := NewFilesystemHandler([]string{dir}, WithBackupDir(backups))
+		require.NoError(t, err)
+
+		write(handler, "third")
+		matches, err := filepath.Glob(filepath.Join(backups, path) + ".*")
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		content, err := os.ReadFile(matches[0])
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(content))
+	})
+
+	t.Run("modify_file", func(t *testing.T) {
+		handler, err := NewFilesystemHandler([]string{dir})
+		require.NoError(t, err)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "find": "third", "replace": "fourth", "backup": true}
+		result, err := handler.HandleModifyFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Backup: "+path+"~")
+		content, err := os.ReadFile(path + "~")
+		require.NoError(t, err)
+		assert.Equal(t, "third", string(content))
+	})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// WithResourceBudget sets how many long-lived resources, follows and
+// watches together, clients may hold open at once, replacing the default of
+// DEFAULT_RESOURCE_BUDGET. A value of 0 or less keeps the default.
+func WithResourceBudget(limit int) Option {
+	return func(fs *FilesystemHandler) {
+		if limit > 0 {
+			fs.budget.limit = limit
+		}
+	}
+}
+
+// resourceBudget counts the resources that outlive a tool call, each of which
+// holds a goroutine and file descriptors, against one server-wide limit so
+// that an aggressive client cannot exhaust them
+type resourceBudget struct {
+	mu    sync.Mutex
+	limit int
+	inUse map[string]int // by kind, e.g. "follow"
+}
+
+// acquire takes one unit of the budget for a resource of the given kind, or
+// returns a resource_exhausted error if the budget is used up
+func (b *resourceBudget) acquire(kind string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	total := 0
+	for _, n := range b.inUse {
+		total += n
+	}
+	if total >= b.limit {
+		return fmt.Errorf("resource_exhausted - all %d follows and watches the server allows are in use; stop one first", b.limit)
+	}
+	if b.inUse == nil {
+		b.inUse = make(map[string]int)
+	}
+	b.inUse[kind]++
+	return nil
+}
+
+// release returns a unit taken by acquire
+func (b *resourceBudget) release(kind string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.inUse[kind] > 0 {
+		b.inUse[kind]--
+	}
+}
+
+// setLimit changes the limit, keeping the resources in use; if they exceed
+// the new limit, no more are handed out until enough are released
+func (b *resourceBudget) setLimit(limit int) {
+	b.mu.Lock()
+	b.limit = limit
+	b.mu.Unlock()
+}
+
+// usage returns the units in use by kind, the kinds sorted, their total and
+// the limit
+func (b *resourceBudget) usage() (kinds []string, inUse map[string]int, total, limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inUse = make(map[string]int, len(b.inUse))
+	for kind, n := range b.inUse {
+		kinds = append(kinds, kind)
+		inUse[kind] = n
+		total += n
+	}
+	slices.Sort(kinds)
+	return kinds, inUse, total, b.limit
+}
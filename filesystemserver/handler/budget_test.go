@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceBudget(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithResourceBudget(2))
+	require.NoError(t, err)
+
+	require.NoError(t, handler.budget.acquire("follow"))
+	require.NoError(t, handler.budget.acquire("watch"))
+	err = handler.budget.acquire("follow")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resource_exhausted")
+
+	result, err := handler.HandleGetServerInfo(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "Resource budget: 2 of 2 in use")
+	assert.Contains(t, text, "  follow: 1\n  watch: 1")
+
+	handler.budget.release("watch")
+	require.NoError(t, handler.budget.acquire("follow"))
+
+	// Lowering the limit keeps what is in use but hands out nothing more
+	handler.budget.setLimit(1)
+	handler.budget.release("follow")
+	assert.Error(t, handler.budget.acquire("follow"))
+}
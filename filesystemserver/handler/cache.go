@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithReadCache enables an in-memory LRU cache of file contents used by
+// read_file, holding at most maxBytes of data. Entries are keyed by path and
+// are only served while the file's modification time and size are unchanged.
+func WithReadCache(maxBytes int64) Option {
+	return func(fs *FilesystemHandler) {
+		if maxBytes > 0 {
+			fs.cache = newContentCache(maxBytes)
+		}
+	}
+}
+
+// contentCache is a size-bounded LRU cache of file contents
+type contentCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // most recently used at the front
+	entries  map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type cacheEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// cacheStats is a snapshot of the cache counters
+type cacheStats struct {
+	entries  int
+	size     int64
+	maxBytes int64
+	hits     uint64
+	misses   uint64
+}
+
+func newContentCache(maxBytes int64) *contentCache {
+	return &contentCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached contents of path if they were cached for the same
+// modification time and size as info
+func (c *contentCache) get(path string, info os.FileInfo) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[path]
+	if ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return entry.data, true
+		}
+		// The file changed since it was cached
+		c.remove(elem)
+	}
+	c.misses++
+	return nil, false
+}
+
+// put caches data for path, evicting the least recently used entries to stay
+// within the byte budget. Files larger than the budget are not cached.
+func (c *contentCache) put(path string, info os.FileInfo, data []byte) {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[path]; ok {
+		c.remove(elem)
+	}
+	for c.size+size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+
+	c.entries[path] = c.order.PushFront(&cacheEntry{
+		path:    path,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		data:    data,
+	})
+	c.size += size
+}
+
+// invalidate drops the entry for path and, if path is a directory, every
+// entry below it
+func (c *contentCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := filepath.Clean(path) + string(filepath.Separator)
+	for key, elem := range c.entries {
+		if key == path || strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+// remove deletes an entry; callers must hold mu
+func (c *contentCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.path)
+	c.size -= int64(len(entry.data))
+}
+
+func (c *contentCache) stats() cacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return cacheStats{
+		entries:  len(c.entries),
+		size:     c.size,
+		maxBytes: c.maxBytes,
+		hits:     c.hits,
+		misses:   c.misses,
+	}
+}
+
+// readFileCached reads a file through the content cache, if enabled
+func (fs *FilesystemHandler) readFileCached(path string, info os.FileInfo) ([]byte, error) {
+	if fs.cache == nil {
+		return readFileFull(fs.fsys, path)
+	}
+	if data, ok := fs.cache.get(path, info); ok {
+		return data, nil
+	}
+
+	data, err := readFileFull(fs.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	fs.cache.put(path, info, data)
+	return data, nil
+}
+
+// invalidateCache drops cached contents, and the cached quota usage of its
+// root, for a path that was written, moved or deleted
+func (fs *FilesystemHandler) invalidateCache(path string) {
+	if fs.cache != nil {
+		fs.cache.invalidate(path)
+	}
+	if fs.quotas != nil {
+		fs.quotas.invalidate(path)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentCache_Eviction(t *testing.T) {
+	fsys := NewMemFileSystem()
+	require.NoError(t, fsys.WriteFile("/a", []byte("aaaa"), 0644))
+	require.NoError(t, fsys.WriteFile("/b", []byte("bbbb"), 0644))
+	require.NoError(t, fsys.WriteFile("/c", []byte("cccc"), 0644))
+
+	cache := newContentCache(8)
+	for _, name := range []string{"/a", "/b", "/c"} {
+		info, err := fsys.Stat(name)
+		require.NoError(t, err)
+		data, err := fsys.ReadFile(name)
+		require.NoError(t, err)
+		cache.put(name, info, data)
+	}
+
+	// The least recently used entry is evicted to stay within the budget
+	info, err := fsys.Stat("/a")
+	require.NoError(t, err)
+	_, ok := cache.get("/a", info)
+	assert.False(t, ok)
+
+	info, err = fsys.Stat("/c")
+	require.NoError(t, err)
+	data, ok := cache.get("/c", info)
+	assert.True(t, ok)
+	assert.Equal(t, "cccc", string(data))
+
+	stats := cache.stats()
+	assert.Equal(t, 2, stats.entries)
+	assert.Equal(t, int64(8), stats.size)
+	assert.Equal(t, uint64(1), stats.hits)
+	assert.Equal(t, uint64(1), stats.misses)
+}
+
+func TestReadFile_Cache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hot.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithReadCache(1024))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	assert.Equal(t, "first", call(handler.HandleReadFile, map[string]any{"path": path}))
+	assert.Equal(t, "first", call(handler.HandleReadFile, map[string]any{"path": path}))
+
+	// Writes invalidate the cached contents
+	call(handler.HandleWriteFile, map[string]any{"path": path, "content": "second"})
+	assert.Equal(t, "second", call(handler.HandleReadFile, map[string]any{"path": path}))
+
+	info := call(handler.HandleGetServerInfo, map[string]any{})
+	assert.Contains(t, info, "Read cache: enabled")
+	assert.Contains(t, info, "Hits: 1")
+	assert.Contains(t, info, "Misses: 2")
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithMaxCallTimeout sets the largest timeout_ms a tool call may ask for,
+// replacing the default of DEFAULT_MAX_CALL_TIMEOUT. A value of 0 or less
+// keeps the default.
+func WithMaxCallTimeout(limit time.Duration) Option {
+	return func(fs *FilesystemHandler) {
+		if limit > 0 {
+			fs.maxCallTimeout = limit
+		}
+	}
+}
+
+// LimitCallDuration wraps a tool handler so that a call with a timeout_ms
+// argument runs under a context with that deadline. Walks stop at the
+// deadline and return their partial results; a call that has not returned
+// shortly after it gets a timeout error, while the handler itself is
+// abandoned rather than stopped; see HoldConfig for how reloads treat it.
+// A cancelled call returns at once.
+func (fs *FilesystemHandler) LimitCallDuration(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, ok := request.GetArguments()["timeout_ms"]; !ok {
+			return next(ctx, request)
+		}
+
+		fs.configMu.RLock()
+		limit := fs.maxCallTimeout
+		fs.configMu.RUnlock()
+		timeout := time.Duration(request.GetInt("timeout_ms", 0)) * time.Millisecond
+		if timeout <= 0 || timeout > limit {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: timeout_ms must be between 1 and %d", limit.Milliseconds()),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		type outcome struct {
+			result *mcp.CallToolResult
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := next(ctx, request)
+			done <- outcome{result, err}
+		}()
+
+		select {
+		case o := <-done:
+			return o.result, o.err
+		case <-ctx.Done():
+		}
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ctx.Err()
+		}
+
+		// Give a handler that watches the context the chance to return what
+		// it has so far; an error it reports is down to the deadline
+		grace := time.NewTimer(CALL_TIMEOUT_GRACE)
+		defer grace.Stop()
+		select {
+		case o := <-done:
+			if o.err == nil && o.result != nil && !o.result.IsError {
+				return o.result, nil
+			}
+		case <-grace.C:
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: timeout - %s did not finish within timeout_ms %d. Its work is abandoned, but changes it was making may still be applied.", request.Params.Name, timeout.Milliseconds()),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+}
+
+// callStoppedReason describes why the context of a tool call is done, in
+// the form of a walk truncation reason
+func callStoppedReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "timeout_ms deadline reached"
+	}
+	return "call cancelled"
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitCallDuration(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithMaxCallTimeout(time.Second))
+	require.NoError(t, err)
+
+	call := func(next func(ctx context.Context) *mcp.CallToolResult, args map[string]any) (*mcp.CallToolResult, error) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "slow_tool"
+		request.Params.Arguments = args
+		return handler.LimitCallDuration(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return next(ctx), nil
+		})(context.Background(), request)
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("no deadline without timeout_ms", func(t *testing.T) {
+		result, err := call(func(ctx context.Context) *mcp.CallToolResult {
+			_, hasDeadline := ctx.Deadline()
+			assert.False(t, hasDeadline)
+			return mcp.NewToolResultText("done")
+		}, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "done", text(result))
+	})
+
+	t.Run("partial results at the deadline", func(t *testing.T) {
+		result, err := call(func(ctx context.Context) *mcp.CallToolResult {
+			<-ctx.Done()
+			return mcp.NewToolResultText("partial")
+		}, map[string]any{"timeout_ms": 20})
+		require.NoError(t, err)
+		assert.Equal(t, "partial", text(result))
+	})
+
+	t.Run("timeout error for calls that do not return", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		result, err := call(func(ctx context.Context) *mcp.CallToolResult {
+			<-release
+			return mcp.NewToolResultText("late")
+		}, map[string]any{"timeout_ms": 20})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(result), "timeout - slow_tool did not finish within timeout_ms 20")
+	})
+
+	t.Run("bounded by the maximum", func(t *testing.T) {
+		result, err := call(func(ctx context.Context) *mcp.CallToolResult {
+			t.Fatal("handler must not run")
+			return nil
+		}, map[string]any{"timeout_ms": 5000})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, text(result), "between 1 and 1000")
+	})
+}
+
+func TestWalkTree_StopsWhenContextDone(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	truncated, err := handler.walkTree(ctx, dir, func(string, os.FileInfo, error) error {
+		t.Fatal("walk must not visit anything")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "timeout_ms deadline reached", truncated)
+}
+
+func TestAbandonedCallDoesNotHoldUpReload(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithConfigReload(func() ([]string, []Option, error) {
+		return []string{dir}, nil, nil
+	}))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plain.txt"), []byte("plain"), 0644))
+
+	// A handler that only returns once release is closed, like one stuck on
+	// a hung filesystem
+	blocked := func(release chan struct{}) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			<-release
+			return mcp.NewToolResultText("late"), nil
+		}
+	}
+	call := func(name string, handle server.ToolHandlerFunc, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handler.LimitCallDuration(handler.HoldConfig(handle))(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+	reload := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := handler.Reload()
+			done <- err
+		}()
+		return done
+	}
+
+	t.Run("reload waits for calls in progress", func(t *testing.T) {
+		release := make(chan struct{})
+		returned := make(chan struct{})
+		go func() {
+			call("slow_tool", blocked(release), nil)
+			close(returned)
+		}()
+		require.Eventually(t, func() bool {
+			handler.callsMu.Lock()
+			defer handler.callsMu.Unlock()
+			return handler.calls == 1
+		}, 5*time.Second, time.Millisecond)
+
+		done := reload()
+		select {
+		case <-done:
+			t.Fatal("reload swapped the configuration under a running call")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		<-returned
+		require.NoError(t, <-done)
+	})
+
+	t.Run("reload fails while an abandoned call runs", func(t *testing.T) {
+		release := make(chan struct{})
+		result := call("slow_tool", blocked(release), map[string]any{"timeout_ms": 20})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "timeout")
+
+		select {
+		case err := <-reload():
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "1 call(s) abandoned after timeout_ms are still running")
+		case <-time.After(5 * time.Second):
+			t.Fatal("reload waited for the abandoned call")
+		}
+		result = call("read_file", handler.HandleReadFile, map[string]any{"path": filepath.Join(dir, "plain.txt")})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "plain")
+
+		// Once the handler returns, reloading works again
+		close(release)
+		require.Eventually(t, func() bool {
+			_, err := handler.Reload()
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleCheckAccess reports whether an operation on a path would be allowed,
+// applying the same confinement, authorization and directory mode checks as
+// the tools that perform it, without touching the path. A denied operation
+// is an ordinary result rather than an error, so that a client can plan a
+// sequence of calls up front.
+func (fs *FilesystemHandler) HandleCheckAccess(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	op := Operation(strings.ToLower(request.GetString("operation", string(OpRead))))
+	if op != OpRead && op != OpWrite && op != OpDelete {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported operation '%s' (expected 'read', 'write' or 'delete')", op),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Path: %s\n", path))
+	result.WriteString(fmt.Sprintf("Operation: %s\n", op))
+
+	validPath, exists, err := fs.checkAccess(path, op)
+	if validPath != "" {
+		result.WriteString(fmt.Sprintf("Resolved path: %s\n", fs.displayPath(validPath)))
+		result.WriteString(fmt.Sprintf("Allowed root: %s (%s)\n", fs.displayPath(fs.rootForPath(validPath)), fs.dirMode(fs.rootForPath(validPath))))
+		result.WriteString(fmt.Sprintf("Exists: %v\n", exists))
+	}
+	if err != nil {
+		result.WriteString(fmt.Sprintf("Allowed: false\nReason: %v\n", err))
+	} else {
+		result.WriteString("Allowed: true\n")
+	}
+
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}
+	res.Meta = map[string]any{"allowed": err == nil}
+	return res, nil
+}
+
+// checkAccess runs the checks a tool performing op on path would run before
+// acting, returning the confined path, whether it exists and, if op would be
+// rejected, why. Reads and deletes need an existing path; writes may create
+// the path and its parent directories but cannot replace a directory.
+// Special files are refused as read_file and write_file refuse them unless
+// allow_special is set.
+func (fs *FilesystemHandler) checkAccess(path string, op Operation) (string, bool, error) {
+	var validPath string
+	var err error
+	if op == OpWrite {
+		validPath, err = fs.validatePathWithParents(path)
+	} else {
+		validPath, err = fs.validatePath(path)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if op != OpRead {
+		if err := fs.authorize(validPath, op); err != nil {
+			return validPath, false, err
+		}
+		if mode := fs.dirMode(fs.rootForPath(validPath)); mode != "read-write" {
+			return validPath, false, fmt.Errorf("%s is %s", fs.displayPath(fs.rootForPath(validPath)), mode)
+		}
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	switch {
+	case os.IsNotExist(err) && op == OpWrite:
+		return validPath, false, nil
+	case os.IsNotExist(err):
+		return validPath, false, fmt.Errorf("path does not exist: %s", path)
+	case err != nil:
+		return validPath, false, err
+	case op == OpWrite && info.IsDir():
+		return validPath, true, fmt.Errorf("cannot write to a directory")
+	case op != OpDelete && specialFileKind(info.Mode()) != "":
+		return validPath, true, fs.refuseSpecialFile(validPath)
+	}
+	return validPath, true, nil
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleCheckAccess(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.key"), []byte("key"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithAuthorizer(AuthorizerFunc(func(path string, op Operation) error {
+		if strings.HasSuffix(path, ".key") && op != OpRead {
+			return errors.New("keys are read-only")
+		}
+		return nil
+	})))
+	require.NoError(t, err)
+
+	check := func(path, op string) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "operation": op}
+		result, err := handler.HandleCheckAccess(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text, result.Meta["allowed"].(bool)
+	}
+
+	tests := []struct {
+		path    string
+		op      string
+		allowed bool
+		reason  string
+	}{
+		{filepath.Join(dir, "notes.txt"), "read", true, ""},
+		{filepath.Join(dir, "notes.txt"), "delete", true, ""},
+		{filepath.Join(dir, "new", "file.txt"), "write", true, ""},
+		{filepath.Join(dir, "missing.txt"), "read", false, "does not exist"},
+		{dir, "write", false, "cannot write to a directory"},
+		{filepath.Join(dir, "secret.key"), "read", true, ""},
+		{filepath.Join(dir, "secret.key"), "write", false, "keys are read-only"},
+		{filepath.Join(t.TempDir(), "outside.txt"), "read", false, "outside allowed directories"},
+	}
+	for _, tt := range tests {
+		text, allowed := check(tt.path, tt.op)
+		assert.Equal(t, tt.allowed, allowed, "%s %s: %s", tt.op, tt.path, text)
+		assert.Contains(t, text, tt.reason)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"path": dir, "operation": "execute"}
+	result, err := handler.HandleCheckAccess(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// treeEntry is a file or directory found below the root of a compared tree
+type treeEntry struct {
+	path string // real path
+	info os.FileInfo
+}
+
+// dirComparison is the difference between two directory trees. Paths are
+// relative to the compared roots and use forward slashes. A directory that
+// exists on one side only is reported without its contents.
+type dirComparison struct {
+	onlyInLeft  []string
+	onlyInRight []string
+	different   []string
+	identical   int
+	truncated   string // why the walk limits cut a tree scan short
+}
+
+// HandleCompareDirectories reports the files and directories that exist in
+// only one of two trees, and the files present in both whose contents differ
+func (fs *FilesystemHandler) HandleCompareDirectories(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	left, err := request.RequireString("left")
+	if err != nil {
+		return nil, err
+	}
+	right, err := request.RequireString("right")
+	if err != nil {
+		return nil, err
+	}
+	compareContent := request.GetBool("compare_content", false)
+
+	filter, err := newListFilter("", request.GetString("exclude", ""))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var roots [2]string
+	for i, dir := range []string{left, right} {
+		validPath, err := fs.validatePath(dir)
+		if err == nil {
+			var info os.FileInfo
+			if info, err = fs.fsys.Stat(validPath); err == nil && !info.IsDir() {
+				err = fmt.Errorf("not a directory: %s", dir)
+			}
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		roots[i] = validPath
+	}
+
+	comparison, err := fs.compareDirectories(ctx, roots[0], roots[1], compareContent, filter)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error comparing directories: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	method := "size and modification time"
+	if compareContent {
+		method = "content"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf(
+		"Compared %s with %s by %s: %d only in left, %d only in right, %d different, %d identical\n",
+		fs.displayPath(roots[0]),
+		fs.displayPath(roots[1]),
+		method,
+		len(comparison.onlyInLeft),
+		len(comparison.onlyInRight),
+		len(comparison.different),
+		comparison.identical,
+	))
+	for _, section := range []struct {
+		name  string
+		paths []string
+	}{
+		{"only_in_left", comparison.onlyInLeft},
+		{"only_in_right", comparison.onlyInRight},
+		{"different", comparison.different},
+	} {
+		if len(section.paths) == 0 {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("\n%s:\n", section.name))
+		for i, p := range section.paths {
+			if i == MAX_SEARCH_RESULTS {
+				result.WriteString(fmt.Sprintf("  ... and %d more\n", len(section.paths)-i))
+				break
+			}
+			result.WriteString(fmt.Sprintf("  %s\n", p))
+		}
+	}
+	result.WriteString(walkTruncatedNote(comparison.truncated))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// compareDirectories compares the trees below left and right. Files are
+// considered different if their sizes differ or, with compareContent, their
+// SHA-256 digests differ; otherwise if their modification times differ.
+// Directories are shown with a trailing slash.
+func (fs *FilesystemHandler) compareDirectories(
+	ctx context.Context, left, right string, compareContent bool, filter *listFilter,
+) (dirComparison, error) {
+	leftTree, leftTruncated, err := fs.scanTree(ctx, left, filter)
+	if err != nil {
+		return dirComparison{}, err
+	}
+	rightTree, rightTruncated, err := fs.scanTree(ctx, right, filter)
+	if err != nil {
+		return dirComparison{}, err
+	}
+
+	comparison := dirComparison{truncated: cmp.Or(leftTruncated, rightTruncated)}
+	for rel, l := range leftTree {
+		r, ok := rightTree[rel]
+		switch {
+		case !ok:
+			if !parentMissing(rel, rightTree) {
+				comparison.onlyInLeft = append(comparison.onlyInLeft, treeName(rel, l.info))
+			}
+		case l.info.IsDir() && r.info.IsDir():
+			// Directories are compared through their contents
+		case l.info.IsDir() != r.info.IsDir():
+			comparison.different = append(comparison.different, treeName(rel, l.info))
+		default:
+			if ctx.Err() != nil {
+				return dirComparison{}, ctx.Err()
+			}
+			same, err := fs.sameFile(l, r, compareContent)
+			if err != nil {
+				return dirComparison{}, err
+			}
+			if same {
+				comparison.identical++
+			} else {
+				comparison.different = append(comparison.different, rel)
+			}
+		}
+	}
+	for rel, r := range rightTree {
+		if _, ok := leftTree[rel]; !ok && !parentMissing(rel, leftTree) {
+			comparison.onlyInRight = append(comparison.onlyInRight, treeName(rel, r.info))
+		}
+	}
+
+	sort.Strings(comparison.onlyInLeft)
+	sort.Strings(comparison.onlyInRight)
+	sort.Strings(comparison.different)
+	return comparison, nil
+}
+
+// scanTree returns the regular files and directories below root, keyed by
+// their slash-separated path relative to root. Symbolic links and paths
+// outside the allowed directories are skipped; excluded directories are not
+// descended into. truncated is set if the walk limits cut the scan short.
+func (fs *FilesystemHandler) scanTree(
+	ctx context.Context, root string, filter *listFilter,
+) (tree map[string]treeEntry, truncated string, err error) {
+	tree = make(map[string]treeEntry)
+	truncated, err = fs.walkTree(
+		ctx,
+		root,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if walkPath == root {
+				return err
+			}
+			if err != nil {
+				return nil // Skip errors and continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			rel, err := filepath.Rel(root, walkPath)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if filter.excluded(rel) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				return nil
+			}
+			if _, err := fs.validatePath(walkPath); err != nil {
+				return nil // Skip invalid paths
+			}
+			tree[rel] = treeEntry{path: walkPath, info: info}
+			return nil
+		},
+	)
+	return tree, truncated, err
+}
+
+// sameFile reports whether two regular files are considered equal. Files too
+// large to hash are compared by size and modification time.
+func (fs *FilesystemHandler) sameFile(a, b treeEntry, compareContent bool) (bool, error) {
+	if a.info.Size() != b.info.Size() {
+		return false, nil
+	}
+	if !compareContent || a.info.Size() > MAX_HASH_SIZE {
+		return a.info.ModTime().Equal(b.info.ModTime()), nil
+	}
+
+	digestA, err := fs.fileSHA256(a.path)
+	if err != nil {
+		return false, err
+	}
+	digestB, err := fs.fileSHA256(b.path)
+	if err != nil {
+		return false, err
+	}
+	return digestA == digestB, nil
+}
+
+// parentMissing reports whether the parent directory of rel is absent from
+// tree, in which case rel is covered by reporting that directory
+func parentMissing(rel string, tree map[string]treeEntry) bool {
+	parent := filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel)))
+	if parent == "." {
+		return false
+	}
+	_, ok := tree[parent]
+	return !ok
+}
+
+// treeName returns rel with a trailing slash if it names a directory
+func treeName(rel string, info os.FileInfo) string {
+	if info.IsDir() {
+		return rel + "/"
+	}
+	return rel
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleCompareDirectories(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	left := filepath.Join(dir, "left")
+	right := filepath.Join(dir, "right")
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	write(filepath.Join(left, "same.txt"), "same")
+	write(filepath.Join(right, "same.txt"), "same")
+	write(filepath.Join(left, "size.txt"), "short")
+	write(filepath.Join(right, "size.txt"), "longer")
+	write(filepath.Join(left, "touched.txt"), "abc")
+	write(filepath.Join(right, "touched.txt"), "abc")
+	require.NoError(t, os.Chtimes(filepath.Join(right, "touched.txt"), mtime, mtime.Add(time.Minute)))
+	write(filepath.Join(left, "content.txt"), "aaa")
+	write(filepath.Join(right, "content.txt"), "bbb")
+	write(filepath.Join(left, "sub", "deep", "only.txt"), "x")
+	write(filepath.Join(right, "extra.txt"), "x")
+	write(filepath.Join(right, "build", "out.o"), "x")
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+	compare := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "compare_directories"
+		request.Params.Arguments = args
+		result, err := handler.HandleCompareDirectories(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("size and mtime", func(t *testing.T) {
+		text := compare(map[string]any{"left": left, "right": right})
+		assert.Contains(t, text, "1 only in left, 2 only in right, 2 different, 2 identical")
+		assert.Contains(t, text, "only_in_left:\n  sub/\n")
+		assert.Contains(t, text, "only_in_right:\n  build/\n  extra.txt\n")
+		assert.Contains(t, text, "different:\n  size.txt\n  touched.txt\n")
+	})
+
+	t.Run("content", func(t *testing.T) {
+		text := compare(map[string]any{"left": left, "right": right, "compare_content": true, "exclude": "build"})
+		assert.Contains(t, text, "1 only in right, 2 different, 2 identical")
+		assert.Contains(t, text, "different:\n  content.txt\n  size.txt\n")
+		assert.NotContains(t, text, "build/")
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"left": left, "right": filepath.Join(right, "same.txt")}
+		result, err := handler.HandleCompareDirectories(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithResultCompression lets clients request gzip compression of text
+// results whose combined size is at least minBytes. Compression is disabled
+// when minBytes is 0.
+func WithResultCompression(minBytes int) Option {
+	return func(fs *FilesystemHandler) {
+		fs.compressMinBytes = minBytes
+	}
+}
+
+// CompressResults wraps the handler of a tool that can return large text so
+// that, when compression is enabled and the request sets accept_encoding to
+// "gzip", its text content is gzip-compressed and base64-encoded. Compressed
+// results carry content_encoding "gzip" in their _meta. Images, embedded
+// resources and errors are returned unchanged.
+func (fs *FilesystemHandler) CompressResults(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError || fs.compressMinBytes <= 0 ||
+			request.GetString("accept_encoding", "") != "gzip" {
+			return result, err
+		}
+		// RelativizePaths cannot rewrite compressed text, so do it first
+		fs.relativizeResult(request, result)
+
+		size := 0
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				size += len(text.Text)
+			}
+		}
+		if size < fs.compressMinBytes {
+			return result, nil
+		}
+
+		compressed := make([]mcp.Content, len(result.Content))
+		for i, content := range result.Content {
+			text, ok := content.(mcp.TextContent)
+			if !ok {
+				compressed[i] = content
+				continue
+			}
+			encoded, err := gzipBase64(text.Text)
+			if err != nil {
+				// Fall back to the uncompressed result
+				return result, nil
+			}
+			text.Text = encoded
+			compressed[i] = text
+		}
+		result.Content = compressed
+		if result.Meta == nil {
+			result.Meta = make(map[string]any)
+		}
+		result.Meta["content_encoding"] = "gzip"
+		return result, nil
+	}
+}
+
+// gzipBase64 returns text gzip-compressed and base64-encoded
+func gzipBase64(text string) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressResults(t *testing.T) {
+	dir := t.TempDir()
+	large := strings.Repeat("all work and no play\n", 100)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "large.txt"), []byte(large), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "small.txt"), []byte("tiny\n"), 0644))
+
+	read := func(handler *FilesystemHandler, name string, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(dir, name)}
+		for k, v := range args {
+			request.Params.Arguments.(map[string]any)[k] = v
+		}
+		result, err := handler.CompressResults(handler.HandleReadFile)(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithResultCompression(1024))
+	require.NoError(t, err)
+
+	t.Run("large result is compressed on request", func(t *testing.T) {
+		result := read(handler, "large.txt", map[string]any{"accept_encoding": "gzip"})
+		assert.Equal(t, "gzip", result.Meta["content_encoding"])
+
+		data, err := base64.StdEncoding.DecodeString(result.Content[0].(mcp.TextContent).Text)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		text, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(text))
+	})
+
+	t.Run("small result is not compressed", func(t *testing.T) {
+		result := read(handler, "small.txt", map[string]any{"accept_encoding": "gzip"})
+		assert.Nil(t, result.Meta["content_encoding"])
+		assert.Equal(t, "tiny\n", result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		result := read(handler, "large.txt", nil)
+		assert.Nil(t, result.Meta["content_encoding"])
+		assert.Equal(t, large, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		plain, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+		require.NoError(t, err)
+		result := read(plain, "large.txt", map[string]any{"accept_encoding": "gzip"})
+		assert.Equal(t, large, result.Content[0].(mcp.TextContent).Text)
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// WithMaxConcurrency bounds how many files a single batch or walk tool call
+// reads at the same time, replacing the default of GOMAXPROCS. Slow storage
+// such as a spinning disk or a network mount may do better with a small
+// value; 1 processes files one after another. A value below 1 keeps the
+// default.
+func WithMaxConcurrency(n int) Option {
+	return func(fs *FilesystemHandler) {
+		if n > 0 {
+			fs.maxConcurrency = n
+		}
+	}
+}
+
+// forEach calls fn for every index below n, running at most maxConcurrency
+// calls at once. Calls start in index order and stop being started once ctx
+// is done; forEach returns when the started calls have returned. fn must
+// only write to state owned by its index.
+func (fs *FilesystemHandler) forEach(ctx context.Context, n int, fn func(i int)) {
+	if fs.maxConcurrency <= 1 {
+		for i := 0; i < n && ctx.Err() == nil; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	semaphore := make(chan struct{}, fs.maxConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEach(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())
+
+	t.Run("bounds the calls in flight", func(t *testing.T) {
+		handler, err := NewFilesystemHandler(dir, WithMaxConcurrency(3))
+		require.NoError(t, err)
+
+		var running, peak atomic.Int32
+		done := make([]bool, 20)
+		handler.forEach(context.Background(), len(done), func(i int) {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			done[i] = true
+		})
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+		assert.NotContains(t, done, false)
+	})
+
+	t.Run("one runs in order", func(t *testing.T) {
+		handler, err := NewFilesystemHandler(dir, WithMaxConcurrency(1))
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var order []int
+		handler.forEach(context.Background(), 5, func(i int) {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+	})
+
+	t.Run("stops on cancellation", func(t *testing.T) {
+		handler, err := NewFilesystemHandler(dir, WithMaxConcurrency(1))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		handler.forEach(ctx, 5, func(i int) {
+			calls++
+			cancel()
+		})
+		assert.Equal(t, 1, calls)
+	})
+}
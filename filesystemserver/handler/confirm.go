@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithConfirmation makes the destructive tools ask for confirmation: a call
+// returns a description of what it would do and a confirmation_token, and
+// only a second call with the same arguments and that token runs. Tokens
+// are single-use and expire after ttl; 0 or less keeps the default of
+// DEFAULT_CONFIRMATION_TTL.
+func WithConfirmation(ttl time.Duration) Option {
+	return func(fs *FilesystemHandler) {
+		if ttl <= 0 {
+			ttl = DEFAULT_CONFIRMATION_TTL
+		}
+		fs.confirmations = &confirmations{
+			ttl:     ttl,
+			pending: make(map[string]pendingConfirmation),
+			now:     time.Now,
+		}
+	}
+}
+
+// pendingConfirmation is a call waiting to be confirmed
+type pendingConfirmation struct {
+	call    string // digest of the tool name and arguments, see callDigest
+	session string // the client session the token was issued to
+	expires time.Time
+}
+
+// confirmations holds the outstanding confirmation tokens by token
+type confirmations struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]pendingConfirmation
+	now     func() time.Time
+}
+
+// issue returns a new token for the call with the given digest, made in
+// the given client session
+func (c *confirmations) issue(call, session string) (string, time.Time, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for t, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, t)
+		}
+	}
+	expires := now.Add(c.ttl)
+	c.pending[token] = pendingConfirmation{call: call, session: session, expires: expires}
+	return token, expires, nil
+}
+
+// redeem uses up token and checks that it was issued for the call with the
+// given digest, in the same client session, and has not expired
+func (c *confirmations) redeem(token, call, session string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[token]
+	delete(c.pending, token)
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown or already used confirmation_token %q", token)
+	case c.now().After(p.expires):
+		return fmt.Errorf("confirmation_token %q has expired", token)
+	case p.session != session:
+		return fmt.Errorf("confirmation_token %q was issued to another client session", token)
+	case p.call != call:
+		return fmt.Errorf("confirmation_token %q was issued for a call with different arguments", token)
+	}
+	return nil
+}
+
+// callDigest identifies a tool call by its name and the arguments that
+// affect what it does
+func callDigest(request mcp.CallToolRequest) string {
+	args := make(map[string]any)
+	for key, value := range request.GetArguments() {
+		switch key {
+		case "confirmation_token", "timeout_ms", "relative_paths":
+		default:
+			args[key] = value
+		}
+	}
+	encoded, _ := json.Marshal(args) // Map keys are sorted, so this is stable
+	sum := sha256.Sum256(append([]byte(request.Params.Name+"\x00"), encoded...))
+	return hex.EncodeToString(sum[:])
+}
+
+// destructive reports whether a call of a destructive tool destroys
+// something and so needs confirmation
+func destructive(request mcp.CallToolRequest) bool {
+	switch request.Params.Name {
+	case "delete_file", "empty_trash":
+		return true
+	case "sync_directories":
+		return request.GetBool("delete", false) && !request.GetBool("dry_run", false)
+	}
+	return false
+}
+
+// awaitsConfirmation reports whether RequireConfirmation answers request
+// with a token instead of running it, so that it changes nothing
+func (fs *FilesystemHandler) awaitsConfirmation(request mcp.CallToolRequest) bool {
+	return fs.confirmations != nil && destructive(request) && request.GetString("confirmation_token", "") == ""
+}
+
+// destructiveEffect describes what a destructive call would do, or returns
+// "" if it would fail without changing anything, which the tool itself then
+// reports
+func (fs *FilesystemHandler) destructiveEffect(ctx context.Context, request mcp.CallToolRequest) string {
+	switch request.Params.Name {
+	case "delete_file":
+		return fs.deleteEffect(ctx, request.GetString("path", ""), request.GetBool("recursive", false))
+	case "empty_trash":
+		if olderThan := request.GetString("older_than", ""); olderThan != "" {
+			return fmt.Sprintf("permanently delete the items trashed more than %s ago", olderThan)
+		}
+		return "permanently delete every item in the trash"
+	case "sync_directories":
+		return fmt.Sprintf(
+			"sync %s into %s, permanently deleting the paths that exist only in %s (call with dry_run to list them)",
+			request.GetString("source", ""), request.GetString("destination", ""), request.GetString("destination", ""),
+		)
+	}
+	return ""
+}
+
+// deleteEffect describes what delete_file would remove at path: the file,
+// or the directory and the entries and bytes below it
+func (fs *FilesystemHandler) deleteEffect(ctx context.Context, path string, recursive bool) string {
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return ""
+	}
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("permanently delete the file %s (%d bytes)", fs.displayPath(validPath), info.Size())
+	}
+	if !recursive {
+		return ""
+	}
+
+	var entries, size int64
+	truncated, err := fs.walkTree(ctx, validPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == validPath {
+			return nil
+		}
+		entries++
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Sprintf("permanently delete the directory %s and everything below it", fs.displayPath(validPath))
+	}
+	count := fmt.Sprintf("%d entries", entries)
+	if truncated != "" {
+		count = "at least " + count
+	}
+	return fmt.Sprintf("permanently delete the directory %s and the %s below it (%d bytes)", fs.displayPath(validPath), count, size)
+}
+
+// RequireConfirmation wraps the handler of a destructive tool so that, when
+// confirmations are enabled, it only runs when called with a valid
+// confirmation_token. A call without one is answered with a description of
+// its effect and a token for the same call in the same client session.
+func (fs *FilesystemHandler) RequireConfirmation(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if fs.confirmations == nil || !destructive(request) {
+			return next(ctx, request)
+		}
+
+		call := callDigest(request)
+		session := ""
+		if s := server.ClientSessionFromContext(ctx); s != nil {
+			session = s.SessionID()
+		}
+		if token := request.GetString("confirmation_token", ""); token != "" {
+			if err := fs.confirmations.redeem(token, call, session); err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: fmt.Sprintf("Error: %v; call again without it for a new token", err),
+						},
+					},
+					IsError: true,
+				}, nil
+			}
+			return next(ctx, request)
+		}
+
+		effect := fs.destructiveEffect(ctx, request)
+		if effect == "" {
+			return next(ctx, request)
+		}
+		token, expires, err := fs.confirmations.issue(call, session)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error issuing confirmation token: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		result := &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf(
+						"Confirmation required: this call would %s. Nothing has been changed.\n"+
+							"To proceed, call %s again with the same arguments and confirmation_token %q before %s.",
+						effect, request.Params.Name, token, expires.UTC().Format(time.RFC3339),
+					),
+				},
+			},
+		}
+		result.Meta = map[string]any{
+			"confirmation_token": token,
+			"expires_at":         expires.UTC().Format(time.RFC3339),
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithConfirmation(time.Minute))
+	require.NoError(t, err)
+	deleteFile := handler.RequireConfirmation(handler.HandleDeleteFile)
+
+	path := filepath.Join(dir, "doomed.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+
+	callIn := func(session string, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "delete_file"
+		request.Params.Arguments = args
+		ctx := context.Background()
+		if session != "" {
+			ctx = server.NewMCPServer("test", "1.0.0").WithContext(ctx, testSession{id: session})
+		}
+		result, err := deleteFile(ctx, request)
+		require.NoError(t, err)
+		return result
+	}
+	call := func(args map[string]any) *mcp.CallToolResult {
+		return callIn("", args)
+	}
+
+	t.Run("first call only describes the effect", func(t *testing.T) {
+		result := call(map[string]any{"path": path})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "permanently delete the file "+path+" (1 bytes)")
+		assert.NotEmpty(t, result.Meta["confirmation_token"])
+		assert.FileExists(t, path)
+	})
+
+	t.Run("token is bound to the arguments", func(t *testing.T) {
+		token := call(map[string]any{"path": path}).Meta["confirmation_token"]
+		result := call(map[string]any{"path": filepath.Join(dir, "other.txt"), "confirmation_token": token})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "different arguments")
+
+		// A failed attempt uses the token up
+		result = call(map[string]any{"path": path, "confirmation_token": token})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "already used")
+		assert.FileExists(t, path)
+	})
+
+	t.Run("directories are counted", func(t *testing.T) {
+		sub := filepath.Join(dir, "build")
+		require.NoError(t, os.MkdirAll(filepath.Join(sub, "obj"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(sub, "obj", "a.o"), []byte("1234"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(sub, "b.o"), []byte("12"), 0644))
+
+		result := call(map[string]any{"path": sub, "recursive": true})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text,
+			"permanently delete the directory "+sub+" and the 3 entries below it (6 bytes)")
+		assert.DirExists(t, sub)
+	})
+
+	t.Run("token is bound to the session", func(t *testing.T) {
+		token := callIn("owner", map[string]any{"path": path}).Meta["confirmation_token"]
+		result := callIn("other", map[string]any{"path": path, "confirmation_token": token})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "another client session")
+		assert.FileExists(t, path)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		token := call(map[string]any{"path": path}).Meta["confirmation_token"]
+		handler.confirmations.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		defer func() { handler.confirmations.now = time.Now }()
+		result := call(map[string]any{"path": path, "confirmation_token": token})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "expired")
+	})
+
+	t.Run("confirmed call runs once", func(t *testing.T) {
+		token := call(map[string]any{"path": path}).Meta["confirmation_token"]
+		result := call(map[string]any{"path": path, "confirmation_token": token, "timeout_ms": 1000})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestRequireConfirmation_ReadOnly(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithConfirmation(time.Minute), WithReadOnly())
+	require.NoError(t, err)
+	path := filepath.Join(dir, "kept.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+
+	// Read-only mode refuses the call before a token is issued
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "delete_file"
+	request.Params.Arguments = map[string]any{"path": path}
+	result, err := handler.RefuseInReadOnly(handler.RequireConfirmation(handler.HandleDeleteFile))(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Nil(t, result.Meta)
+	assert.Empty(t, handler.confirmations.pending)
+}
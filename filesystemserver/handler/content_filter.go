@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// contentFilter drops files that are not worth reading from the walks of the
+// content tools, by size (skip_larger_than) and by content (skip_binary). It
+// counts the files it dropped by reason so the result can say what was not
+// looked at.
+type contentFilter struct {
+	skipBinary bool
+	// maxSize is the skip_larger_than limit in bytes, or -1 for none
+	maxSize int64
+
+	mu     sync.Mutex
+	binary int
+	larger int
+}
+
+// parseContentFilter reads the skip_binary and skip_larger_than arguments
+func parseContentFilter(request mcp.CallToolRequest) (*contentFilter, error) {
+	filter := &contentFilter{
+		skipBinary: request.GetBool("skip_binary", false),
+		maxSize:    -1,
+	}
+	if maxSize, err := request.RequireFloat("skip_larger_than"); err == nil {
+		if maxSize < 0 {
+			return nil, fmt.Errorf("skip_larger_than cannot be negative")
+		}
+		filter.maxSize = int64(maxSize)
+	}
+	return filter, nil
+}
+
+// skip reports whether the file at path should be left out, counting it if
+// so. The size is checked first since it needs no read; binary files are
+// recognized like classifyFile does, by a NUL byte near the start.
+func (f *contentFilter) skip(fs *FilesystemHandler, path string, info os.FileInfo) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.maxSize >= 0 && info.Size() > f.maxSize {
+		f.larger++
+		return true
+	}
+	if f.skipBinary && fs.classifyFile(path) == "binary" {
+		f.binary++
+		return true
+	}
+	return false
+}
+
+// note returns a line reporting the skipped files by reason, or "" if none
+// were skipped
+func (f *contentFilter) note() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var reasons []string
+	if f.binary > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d binary (skip_binary)", f.binary))
+	}
+	if f.larger > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d larger than %d bytes (skip_larger_than)", f.larger, f.maxSize))
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nSkipped %d file(s): %s\n", f.binary+f.larger, strings.Join(reasons, ", "))
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// WithContentIndex keeps a trigram index of the text files below the allowed
+// directories, so that search_within_files reads only the files that can
+// contain a literal it searches for instead of walking the tree. The index is
+// loaded from file at startup if it exists, refreshed in the background
+// every interval (0 or less keeps DEFAULT_INDEX_REFRESH_INTERVAL) and saved
+// to file after each refresh. It runs until Close; an empty file disables
+// it.
+func WithContentIndex(file string, interval time.Duration) Option {
+	return func(fs *FilesystemHandler) {
+		fs.indexFile = file
+		fs.indexInterval = interval
+	}
+}
+
+// indexedFile is a text file known to the content index. Files that changed
+// or disappeared are marked dead rather than removed, since the posting
+// lists refer to files by their position.
+type indexedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Live    bool
+}
+
+// indexSnapshot is the state of the content index after a refresh. Once
+// published a snapshot is not modified; a refresh copies it.
+type indexSnapshot struct {
+	Version int
+	Built   time.Time
+	Roots   []string
+	// Truncated says why the index is incomplete: it stopped at
+	// DEFAULT_INDEX_MAX_FILES or the walk limits. It is empty otherwise.
+	Truncated string
+	Files     []indexedFile
+	Postings  map[uint32][]uint32 // trigram to the files containing it
+	Dead      int
+}
+
+// contentIndex maintains the index in the background
+type contentIndex struct {
+	file     string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	current *indexSnapshot // nil until loaded or first built
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Close stops the background refresh and waits for a refresh in progress to
+// give up
+func (ix *contentIndex) Close() error {
+	ix.stopOnce.Do(func() { close(ix.stop) })
+	<-ix.done
+	return nil
+}
+
+// stopped reports whether Close was called
+func (ix *contentIndex) stopped() bool {
+	select {
+	case <-ix.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapshot returns the current state of the index, or nil
+func (ix *contentIndex) snapshot() *indexSnapshot {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.current
+}
+
+// startContentIndex loads the saved index and refreshes it once and then
+// every indexInterval until the handler is closed
+func (fs *FilesystemHandler) startContentIndex() {
+	interval := fs.indexInterval
+	if interval <= 0 {
+		interval = DEFAULT_INDEX_REFRESH_INTERVAL
+	}
+	fs.index = &contentIndex{
+		file:     fs.indexFile,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	fs.registerCloser(fs.index)
+	go func() {
+		defer close(fs.index.done)
+		if snapshot, err := loadIndexSnapshot(fs.index.file); err == nil {
+			fs.index.mu.Lock()
+			fs.index.current = snapshot
+			fs.index.mu.Unlock()
+			fs.logger.Info("Content index loaded", "path", fs.index.file, "files", len(snapshot.Files)-snapshot.Dead, "built", snapshot.Built)
+		} else if !os.IsNotExist(err) {
+			fs.logger.Warn("Cannot load content index, rebuilding it", "path", fs.index.file, "error", err)
+		}
+
+		ticker := time.NewTicker(fs.index.interval)
+		defer ticker.Stop()
+		for {
+			fs.refreshContentIndex()
+			select {
+			case <-ticker.C:
+			case <-fs.index.stop:
+				return
+			}
+		}
+	}()
+}
+
+// refreshContentIndex brings the index up to date with the allowed
+// directories and saves it. Files whose size and modification time are
+// unchanged are not read again.
+func (fs *FilesystemHandler) refreshContentIndex() {
+	ix := fs.index
+	// The refresh runs outside tool calls, so it holds the configuration
+	// itself while it consults it
+	fs.configMu.RLock()
+	roots := slices.Clone(fs.allowedDirs)
+	maxDepth, maxEntries := fs.maxWalkDepth, fs.maxWalkEntries
+	fs.configMu.RUnlock()
+	skip := func(path string) bool {
+		fs.configMu.RLock()
+		defer fs.configMu.RUnlock()
+		return fs.hiddenFromListings(path)
+	}
+
+	previous := ix.snapshot()
+	if previous != nil && (previous.Dead > len(previous.Files)/2 || !slices.Equal(previous.Roots, roots)) {
+		// Rebuilding drops the dead files and the directories removed by a
+		// reload
+		previous = nil
+	}
+	next := &indexSnapshot{
+		Version:  CONTENT_INDEX_VERSION,
+		Roots:    roots,
+		Postings: make(map[uint32][]uint32),
+	}
+	known := make(map[string]uint32)
+	if previous != nil {
+		next.Files = slices.Clone(previous.Files)
+		for trigram, ids := range previous.Postings {
+			next.Postings[trigram] = ids
+		}
+		next.Dead = previous.Dead
+		for id, file := range previous.Files {
+			if file.Live {
+				known[file.Path] = uint32(id)
+			}
+		}
+	}
+
+	started := time.Now()
+	seen := make(map[uint32]bool, len(known))
+	live := len(next.Files) - next.Dead
+	for _, root := range roots {
+		guard := &walkGuard{maxDepth: maxDepth, maxEntries: maxEntries, skip: skip}
+		walkGuarded(fs.fsys, root, guard, func(path string, info os.FileInfo, err error) error {
+			if ix.stopped() {
+				return filepath.SkipAll
+			}
+			if err != nil || !info.Mode().IsRegular() || info.Size() > MAX_SEARCHABLE_SIZE {
+				return nil
+			}
+			id, ok := known[path]
+			if ok && next.Files[id].Size == info.Size() && next.Files[id].ModTime.Equal(info.ModTime()) {
+				seen[id] = true
+				return nil
+			}
+			if ok {
+				seen[id] = true
+				next.Files[id].Live = false
+				next.Dead++
+				live--
+			}
+			if live >= DEFAULT_INDEX_MAX_FILES {
+				next.Truncated = fmt.Sprintf("more than %d files", DEFAULT_INDEX_MAX_FILES)
+				return filepath.SkipAll
+			}
+			data, err := fs.fsys.ReadFile(path)
+			if err != nil || !isTextFile(mimetype.Detect(data).String()) {
+				return nil
+			}
+			id = uint32(len(next.Files))
+			next.Files = append(next.Files, indexedFile{Path: path, Size: info.Size(), ModTime: info.ModTime(), Live: true})
+			for trigram := range trigramsOf(data) {
+				next.Postings[trigram] = append(next.Postings[trigram], id)
+			}
+			live++
+			return nil
+		})
+		if guard.truncated != "" && next.Truncated == "" {
+			next.Truncated = guard.truncated
+		}
+	}
+	if ix.stopped() {
+		return
+	}
+	// Files not met by the walk were deleted or are now hidden
+	for _, id := range known {
+		if !seen[id] {
+			next.Files[id].Live = false
+			next.Dead++
+		}
+	}
+	next.Built = started
+
+	ix.mu.Lock()
+	ix.current = next
+	ix.mu.Unlock()
+	if next.Truncated != "" {
+		fs.logger.Warn("Content index is incomplete, searches walk the tree instead", "reason", next.Truncated)
+	}
+	fs.logger.Debug("Content index refreshed", "files", len(next.Files)-next.Dead, "trigrams", len(next.Postings), "duration", time.Since(started))
+	if err := saveIndexSnapshot(ix.file, next); err != nil {
+		fs.logger.Warn("Cannot save content index", "path", ix.file, "error", err)
+	}
+}
+
+// trigramsOf returns the distinct three-byte sequences of data
+func trigramsOf(data []byte) map[uint32]struct{} {
+	trigrams := make(map[uint32]struct{})
+	for i := 0; i+3 <= len(data); i++ {
+		trigrams[uint32(data[i])<<16|uint32(data[i+1])<<8|uint32(data[i+2])] = struct{}{}
+	}
+	return trigrams
+}
+
+// indexCandidates returns the files below rootPath that may contain literal,
+// sorted by path, and when the index was built. ok is false if the index
+// cannot answer: it is not ready or incomplete, rootPath is outside the
+// directories it covers, or literal is shorter than a trigram.
+func (fs *FilesystemHandler) indexCandidates(rootPath, literal string) (files []string, built time.Time, ok bool) {
+	if fs.index == nil || len(literal) < 3 {
+		return nil, time.Time{}, false
+	}
+	snapshot := fs.index.snapshot()
+	if snapshot == nil || snapshot.Truncated != "" || !slices.ContainsFunc(snapshot.Roots, func(root string) bool {
+		return rootPath == strings.TrimSuffix(root, string(filepath.Separator)) || isWithin(root, rootPath)
+	}) {
+		return nil, time.Time{}, false
+	}
+
+	// Intersect the posting lists, which are sorted by file, starting from
+	// the shortest
+	var lists [][]uint32
+	for trigram := range trigramsOf([]byte(literal)) {
+		lists = append(lists, snapshot.Postings[trigram])
+	}
+	slices.SortFunc(lists, func(a, b []uint32) int { return len(a) - len(b) })
+	ids := lists[0]
+	for _, list := range lists[1:] {
+		ids = intersectSorted(ids, list)
+	}
+
+	for _, id := range ids {
+		file := snapshot.Files[id]
+		if file.Live && (file.Path == rootPath || isWithin(rootPath, file.Path)) {
+			files = append(files, file.Path)
+		}
+	}
+	slices.Sort(files)
+	return files, snapshot.Built, true
+}
+
+// intersectSorted returns the values present in both sorted lists
+func intersectSorted(a, b []uint32) []uint32 {
+	var both []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			both = append(both, a[i])
+			i++
+			j++
+		}
+	}
+	return both
+}
+
+// indexReport describes the content index for get_server_info, or returns ""
+// when it is not running
+func (fs *FilesystemHandler) indexReport() string {
+	if fs.index == nil {
+		return ""
+	}
+	snapshot := fs.index.snapshot()
+	switch {
+	case snapshot == nil:
+		return fmt.Sprintf("Content index (refreshed every %v): building\n", fs.index.interval)
+	case snapshot.Truncated != "":
+		return fmt.Sprintf("Content index (refreshed every %v): incomplete, %s (built %s)\n",
+			fs.index.interval, snapshot.Truncated, snapshot.Built.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("Content index (refreshed every %v): %d files (built %s)\n",
+			fs.index.interval, len(snapshot.Files)-snapshot.Dead, snapshot.Built.Format(time.RFC3339))
+	}
+}
+
+// loadIndexSnapshot reads an index saved by saveIndexSnapshot. An index
+// saved by another version of the server is not used.
+func loadIndexSnapshot(file string) (*indexSnapshot, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var snapshot indexSnapshot
+	if err := gob.NewDecoder(f).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	if snapshot.Version != CONTENT_INDEX_VERSION {
+		return nil, fmt.Errorf("index version %d is not supported", snapshot.Version)
+	}
+	return &snapshot, nil
+}
+
+// saveIndexSnapshot writes snapshot to file, replacing it only once the new
+// index has been written completely
+func saveIndexSnapshot(file string, snapshot *indexSnapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
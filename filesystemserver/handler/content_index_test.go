@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentIndex(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello world\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.go"), []byte("func HelloHandler() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("nothing here\n"), 0644))
+	indexFile := filepath.Join(t.TempDir(), "index.gob")
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithContentIndex(indexFile, time.Hour))
+	require.NoError(t, err)
+	defer handler.Close()
+
+	// Wait for the first refresh to be saved rather than racing it
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(indexFile)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	files, _, ok := handler.indexCandidates(dir, "hello")
+	require.True(t, ok)
+	assert.Equal(t, []string{filepath.Join(dir, "a.txt")}, files)
+	_, _, ok = handler.indexCandidates(dir, "he")
+	assert.False(t, ok, "literals shorter than a trigram are not looked up")
+
+	search := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "search_within_files"
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchWithinFiles(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("substring", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "substring": "hello", "use_index": true})
+		assert.Contains(t, text, "a.txt")
+		assert.Contains(t, text, "searched 1 files that the content index")
+	})
+
+	t.Run("pattern with a literal prefix", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": `Hello\w+\(`, "use_index": true})
+		assert.Contains(t, text, "b.go")
+		assert.Contains(t, text, "content index")
+	})
+
+	t.Run("case-insensitive searches walk the tree", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "substring": "HELLO", "case_insensitive": true, "use_index": true})
+		assert.Contains(t, text, "a.txt")
+		assert.Contains(t, text, "b.go")
+		assert.NotContains(t, text, "content index")
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "d.txt"), []byte("hello again\n"), 0644))
+		require.NoError(t, os.Remove(filepath.Join(dir, "a.txt")))
+
+		// Until the next refresh an indexed search misses a new file, while
+		// a default search walks the tree and finds it
+		assert.NotContains(t, search(map[string]any{"path": dir, "substring": "hello", "use_index": true}), "d.txt")
+		text := search(map[string]any{"path": dir, "substring": "hello"})
+		assert.Contains(t, text, "d.txt")
+		assert.NotContains(t, text, "content index")
+
+		handler.refreshContentIndex()
+		files, _, ok := handler.indexCandidates(dir, "hello")
+		require.True(t, ok)
+		assert.Equal(t, []string{filepath.Join(dir, "d.txt")}, files)
+	})
+
+	t.Run("saved index is loaded", func(t *testing.T) {
+		snapshot, err := loadIndexSnapshot(indexFile)
+		require.NoError(t, err)
+		assert.Equal(t, []string{dir + string(filepath.Separator)}, snapshot.Roots)
+		assert.Equal(t, 3, len(snapshot.Files)-snapshot.Dead)
+	})
+}
+
+func TestContentIndex_WalkLimits(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("hello world\n"), 0644))
+	}
+	indexFile := filepath.Join(t.TempDir(), "index.gob")
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithContentIndex(indexFile, time.Hour), WithWalkLimits(0, 2))
+	require.NoError(t, err)
+	defer handler.Close()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(indexFile)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A build walk cut short by max_walk_entries leaves an index that
+	// searches do not trust
+	snapshot, err := loadIndexSnapshot(indexFile)
+	require.NoError(t, err)
+	assert.NotEmpty(t, snapshot.Truncated)
+	_, _, ok := handler.indexCandidates(dir, "hello")
+	assert.False(t, ok)
+}
+
+func TestIntersectSorted(t *testing.T) {
+	assert.Equal(t, []uint32{2, 5}, intersectSorted([]uint32{1, 2, 5, 7}, []uint32{2, 3, 5}))
+	assert.Empty(t, intersectSorted([]uint32{1}, nil))
+}
@@ -68,8 +68,20 @@ func (fs *FilesystemHandler) HandleCopyFile(
 		}, nil
 	}
 
+	if err := fs.refuseSpecialFile(validSource); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Check if source exists
-	srcInfo, err := os.Stat(validSource)
+	srcInfo, err := fs.fsys.Stat(validSource)
 	if os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -93,6 +105,12 @@ func (fs *FilesystemHandler) HandleCopyFile(
 	}
 
 	validDest, err := fs.validatePath(destination)
+	if err == nil {
+		err = fs.authorize(validDest, OpWrite)
+	}
+	if err == nil {
+		err = fs.authorizeTree(validSource, OpRead, validDest)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -105,9 +123,27 @@ func (fs *FilesystemHandler) HandleCopyFile(
 		}, nil
 	}
 
+	if fs.quotas != nil {
+		size, err := fs.treeSize(validSource)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error measuring source: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		if result := fs.checkQuota(validDest, size, !srcInfo.IsDir()); result != nil {
+			return result, nil
+		}
+	}
+
 	// Create parent directory for destination if it doesn't exist
 	destDir := filepath.Dir(validDest)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if _, err := fs.makeDirs(destDir, fs.dirPerm); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -119,10 +155,12 @@ func (fs *FilesystemHandler) HandleCopyFile(
 		}, nil
 	}
 
+	defer fs.invalidateCache(validDest)
+
 	// Perform the copy operation based on whether source is a file or directory
 	if srcInfo.IsDir() {
 		// It's a directory, copy recursively
-		if err := copyDir(validSource, validDest); err != nil {
+		if err := fs.copyDir(validSource, validDest, copyProgressNotifier(ctx, request)); err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -135,7 +173,7 @@ func (fs *FilesystemHandler) HandleCopyFile(
 		}
 	} else {
 		// It's a file, copy directly
-		if err := copyFile(validSource, validDest); err != nil {
+		if err := fs.copyFileChecked(validSource, validDest, copyProgressNotifier(ctx, request)); err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -148,7 +186,7 @@ func (fs *FilesystemHandler) HandleCopyFile(
 		}
 	}
 
-	resourceURI := pathToResourceURI(validDest)
+	resourceURI := fs.resourceURI(validDest)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
@@ -164,7 +202,7 @@ func (fs *FilesystemHandler) HandleCopyFile(
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Copied file: %s", validDest),
+					Text:     fmt.Sprintf("Copied file: %s", fs.displayPath(validDest)),
 				},
 			},
 		},
@@ -172,51 +210,56 @@ func (fs *FilesystemHandler) HandleCopyFile(
 }
 
 // copyFile copies a single file from src to dst
-func copyFile(src, dst string) error {
+func (fs *FilesystemHandler) copyFile(src, dst string) error {
 	// Open the source file
-	sourceFile, err := os.Open(src)
+	sourceFile, err := fs.fsys.Open(src)
 	if err != nil {
 		return err
 	}
 	defer sourceFile.Close()
 
 	// Create the destination file
-	destFile, err := os.Create(dst)
+	destFile, err := createFile(fs.fsys, dst)
 	if err != nil {
 		return err
 	}
 	defer destFile.Close()
 
-	// Copy the contents
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
+	// Get source file mode and size
+	sourceInfo, err := fs.fsys.Stat(src)
+	if err != nil {
 		return err
 	}
 
-	// Get source file mode
-	sourceInfo, err := os.Stat(src)
+	// Copy the contents, making sure the whole file made it across
+	copied, err := io.Copy(destFile, sourceFile)
 	if err != nil {
 		return err
 	}
+	if sourceInfo.Mode().IsRegular() && copied < sourceInfo.Size() {
+		return shortIOError("copy", src, copied, sourceInfo.Size())
+	}
 
 	// Set the same file mode on destination
-	return os.Chmod(dst, sourceInfo.Mode())
+	return fs.fsys.Chmod(dst, sourceInfo.Mode())
 }
 
-// copyDir recursively copies a directory tree from src to dst
-func copyDir(src, dst string) error {
+// copyDir recursively copies a directory tree from src to dst. Large files
+// are copied with verification and report progress.
+func (fs *FilesystemHandler) copyDir(src, dst string, progress copyProgressFunc) error {
 	// Get properties of source dir
-	srcInfo, err := os.Stat(src)
+	srcInfo, err := fs.fsys.Stat(src)
 	if err != nil {
 		return err
 	}
 
 	// Create the destination directory with the same permissions
-	if err = os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+	if err = fs.fsys.MkdirAll(dst, srcInfo.Mode()); err != nil {
 		return err
 	}
 
 	// Read directory entries
-	entries, err := os.ReadDir(src)
+	entries, err := fs.fsys.ReadDir(src)
 	if err != nil {
 		return err
 	}
@@ -233,11 +276,11 @@ func copyDir(src, dst string) error {
 
 		// Recursively copy subdirectories or copy files
 		if entry.IsDir() {
-			if err = copyDir(srcPath, dstPath); err != nil {
+			if err = fs.copyDir(srcPath, dstPath, progress); err != nil {
 				return err
 			}
 		} else {
-			if err = copyFile(srcPath, dstPath); err != nil {
+			if err = fs.copyFileChecked(srcPath, dstPath, progress); err != nil {
 				return err
 			}
 		}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
@@ -123,3 +124,34 @@ func TestHandleCopyFile(t *testing.T) {
 		require.True(t, res.IsError)
 	})
 }
+
+func TestCopyFileVerified(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "large.bin")
+	dst := filepath.Join(tmpDir, "copy.bin")
+
+	content := bytes.Repeat([]byte("0123456789"), COPY_CHUNK_SIZE/10+100)
+	require.NoError(t, os.WriteFile(src, content, 0640))
+
+	fsHandler, err := NewFilesystemHandler(resolveAllowedDirs(t, tmpDir))
+	require.NoError(t, err)
+
+	var reported []int64
+	err = fsHandler.copyFileVerified(src, dst, func(copied, total int64) {
+		assert.Equal(t, int64(len(content)), total)
+		reported = append(reported, copied)
+	})
+	require.NoError(t, err)
+
+	copied, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, content, copied)
+
+	// One progress report per chunk, ending with the full size
+	require.Len(t, reported, 2)
+	assert.Equal(t, int64(len(content)), reported[len(reported)-1])
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
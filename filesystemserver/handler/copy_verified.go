@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// copyProgressFunc receives the number of bytes copied so far and the total
+type copyProgressFunc func(copied, total int64)
+
+// copyProgressNotifier returns a progress callback that sends MCP progress
+// notifications when the client supplied a progress token, or nil otherwise
+func copyProgressNotifier(ctx context.Context, request mcp.CallToolRequest) copyProgressFunc {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return nil
+	}
+
+	token := request.Params.Meta.ProgressToken
+	return func(copied, total int64) {
+		// Progress is best effort; a blocked client must not fail the copy
+		_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      copied,
+			"total":         total,
+		})
+	}
+}
+
+// copyFileChecked copies a file, switching to the chunked and verified copy
+// for files of at least VERIFIED_COPY_THRESHOLD bytes
+func (fs *FilesystemHandler) copyFileChecked(src, dst string, progress copyProgressFunc) error {
+	info, err := fs.fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.Size() < VERIFIED_COPY_THRESHOLD {
+		return fs.copyFile(src, dst)
+	}
+	return fs.copyFileVerified(src, dst, progress)
+}
+
+// copyFileVerified copies src to dst in chunks, hashing the source as it is
+// read. The destination is then read back and hashed, and removed if the two
+// digests differ. progress, if not nil, is called after every chunk.
+func (fs *FilesystemHandler) copyFileVerified(src, dst string, progress copyProgressFunc) error {
+	sourceFile, err := fs.fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	sourceInfo, err := sourceFile.Stat()
+	if err != nil {
+		return err
+	}
+	total := sourceInfo.Size()
+
+	destFile, err := createFile(fs.fsys, dst)
+	if err != nil {
+		return err
+	}
+
+	sourceHash := sha256.New()
+	buf := make([]byte, COPY_CHUNK_SIZE)
+	var copied int64
+	for {
+		n, readErr := sourceFile.Read(buf)
+		if n > 0 {
+			sourceHash.Write(buf[:n])
+			if written, err := destFile.Write(buf[:n]); err != nil || written < n {
+				destFile.Close()
+				fs.fsys.Remove(dst)
+				if err == nil {
+					err = shortIOError("write", dst, copied+int64(written), copied+int64(n))
+				}
+				return err
+			}
+			copied += int64(n)
+			if progress != nil {
+				progress(copied, total)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			destFile.Close()
+			fs.fsys.Remove(dst)
+			return readErr
+		}
+	}
+
+	if err := destFile.Close(); err != nil {
+		fs.fsys.Remove(dst)
+		return err
+	}
+	if sourceInfo.Mode().IsRegular() && copied < total {
+		fs.fsys.Remove(dst)
+		return shortIOError("read", src, copied, total)
+	}
+
+	// Read the destination back to confirm the bytes landed correctly
+	destDigest, err := fs.fileSHA256(dst)
+	if err != nil {
+		fs.fsys.Remove(dst)
+		return err
+	}
+	if hex.EncodeToString(sourceHash.Sum(nil)) != destDigest {
+		fs.fsys.Remove(dst)
+		return fmt.Errorf("verification failed: destination %s does not match source %s", dst, src)
+	}
+
+	return fs.fsys.Chmod(dst, sourceInfo.Mode())
+}
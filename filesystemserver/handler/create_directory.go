@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -35,7 +36,36 @@ func (fs *FilesystemHandler) HandleCreateDirectory(
 		path = cwd
 	}
 
-	validPath, err := fs.validatePath(path)
+	parents := request.GetBool("parents", true)
+	failIfExists := request.GetBool("fail_if_exists", false)
+
+	perm := fs.dirPerm
+	if modeArg := request.GetString("mode", ""); modeArg != "" {
+		mode, err := strconv.ParseUint(modeArg, 8, 32)
+		if err != nil || mode > 0777 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: invalid mode '%s' (expected an octal permission such as '0755')", modeArg),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		perm = os.FileMode(mode)
+	}
+
+	// Missing parent directories are only acceptable when they will be created
+	var validPath string
+	if parents {
+		validPath, err = fs.validatePathWithParents(path)
+	} else {
+		validPath, err = fs.validatePath(path)
+	}
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -49,9 +79,20 @@ func (fs *FilesystemHandler) HandleCreateDirectory(
 	}
 
 	// Check if path already exists
-	if info, err := os.Stat(validPath); err == nil {
+	if info, err := fs.fsys.Stat(validPath); err == nil {
+		if info.IsDir() && failIfExists {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: directory already exists: %s", path),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
 		if info.IsDir() {
-			resourceURI := pathToResourceURI(validPath)
+			resourceURI := fs.resourceURI(validPath)
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -63,7 +104,7 @@ func (fs *FilesystemHandler) HandleCreateDirectory(
 						Resource: mcp.TextResourceContents{
 							URI:      resourceURI,
 							MIMEType: "text/plain",
-							Text:     fmt.Sprintf("Directory: %s", validPath),
+							Text:     fmt.Sprintf("Directory: %s", fs.displayPath(validPath)),
 						},
 					},
 				},
@@ -80,7 +121,9 @@ func (fs *FilesystemHandler) HandleCreateDirectory(
 		}, nil
 	}
 
-	if err := os.MkdirAll(validPath, 0755); err != nil {
+	// Only the directories created here get the mode
+	created, err := fs.makeDirs(validPath, perm)
+	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -92,19 +135,24 @@ func (fs *FilesystemHandler) HandleCreateDirectory(
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validPath)
+	message := fmt.Sprintf("Successfully created directory %s", path)
+	if len(created) > 1 {
+		message += fmt.Sprintf(" (including %d parent directories)", len(created)-1)
+	}
+
+	resourceURI := fs.resourceURI(validPath)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Successfully created directory %s", path),
+				Text: message,
 			},
 			mcp.EmbeddedResource{
 				Type: "resource",
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Directory: %s", validPath),
+					Text:     fmt.Sprintf("Directory: %s", fs.displayPath(validPath)),
 				},
 			},
 		},
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -94,3 +95,61 @@ func TestHandleCreateDirectory(t *testing.T) {
 		require.True(t, res.IsError)
 	})
 }
+
+func TestHandleCreateDirectory_Options(t *testing.T) {
+	tmpDir := resolveAllowedDirs(t, t.TempDir())[0]
+	fsHandler, err := NewFilesystemHandler([]string{tmpDir})
+	require.NoError(t, err)
+
+	create := func(args map[string]interface{}) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := fsHandler.HandleCreateDirectory(context.Background(), req)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("creates parents with mode", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "a", "b", "c")
+		res := create(map[string]interface{}{"path": path, "mode": "0750"})
+		require.False(t, res.IsError, res.Content[0].(mcp.TextContent).Text)
+		assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "Successfully created directory")
+		assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "including 2 parent directories")
+
+		if runtime.GOOS != "windows" {
+			for _, dir := range []string{"a", "a/b", "a/b/c"} {
+				info, err := os.Stat(filepath.Join(tmpDir, dir))
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0750), info.Mode().Perm(), dir)
+			}
+		}
+	})
+
+	t.Run("parents disabled", func(t *testing.T) {
+		res := create(map[string]interface{}{"path": filepath.Join(tmpDir, "x", "y"), "parents": false})
+		assert.True(t, res.IsError)
+		_, err := os.Stat(filepath.Join(tmpDir, "x"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		path := filepath.Join(tmpDir, "a")
+		res := create(map[string]interface{}{"path": path})
+		require.False(t, res.IsError)
+		assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "Directory already exists")
+
+		res = create(map[string]interface{}{"path": path, "fail_if_exists": true})
+		require.True(t, res.IsError)
+		assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "directory already exists")
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		res := create(map[string]interface{}{"path": filepath.Join(tmpDir, "m"), "mode": "rwx"})
+		assert.True(t, res.IsError)
+	})
+
+	t.Run("parents cannot escape", func(t *testing.T) {
+		res := create(map[string]interface{}{"path": filepath.Join(t.TempDir(), "p", "q")})
+		assert.True(t, res.IsError)
+	})
+}
@@ -36,6 +36,9 @@ func (fs *FilesystemHandler) HandleDeleteFile(
 	}
 
 	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpDelete)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -49,7 +52,7 @@ func (fs *FilesystemHandler) HandleDeleteFile(
 	}
 
 	// Check if path exists
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -92,8 +95,22 @@ func (fs *FilesystemHandler) HandleDeleteFile(
 			}, nil
 		}
 
+		// Everything below the directory goes with it
+		if err := fs.authorizeTree(validPath, OpDelete, ""); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+
 		// It's a directory and recursive is true, so remove it
-		if err := os.RemoveAll(validPath); err != nil {
+		defer fs.invalidateCache(validPath)
+		if err := fs.fsys.RemoveAll(validPath); err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -116,7 +133,8 @@ func (fs *FilesystemHandler) HandleDeleteFile(
 	}
 
 	// It's a file, delete it
-	if err := os.Remove(validPath); err != nil {
+	defer fs.invalidateCache(validPath)
+	if err := fs.fsys.Remove(validPath); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// WithDeniedPaths keeps files matching any of patterns away from clients,
+// such as **/.env, **/id_rsa or **/*.pem. Patterns are globs matched against
+// the slash-separated path relative to the allowed directory; a pattern
+// without a slash matches a name at any depth, and a leading **/ also
+// matches at the top of the directory. Paths inside a matching directory are
+// denied too. A denied path is refused for every operation, whichever tool
+// asks, and is left out of listings, trees and searches.
+func WithDeniedPaths(patterns []string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.deniedConfig = patterns
+	}
+}
+
+// deniedPattern is a compiled pattern of the deny list
+type deniedPattern struct {
+	pattern  string
+	globs    []glob.Glob
+	hasSlash bool
+}
+
+// resolveDeniedPaths compiles the configured deny list
+func (fs *FilesystemHandler) resolveDeniedPaths() error {
+	fs.denied = nil
+	for _, pattern := range fs.deniedConfig {
+		denied := deniedPattern{pattern: pattern, hasSlash: strings.Contains(pattern, "/")}
+		alternatives := []string{pattern}
+		if rest, ok := strings.CutPrefix(pattern, "**/"); ok {
+			alternatives = append(alternatives, rest)
+		}
+		for _, alternative := range alternatives {
+			g, err := glob.Compile(alternative, '/')
+			if err != nil {
+				return fmt.Errorf("invalid denied path pattern %q: %w", pattern, err)
+			}
+			denied.globs = append(denied.globs, g)
+		}
+		fs.denied = append(fs.denied, denied)
+	}
+	return nil
+}
+
+// deniedBy returns the deny list pattern that path, or one of the
+// directories containing it below its allowed directory, matches. It is
+// empty if the path may be accessed.
+func (fs *FilesystemHandler) deniedBy(path string) string {
+	if len(fs.denied) == 0 {
+		return ""
+	}
+	root := fs.rootForPath(path)
+	if root == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	for {
+		for _, denied := range fs.denied {
+			for _, g := range denied.globs {
+				if globMatch(g, denied.hasSlash, rel) {
+					return denied.pattern
+				}
+			}
+		}
+		i := strings.LastIndexByte(rel, '/')
+		if i < 0 {
+			return ""
+		}
+		rel = rel[:i]
+	}
+}
+
+// isDenied reports whether path is on the deny list, for leaving it out of
+// listings
+func (fs *FilesystemHandler) isDenied(path string) bool {
+	return fs.deniedBy(path) != ""
+}
+
+// authorizeDenied refuses every access to a path on the deny list
+func (fs *FilesystemHandler) authorizeDenied(path string) error {
+	if pattern := fs.deniedBy(path); pattern != "" {
+		return fmt.Errorf("access denied - %s matches the denied pattern %s", fs.displayPath(path), pattern)
+	}
+	return nil
+}
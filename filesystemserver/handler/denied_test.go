@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeniedPaths(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "keys"), 0755))
+	files := map[string]string{
+		".env":             "TOKEN=secret",
+		"app/.env":         "TOKEN=secret",
+		"app/keys/id_rsa":  "key",
+		"app/cert.pem":     "cert",
+		"app/main.go":      "package main",
+		"app/environment":  "not a secret",
+		"secrets/token.go": "secret",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithDeniedPaths([]string{"**/.env", "id_rsa", "**/*.pem", "secrets"}))
+	require.NoError(t, err)
+
+	callTool := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("denied files cannot be read or written", func(t *testing.T) {
+		for _, name := range []string{".env", "app/.env", "app/keys/id_rsa", "app/cert.pem", "secrets/token.go"} {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			result := callTool(handler.HandleReadFile, map[string]any{"path": path})
+			require.True(t, result.IsError, name)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "access denied", name)
+
+			result = callTool(handler.HandleWriteFile, map[string]any{"path": path, "content": "x"})
+			require.True(t, result.IsError, name)
+		}
+		result := callTool(handler.HandleWriteFile, map[string]any{"path": filepath.Join(dir, "app", "new.pem"), "content": "x"})
+		require.True(t, result.IsError)
+		assert.NoFileExists(t, filepath.Join(dir, "app", "new.pem"))
+	})
+
+	t.Run("other files are unaffected", func(t *testing.T) {
+		for _, name := range []string{"app/main.go", "app/environment"} {
+			result := callTool(handler.HandleReadFile, map[string]any{"path": filepath.Join(dir, filepath.FromSlash(name))})
+			require.False(t, result.IsError, name)
+		}
+	})
+
+	t.Run("denied files are left out of listings", func(t *testing.T) {
+		result := callTool(handler.HandleListDirectory, map[string]any{"path": dir, "recursive": true})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "main.go")
+		assert.Contains(t, text, "environment")
+		assert.NotContains(t, text, ".env")
+		assert.NotContains(t, text, "id_rsa")
+		assert.NotContains(t, text, "cert.pem")
+		assert.NotContains(t, text, "token.go")
+	})
+
+	t.Run("invalid patterns are rejected", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{dir}, WithDeniedPaths([]string{"[unclosed"}))
+		assert.Error(t, err)
+	})
+}
+
+func TestDeniedPaths_ParentDirectories(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	setup := func(t *testing.T) *FilesystemHandler {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "app")))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "private"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "private", "key"), []byte("SECRET"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "main.go"), []byte("package main"), 0644))
+		handler, err := NewFilesystemHandler([]string{dir},
+			WithDeniedPaths([]string{"app/private/**"}),
+			WithTrashDir(filepath.Join(dir, ".trash")),
+		)
+		require.NoError(t, err)
+		return handler
+	}
+	callTool := func(t *testing.T, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "access denied")
+		assert.FileExists(t, filepath.Join(dir, "app", "private", "key"))
+	}
+	app := filepath.Join(dir, "app")
+
+	t.Run("copy", func(t *testing.T) {
+		handler := setup(t)
+		callTool(t, handler.HandleCopyFile, map[string]any{"source": app, "destination": filepath.Join(dir, "copy")})
+		assert.NoDirExists(t, filepath.Join(dir, "copy"))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		handler := setup(t)
+		callTool(t, handler.HandleMoveFile, map[string]any{"source": app, "destination": filepath.Join(dir, "moved")})
+		assert.NoDirExists(t, filepath.Join(dir, "moved"))
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		handler := setup(t)
+		callTool(t, handler.HandleMoveToTrash, map[string]any{"path": app})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		handler := setup(t)
+		callTool(t, handler.HandleDeleteFile, map[string]any{"path": app, "recursive": true})
+	})
+}
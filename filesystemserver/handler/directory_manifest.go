@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleDirectoryManifest lists every file below a directory with its
+// relative path, size and SHA-256, sorted by path. The manifest does not
+// mention the directory itself, so identical trees produce identical
+// manifests wherever they are.
+func (fs *FilesystemHandler) HandleDirectoryManifest(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	aggregate := request.GetBool("aggregate", false)
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: path must be a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	manifest, err := fs.directoryManifest(ctx, validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error building manifest: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if aggregate {
+		manifest.AggregateSHA256 = manifestDigest(manifest.Files)
+	}
+
+	jsonData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error generating JSON: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	summary := fmt.Sprintf("Manifest of %s: %d files, %d bytes", path, len(manifest.Files), manifest.TotalSize)
+	if len(manifest.Skipped) > 0 {
+		summary += fmt.Sprintf(", %d skipped", len(manifest.Skipped))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: summary + "\n" + walkTruncatedNote(manifest.Truncated) + "\n" + string(jsonData),
+			},
+		},
+	}, nil
+}
+
+// directoryManifest walks root and hashes its files. Symbolic links to files
+// within the allowed directories are listed with the contents they point to;
+// other links, and files larger than MAX_HASH_SIZE, are listed as skipped.
+func (fs *FilesystemHandler) directoryManifest(ctx context.Context, root string) (*DirectoryManifest, error) {
+	manifest := &DirectoryManifest{Files: []ManifestEntry{}}
+	var targets []string
+	truncated, err := fs.walkTree(
+		ctx,
+		root,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, walkPath)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+
+			// Links are followed to files only, and only within the allowed
+			// directories
+			target := walkPath
+			if info.Mode()&os.ModeSymlink != 0 {
+				validTarget, err := fs.validatePath(walkPath)
+				if err != nil {
+					manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: rel, Reason: "symbolic link outside the allowed directories"})
+					return nil
+				}
+				if info, err = fs.fsys.Stat(validTarget); err != nil {
+					manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: rel, Reason: "broken symbolic link"})
+					return nil
+				}
+				if info.IsDir() {
+					manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: rel, Reason: "symbolic link to a directory"})
+					return nil
+				}
+				target = validTarget
+			}
+
+			switch {
+			case !info.Mode().IsRegular():
+				manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: rel, Reason: "not a regular file"})
+			case info.Size() > MAX_HASH_SIZE:
+				manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: rel, Reason: fmt.Sprintf("larger than %d bytes", MAX_HASH_SIZE)})
+			default:
+				manifest.Files = append(manifest.Files, ManifestEntry{Path: rel, Size: info.Size()})
+				targets = append(targets, target)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Truncated = truncated
+
+	errs := make([]error, len(targets))
+	fs.forEach(ctx, len(targets), func(i int) {
+		manifest.Files[i].SHA256, errs[i] = fs.fileSHA256(targets[i])
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// Files that could not be read move to the skipped list
+	files := manifest.Files[:0]
+	for i, entry := range manifest.Files {
+		if errs[i] != nil {
+			reason := "could not be read"
+			if pathErr, ok := errs[i].(*os.PathError); ok {
+				reason += ": " + pathErr.Err.Error()
+			}
+			manifest.Skipped = append(manifest.Skipped, ManifestSkip{Path: entry.Path, Reason: reason})
+			continue
+		}
+		manifest.TotalSize += entry.Size
+		files = append(files, entry)
+	}
+	manifest.Files = files
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+	sort.Slice(manifest.Skipped, func(i, j int) bool { return manifest.Skipped[i].Path < manifest.Skipped[j].Path })
+	return manifest, nil
+}
+
+// manifestDigest returns the SHA-256 of the manifest in sha256sum format, one
+// "<sha256>  <path>\n" line per file in path order, so that it can also be
+// reproduced with `sha256sum` and `sort`
+func manifestDigest(files []ManifestEntry) string {
+	h := sha256.New()
+	for _, entry := range files {
+		fmt.Fprintf(h, "%s  %s\n", entry.SHA256, entry.Path)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
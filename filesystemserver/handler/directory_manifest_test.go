@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDirectoryManifest(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	outside := t.TempDir()
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	// Two identical trees in different places
+	for _, root := range []string{"one", "two"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, root, "src", "pkg"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, root, "b.txt"), []byte("bravo"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, root, "src", "a.go"), []byte("package a"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, root, "src", "pkg", "c.go"), nil, 0644))
+	}
+
+	manifest := func(root string, aggregate bool) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(dir, root), "aggregate": aggregate}
+		result, err := handler.HandleDirectoryManifest(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text[strings.Index(text, "{"):]
+	}
+
+	one := manifest("one", true)
+	assert.Equal(t, one, manifest("two", true))
+	assert.Less(t, strings.Index(one, `"b.txt"`), strings.Index(one, `"src/a.go"`))
+	assert.Less(t, strings.Index(one, `"src/a.go"`), strings.Index(one, `"src/pkg/c.go"`))
+	sum := sha256.Sum256([]byte("bravo"))
+	assert.Contains(t, one, `"sha256": "`+hex.EncodeToString(sum[:])+`"`)
+	assert.Contains(t, one, `"aggregate_sha256"`)
+	assert.NotContains(t, manifest("one", false), "aggregate_sha256")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "two", "b.txt"), []byte("changed"), 0644))
+	assert.NotEqual(t, one, manifest("two", true))
+
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0644))
+		require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "one", "escape")))
+		require.NoError(t, os.Symlink("b.txt", filepath.Join(dir, "one", "alias.txt")))
+
+		text := manifest("one", false)
+		assert.Contains(t, text, `"path": "alias.txt"`)
+		assert.Contains(t, text, `"reason": "symbolic link outside the allowed directories"`)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithDisabledTools turns off the named tools: they are left out of the tool
+// list and calls to them fail. A reload can turn tools on and off again.
+func WithDisabledTools(names []string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.disabledTools = make(map[string]bool, len(names))
+		for _, name := range names {
+			fs.disabledTools[name] = true
+		}
+	}
+}
+
+// FilterTools is a server.ToolFilterFunc that hides the disabled tools, and
+// in read-only mode the tools that change the filesystem, from tools/list
+func (fs *FilesystemHandler) FilterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	fs.configMu.RLock()
+	defer fs.configMu.RUnlock()
+	if len(fs.disabledTools) == 0 && !fs.readOnly {
+		return tools
+	}
+	enabled := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !fs.disabledTools[tool.Name] && !(fs.readOnly && hiddenWhenReadOnly(tool)) {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+// RefuseDisabledTools wraps a tool handler so that calls to a disabled tool
+// fail, for clients that call it from a tool list fetched before it was
+// disabled. It must run inside HoldConfig.
+func (fs *FilesystemHandler) RefuseDisabledTools(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if fs.disabledTools[request.Params.Name] {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: tool_disabled - %s is disabled by the server configuration", request.Params.Name),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// editorConfigSection is one [glob] section of an .editorconfig file
+type editorConfigSection struct {
+	pattern *regexp.Regexp
+	// ranges holds the bounds of the {num1..num2} groups of the glob, in the
+	// order of their capture groups in pattern
+	ranges [][2]int
+	props  map[string]string
+}
+
+// editorConfigFile is a parsed .editorconfig file
+type editorConfigFile struct {
+	root     bool
+	sections []editorConfigSection
+}
+
+// editorConfigEOL maps the end_of_line values to the line endings they stand for
+var editorConfigEOL = map[string]string{"lf": "\n", "crlf": "\r\n", "cr": "\r"}
+
+// editorConfigNumericRange matches the body of a {num1..num2} glob group
+var editorConfigNumericRange = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// editorConfigFor returns the .editorconfig properties that apply to path.
+// The .editorconfig files in its directory and each parent are consulted,
+// stopping at the allowed directory containing path or at a file marked
+// root = true. Closer files take precedence, as do later sections within a
+// file; a property set to unset is removed.
+func (fs *FilesystemHandler) editorConfigFor(path string) (map[string]string, error) {
+	root := fs.rootForPath(path)
+	var files []editorConfigFile
+	var dirs []string
+	for dir := filepath.Dir(path); ; {
+		configPath, err := fs.confinePath(filepath.Join(dir, ".editorconfig"))
+		if err == nil {
+			content, err := fs.fsys.ReadFile(configPath)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			if err == nil {
+				file := parseEditorConfig(content)
+				files = append(files, file)
+				dirs = append(dirs, dir)
+				if file.root {
+					break
+				}
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == root || root == "" || parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	props := make(map[string]string)
+	for i := len(files) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, section := range files[i].sections {
+			if !section.matches(rel) {
+				continue
+			}
+			for key, value := range section.props {
+				if value == "unset" {
+					delete(props, key)
+				} else {
+					props[key] = value
+				}
+			}
+		}
+	}
+	return props, nil
+}
+
+// parseEditorConfig reads an .editorconfig file. Keys and values are
+// lowercased; comments, malformed lines and sections with a glob that cannot
+// be used are skipped, as the format asks.
+func parseEditorConfig(content []byte) editorConfigFile {
+	var file editorConfigFile
+	var section *editorConfigSection
+	preamble := true
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section, preamble = nil, false
+			pattern, ranges, err := editorConfigGlob(line[1 : len(line)-1])
+			if err == nil {
+				file.sections = append(file.sections, editorConfigSection{
+					pattern: pattern,
+					ranges:  ranges,
+					props:   make(map[string]string),
+				})
+				section = &file.sections[len(file.sections)-1]
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch {
+		case preamble:
+			// The preamble only knows the root property
+			if key == "root" {
+				file.root = value == "true"
+			}
+		case section != nil:
+			section.props[key] = value
+		}
+	}
+	return file
+}
+
+// matches reports whether the section applies to rel, a slash-separated path
+// relative to the directory of its .editorconfig file
+func (s editorConfigSection) matches(rel string) bool {
+	match := s.pattern.FindStringSubmatch(rel)
+	if match == nil {
+		return false
+	}
+	for i, bounds := range s.ranges {
+		if match[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil || n < bounds[0] || n > bounds[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// editorConfigGlob converts an .editorconfig section glob to a regular
+// expression. A glob without a slash matches a file name in any directory;
+// one with a slash is relative to the .editorconfig file.
+func editorConfigGlob(glob string) (*regexp.Regexp, [][2]int, error) {
+	var ranges [][2]int
+	prefix := "^(?:.*/)?"
+	if strings.Contains(glob, "/") {
+		prefix = "^"
+		glob = strings.TrimPrefix(glob, "/")
+	}
+	pattern, err := regexp.Compile(prefix + editorConfigGlobBody(glob, &ranges) + "$")
+	return pattern, ranges, err
+}
+
+// editorConfigGlobBody converts glob without anchoring it, appending the
+// bounds of every {num1..num2} group to ranges
+func editorConfigGlobBody(glob string, ranges *[][2]int) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end <= 0 || strings.Contains(glob[i+1:i+1+end], "/") {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '{':
+			end := matchingBrace(glob, i)
+			if end < 0 {
+				sb.WriteString(`\{`)
+				continue
+			}
+			body := glob[i+1 : end]
+			i = end
+			if m := editorConfigNumericRange.FindStringSubmatch(body); m != nil {
+				lo, _ := strconv.Atoi(m[1])
+				hi, _ := strconv.Atoi(m[2])
+				*ranges = append(*ranges, [2]int{min(lo, hi), max(lo, hi)})
+				sb.WriteString(`([+-]?\d+)`)
+				continue
+			}
+			alternatives := splitBraceAlternatives(body)
+			if len(alternatives) < 2 {
+				// A brace group without a comma is taken literally
+				sb.WriteString(regexp.QuoteMeta("{") + editorConfigGlobBody(body, ranges) + regexp.QuoteMeta("}"))
+				continue
+			}
+			sb.WriteString("(?:")
+			for j, alternative := range alternatives {
+				if j > 0 {
+					sb.WriteString("|")
+				}
+				sb.WriteString(editorConfigGlobBody(alternative, ranges))
+			}
+			sb.WriteString(")")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// matchingBrace returns the index of the brace closing the one at open, or
+// -1 if it is never closed
+func matchingBrace(glob string, open int) int {
+	depth := 0
+	for i := open; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitBraceAlternatives splits the body of a brace group at its top-level
+// commas
+func splitBraceAlternatives(body string) []string {
+	var alternatives []string
+	depth, start := 0, 0
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, body[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, body[start:])
+}
+
+// applyEditorConfig rewrites content according to the end_of_line,
+// trim_trailing_whitespace and insert_final_newline properties and returns
+// it with the properties that were applied, as key=value pairs
+func applyEditorConfig(content string, props map[string]string) (string, []string) {
+	var applied []string
+	eol := editorConfigEOL[props["end_of_line"]]
+	trim := props["trim_trailing_whitespace"] == "true"
+	if eol != "" {
+		applied = append(applied, "end_of_line="+props["end_of_line"])
+	}
+	if value := props["trim_trailing_whitespace"]; value == "true" || value == "false" {
+		applied = append(applied, "trim_trailing_whitespace="+value)
+	}
+
+	if eol != "" || trim {
+		var sb strings.Builder
+		for rest := content; rest != ""; {
+			line, ending := rest, ""
+			if i := strings.IndexAny(rest, "\r\n"); i >= 0 {
+				line, ending = rest[:i], rest[i:i+1]
+				if strings.HasPrefix(rest[i:], "\r\n") {
+					ending = "\r\n"
+				}
+			}
+			rest = rest[len(line)+len(ending):]
+			if trim {
+				line = strings.TrimRight(line, " \t")
+			}
+			if eol != "" && ending != "" {
+				ending = eol
+			}
+			sb.WriteString(line)
+			sb.WriteString(ending)
+		}
+		content = sb.String()
+	}
+
+	switch props["insert_final_newline"] {
+	case "true":
+		applied = append(applied, "insert_final_newline=true")
+		if content != "" && !strings.HasSuffix(content, "\n") && !strings.HasSuffix(content, "\r") {
+			if eol == "" {
+				eol = firstLineEnding(content)
+			}
+			content += eol
+		}
+	case "false":
+		applied = append(applied, "insert_final_newline=false")
+		content = strings.TrimRight(content, "\r\n")
+	}
+	return content, applied
+}
+
+// firstLineEnding returns the first line ending used in content, or "\n" if
+// it has none
+func firstLineEnding(content string) string {
+	i := strings.IndexAny(content, "\r\n")
+	switch {
+	case i < 0:
+		return "\n"
+	case strings.HasPrefix(content[i:], "\r\n"):
+		return "\r\n"
+	default:
+		return content[i : i+1]
+	}
+}
+
+// editorConfigCharset maps a charset property to the encoding name and byte
+// order mark flag understood by encodeText; ok is false for values it does
+// not know
+func editorConfigCharset(charset string) (name string, bom bool, ok bool) {
+	switch charset {
+	case "utf-8":
+		return "utf-8", false, true
+	case "utf-8-bom":
+		return "utf-8", true, true
+	case "latin1":
+		return "iso-8859-1", false, true
+	case "utf-16be", "utf-16le":
+		return charset, false, true
+	}
+	return "", false, false
+}
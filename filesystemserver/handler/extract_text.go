@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	mimePDF  = "application/pdf"
+	mimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	// wordNamespace is the XML namespace of the WordprocessingML body
+	wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+)
+
+// HandleExtractText returns the plain text of a PDF or DOCX document, marking
+// page (PDF) or paragraph (DOCX) boundaries so that passages can be cited
+func (fs *FilesystemHandler) HandleExtractText(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: Cannot extract text from a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info.Size() > MAX_EXTRACT_SIZE {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: file is too large to extract text from (%d bytes, limit %d bytes)", info.Size(), MAX_EXTRACT_SIZE),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	mimeType := documentType(fs.detectMimeType(validPath), validPath)
+	if mimeType != mimePDF && mimeType != mimeDOCX {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported format %s (extract_text supports PDF and DOCX documents)", mimeType),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	data, err := readFileFull(fs.fsys, validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var text, summary string
+	if mimeType == mimePDF {
+		var pages int
+		text, pages, err = extractPDFText(data)
+		summary = fmt.Sprintf("PDF, %d page(s)", pages)
+	} else {
+		var paragraphs int
+		text, paragraphs, err = extractDOCXText(data)
+		summary = fmt.Sprintf("DOCX, %d paragraph(s)", paragraphs)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error extracting text: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Text of %s (%s):\n\n", fs.outputPath(ctx, validPath), summary))
+	if len(text) > MAX_INLINE_SIZE {
+		result.WriteString(strings.ToValidUTF8(text[:MAX_INLINE_SIZE], ""))
+		result.WriteString(fmt.Sprintf("\n\nNote: text truncated to %d bytes.", MAX_INLINE_SIZE))
+	} else {
+		result.WriteString(text)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// documentType refines a detected MIME type using the file extension, for
+// documents whose magic bytes are too generic (DOCX files are ZIP archives)
+func documentType(mimeType, path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		if mimeType == "application/octet-stream" {
+			return mimePDF
+		}
+	case ".docx":
+		if mimeType == "application/zip" || mimeType == "application/octet-stream" {
+			return mimeDOCX
+		}
+	}
+	return mimeType
+}
+
+// extractPDFText returns the text layer of a PDF, one section per page, along
+// with the number of pages
+func extractPDFText(data []byte) (text string, pages int, err error) {
+	// The PDF reader panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var result strings.Builder
+	fonts := make(map[string]*pdf.Font)
+	pages = reader.NumPage()
+	for i := 1; i <= pages; i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", 0, fmt.Errorf("page %d: %w", i, err)
+		}
+		result.WriteString(fmt.Sprintf("--- Page %d ---\n", i))
+		result.WriteString(strings.TrimSpace(pageText))
+		result.WriteString("\n\n")
+	}
+	return result.String(), pages, nil
+}
+
+// extractDOCXText returns the paragraphs of a DOCX document's body, each
+// prefixed with its position in the document, along with the number of
+// paragraphs. Empty paragraphs are counted but not printed.
+func extractDOCXText(data []byte) (string, int, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, fmt.Errorf("not a valid DOCX archive: %w", err)
+	}
+	document, err := archive.Open("word/document.xml")
+	if err != nil {
+		return "", 0, errors.New("not a valid DOCX archive: word/document.xml is missing")
+	}
+	defer document.Close()
+
+	var result, paragraph strings.Builder
+	paragraphs := 0
+	inText := false
+	decoder := xml.NewDecoder(document)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid document.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "p":
+				paragraphs++
+				paragraph.Reset()
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			}
+		case xml.EndElement:
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if text := strings.TrimSpace(paragraph.String()); text != "" {
+					result.WriteString(fmt.Sprintf("[Paragraph %d] %s\n", paragraphs, text))
+				}
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+	return result.String(), paragraphs, nil
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF returns a minimal PDF with one page of text per entry in pages
+func buildPDF(pages ...string) []byte {
+	var objects []string
+	kids := ""
+	for i := range pages {
+		kids += fmt.Sprintf("%d 0 R ", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// buildDOCX returns a minimal DOCX document with the given paragraphs
+func buildDOCX(t *testing.T, paragraphs ...string) []byte {
+	var body string
+	for _, p := range paragraphs {
+		body += fmt.Sprintf("<w:p><w:r><w:t>%s</w:t></w:r></w:p>", p)
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"word/document.xml":   `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`,
+	} {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestExtractText(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	extract := func(path string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "extract_text"
+		request.Params.Arguments = map[string]any{"path": path}
+		result, err := handler.HandleExtractText(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("pdf pages", func(t *testing.T) {
+		path := filepath.Join(dir, "doc.pdf")
+		require.NoError(t, os.WriteFile(path, buildPDF("Hello first page", "Second page text"), 0644))
+
+		result := extract(path)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "PDF, 2 page(s)")
+		assert.Contains(t, text, "--- Page 1 ---\nHello first page")
+		assert.Contains(t, text, "--- Page 2 ---\nSecond page text")
+	})
+
+	t.Run("docx paragraphs", func(t *testing.T) {
+		path := filepath.Join(dir, "doc.docx")
+		require.NoError(t, os.WriteFile(path, buildDOCX(t, "Introduction", "", "Body &amp; more"), 0644))
+
+		result := extract(path)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "DOCX, 3 paragraph(s)")
+		assert.Contains(t, text, "[Paragraph 1] Introduction\n")
+		assert.Contains(t, text, "[Paragraph 3] Body & more\n")
+		assert.NotContains(t, text, "[Paragraph 2]")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		path := filepath.Join(dir, "notes.txt")
+		require.NoError(t, os.WriteFile(path, []byte("plain"), 0644))
+
+		result := extract(path)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "unsupported format")
+	})
+
+	t.Run("corrupt pdf", func(t *testing.T) {
+		path := filepath.Join(dir, "broken.pdf")
+		require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4\ngarbage"), 0644))
+
+		result := extract(path)
+		require.True(t, result.IsError)
+	})
+}
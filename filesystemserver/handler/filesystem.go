@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// File is an open file returned by a FileSystem. *os.File implements it.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Seeker
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Sync() error
+}
+
+// FileSystem is the set of filesystem operations used by the tool handlers.
+// Paths passed to it are absolute and have already been validated against
+// the allowed directories. OSFileSystem is used unless another FileSystem
+// is supplied with WithFileSystem.
+type FileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+	Chmod(name string, mode os.FileMode) error
+	Chtimes(name string, atime, mtime time.Time) error
+	EvalSymlinks(path string) (string, error)
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+	Link(oldname, newname string) error
+}
+
+// WithFileSystem makes the handler use fsys instead of the real disk, for
+// example a MemFileSystem in tests
+func WithFileSystem(fsys FileSystem) Option {
+	return func(fs *FilesystemHandler) {
+		fs.fsys = fsys
+	}
+}
+
+// OSFileSystem implements FileSystem using the os package
+type OSFileSystem struct{}
+
+func (OSFileSystem) Stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+func (OSFileSystem) Lstat(name string) (os.FileInfo, error)     { return os.Lstat(name) }
+func (OSFileSystem) ReadDir(name string) ([]os.DirEntry, error) { return os.ReadDir(name) }
+func (OSFileSystem) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+func (OSFileSystem) Remove(name string) error                  { return os.Remove(name) }
+func (OSFileSystem) RemoveAll(path string) error               { return os.RemoveAll(path) }
+func (OSFileSystem) Rename(oldpath, newpath string) error      { return os.Rename(oldpath, newpath) }
+func (OSFileSystem) Chmod(name string, mode os.FileMode) error { return os.Chmod(name, mode) }
+func (OSFileSystem) EvalSymlinks(path string) (string, error)  { return filepath.EvalSymlinks(path) }
+func (OSFileSystem) Symlink(oldname, newname string) error     { return os.Symlink(oldname, newname) }
+func (OSFileSystem) Readlink(name string) (string, error)      { return os.Readlink(name) }
+func (OSFileSystem) Link(oldname, newname string) error        { return os.Link(oldname, newname) }
+
+func (OSFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OSFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (OSFileSystem) Open(name string) (File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSFileSystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// createFile creates or truncates the named file for writing, like os.Create
+func createFile(fsys FileSystem, name string) (File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// syncDir flushes the entries of the named directory to disk, so that a file
+// created or renamed in it survives a crash. Windows cannot sync a
+// directory, so there it does nothing.
+func syncDir(fsys FileSystem, dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// writeFileAtomic replaces the named file with data by writing a temporary
+// file in the same directory and renaming it over the original, so readers
+// never observe a partially written file. The file keeps perm.
+func writeFileAtomic(fsys FileSystem, name string, data []byte, perm os.FileMode) error {
+	return writeAtomic(fsys, name, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeAtomic replaces the named file with the output of write, using a
+// temporary file in the same directory that is renamed over the original
+// once write succeeds. The file keeps perm. The temporary file must be as
+// large as the bytes written to it, or the write fails with a short_io error
+// and the original is left alone.
+func writeAtomic(fsys FileSystem, name string, perm os.FileMode, write func(io.Writer) error) error {
+	dir, base := filepath.Split(name)
+
+	var tmp File
+	var tmpName string
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		tmpName = filepath.Join(dir, fmt.Sprintf(".%s.tmp-%d", base, rand.Int63()))
+		tmp, err = fsys.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	w := &checkedWriter{w: tmp, name: name}
+	if err := write(w); err != nil {
+		tmp.Close()
+		fsys.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		fsys.Remove(tmpName)
+		return err
+	}
+	if info, err := fsys.Stat(tmpName); err != nil || info.Size() != w.n {
+		fsys.Remove(tmpName)
+		if err != nil {
+			return err
+		}
+		return shortIOError("write", name, info.Size(), w.n)
+	}
+	// The create mode is subject to the umask
+	if err := fsys.Chmod(tmpName, perm); err != nil {
+		fsys.Remove(tmpName)
+		return err
+	}
+	if err := fsys.Rename(tmpName, name); err != nil {
+		fsys.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// walk walks the file tree rooted at root like filepath.Walk, reading the
+// tree through fsys. Symbolic links are not followed.
+func walk(fsys FileSystem, root string, fn filepath.WalkFunc) error {
+	return walkGuarded(fsys, root, &walkGuard{}, fn)
+}
+
+// walkGuard bounds a walk. A directory deeper than maxDepth below the root is
+// reported but not descended into, and the walk stops once maxEntries entries
+// have been visited or ctx, if set, is done. Zero limits are unbounded.
+// Entries for which skip, if set, returns true and entries that ignore
+// excludes are neither visited nor counted. truncated records why a limit
+// cut the walk short.
+type walkGuard struct {
+	ctx        context.Context
+	maxDepth   int
+	maxEntries int
+	skip       func(path string) bool
+	ignore     *gitignore
+	entries    int
+	truncated  string
+}
+
+// stopped reports whether the context of the walk is done, recording why in
+// truncated
+func (g *walkGuard) stopped() bool {
+	if g.ctx == nil || g.ctx.Err() == nil {
+		return false
+	}
+	g.truncated = callStoppedReason(g.ctx)
+	return true
+}
+
+// walkGuarded is walk with the limits of guard
+func walkGuarded(fsys FileSystem, root string, guard *walkGuard, fn filepath.WalkFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkPath(fsys, root, info, 0, guard, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkPath(fsys FileSystem, path string, info os.FileInfo, depth int, guard *walkGuard, fn filepath.WalkFunc) error {
+	if guard.stopped() {
+		return filepath.SkipAll
+	}
+	if depth > 0 {
+		guard.entries++
+		if guard.maxEntries > 0 && guard.entries > guard.maxEntries {
+			guard.truncated = fmt.Sprintf("max_walk_entries limit of %d reached", guard.maxEntries)
+			return filepath.SkipAll
+		}
+	}
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := fsys.ReadDir(path)
+	err1 := fn(path, info, err)
+	// If err != nil, walk can't descend into this directory
+	if err != nil || err1 != nil {
+		return err1
+	}
+	if guard.maxDepth > 0 && depth >= guard.maxDepth && len(entries) > 0 {
+		guard.truncated = fmt.Sprintf("max_walk_depth limit of %d reached", guard.maxDepth)
+		return nil
+	}
+
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+		if guard.skip != nil && guard.skip(name) || guard.ignore.ignored(name, entry.IsDir()) {
+			continue
+		}
+		entryInfo, err := fsys.Lstat(name)
+		if err != nil {
+			if err := fn(name, entryInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := walkPath(fsys, name, entryInfo, depth+1, guard, fn); err != nil {
+			if !entryInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sameFile reports whether two FileInfos describe the same file. It extends
+// os.SameFile to FileInfos returned by other FileSystem implementations,
+// which identify a file by their Sys value.
+func sameFile(a, b os.FileInfo) bool {
+	if a.Sys() != nil && a.Sys() == b.Sys() {
+		return true
+	}
+	return os.SameFile(a, b)
+}
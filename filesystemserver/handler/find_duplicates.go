@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// duplicateGroup is a set of files with identical contents
+type duplicateGroup struct {
+	size   int64
+	digest string
+	paths  []string
+}
+
+// HandleFindDuplicates reports files below a directory that have identical
+// contents. Files are grouped by size first and only files sharing a size
+// are hashed.
+func (fs *FilesystemHandler) HandleFindDuplicates(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	minSize := int64(1)
+	if minSizeArg, err := request.RequireFloat("min_size"); err == nil {
+		if minSizeArg < 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: min_size cannot be negative",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		minSize = int64(minSizeArg)
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: path must be a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	groups, skipped, truncated, err := fs.findDuplicates(ctx, validPath, minSize)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error finding duplicates: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	if len(groups) == 0 {
+		result.WriteString(fmt.Sprintf("No duplicate files found under %s\n", path))
+	} else {
+		files := 0
+		var reclaimable int64
+		for _, group := range groups {
+			files += len(group.paths)
+			reclaimable += group.size * int64(len(group.paths)-1)
+		}
+		result.WriteString(fmt.Sprintf("Found %d group(s) of duplicate files (%d files, %d bytes reclaimable):\n", len(groups), files, reclaimable))
+		for i, group := range groups {
+			result.WriteString(fmt.Sprintf("\nGroup %d: %d files of %d bytes (sha256 %s)\n", i+1, len(group.paths), group.size, group.digest))
+			for _, p := range group.paths {
+				result.WriteString(fmt.Sprintf("  %s\n", fs.displayPath(p)))
+			}
+		}
+	}
+	if skipped > 0 {
+		result.WriteString(fmt.Sprintf("\nNote: %d file(s) larger than %d bytes were not compared.\n", skipped, MAX_HASH_SIZE))
+	}
+	result.WriteString(walkTruncatedNote(truncated))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// findDuplicates walks root and returns the groups of regular files with
+// identical contents, largest potential savings first, along with the number
+// of files too large to hash. Symbolic links are not followed. truncated is
+// set if the walk limits cut the walk short.
+func (fs *FilesystemHandler) findDuplicates(
+	ctx context.Context, root string, minSize int64,
+) (groups []duplicateGroup, skipped int, truncated string, err error) {
+	bySize := make(map[int64][]string)
+	truncated, err = fs.walkTree(
+		ctx,
+		root,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip errors and continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !info.Mode().IsRegular() || info.Size() < minSize {
+				return nil
+			}
+			if _, err := fs.validatePath(walkPath); err != nil {
+				return nil // Skip invalid paths
+			}
+			if info.Size() > MAX_HASH_SIZE {
+				skipped++
+				return nil
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], walkPath)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		digests := make([]string, len(paths))
+		fs.forEach(ctx, len(paths), func(i int) {
+			digests[i], _ = fs.fileSHA256(paths[i]) // Unreadable files are skipped
+		})
+		if ctx.Err() != nil {
+			return nil, 0, "", ctx.Err()
+		}
+		byDigest := make(map[string][]string)
+		for i, digest := range digests {
+			if digest != "" {
+				byDigest[digest] = append(byDigest[digest], paths[i])
+			}
+		}
+		for digest, same := range byDigest {
+			if len(same) < 2 {
+				continue
+			}
+			sort.Strings(same)
+			groups = append(groups, duplicateGroup{size: size, digest: digest, paths: same})
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		wasteI := groups[i].size * int64(len(groups[i].paths)-1)
+		wasteJ := groups[j].size * int64(len(groups[j].paths)-1)
+		if wasteI != wasteJ {
+			return wasteI > wasteJ
+		}
+		return groups[i].paths[0] < groups[j].paths[0]
+	})
+	return groups, skipped, truncated, nil
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+
+	files := map[string]string{
+		"a.txt":          "duplicate contents",
+		"sub/b.txt":      "duplicate contents",
+		"c.txt":          "different contents",
+		"d.txt":          "x",
+		"sub/e.txt":      "x",
+		"f.txt":          "same size  content",
+		"empty1.txt":     "",
+		"sub/empty2.txt": "",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("duplicate contents"), 0644))
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "link.txt")))
+	}
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	find := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "find_duplicates"
+		request.Params.Arguments = args
+		result, err := handler.HandleFindDuplicates(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := find(map[string]any{"path": dir})
+	assert.Contains(t, text, "Found 2 group(s) of duplicate files (4 files, 19 bytes reclaimable)")
+	assert.Contains(t, text, "a.txt")
+	assert.Contains(t, text, filepath.Join("sub", "b.txt"))
+	assert.NotContains(t, text, "c.txt")
+	assert.NotContains(t, text, "f.txt")
+	assert.NotContains(t, text, "empty")
+	assert.NotContains(t, text, "link.txt")
+	assert.NotContains(t, text, "secret.txt")
+
+	text = find(map[string]any{"path": dir, "min_size": float64(2)})
+	assert.Contains(t, text, "Found 1 group(s)")
+	assert.NotContains(t, text, "d.txt")
+
+	text = find(map[string]any{"path": filepath.Join(dir, "sub")})
+	assert.Contains(t, text, "No duplicate files found")
+}
@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fileFollower polls a file for appended content and reports complete lines
+// through notify, similar to `tail -f`
+type fileFollower struct {
+	fsys    FileSystem
+	id      string
+	session string // the client session that started the follow
+	path    string
+	notify  func(lines []string) error
+
+	// state of the followed file, only accessed from the polling goroutine
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newFileFollower(fsys FileSystem, id, session, path string, notify func(lines []string) error) (*fileFollower, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileFollower{
+		fsys:    fsys,
+		id:      id,
+		session: session,
+		path:    path,
+		notify:  notify,
+		info:    info,
+		offset:  info.Size(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// run polls the file until the follower is closed or notify reports that the
+// client session is gone. onExit is called when run returns.
+func (f *fileFollower) run(interval time.Duration, onExit func()) {
+	defer close(f.done)
+	defer onExit()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			lines, err := f.poll()
+			if err != nil || len(lines) == 0 {
+				// The file may be temporarily missing during rotation
+				continue
+			}
+			if err := f.notify(lines); errors.Is(err, server.ErrSessionNotFound) {
+				return
+			}
+		}
+	}
+}
+
+// poll reads any content appended since the last poll and returns the
+// complete lines. If the file was truncated or replaced it is read again from
+// the start.
+func (f *fileFollower) poll() ([]string, error) {
+	info, err := f.fsys.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle rotation (a new file at the same path) and truncation
+	if !sameFile(f.info, info) || info.Size() < f.offset {
+		f.offset = 0
+		f.partial = nil
+	}
+	f.info = info
+
+	if info.Size() == f.offset {
+		return nil, nil
+	}
+
+	file, err := f.fsys.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	toRead := info.Size() - f.offset
+	if toRead > MAX_FOLLOW_READ_SIZE {
+		toRead = MAX_FOLLOW_READ_SIZE
+	}
+	buf := make([]byte, toRead)
+	n, err := file.ReadAt(buf, f.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	f.offset += int64(n)
+
+	data := append(f.partial, buf[:n]...)
+	lastNewline := bytes.LastIndexByte(data, '\n')
+	if lastNewline < 0 {
+		// A line longer than MAX_FOLLOW_READ_SIZE is reported in pieces
+		// rather than held in memory until it ends
+		if len(data) >= MAX_FOLLOW_READ_SIZE {
+			f.partial = nil
+			return []string{string(data)}, nil
+		}
+		f.partial = data
+		return nil, nil
+	}
+	f.partial = append([]byte(nil), data[lastNewline+1:]...)
+
+	lines := strings.Split(string(data[:lastNewline]), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+// Close stops the follower and waits for its polling goroutine to exit
+func (f *fileFollower) Close() error {
+	f.stopOnce.Do(func() { close(f.stop) })
+	<-f.done
+	return nil
+}
+
+// removeFollow forgets a follower once it has stopped and returns its share
+// of the resource budget
+func (fs *FilesystemHandler) removeFollow(f *fileFollower) {
+	fs.followsMu.Lock()
+	if fs.follows[f.id] == f {
+		delete(fs.follows, f.id)
+	}
+	fs.followsMu.Unlock()
+	fs.unregisterCloser(f)
+	fs.budget.release("follow")
+}
+
+func (fs *FilesystemHandler) HandleFollowFile(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract last_lines parameter (optional, default: 0)
+	lastLines := 0
+	if lastLinesParam, err := request.RequireFloat("last_lines"); err == nil {
+		lastLines = int(lastLinesParam)
+		if lastLines < 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: last_lines cannot be negative",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// New lines are delivered as notifications, which requires a client session
+	mcpServer := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if mcpServer == nil || session == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: follow_file requires an active client session to deliver notifications",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: Cannot follow a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Read the requested number of trailing lines before following
+	var initialLines []string
+	if lastLines > 0 {
+		initialLines, err = readLastLines(fs.fsys, validPath, lastLines)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error reading file: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	fs.followsMu.Lock()
+	if len(fs.follows) >= MAX_FOLLOWS {
+		fs.followsMu.Unlock()
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: too many active follows (maximum is %d). Use stop_follow to stop one first.", MAX_FOLLOWS),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if err := fs.budget.acquire("follow"); err != nil {
+		fs.followsMu.Unlock()
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	fs.followSeq++
+	id := fmt.Sprintf("follow-%d", fs.followSeq)
+
+	sessionID := session.SessionID()
+	displayPath := fs.outputPath(ctx, validPath)
+	notify := func(lines []string) error {
+		return mcpServer.SendNotificationToSpecificClient(sessionID, "notifications/message", map[string]any{
+			"level":  "info",
+			"logger": "follow_file",
+			"data": map[string]any{
+				"follow_id": id,
+				"path":      displayPath,
+				"lines":     lines,
+			},
+		})
+	}
+
+	follower, err := newFileFollower(fs.fsys, id, sessionID, validPath, notify)
+	if err != nil {
+		fs.followsMu.Unlock()
+		fs.budget.release("follow")
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if fs.follows == nil {
+		fs.follows = make(map[string]*fileFollower)
+	}
+	fs.follows[id] = follower
+	fs.followsMu.Unlock()
+
+	fs.registerCloser(follower)
+	go follower.run(FOLLOW_POLL_INTERVAL, func() { fs.removeFollow(follower) })
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Following %s (follow_id: %s)\n", fs.outputPath(ctx, validPath), id))
+	result.WriteString("New lines are delivered as notifications/message notifications. Use stop_follow to stop following.\n")
+	if len(initialLines) > 0 {
+		result.WriteString(fmt.Sprintf("\nLast %d line(s):\n", len(initialLines)))
+		result.WriteString(strings.Join(initialLines, "\n"))
+		result.WriteString("\n")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+func (fs *FilesystemHandler) HandleStopFollow(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("follow_id")
+	if err != nil {
+		return nil, err
+	}
+
+	// A follow can only be stopped by the session that started it
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+
+	fs.followsMu.Lock()
+	follower, ok := fs.follows[id]
+	ok = ok && follower.session == sessionID
+	if ok {
+		delete(fs.follows, id)
+	}
+	fs.followsMu.Unlock()
+
+	if !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: no active follow with id %s", id),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	follower.Close()
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Stopped following %s (follow_id: %s)", fs.displayPath(follower.path), id),
+			},
+		},
+	}, nil
+}
+
+// readLastLines returns up to n complete lines from the end of a file,
+// reading at most MAX_FOLLOW_READ_SIZE bytes
+func readLastLines(fsys FileSystem, path string, n int) ([]string, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	start := info.Size() - MAX_FOLLOW_READ_SIZE
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, info.Size()-start)
+	if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	text := strings.TrimSuffix(strings.ReplaceAll(string(buf), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	// Drop a leading partial line when the read did not start at the beginning
+	if start > 0 && len(lines) > 1 {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
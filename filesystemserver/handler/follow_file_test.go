@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFollower(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0644))
+
+	received := make(chan []string, 10)
+	follower, err := newFileFollower(OSFileSystem{}, "follow-1", "", path, func(lines []string) error {
+		received <- lines
+		return nil
+	})
+	require.NoError(t, err)
+	go follower.run(10*time.Millisecond, func() {})
+	defer follower.Close()
+
+	next := func() []string {
+		select {
+		case lines := <-received:
+			return lines
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for lines")
+			return nil
+		}
+	}
+
+	t.Run("appended lines are reported", func(t *testing.T) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString("first\nsecond\npart")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, []string{"first", "second"}, next())
+	})
+
+	t.Run("truncation restarts from the beginning", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("fresh\n"), 0644))
+		assert.Equal(t, []string{"fresh"}, next())
+	})
+}
+
+func TestFileFollower_LongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	follower, err := newFileFollower(OSFileSystem{}, "follow-1", "", path, func([]string) error { return nil })
+	require.NoError(t, err)
+
+	long := strings.Repeat("x", MAX_FOLLOW_READ_SIZE+100)
+	require.NoError(t, os.WriteFile(path, []byte(long+"\n"), 0644))
+
+	lines, err := follower.poll()
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Len(t, lines[0], MAX_FOLLOW_READ_SIZE)
+	assert.Empty(t, follower.partial)
+
+	lines, err = follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{strings.Repeat("x", 100)}, lines)
+}
+
+func TestReadLastLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644))
+
+	lines, err := readLastLines(OSFileSystem{}, path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "three"}, lines)
+}
+
+func TestHandleFollowFile_RequiresSession(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("line\n"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "follow_file"
+	request.Params.Arguments = map[string]any{"path": path}
+	result, err := handler.HandleFollowFile(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "requires an active client session")
+}
+
+func TestHandleStopFollow_OtherSession(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("line\n"), 0644))
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	defer handler.Close()
+	call := sessionCaller(t, handler)
+
+	result := call("owner", "follow_file", map[string]any{"path": path})
+	require.False(t, result.IsError, "%v", result.Content)
+
+	result = call("intruder", "stop_follow", map[string]any{"follow_id": "follow-1"})
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no active follow")
+
+	result = call("owner", "stop_follow", map[string]any{"follow_id": "follow-1"})
+	assert.False(t, result.IsError, "%v", result.Content)
+}
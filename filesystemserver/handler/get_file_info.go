@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/djherbis/times"
@@ -18,6 +21,9 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 	if err != nil {
 		return nil, err
 	}
+	// Symbolic links are followed by default, like every other tool
+	follow := request.GetBool("follow", true)
+	recursive := request.GetBool("recursive", false)
 
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
@@ -37,26 +43,33 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 		path = cwd
 	}
 
-	validPath, err := fs.validatePath(path)
+	// Without follow only the parent directories are resolved, so that a
+	// symbolic link itself can be inspected
+	var validPath string
+	if follow {
+		validPath, err = fs.validatePath(path)
+	} else {
+		validPath, err = fs.validateLinkPath(path)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error: %v", err),
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
 		}, nil
 	}
 
-	info, err := fs.getFileStats(validPath)
+	info, err := fs.getFileStats(validPath, follow)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error getting file info: %v", err),
+					Text: fmt.Sprintf("Error getting file info: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
@@ -65,27 +78,63 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 
 	// Get MIME type for files
 	mimeType := "directory"
-	if info.IsFile {
-		mimeType = detectMimeType(validPath)
+	if info.Type == "symlink" {
+		mimeType = "inode/symlink"
+	} else if info.IsFile {
+		mimeType = fs.detectMimeType(validPath)
 	}
 
-	resourceURI := pathToResourceURI(validPath)
+	resourceURI := fs.resourceURI(validPath)
 
 	// Determine file type text
 	var fileTypeText string
-	if info.IsDirectory {
+	switch info.Type {
+	case "directory":
 		fileTypeText = "Directory"
-	} else {
+	case "symlink":
+		fileTypeText = "Symlink"
+	default:
 		fileTypeText = "File"
 	}
 
+	var linkTarget string
+	if info.LinkTarget != "" {
+		linkTarget = fmt.Sprintf("\nLink Target: %s", fs.displayLinkTarget(info.LinkTarget))
+	}
+
+	// Ownership is only known on Unix
+	var ownership string
+	if info.Owner != "" {
+		ownership = fmt.Sprintf("\nOwner: %s\nGroup: %s", info.Owner, info.Group)
+	}
+
+	// A directory can be summarized with a walk of everything below it
+	var summary string
+	if recursive && info.Type == "directory" {
+		result, truncated, err := fs.summarizeDirectory(ctx, validPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error summarizing directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		summary = fs.formatDirectorySummary(result) + walkTruncatedNote(truncated)
+	}
+
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
 				Text: fmt.Sprintf(
-					"File information for: %s\n\nSize: %d bytes\nCreated: %s\nModified: %s\nAccessed: %s\nIsDirectory: %v\nIsFile: %v\nPermissions: %s\nMIME Type: %s\nResource URI: %s",
-					validPath,
+					"File information for: %s\n\nType: %s%s\nSize: %d bytes\nCreated: %s\nModified: %s\nAccessed: %s\nIsDirectory: %v\nIsFile: %v\nPermissions: %s\nMode: %s (%s)%s\nMIME Type: %s\nResource URI: %s%s",
+					fs.displayPath(validPath),
+					info.Type,
+					linkTarget,
 					info.Size,
 					info.Created.Format(time.RFC3339),
 					info.Modified.Format(time.RFC3339),
@@ -93,8 +142,12 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 					info.IsDirectory,
 					info.IsFile,
 					info.Permissions,
+					info.ModeOctal,
+					info.ModeString,
+					ownership,
 					mimeType,
 					resourceURI,
+					summary,
 				),
 			},
 			mcp.EmbeddedResource{
@@ -104,7 +157,7 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 					MIMEType: "text/plain",
 					Text: fmt.Sprintf("%s: %s (%s, %d bytes)",
 						fileTypeText,
-						validPath,
+						fs.displayPath(validPath),
 						mimeType,
 						info.Size),
 				},
@@ -113,29 +166,170 @@ func (fs *FilesystemHandler) HandleGetFileInfo(
 	}, nil
 }
 
-func (fs *FilesystemHandler) getFileStats(path string) (FileInfo, error) {
-	info, err := os.Stat(path)
+// getFileStats returns the metadata of path. When follow is false a symbolic
+// link is described itself rather than its target.
+func (fs *FilesystemHandler) getFileStats(path string, follow bool) (FileInfo, error) {
+	stat, timesStat := fs.fsys.Stat, times.Stat
+	if !follow {
+		stat, timesStat = fs.fsys.Lstat, times.Lstat
+	}
+	info, err := stat(path)
 	if err != nil {
 		return FileInfo{}, err
 	}
 
-	timespec, err := times.Stat(path)
-	if err != nil {
-		return FileInfo{}, fmt.Errorf("failed to get file times: %w", err)
+	fileType, linkTarget := "file", ""
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		fileType = "symlink"
+		if linkTarget, err = fs.fsys.Readlink(path); err != nil {
+			return FileInfo{}, fmt.Errorf("failed to read link target: %w", err)
+		}
+	case info.IsDir():
+		fileType = "directory"
 	}
 
-	createdTime := time.Time{}
-	if timespec.HasBirthTime() {
-		createdTime = timespec.BirthTime()
+	// Other filesystems only provide the modification time
+	modified, accessed, created := info.ModTime(), info.ModTime(), time.Time{}
+	if fs.onDisk() {
+		var timespec times.Timespec
+		if fs.opTimeout > 0 {
+			timespec, err = withTimeout(fs.opTimeout, "stat", path, func() (times.Timespec, error) {
+				return timesStat(path)
+			})
+		} else {
+			timespec, err = timesStat(path)
+		}
+		if err != nil {
+			return FileInfo{}, fmt.Errorf("failed to get file times: %w", err)
+		}
+		if timespec.HasBirthTime() {
+			created = timespec.BirthTime()
+		}
+		modified, accessed = timespec.ModTime(), timespec.AccessTime()
 	}
 
+	owner, group, _ := fileOwnership(info)
+
 	return FileInfo{
 		Size:        info.Size(),
-		Created:     createdTime,
-		Modified:    timespec.ModTime(),
-		Accessed:    timespec.AccessTime(),
+		Created:     created,
+		Modified:    modified,
+		Accessed:    accessed,
 		IsDirectory: info.IsDir(),
-		IsFile:      !info.IsDir(),
+		IsFile:      fileType == "file",
+		Type:        fileType,
+		LinkTarget:  linkTarget,
 		Permissions: fmt.Sprintf("%o", info.Mode().Perm()),
+		ModeOctal:   modeOctal(info.Mode()),
+		ModeString:  info.Mode().String(),
+		Owner:       owner,
+		Group:       group,
 	}, nil
 }
+
+// modeOctal formats the permission bits of mode, together with the setuid,
+// setgid and sticky bits, as a four-digit octal number such as "0755"
+func modeOctal(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// summarizeDirectory walks the tree below root within the walk limits and
+// totals its files, directories and bytes. Symbolic links are not followed;
+// those that are broken or lead outside the allowed directories are only
+// counted as skipped. If a limit cut the walk short, truncated gives the
+// reason.
+func (fs *FilesystemHandler) summarizeDirectory(ctx context.Context, root string) (*DirectorySummary, string, error) {
+	summary := &DirectorySummary{Extensions: make(map[string]*ExtensionSummary)}
+	truncated, err := fs.walkTree(ctx, root, func(path string, info os.FileInfo, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err != nil || path == root {
+			// Unreadable entries are left out of the summary
+			return nil
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := fs.fsys.EvalSymlinks(path)
+			if err != nil || !fs.isPathInAllowedDirs(target) {
+				summary.SkippedSymlinks++
+			} else {
+				summary.Symlinks++
+			}
+		case info.IsDir():
+			summary.Directories++
+		default:
+			summary.Files++
+			summary.TotalBytes += info.Size()
+
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext == "" {
+				ext = "(none)"
+			}
+			if summary.Extensions[ext] == nil {
+				summary.Extensions[ext] = &ExtensionSummary{}
+			}
+			summary.Extensions[ext].Files++
+			summary.Extensions[ext].Bytes += info.Size()
+
+			if modified := info.ModTime(); summary.NewestPath == "" || modified.After(summary.Newest) {
+				summary.Newest, summary.NewestPath = modified, path
+			}
+			if modified := info.ModTime(); summary.OldestPath == "" || modified.Before(summary.Oldest) {
+				summary.Oldest, summary.OldestPath = modified, path
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return summary, truncated, nil
+}
+
+// formatDirectorySummary renders a summary for get_file_info, listing the
+// extensions with the most files first
+func (fs *FilesystemHandler) formatDirectorySummary(summary *DirectorySummary) string {
+	var sb strings.Builder
+	sb.WriteString("\n\nRecursive summary:\n")
+	fmt.Fprintf(&sb, "Files: %d\nDirectories: %d\nSymlinks: %d", summary.Files, summary.Directories, summary.Symlinks)
+	if summary.SkippedSymlinks > 0 {
+		fmt.Fprintf(&sb, " (%d broken or outside the allowed directories skipped)", summary.SkippedSymlinks)
+	}
+	fmt.Fprintf(&sb, "\nTotal size: %d bytes\n", summary.TotalBytes)
+	if summary.Files > 0 {
+		fmt.Fprintf(&sb, "Newest file: %s (%s)\n", fs.displayPath(summary.NewestPath), summary.Newest.Format(time.RFC3339))
+		fmt.Fprintf(&sb, "Oldest file: %s (%s)\n", fs.displayPath(summary.OldestPath), summary.Oldest.Format(time.RFC3339))
+	}
+
+	exts := make([]string, 0, len(summary.Extensions))
+	for ext := range summary.Extensions {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		a, b := summary.Extensions[exts[i]], summary.Extensions[exts[j]]
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return exts[i] < exts[j]
+	})
+	if len(exts) > 0 {
+		sb.WriteString("Extensions:\n")
+	}
+	for _, ext := range exts {
+		fmt.Fprintf(&sb, "  %s: %d files, %d bytes\n", ext, summary.Extensions[ext].Files, summary.Extensions[ext].Bytes)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
@@ -109,3 +112,127 @@ func TestHandleGetFileInfo(t *testing.T) {
 		require.True(t, res.IsError)
 	})
 }
+
+func TestHandleGetFileInfo_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires extra privileges on Windows")
+	}
+
+	tmpDir := resolveAllowedDirs(t, t.TempDir())[0]
+	otherDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.txt")
+	require.NoError(t, os.WriteFile(target, []byte("Hello, world!"), 0644))
+	require.NoError(t, os.Symlink("target.txt", filepath.Join(tmpDir, "link")))
+	require.NoError(t, os.Symlink("missing.txt", filepath.Join(tmpDir, "dangling")))
+	require.NoError(t, os.Symlink(otherDir, filepath.Join(tmpDir, "escape")))
+
+	fsHandler, err := NewFilesystemHandler([]string{tmpDir})
+	require.NoError(t, err)
+
+	getInfo := func(args map[string]interface{}) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := fsHandler.HandleGetFileInfo(context.Background(), req)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("follows links by default", func(t *testing.T) {
+		res := getInfo(map[string]interface{}{"path": filepath.Join(tmpDir, "link")})
+		require.False(t, res.IsError)
+		text := res.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Type: file")
+		assert.Contains(t, text, "Size: 13 bytes")
+		assert.NotContains(t, text, "Link Target")
+	})
+
+	t.Run("describes the link itself without follow", func(t *testing.T) {
+		for _, name := range []string{"link", "dangling", "escape"} {
+			res := getInfo(map[string]interface{}{"path": filepath.Join(tmpDir, name), "follow": false})
+			require.False(t, res.IsError, name)
+			text := res.Content[0].(mcp.TextContent).Text
+			assert.Contains(t, text, "Type: symlink", name)
+			assert.Contains(t, text, "Link Target: ", name)
+			assert.Contains(t, text, "IsFile: false", name)
+		}
+	})
+
+	t.Run("allowed directory without follow", func(t *testing.T) {
+		res := getInfo(map[string]interface{}{"path": tmpDir, "follow": false})
+		require.False(t, res.IsError)
+		assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "Type: directory")
+	})
+
+	t.Run("missing or escaping targets fail when followed", func(t *testing.T) {
+		for _, name := range []string{"dangling", "escape"} {
+			res := getInfo(map[string]interface{}{"path": filepath.Join(tmpDir, name), "follow": true})
+			assert.True(t, res.IsError, name)
+		}
+	})
+}
+
+func TestHandleGetFileInfo_Ownership(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	path := filepath.Join(dir, "script.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0644))
+	require.NoError(t, os.Chmod(path, 0750))
+
+	fsHandler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	info, err := fsHandler.getFileStats(path, true)
+	require.NoError(t, err)
+
+	res, err := fsHandler.HandleGetFileInfo(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]any{"path": path}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	text := res.Content[0].(mcp.TextContent).Text
+
+	if runtime.GOOS == "windows" {
+		assert.Empty(t, info.Owner)
+		assert.NotContains(t, text, "Owner:")
+		return
+	}
+	assert.Equal(t, "0750", info.ModeOctal)
+	assert.Equal(t, "-rwxr-x---", info.ModeString)
+	assert.Contains(t, text, "Mode: 0750 (-rwxr-x---)")
+
+	uid := strconv.Itoa(os.Getuid())
+	assert.Contains(t, info.Owner, uid)
+	assert.Contains(t, info.Group, strconv.Itoa(os.Getgid()))
+	assert.Contains(t, text, "Owner: "+info.Owner+"\nGroup: "+info.Group+"\n")
+}
+
+func TestHandleGetFileInfo_Recursive(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	outside := t.TempDir()
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "pkg", "util.go"), []byte("package pkg"), 0644))
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "README.md"), old, old))
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(outside, filepath.Join(dir, "escape")))
+	}
+
+	res, err := handler.HandleGetFileInfo(context.Background(), mcp.CallToolRequest{
+		Params: mcp.CallToolParams{Arguments: map[string]any{"path": dir, "recursive": true}},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	text := res.Content[0].(mcp.TextContent).Text
+
+	assert.Contains(t, text, "Files: 3\nDirectories: 2\n")
+	assert.Contains(t, text, "Total size: 29 bytes")
+	assert.Contains(t, text, "Oldest file: "+filepath.Join(dir, "README.md"))
+	assert.Contains(t, text, "  .go: 2 files, 23 bytes\n  .md: 1 files, 6 bytes")
+	if runtime.GOOS != "windows" {
+		assert.Contains(t, text, "1 broken or outside the allowed directories skipped")
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleGetServerInfo reports the server version, its configuration and the
+// read cache counters
+func (fs *FilesystemHandler) HandleGetServerInfo(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	var result strings.Builder
+	result.WriteString("Server information:\n\n")
+	if fs.serverName != "" {
+		result.WriteString(fmt.Sprintf("Name: %s\n", fs.serverName))
+	}
+	if fs.serverVersion != "" {
+		result.WriteString(fmt.Sprintf("Version: %s\n", fs.serverVersion))
+	}
+	result.WriteString(fmt.Sprintf("Allowed directories: %d\n", len(fs.allowedDirs)))
+	result.WriteString(fmt.Sprintf("Root-relative paths: %v\n", fs.rootRelative))
+	result.WriteString(fmt.Sprintf("Read-only: %v\n", fs.readOnly))
+	result.WriteString(fmt.Sprintf("Symlink policy: %s\n", fs.symlinkPolicy))
+	result.WriteString(fmt.Sprintf("Respect gitignore: %v\n", fs.respectGitignore))
+
+	if fs.templatesDir != "" {
+		result.WriteString(fmt.Sprintf("Templates directory: %s\n", fs.displayPath(fs.templatesDir)))
+	}
+	if fs.trashDir != "" {
+		result.WriteString(fmt.Sprintf("Trash directory: %s\n", fs.displayPath(fs.trashDir)))
+	}
+	if fs.backupDir != "" {
+		result.WriteString(fmt.Sprintf("Backup directory: %s\n", fs.displayPath(fs.backupDir)))
+	}
+
+	result.WriteString(fmt.Sprintf("New file mode: %04o\n", fs.filePerm))
+	result.WriteString(fmt.Sprintf("New directory mode: %04o\n", fs.dirPerm))
+
+	result.WriteString(fmt.Sprintf("Walk limits: depth %d, %d entries\n", fs.maxWalkDepth, fs.maxWalkEntries))
+	result.WriteString(fmt.Sprintf("Max concurrency: %d\n", fs.maxConcurrency))
+	result.WriteString(fmt.Sprintf("Max list entries per response: %d\n", fs.maxListEntries))
+	if fs.confirmations != nil {
+		result.WriteString(fmt.Sprintf("Destructive calls need confirmation within: %v\n", fs.confirmations.ttl))
+	}
+	if fs.quotas != nil {
+		roots := make([]string, 0, len(fs.quotas.limits))
+		for root := range fs.quotas.limits {
+			roots = append(roots, root)
+		}
+		slices.Sort(roots)
+		for _, root := range roots {
+			result.WriteString(fmt.Sprintf("Quota: %s, %d bytes\n", fs.displayPath(root), fs.quotas.limits[root]))
+		}
+	}
+	readOnlyRoots := make([]string, 0, len(fs.readOnlyRoots))
+	for root := range fs.readOnlyRoots {
+		readOnlyRoots = append(readOnlyRoots, root)
+	}
+	slices.Sort(readOnlyRoots)
+	for _, root := range readOnlyRoots {
+		result.WriteString(fmt.Sprintf("Read-only directory: %s\n", fs.displayPath(root)))
+	}
+	if len(fs.deniedConfig) > 0 {
+		result.WriteString(fmt.Sprintf("Denied paths: %s\n", strings.Join(fs.deniedConfig, ", ")))
+	}
+
+	if fs.opTimeout > 0 {
+		result.WriteString(fmt.Sprintf("Operation timeout: %v\n", fs.opTimeout))
+	} else {
+		result.WriteString("Operation timeout: disabled\n")
+	}
+
+	if fs.limiter == nil {
+		result.WriteString("Write rate limit: disabled\n")
+	} else {
+		result.WriteString("Write rate limit: enabled\n")
+		if fs.limiter.writesPerMinute > 0 {
+			result.WriteString(fmt.Sprintf("  Operations per minute: %d\n", fs.limiter.writesPerMinute))
+		}
+		if fs.limiter.bytesPerMinute > 0 {
+			result.WriteString(fmt.Sprintf("  Bytes per minute: %d\n", fs.limiter.bytesPerMinute))
+		}
+	}
+
+	kinds, inUse, total, limit := fs.budget.usage()
+	result.WriteString(fmt.Sprintf("Resource budget: %d of %d in use\n", total, limit))
+	for _, kind := range kinds {
+		result.WriteString(fmt.Sprintf("  %s: %d\n", kind, inUse[kind]))
+	}
+
+	if fs.compressMinBytes > 0 {
+		result.WriteString(fmt.Sprintf("Result compression: gzip from %d bytes, on request\n", fs.compressMinBytes))
+	} else {
+		result.WriteString("Result compression: disabled\n")
+	}
+
+	if fs.cache == nil {
+		result.WriteString("Read cache: disabled\n")
+	} else {
+		stats := fs.cache.stats()
+		result.WriteString("Read cache: enabled\n")
+		result.WriteString(fmt.Sprintf("  Entries: %d\n", stats.entries))
+		result.WriteString(fmt.Sprintf("  Size: %d of %d bytes\n", stats.size, stats.maxBytes))
+		result.WriteString(fmt.Sprintf("  Hits: %d\n", stats.hits))
+		result.WriteString(fmt.Sprintf("  Misses: %d\n", stats.misses))
+	}
+
+	if report := fs.healthReport(); report != "" {
+		result.WriteString(report)
+	} else {
+		result.WriteString("Root health check: disabled\n")
+	}
+
+	if report := fs.indexReport(); report != "" {
+		result.WriteString(report)
+	} else {
+		result.WriteString("Content index: disabled\n")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
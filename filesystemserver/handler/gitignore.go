@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Ignore files honored by walks that respect_gitignore, in increasing order
+// of precedence within a directory
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// WithRespectGitignore makes tree, list_directory, search_files, read_glob
+// and search_within_files leave out the files and directories that
+// .gitignore and .ignore files exclude, along with .git directories. Calls
+// can override it with respect_gitignore.
+func WithRespectGitignore() Option {
+	return func(fs *FilesystemHandler) {
+		fs.respectGitignore = true
+	}
+}
+
+// ignoreRule is a line of an ignore file
+type ignoreRule struct {
+	globs    []glob.Glob
+	anchored bool // matched against the path below the ignore file's directory rather than the name
+	dirOnly  bool
+	negate   bool
+}
+
+// match reports whether the rule applies to rel, the slash-separated path
+// below the directory of its ignore file
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		rel = path.Base(rel)
+	}
+	for _, g := range r.globs {
+		if g.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIgnoreFile reads the rules of a .gitignore file. Lines that are not
+// valid patterns are left out.
+func parseIgnoreFile(data string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		line, rule.negate = strings.CutPrefix(line, "!")
+		// \# and \! start patterns with a literal # or !
+		line = strings.TrimPrefix(line, `\`)
+		line, rule.dirOnly = strings.CutSuffix(line, "/")
+		rule.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		// Braces are literal in gitignore patterns but alternatives in globs
+		line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+
+		// A **/ may stand for no directory at all
+		alternatives := []string{line}
+		if rest, ok := strings.CutPrefix(line, "**/"); ok {
+			alternatives = append(alternatives, rest)
+		}
+		if strings.Contains(line, "/**/") {
+			alternatives = append(alternatives, strings.Replace(line, "/**/", "/", 1))
+		}
+		for _, alternative := range alternatives {
+			if g, err := glob.Compile(alternative, '/'); err == nil {
+				rule.globs = append(rule.globs, g)
+			}
+		}
+		if len(rule.globs) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// gitignore decides which entries of a walk the ignore files exclude. The
+// ignore files of the walk's allowed directory and of every directory down
+// to an entry apply, deeper ones and later lines taking precedence, as in
+// git. The walk root itself and the directories above it are never ignored,
+// so that an ignored directory can still be searched by naming it. A
+// gitignore caches what it reads and is not safe for concurrent use.
+type gitignore struct {
+	fs    *FilesystemHandler
+	base  string
+	rules map[string][]ignoreRule // by directory
+	dirs  map[string]bool         // whether a directory is ignored
+}
+
+// gitignoreFor returns the gitignore of a walk from base if the request
+// asks to respect ignore files, or the server does by default, and nil
+// otherwise
+func (fs *FilesystemHandler) gitignoreFor(request mcp.CallToolRequest, base string) *gitignore {
+	if !request.GetBool("respect_gitignore", fs.respectGitignore) {
+		return nil
+	}
+	return &gitignore{
+		fs:    fs,
+		base:  base,
+		rules: make(map[string][]ignoreRule),
+		dirs:  make(map[string]bool),
+	}
+}
+
+// ignored reports whether name, or a directory containing it below the walk
+// root, is excluded. A nil gitignore excludes nothing.
+func (g *gitignore) ignored(name string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+	root := g.fs.rootForPath(name)
+	if root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, name)
+	if err != nil || rel == "." {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	below := 0
+	if rel, err := filepath.Rel(root, g.base); err == nil && rel != "." {
+		below = strings.Count(filepath.ToSlash(rel), "/") + 1
+	}
+	if len(parts) <= below {
+		return false
+	}
+
+	for i := below + 1; i < len(parts); i++ {
+		dir := filepath.Join(root, filepath.FromSlash(strings.Join(parts[:i], "/")))
+		ignored, ok := g.dirs[dir]
+		if !ok {
+			ignored = g.matches(root, parts[:i], true)
+			g.dirs[dir] = ignored
+		}
+		if ignored {
+			return true
+		}
+	}
+	return g.matches(root, parts, isDir)
+}
+
+// matches applies the rules of the ignore files from root down to the
+// directory holding the entry that parts names
+func (g *gitignore) matches(root string, parts []string, isDir bool) bool {
+	if isDir && parts[len(parts)-1] == ".git" {
+		return true
+	}
+	ignored := false
+	dir := root
+	for i := range parts {
+		rel := strings.Join(parts[i:], "/")
+		for _, rule := range g.rulesIn(dir) {
+			if rule.match(rel, isDir) {
+				ignored = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// rulesIn returns the rules of the ignore files in dir
+func (g *gitignore) rulesIn(dir string) []ignoreRule {
+	rules, ok := g.rules[dir]
+	if ok {
+		return rules
+	}
+	for _, name := range ignoreFileNames {
+		if data, err := g.fs.fsys.ReadFile(filepath.Join(dir, name)); err == nil {
+			rules = append(rules, parseIgnoreFile(string(data))...)
+		}
+	}
+	g.rules[dir] = rules
+	return rules
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespectGitignore(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	files := map[string]string{
+		".gitignore":                   "# dependencies\nnode_modules/\n*.log\n!keep.log\n/build\n",
+		"main.go":                      "package main // TODO\n",
+		"debug.log":                    "TODO\n",
+		"keep.log":                     "TODO\n",
+		"node_modules/pkg/index.js":    "// TODO\n",
+		"build/out.txt":                "TODO\n",
+		"src/build/gen.go":             "package build // TODO\n",
+		"src/.ignore":                  "secret.txt\n",
+		"src/secret.txt":               "TODO\n",
+		"src/app.go":                   "package src // TODO\n",
+		".git/HEAD":                    "TODO\n",
+		"docs/{draft}/notes.txt":       "TODO\n",
+		"docs/.gitignore":              "{draft}/\n",
+		"docs/guide.txt":               "TODO\n",
+		"src/node_modules/lib/lib.txt": "TODO\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	visible := []string{"main.go", "keep.log", "src/build/gen.go", "src/app.go", "docs/guide.txt"}
+	hidden := []string{"debug.log", "node_modules", "build/out.txt", "secret.txt", "HEAD", "notes.txt", "lib.txt"}
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		result, err := handle(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "%v", result.Content)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("search_within_files", func(t *testing.T) {
+		text := call(handler.HandleSearchWithinFiles, map[string]any{"path": dir, "substring": "TODO", "respect_gitignore": true})
+		for _, name := range visible {
+			assert.Contains(t, text, filepath.FromSlash(name))
+		}
+		for _, name := range hidden {
+			assert.NotContains(t, text, filepath.FromSlash(name))
+		}
+
+		// Ignore files are not honored unless asked to
+		assert.Contains(t, call(handler.HandleSearchWithinFiles, map[string]any{"path": dir, "substring": "TODO"}), "debug.log")
+	})
+
+	t.Run("list_directory", func(t *testing.T) {
+		text := call(handler.HandleListDirectory, map[string]any{"path": dir, "recursive": true, "respect_gitignore": true})
+		for _, name := range visible {
+			assert.Contains(t, text, name)
+		}
+		for _, name := range hidden {
+			assert.NotContains(t, text, name)
+		}
+	})
+
+	t.Run("search_files", func(t *testing.T) {
+		text := call(handler.HandleSearchFiles, map[string]any{"path": dir, "pattern": "*.txt", "respect_gitignore": true})
+		assert.Contains(t, text, "guide.txt")
+		assert.NotContains(t, text, "out.txt")
+		assert.NotContains(t, text, "lib.txt")
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		text := call(handler.HandleTree, map[string]any{"path": dir, "depth": 5, "format": "ascii", "respect_gitignore": true})
+		assert.Contains(t, text, "gen.go")
+		assert.NotContains(t, text, "node_modules")
+		assert.NotContains(t, text, ".git\n")
+	})
+
+	t.Run("read_glob", func(t *testing.T) {
+		text := call(handler.HandleReadGlob, map[string]any{"path": dir, "pattern": "*.log", "respect_gitignore": true})
+		assert.Contains(t, text, "keep.log")
+		assert.NotContains(t, text, "debug.log")
+	})
+
+	t.Run("an ignored directory can be searched by naming it", func(t *testing.T) {
+		text := call(handler.HandleSearchWithinFiles, map[string]any{"path": filepath.Join(dir, "node_modules"), "substring": "TODO", "respect_gitignore": true})
+		assert.Contains(t, text, "index.js")
+	})
+
+	t.Run("server default", func(t *testing.T) {
+		handler, err := NewFilesystemHandler([]string{dir}, WithRespectGitignore())
+		require.NoError(t, err)
+		text := call(handler.HandleSearchFiles, map[string]any{"path": dir, "pattern": "*.log"})
+		assert.NotContains(t, text, "debug.log")
+		text = call(handler.HandleSearchFiles, map[string]any{"path": dir, "pattern": "*.log", "respect_gitignore": false})
+		assert.Contains(t, text, "debug.log")
+	})
+}
+
+func TestParseIgnoreFile(t *testing.T) {
+	rules := parseIgnoreFile("# comment\n\n\\#hash\n**/logs\na/**/b\ndist/\n")
+	require.Len(t, rules, 4)
+	assert.True(t, rules[0].match("#hash", false))
+	assert.True(t, rules[1].match("logs", true))
+	assert.True(t, rules[1].match("x/logs", true))
+	assert.True(t, rules[2].match("a/b", false))
+	assert.True(t, rules[2].match("a/x/y/b", false))
+	assert.True(t, rules[3].match("x/dist", true))
+	assert.False(t, rules[3].match("x/dist", false))
+}
@@ -1,17 +1,286 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
 type FilesystemHandler struct {
 	allowedDirs []string
+
+	// aliases maps alias names to allowed directories, with their trailing
+	// separator; see WithRootAliases
+	aliases map[string]string
+
+	// authorizer applies an embedder's access policy on top of the allowed
+	// directories; nil when only the allowed directories apply
+	authorizer Authorizer
+
+	// fsys performs all filesystem access; OSFileSystem unless replaced
+	// with WithFileSystem
+	fsys FileSystem
+
+	// opTimeout bounds every operation on fsys; 0 when unlimited
+	opTimeout time.Duration
+
+	// cache holds recently read file contents; nil when disabled
+	cache *contentCache
+
+	// limiter throttles the mutating tools; nil when unlimited
+	limiter *writeLimiter
+
+	// confirmations holds the tokens of destructive calls awaiting
+	// confirmation; nil when destructive tools run without one
+	confirmations *confirmations
+
+	// audit records every mutating tool call; nil when disabled
+	audit *auditLog
+
+	// compressMinBytes is the size from which text results are compressed
+	// for clients that ask for it; 0 when compression is disabled
+	compressMinBytes int
+
+	// templatesDir holds the templates of write_from_template; empty when
+	// the tool is not configured
+	templatesDir string
+
+	// quotaConfig holds the quotas passed to WithRootQuotas, and quotas
+	// their resolved form; quotas is nil when no root has a quota
+	quotaConfig map[string]int64
+	quotas      *rootQuotas
+
+	// trashDir receives the files of move_to_trash; empty when the trash
+	// tools are not configured
+	trashDir string
+
+	// backupDir receives the backups of write_file and modify_file; empty
+	// to keep them next to the file as path~
+	backupDir string
+
+	// maxConcurrency bounds the files a batch or walk tool call reads at
+	// the same time
+	maxConcurrency int
+
+	// maxWalkDepth and maxWalkEntries bound every directory walk, so that a
+	// pathological tree returns partial results instead of running forever
+	maxWalkDepth   int
+	maxWalkEntries int
+
+	// maxCallTimeout bounds the timeout_ms a tool call may ask for
+	maxCallTimeout time.Duration
+
+	// maxListEntries bounds the entries of one list_directory response
+	maxListEntries int
+
+	// filePerm and dirPerm are the permissions given to files and
+	// directories that the tools create
+	filePerm os.FileMode
+	dirPerm  os.FileMode
+
+	// serverName and serverVersion are reported by get_server_info
+	serverName    string
+	serverVersion string
+
+	// logger receives startup diagnostics
+	logger *slog.Logger
+
+	// logLevel, when set with WithLogLevel, is the level of the logger,
+	// which a reload sets to wantLogLevel
+	logLevel     *slog.LevelVar
+	wantLogLevel slog.Level
+
+	// disabledTools holds the names of the tools turned off with
+	// WithDisabledTools
+	disabledTools map[string]bool
+
+	// readOnly refuses every change to the filesystem, see WithReadOnly
+	readOnly bool
+
+	// accessConfig holds the access modes passed to WithRootAccess, and
+	// readOnlyRoots the allowed directories they make read-only, without
+	// trailing separator
+	accessConfig  map[string]string
+	readOnlyRoots map[string]bool
+
+	// deniedConfig holds the patterns passed to WithDeniedPaths, and denied
+	// their compiled form
+	deniedConfig []string
+	denied       []deniedPattern
+
+	// symlinkPolicy decides how links that resolve outside the allowed
+	// directories are treated
+	symlinkPolicy SymlinkPolicy
+
+	// startedAt is when the handler was created, for the uptime reported by ping
+	startedAt time.Time
+
+	// rootRelative presents and accepts paths relative to the single allowed
+	// directory, e.g. /src/main.go, instead of absolute host paths
+	rootRelative bool
+
+	// relativeOutput reports result paths relative to their allowed
+	// directory unless a call sets relative_paths to false
+	relativeOutput bool
+
+	// respectGitignore leaves the entries that ignore files exclude out of
+	// walks unless a call sets respect_gitignore to false
+	respectGitignore bool
+
+	// configMu is held for reading by every tool call and resource read,
+	// and for writing while reload_config swaps in a new configuration;
+	// reload loads that configuration and is nil when reloading is not
+	// enabled
+	configMu sync.RWMutex
+	reload   func() ([]string, []Option, error)
+
+	// calls counts the tool calls that hold the configuration, abandoned
+	// those of them that LimitCallDuration gave up on, and reloading is set
+	// while a reload keeps new calls out; callsCond signals their changes
+	callsMu   sync.Mutex
+	callsCond *sync.Cond
+	calls     int
+	abandoned int
+	reloading bool
+
+	// closers holds long-lived resources (such as file watchers) that must be
+	// released when the server shuts down
+	closersMu sync.Mutex
+	closers   []io.Closer
+
+	// follows tracks the active follow_file subscriptions by id
+	followsMu sync.Mutex
+	follows   map[string]*fileFollower
+	followSeq int
+
+	// watches tracks the active watch_path subscriptions by id
+	watchesMu sync.Mutex
+	watches   map[string]*pathWatcher
+	watchSeq  int
+
+	// budget limits the follows and watches active at once across all
+	// clients
+	budget resourceBudget
+
+	// healthInterval is the period of the root health check and health its
+	// state; health is nil when the check is not running
+	healthInterval time.Duration
+	health         *rootHealth
+	// indexFile is where the content index is saved and index its state;
+	// index is nil when no index is kept
+	indexFile     string
+	indexInterval time.Duration
+	index         *contentIndex
+}
+
+// Option configures optional FilesystemHandler behaviour
+type Option func(*FilesystemHandler)
+
+// WithRootRelativePaths makes every tool accept and report paths relative to
+// the allowed directory, hiding its location on the host. It requires exactly
+// one allowed directory.
+func WithRootRelativePaths() Option {
+	return func(fs *FilesystemHandler) {
+		fs.rootRelative = true
+	}
+}
+
+// WithLogger sets the logger used for startup diagnostics, such as skipped
+// allowed directories. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(fs *FilesystemHandler) {
+		fs.logger = logger
+	}
+}
+
+// WithDefaultModes sets the permissions of newly created files and
+// directories, replacing the defaults of 0644 and 0755. The modes are applied
+// exactly rather than being masked by the process umask. A zero mode keeps
+// the default.
+func WithDefaultModes(fileMode, dirMode os.FileMode) Option {
+	return func(fs *FilesystemHandler) {
+		if fileMode != 0 {
+			fs.filePerm = fileMode.Perm()
+		}
+		if dirMode != 0 {
+			fs.dirPerm = dirMode.Perm()
+		}
+	}
+}
+
+// WithWalkLimits bounds the directory walks of the recursive tools: walks do
+// not descend more than maxDepth levels below their starting directory and
+// stop after visiting maxEntries entries, returning partial results. A zero
+// limit keeps the default.
+func WithWalkLimits(maxDepth, maxEntries int) Option {
+	return func(fs *FilesystemHandler) {
+		if maxDepth > 0 {
+			fs.maxWalkDepth = maxDepth
+		}
+		if maxEntries > 0 {
+			fs.maxWalkEntries = maxEntries
+		}
+	}
+}
+
+// WithMaxListEntries sets the largest number of entries a list_directory
+// response holds; further entries are counted and left to later pages. A
+// value of 0 or less keeps the default of MAX_SEARCH_RESULTS.
+func WithMaxListEntries(n int) Option {
+	return func(fs *FilesystemHandler) {
+		if n > 0 {
+			fs.maxListEntries = n
+		}
+	}
+}
+
+// WithServerInfo sets the server name and version reported by get_server_info
+func WithServerInfo(name, version string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.serverName = name
+		fs.serverVersion = version
+	}
 }
 
-func NewFilesystemHandler(allowedDirs []string) (*FilesystemHandler, error) {
-	// Normalize and validate directories
+func NewFilesystemHandler(allowedDirs []string, opts ...Option) (*FilesystemHandler, error) {
+	fs := &FilesystemHandler{
+		fsys:      OSFileSystem{},
+		startedAt: time.Now(),
+		logger:    slog.New(slog.DiscardHandler),
+		filePerm:  0644,
+		dirPerm:   0755,
+
+		symlinkPolicy: SymlinkMetadata,
+
+		maxWalkDepth:   DEFAULT_MAX_WALK_DEPTH,
+		maxWalkEntries: DEFAULT_MAX_WALK_ENTRIES,
+		maxConcurrency: runtime.GOMAXPROCS(0),
+		maxCallTimeout: DEFAULT_MAX_CALL_TIMEOUT,
+		maxListEntries: MAX_SEARCH_RESULTS,
+		budget:         resourceBudget{limit: DEFAULT_RESOURCE_BUDGET},
+	}
+	fs.callsCond = sync.NewCond(&fs.callsMu)
+	for _, opt := range opts {
+		opt(fs)
+	}
+	if err := fs.validateSymlinkPolicy(); err != nil {
+		return nil, err
+	}
+	if fs.opTimeout > 0 {
+		fs.fsys = timeoutFileSystem{fsys: fs.fsys, timeout: fs.opTimeout}
+	}
+
+	// Normalize and validate directories. A directory that cannot be
+	// accessed is skipped with a warning so that one stale entry does not
+	// stop the server, but an entry naming a file is a configuration error.
 	normalized := make([]string, 0, len(allowedDirs))
 	for _, dir := range allowedDirs {
 		abs, err := filepath.Abs(dir)
@@ -19,13 +288,10 @@ func NewFilesystemHandler(allowedDirs []string) (*FilesystemHandler, error) {
 			return nil, fmt.Errorf("failed to resolve path %s: %w", dir, err)
 		}
 
-		info, err := os.Stat(abs)
+		info, err := fs.fsys.Stat(abs)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to access directory %s: %w",
-				abs,
-				err,
-			)
+			fs.logger.Warn("Skipping inaccessible allowed directory", "path", abs, "error", err)
+			continue
 		}
 		if !info.IsDir() {
 			return nil, fmt.Errorf("path is not a directory: %s", abs)
@@ -35,12 +301,180 @@ func NewFilesystemHandler(allowedDirs []string) (*FilesystemHandler, error) {
 		// For example, /tmp/foo should not match /tmp/foobar
 		normalized = append(normalized, filepath.Clean(abs)+string(filepath.Separator))
 	}
-	return &FilesystemHandler{
-		allowedDirs: normalized,
-	}, nil
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("none of the allowed directories is accessible: %s", strings.Join(allowedDirs, ", "))
+	}
+	fs.allowedDirs = normalized
+
+	if fs.rootRelative && len(fs.allowedDirs) != 1 {
+		return nil, fmt.Errorf(
+			"root-relative paths require exactly one allowed directory, got %d",
+			len(fs.allowedDirs),
+		)
+	}
+
+	if err := fs.resolveAliases(allowedDirs); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveQuotas(allowedDirs); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveRootAccess(allowedDirs); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveDeniedPaths(); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveTemplatesDir(); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveTrashDir(); err != nil {
+		return nil, err
+	}
+	if err := fs.resolveBackupDir(); err != nil {
+		return nil, err
+	}
+
+	if fs.healthInterval > 0 {
+		fs.startHealthCheck()
+	}
+	if fs.indexFile != "" {
+		fs.startContentIndex()
+	}
+
+	fs.logger.Info("Allowed directories accepted", "directories", fs.allowedDirs)
+	return fs, nil
+}
+
+// walkTree walks the tree rooted at root like walk, within the handler's walk
+// limits, and stops early once ctx is done. Paths hidden from listings are
+// skipped. If a limit or the context cut the walk short, truncated gives the
+// reason.
+func (fs *FilesystemHandler) walkTree(ctx context.Context, root string, fn filepath.WalkFunc) (truncated string, err error) {
+	return fs.walkTreeIgnoring(ctx, root, nil, fn)
+}
+
+// walkTreeIgnoring is walkTree leaving out the entries that ignore excludes
+func (fs *FilesystemHandler) walkTreeIgnoring(ctx context.Context, root string, ignore *gitignore, fn filepath.WalkFunc) (truncated string, err error) {
+	guard := &walkGuard{ctx: ctx, maxDepth: fs.maxWalkDepth, maxEntries: fs.maxWalkEntries, skip: fs.hiddenFromListings, ignore: ignore}
+	err = walkGuarded(fs.fsys, root, guard, fn)
+	return guard.truncated, err
+}
+
+// walkTruncatedNote is appended to the output of a tool whose walk was cut
+// short by the walk limits. It is empty if the walk completed.
+func walkTruncatedNote(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nNote: truncated: true (%s). Results are partial.\n", reason)
 }
 
 // pathToResourceURI converts a file path to a resource URI
 func pathToResourceURI(path string) string {
 	return "file://" + path
 }
+
+// resourceURI converts a real file path to the resource URI shown to clients
+func (fs *FilesystemHandler) resourceURI(path string) string {
+	return pathToResourceURI(fs.displayPath(path))
+}
+
+// displayPath converts a real file path to the form shown to clients. In
+// root-relative mode this is the path below the allowed directory using
+// forward slashes, e.g. /src/main.go; otherwise the path is unchanged.
+func (fs *FilesystemHandler) displayPath(path string) string {
+	if !fs.rootRelative {
+		return path
+	}
+
+	root := strings.TrimSuffix(fs.allowedDirs[0], string(filepath.Separator))
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// Never expose a host path that is not below the root
+		return "/"
+	}
+	if rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+// displayError returns err with the path of the *os.PathError it carries in
+// the form shown to clients, so that error messages do not reveal host paths
+// in root-relative mode
+func (fs *FilesystemHandler) displayError(err error) error {
+	var pathErr *os.PathError
+	if !fs.rootRelative || !errors.As(err, &pathErr) {
+		return err
+	}
+	if err == error(pathErr) {
+		return &os.PathError{Op: pathErr.Op, Path: fs.displayPath(pathErr.Path), Err: pathErr.Err}
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), pathErr.Path, fs.displayPath(pathErr.Path)))
+}
+
+// fromRootRelative maps a client supplied path onto the real filesystem in
+// root-relative mode. Paths are always resolved below the allowed directory,
+// so "..", "/" and absolute forms cannot escape it. Paths that already point
+// inside the real root, such as those produced while walking a directory,
+// are returned unchanged. A leading root alias is expanded first, in either
+// mode.
+func (fs *FilesystemHandler) fromRootRelative(path string) string {
+	path = fs.expandAlias(path)
+	if !fs.rootRelative {
+		return path
+	}
+
+	root := strings.TrimSuffix(fs.allowedDirs[0], string(filepath.Separator))
+	cleaned := filepath.Clean(path)
+	if cleaned == root || strings.HasPrefix(cleaned, fs.allowedDirs[0]) {
+		return cleaned
+	}
+
+	// Handlers expand "." to the working directory before validation; with a
+	// logical root it refers to the root itself
+	if cwd, err := os.Getwd(); err == nil && cleaned == cwd {
+		return root
+	}
+
+	// Resource URIs and tool arguments use forward slashes
+	rel := filepath.Clean(string(filepath.Separator) + filepath.FromSlash(path))
+	return filepath.Join(root, rel)
+}
+
+// registerCloser records a resource that must be released on shutdown
+func (fs *FilesystemHandler) registerCloser(c io.Closer) {
+	fs.closersMu.Lock()
+	defer fs.closersMu.Unlock()
+	fs.closers = append(fs.closers, c)
+}
+
+// unregisterCloser removes a resource that has already been released
+func (fs *FilesystemHandler) unregisterCloser(c io.Closer) {
+	fs.closersMu.Lock()
+	defer fs.closersMu.Unlock()
+	for i, existing := range fs.closers {
+		if existing == c {
+			fs.closers = append(fs.closers[:i], fs.closers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close releases all registered resources, such as active file watchers.
+// It is safe to call Close more than once.
+func (fs *FilesystemHandler) Close() error {
+	fs.closersMu.Lock()
+	closers := fs.closers
+	fs.closers = nil
+	fs.closersMu.Unlock()
+
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
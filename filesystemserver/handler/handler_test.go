@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,3 +33,259 @@ func resolveAllowedDirs(t *testing.T, dirs ...string) []string {
 	}
 	return allowedDirs
 }
+
+type countingCloser struct {
+	closed *int
+}
+
+func (c *countingCloser) Close() error {
+	*c.closed++
+	return nil
+}
+
+func TestFilesystemHandler_Close(t *testing.T) {
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, t.TempDir()))
+	require.NoError(t, err)
+
+	closed := 0
+	first := &countingCloser{closed: &closed}
+	second := &countingCloser{closed: &closed}
+	handler.registerCloser(first)
+	handler.registerCloser(second)
+	handler.unregisterCloser(second)
+
+	require.NoError(t, handler.Close())
+	require.Equal(t, 1, closed)
+
+	// Closing again must not release resources twice
+	require.NoError(t, handler.Close())
+	require.Equal(t, 1, closed)
+}
+
+func TestFilesystemHandler_RootRelativePaths(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithRootRelativePaths())
+	require.NoError(t, err)
+
+	callTool := func(name string, args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		var result *mcp.CallToolResult
+		switch name {
+		case "read_file":
+			result, err = handler.HandleReadFile(context.Background(), request)
+		case "list_directory":
+			result, err = handler.HandleListDirectory(context.Background(), request)
+		}
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("inputs are mapped onto the root", func(t *testing.T) {
+		assert.Equal(t, "package main", callTool("read_file", map[string]any{"path": "/src/main.go"}))
+		assert.Equal(t, "package main", callTool("read_file", map[string]any{"path": "src/main.go"}))
+	})
+
+	t.Run("outputs hide the host path", func(t *testing.T) {
+		text := callTool("list_directory", map[string]any{"path": "/src"})
+		assert.Contains(t, text, "file:///src/main.go")
+		assert.NotContains(t, text, dir)
+	})
+
+	t.Run("errors hide the host path", func(t *testing.T) {
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		for _, call := range []struct {
+			handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+			path   string
+		}{
+			{handler.HandleReadFile, "/src/main.go/x"},
+			{handler.HandleGetFileInfo, "/src/missing.go"},
+			{handler.HandleListDirectory, "/missing"},
+			{handler.HandleResolvePath, "."},
+		} {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"path": call.path}
+			result, err := call.handle(context.Background(), request)
+			require.NoError(t, err)
+			text := result.Content[0].(mcp.TextContent).Text
+			assert.NotContains(t, text, dir, call.path)
+			assert.NotContains(t, text, cwd, call.path)
+		}
+	})
+
+	t.Run("paths cannot escape the root", func(t *testing.T) {
+		validPath, err := handler.validatePath("/../../src/main.go")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "src", "main.go"), validPath)
+		assert.Equal(t, "/src/main.go", handler.displayPath(validPath))
+	})
+
+	t.Run("requires a single allowed directory", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{dir, t.TempDir()}, WithRootRelativePaths())
+		require.Error(t, err)
+	})
+}
+
+func TestFilesystemHandler_RootAliases(t *testing.T) {
+	docs, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "guide.md"), []byte("# Guide"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{docs, other}, WithRootAliases(map[string]string{"docs": docs}))
+	require.NoError(t, err)
+
+	t.Run("aliases are expanded", func(t *testing.T) {
+		validPath, err := handler.validatePath("@docs/guide.md")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(docs, "guide.md"), validPath)
+
+		validPath, err = handler.validatePath("@docs")
+		require.NoError(t, err)
+		assert.Equal(t, docs, validPath)
+	})
+
+	t.Run("aliases cannot escape their directory", func(t *testing.T) {
+		_, err := handler.validatePath("@docs/../../etc/passwd")
+		require.Error(t, err)
+	})
+
+	t.Run("listed with their directory", func(t *testing.T) {
+		result, err := handler.HandleListAllowedDirectories(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "[read-write] @docs\n")
+	})
+
+	t.Run("must name an allowed directory", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{docs}, WithRootAliases(map[string]string{"bad/name": docs}))
+		require.Error(t, err)
+
+		_, err = NewFilesystemHandler([]string{docs}, WithRootAliases(map[string]string{"other": other}))
+		require.Error(t, err)
+	})
+}
+
+func TestFilesystemHandler_Authorizer(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.secret"), []byte("token"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644))
+
+	var ops []Operation
+	handler, err := NewFilesystemHandler([]string{dir}, WithAuthorizer(AuthorizerFunc(func(path string, op Operation) error {
+		if filepath.Ext(path) == ".secret" {
+			return errors.New("secret files are off-limits")
+		}
+		if filepath.Base(path) == "notes.txt" {
+			ops = append(ops, op)
+			if op == OpDelete {
+				return errors.New("notes are kept")
+			}
+		}
+		return nil
+	})))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("denied paths", func(t *testing.T) {
+		result := call(handler.HandleReadFile, map[string]any{"path": filepath.Join(dir, "api.secret")})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "access denied")
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "secret files are off-limits")
+	})
+
+	t.Run("operations", func(t *testing.T) {
+		result := call(handler.HandleWriteFile, map[string]any{"path": filepath.Join(dir, "notes.txt"), "content": "more notes"})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		result = call(handler.HandleDeleteFile, map[string]any{"path": filepath.Join(dir, "notes.txt")})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "notes are kept")
+		assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+
+		assert.Equal(t, []Operation{OpRead, OpWrite, OpRead, OpDelete}, ops)
+	})
+}
+
+func TestNewFilesystemHandler_ValidatesAllowedDirs(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing")
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+
+	var log bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&log, nil))
+
+	t.Run("skips missing directories", func(t *testing.T) {
+		handler, err := NewFilesystemHandler([]string{missing, dir}, WithLogger(logger))
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Clean(dir) + string(filepath.Separator)}, handler.allowedDirs)
+		assert.Contains(t, log.String(), "Skipping inaccessible allowed directory")
+		assert.Contains(t, log.String(), "Allowed directories accepted")
+	})
+
+	t.Run("fails when no directory is usable", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{missing})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "none of the allowed directories is accessible")
+	})
+
+	t.Run("rejects files", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{dir, file})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "path is not a directory")
+	})
+}
+
+func TestFilesystemHandler_DefaultModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not applied on Windows")
+	}
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	existing := filepath.Join(dir, "existing.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("old"), 0600))
+	require.NoError(t, os.Chmod(existing, 0600))
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithDefaultModes(0660, 0770))
+	require.NoError(t, err)
+
+	call := func(h func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := h(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	}
+	perm := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info.Mode().Perm()
+	}
+
+	call(handler.HandleCreateDirectory, map[string]any{"path": filepath.Join(dir, "out", "sub")})
+	assert.Equal(t, os.FileMode(0770), perm(filepath.Join(dir, "out")))
+	assert.Equal(t, os.FileMode(0770), perm(filepath.Join(dir, "out", "sub")))
+
+	call(handler.HandleWriteFile, map[string]any{"path": filepath.Join(dir, "out", "new.txt"), "content": "x"})
+	assert.Equal(t, os.FileMode(0660), perm(filepath.Join(dir, "out", "new.txt")))
+
+	call(handler.HandleWriteFile, map[string]any{"path": existing, "content": "new"})
+	assert.Equal(t, os.FileMode(0600), perm(existing), "existing files keep their mode")
+
+	call(handler.HandleCopyFile, map[string]any{"source": existing, "destination": filepath.Join(dir, "out", "copy.txt")})
+	assert.Equal(t, os.FileMode(0600), perm(filepath.Join(dir, "out", "copy.txt")), "copies keep the source mode")
+}
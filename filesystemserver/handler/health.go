@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithRootHealthCheck starts a background check that stats every allowed
+// directory each interval and logs a warning when one becomes unavailable and
+// an info message when it comes back. The last observed status is reported by
+// get_server_info and ping. The check runs until Close; 0 or less disables
+// it.
+func WithRootHealthCheck(interval time.Duration) Option {
+	return func(fs *FilesystemHandler) {
+		fs.healthInterval = interval
+	}
+}
+
+// rootStatus is the last observed availability of an allowed directory
+type rootStatus struct {
+	available bool
+	err       string    // why the directory is unavailable
+	since     time.Time // when it last changed availability
+	checked   time.Time
+}
+
+// rootHealth runs the periodic check of the allowed directories
+type rootHealth struct {
+	mu       sync.Mutex
+	status   map[string]rootStatus
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Close stops the check
+func (h *rootHealth) Close() error {
+	h.stopOnce.Do(func() { close(h.stop) })
+	return nil
+}
+
+// startHealthCheck checks the allowed directories once and then every
+// healthInterval until the handler is closed
+func (fs *FilesystemHandler) startHealthCheck() {
+	fs.health = &rootHealth{
+		status: make(map[string]rootStatus),
+		stop:   make(chan struct{}),
+	}
+	fs.registerCloser(fs.health)
+	go func() {
+		ticker := time.NewTicker(fs.healthInterval)
+		defer ticker.Stop()
+		for {
+			fs.checkRoots()
+			select {
+			case <-ticker.C:
+			case <-fs.health.stop:
+				return
+			}
+		}
+	}()
+}
+
+// checkRoots stats every allowed directory, as ping does, and logs the
+// directories whose availability changed since the previous check
+func (fs *FilesystemHandler) checkRoots() {
+	fs.configMu.RLock()
+	dirs := slices.Clone(fs.allowedDirs)
+	fs.configMu.RUnlock()
+
+	errs := make([]chan error, len(dirs))
+	for i, dir := range dirs {
+		errs[i] = make(chan error, 1)
+		go func(dir string, result chan<- error) {
+			info, err := fs.fsys.Stat(dir)
+			if err == nil && !info.IsDir() {
+				err = fmt.Errorf("not a directory")
+			}
+			result <- err
+		}(dir, errs[i])
+	}
+
+	timeout := time.NewTimer(PING_STAT_TIMEOUT)
+	defer timeout.Stop()
+	now := time.Now()
+	observed := make(map[string]rootStatus, len(dirs))
+	for i, dir := range dirs {
+		var err error
+		select {
+		case err = <-errs[i]:
+		case <-timeout.C:
+			err = fmt.Errorf("no response within %v", PING_STAT_TIMEOUT)
+		}
+		observed[dir] = rootStatus{available: err == nil, checked: now}
+		if err != nil {
+			observed[dir] = rootStatus{err: err.Error(), checked: now}
+		}
+	}
+
+	fs.health.mu.Lock()
+	defer fs.health.mu.Unlock()
+	for dir, status := range observed {
+		previous, known := fs.health.status[dir]
+		name := strings.TrimSuffix(dir, string(filepath.Separator))
+		switch {
+		case known && previous.available == status.available:
+			status.since = previous.since
+		case !status.available:
+			status.since = now
+			fs.logger.Warn("Allowed directory became unavailable", "directory", name, "error", status.err)
+		case known:
+			status.since = now
+			fs.logger.Info("Allowed directory is available again", "directory", name, "unavailable_for", now.Sub(previous.since).Truncate(time.Second))
+		default:
+			status.since = now
+		}
+		observed[dir] = status
+	}
+	// Directories removed by a reload are no longer reported
+	fs.health.status = observed
+}
+
+// healthReport describes the last observed status of every allowed
+// directory, or returns "" when the health check is not running
+func (fs *FilesystemHandler) healthReport() string {
+	if fs.health == nil {
+		return ""
+	}
+	fs.health.mu.Lock()
+	defer fs.health.mu.Unlock()
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("Root health check (every %v):\n", fs.healthInterval))
+	for _, dir := range fs.allowedDirs {
+		name := fs.displayPath(strings.TrimSuffix(dir, string(filepath.Separator)))
+		status, ok := fs.health.status[dir]
+		switch {
+		case !ok:
+			report.WriteString(fmt.Sprintf("  %s: not checked yet\n", name))
+		case status.available:
+			report.WriteString(fmt.Sprintf("  %s: available since %s (checked %s)\n",
+				name, status.since.Format(time.RFC3339), status.checked.Format(time.RFC3339)))
+		default:
+			report.WriteString(fmt.Sprintf("  %s: unavailable since %s (%s; checked %s)\n",
+				name, status.since.Format(time.RFC3339), status.err, status.checked.Format(time.RFC3339)))
+		}
+	}
+	return report.String()
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootHealthCheck(t *testing.T) {
+	parent := resolveAllowedDirs(t, t.TempDir())[0]
+	dir := filepath.Join(parent, "mount")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	var logs bytes.Buffer
+	handler, err := NewFilesystemHandler([]string{dir},
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithRootHealthCheck(time.Hour),
+	)
+	require.NoError(t, err)
+	defer handler.Close()
+
+	// The first check runs at once; wait for it rather than racing it
+	require.Eventually(t, func() bool {
+		return !bytes.Contains([]byte(handler.healthReport()), []byte("not checked yet"))
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, handler.healthReport(), "available since")
+
+	require.NoError(t, os.Rename(dir, dir+".gone"))
+	handler.checkRoots()
+	assert.Contains(t, handler.healthReport(), "unavailable since")
+	assert.Contains(t, logs.String(), "Allowed directory became unavailable")
+
+	require.NoError(t, os.Rename(dir+".gone", dir))
+	handler.checkRoots()
+	assert.Contains(t, logs.String(), "Allowed directory is available again")
+
+	result, err := handler.HandlePing(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Root health check (every 1h0m0s)")
+}
@@ -1,14 +1,20 @@
 package handler
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"mime"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // isPathInAllowedDirs checks if a path is within any of the allowed directories
@@ -23,7 +29,7 @@ func (fs *FilesystemHandler) isPathInAllowedDirs(path string) bool {
 	// and not a prefix match (e.g., /tmp/foo should not match /tmp/foobar)
 	if !strings.HasSuffix(absPath, string(filepath.Separator)) {
 		// If it's a file, we need to check its directory
-		if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		if info, err := fs.fsys.Stat(absPath); err == nil && !info.IsDir() {
 			absPath = filepath.Dir(absPath) + string(filepath.Separator)
 		} else {
 			absPath = absPath + string(filepath.Separator)
@@ -39,9 +45,32 @@ func (fs *FilesystemHandler) isPathInAllowedDirs(path string) bool {
 	return false
 }
 
-func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error) {
-	// Always convert to absolute path first
-	abs, err := filepath.Abs(requestedPath)
+// rootForPath returns the allowed directory that contains path, without its
+// trailing separator, or an empty string if path is outside every allowed directory
+func (fs *FilesystemHandler) rootForPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	absPath = filepath.Clean(absPath) + string(filepath.Separator)
+
+	// Prefer the most specific root when allowed directories are nested
+	root := ""
+	for _, dir := range fs.allowedDirs {
+		if strings.HasPrefix(absPath, dir) && len(dir) > len(root) {
+			root = dir
+		}
+	}
+	return strings.TrimSuffix(root, string(filepath.Separator))
+}
+
+// confinePath resolves a requested path, following symbolic links, and
+// checks that it lies within the allowed directories. A path that does not
+// exist yet is accepted if its parent directory does. Under SymlinkFollow a
+// path whose links lead outside is accepted as given, without resolving it.
+func (fs *FilesystemHandler) confinePath(requestedPath string) (string, error) {
+	// Map root-relative paths onto the real root, then convert to absolute
+	abs, err := filepath.Abs(fs.fromRootRelative(requestedPath))
 	if err != nil {
 		return "", fmt.Errorf("invalid path: %w", err)
 	}
@@ -50,24 +79,24 @@ func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error)
 	if !fs.isPathInAllowedDirs(abs) {
 		return "", fmt.Errorf(
 			"access denied - path outside allowed directories: %s",
-			abs,
+			fs.displayPath(abs),
 		)
 	}
 
 	// Handle symlinks
-	realPath, err := filepath.EvalSymlinks(abs)
+	realPath, err := fs.fsys.EvalSymlinks(abs)
 	if err != nil {
 		if !os.IsNotExist(err) {
-			return "", err
+			return "", fs.displayError(err)
 		}
 		// For new files, check parent directory
 		parent := filepath.Dir(abs)
-		realParent, err := filepath.EvalSymlinks(parent)
+		realParent, err := fs.fsys.EvalSymlinks(parent)
 		if err != nil {
-			return "", fmt.Errorf("parent directory does not exist: %s", parent)
+			return "", fmt.Errorf("parent directory does not exist: %s", fs.displayPath(parent))
 		}
 
-		if !fs.isPathInAllowedDirs(realParent) {
+		if !fs.isPathInAllowedDirs(realParent) && fs.symlinkPolicy != SymlinkFollow {
 			return "", fmt.Errorf(
 				"access denied - parent directory outside allowed directories",
 			)
@@ -75,8 +104,13 @@ func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error)
 		return abs, nil
 	}
 
-	// Check if the real path (after resolving symlinks) is still within allowed directories
+	// Check if the real path (after resolving symlinks) is still within
+	// allowed directories. Links that escape are followed through the link
+	// path if the symlink policy allows it.
 	if !fs.isPathInAllowedDirs(realPath) {
+		if fs.symlinkPolicy == SymlinkFollow {
+			return abs, nil
+		}
 		return "", fmt.Errorf(
 			"access denied - symlink target outside allowed directories",
 		)
@@ -85,10 +119,199 @@ func (fs *FilesystemHandler) validatePath(requestedPath string) (string, error)
 	return realPath, nil
 }
 
+// confinePathWithParents confines a path whose parent directories may not
+// exist yet, such as a directory created together with its parents. The
+// nearest existing ancestor must resolve within the allowed directories.
+func (fs *FilesystemHandler) confinePathWithParents(requestedPath string) (string, error) {
+	abs, err := filepath.Abs(fs.fromRootRelative(requestedPath))
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+	if !fs.isPathInAllowedDirs(abs) {
+		return "", fmt.Errorf(
+			"access denied - path outside allowed directories: %s",
+			fs.displayPath(abs),
+		)
+	}
+
+	// Resolve the deepest ancestor that exists and re-attach the rest
+	var missing []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		realDir, err := fs.fsys.EvalSymlinks(dir)
+		if err == nil {
+			if !fs.isPathInAllowedDirs(realDir) {
+				if fs.symlinkPolicy == SymlinkFollow {
+					return abs, nil
+				}
+				return "", fmt.Errorf(
+					"access denied - symlink target outside allowed directories",
+				)
+			}
+			for i := len(missing) - 1; i >= 0; i-- {
+				realDir = filepath.Join(realDir, missing[i])
+			}
+			return realDir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fs.displayError(err)
+		}
+		if filepath.Dir(dir) == dir {
+			return "", fmt.Errorf("no existing parent directory for %s", fs.displayPath(abs))
+		}
+		missing = append(missing, filepath.Base(dir))
+	}
+}
+
+// confineLinkPath confines the path of a symbolic link itself, without
+// following the link. The link must lie within the allowed directories once
+// its parent directory is resolved; under SymlinkReject it must not resolve
+// outside them either.
+func (fs *FilesystemHandler) confineLinkPath(requestedPath string) (string, error) {
+	abs, err := filepath.Abs(fs.fromRootRelative(requestedPath))
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+
+	// The allowed directories themselves have no allowed parent
+	for _, dir := range fs.allowedDirs {
+		if abs == strings.TrimSuffix(dir, string(filepath.Separator)) {
+			return abs, nil
+		}
+	}
+
+	parent := filepath.Dir(abs)
+	realParent, err := fs.fsys.EvalSymlinks(parent)
+	if err != nil {
+		return "", fmt.Errorf("parent directory does not exist: %s", fs.displayPath(parent))
+	}
+
+	linkPath := filepath.Join(realParent, filepath.Base(abs))
+	if !fs.isPathInAllowedDirs(realParent) || fs.rootForPath(linkPath) == "" {
+		return "", fmt.Errorf(
+			"access denied - path outside allowed directories: %s",
+			fs.displayPath(abs),
+		)
+	}
+	if fs.symlinkPolicy == SymlinkReject && fs.escapingLink(linkPath) {
+		return "", fmt.Errorf(
+			"access denied - symlink target outside allowed directories",
+		)
+	}
+	return linkPath, nil
+}
+
+// makeDirs creates dir along with any missing parents and gives every
+// directory it created perm, regardless of the umask. It returns the created
+// directories, deepest first. Permissions are not applied on Windows.
+func (fs *FilesystemHandler) makeDirs(dir string, perm os.FileMode) ([]string, error) {
+	var created []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := fs.fsys.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		created = append(created, d)
+	}
+
+	if err := fs.fsys.MkdirAll(dir, perm); err != nil {
+		return nil, err
+	}
+	if runtime.GOOS != "windows" {
+		for _, d := range created {
+			if err := fs.fsys.Chmod(d, perm); err != nil {
+				return created, fmt.Errorf("setting mode of %s: %w", fs.displayPath(d), err)
+			}
+		}
+	}
+	return created, nil
+}
+
+// specialFileKind names the kind of a file that is neither a regular file
+// nor a directory, such as a named pipe or a device, and returns "" for
+// regular files and directories
+func specialFileKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "block device"
+	case mode&os.ModeIrregular != 0:
+		return "irregular file"
+	}
+	return ""
+}
+
+// refuseSpecialFile returns a not_a_regular_file error when path names a
+// named pipe, device, socket or other special file, as opening or reading
+// one can block forever or never reach the end. Paths that cannot be
+// stat'ed are left for the caller to report.
+func (fs *FilesystemHandler) refuseSpecialFile(path string) error {
+	info, err := fs.fsys.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if kind := specialFileKind(info.Mode()); kind != "" {
+		return fmt.Errorf("not_a_regular_file - %s is a %s; pass allow_special to read_file or write_file to access it anyway", fs.displayPath(path), kind)
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of a file's contents
+func (fs *FilesystemHandler) fileSHA256(path string) (string, error) {
+	f, err := fs.fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode().IsRegular() && n < info.Size() {
+		return "", shortIOError("read", path, n, info.Size())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// conflictResult reports a failed if_match_sha256 precondition together with
+// the current digest, so that the client can re-read the file and retry.
+// An empty current digest means the file does not exist.
+func conflictResult(path, expected, current string) *mcp.CallToolResult {
+	if current == "" {
+		current = "none (file does not exist)"
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf(
+					"Error: conflict - %s has changed (if_match_sha256 %s, current sha256 %s). Re-read the file and retry.",
+					path, expected, current,
+				),
+			},
+		},
+		IsError: true,
+	}
+}
+
 // detectMimeType tries to determine the MIME type of a file
-func detectMimeType(path string) string {
+func (fs *FilesystemHandler) detectMimeType(path string) string {
 	// Use mimetype library for more accurate detection
-	mtype, err := mimetype.DetectFile(path)
+	f, err := fs.fsys.Open(path)
+	var mtype *mimetype.MIME
+	if err == nil {
+		mtype, err = mimetype.DetectReader(f)
+		f.Close()
+	}
 	if err != nil {
 		// Fallback to extension-based detection if file can't be read
 		ext := filepath.Ext(path)
@@ -104,6 +327,35 @@ func detectMimeType(path string) string {
 	return mtype.String()
 }
 
+// classifyFile tags a regular file as "text" or "binary" by looking for a
+// NUL byte in its first CLASSIFY_SNIFF_SIZE bytes. Special files, links that
+// leave the allowed directories and files that cannot be read are not
+// classified and yield "".
+func (fs *FilesystemHandler) classifyFile(path string) string {
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := fs.fsys.Stat(validPath); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	f, err := fs.fsys.Open(validPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, CLASSIFY_SNIFF_SIZE)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	if bytes.IndexByte(buf[:n], 0) >= 0 {
+		return "binary"
+	}
+	return "text"
+}
+
 // isTextFile determines if a file is likely a text file based on MIME type
 func isTextFile(mimeType string) bool {
 	// Check for common text MIME types
@@ -152,8 +404,17 @@ func isTextFile(mimeType string) bool {
 	return false
 }
 
-// isImageFile determines if a file is an image based on MIME type
+// inlineImageTypes are the image formats that MCP clients can display from an
+// image content block. Other images are returned like any other binary file.
+var inlineImageTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+}
+
+// isImageFile determines if a file is an image that can be returned as an
+// image content block, based on MIME type
 func isImageFile(mimeType string) bool {
-	return strings.HasPrefix(mimeType, "image/") ||
-		(mimeType == "application/xml" && strings.HasSuffix(strings.ToLower(mimeType), ".svg"))
+	return slices.Contains(inlineImageTypes, mimeType)
 }
@@ -0,0 +1,476 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// jsonObject is a decoded JSON object that remembers the order of its keys,
+// so that a patched document is written back in its original order
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// jsonArray is a decoded JSON array. It is a pointer type so that patches
+// can grow and shrink arrays in place.
+type jsonArray struct {
+	items []any
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) remove(key string) {
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// decodeOrderedJSON decodes a single JSON document into jsonObject,
+// jsonArray, string, json.Number, bool and nil values
+func decodeOrderedJSON(content []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, jsonErrorPosition(content, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		line, col := offsetPosition(content, decoder.InputOffset())
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after top-level value", line, col)
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{values: map[string]any{}}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key.(string), value)
+		}
+		_, err = decoder.Token() // The closing brace
+		return object, err
+	case json.Delim('['):
+		array := &jsonArray{items: []any{}}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, value)
+		}
+		_, err = decoder.Token() // The closing bracket
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// toOrderedJSON converts a value decoded by encoding/json, such as a tool
+// argument, to the ordered representation. Object keys end up sorted.
+func toOrderedJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrderedJSON(data)
+}
+
+// jsonStyle is the layout of a JSON file, kept when it is written back
+type jsonStyle struct {
+	indent   string // empty for compact documents
+	newline  string
+	trailing bool // whether the file ends with a newline
+}
+
+// detectJSONStyle takes the indentation from the first indented line. Files
+// with any CRLF line ending are written with CRLF.
+func detectJSONStyle(content []byte) jsonStyle {
+	style := jsonStyle{newline: "\n"}
+	if bytes.Contains(content, []byte("\r\n")) {
+		style.newline = "\r\n"
+	}
+	style.trailing = bytes.HasSuffix(content, []byte("\n"))
+	for _, line := range bytes.Split(content, []byte("\n"))[1:] {
+		if indent := len(line) - len(bytes.TrimLeft(line, " \t")); indent > 0 {
+			style.indent = string(line[:indent])
+			break
+		}
+	}
+	return style
+}
+
+// encodeOrderedJSON writes value in the given style
+func encodeOrderedJSON(value any, style jsonStyle) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeOrderedValue(&b, value, style, 0); err != nil {
+		return nil, err
+	}
+	if style.trailing {
+		b.WriteString(style.newline)
+	}
+	return b.Bytes(), nil
+}
+
+func writeOrderedValue(b *bytes.Buffer, value any, style jsonStyle, depth int) error {
+	// newline starts a new line indented to the given depth
+	newline := func(depth int) {
+		if style.indent != "" {
+			b.WriteString(style.newline)
+			b.WriteString(strings.Repeat(style.indent, depth))
+		}
+	}
+	colon := ":"
+	if style.indent != "" {
+		colon = ": "
+	}
+
+	switch v := value.(type) {
+	case *jsonObject:
+		if len(v.keys) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{")
+		for i, key := range v.keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			newline(depth + 1)
+			if err := writeJSONScalar(b, key); err != nil {
+				return err
+			}
+			b.WriteString(colon)
+			if err := writeOrderedValue(b, v.values[key], style, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteString("}")
+	case *jsonArray:
+		if len(v.items) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[")
+		for i, item := range v.items {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			newline(depth + 1)
+			if err := writeOrderedValue(b, item, style, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteString("]")
+	default:
+		return writeJSONScalar(b, v)
+	}
+	return nil
+}
+
+// writeJSONScalar writes a string, number, boolean or null without the HTML
+// escaping of json.Marshal
+func writeJSONScalar(b *bytes.Buffer, value any) error {
+	var scalar bytes.Buffer
+	encoder := json.NewEncoder(&scalar)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	b.Write(bytes.TrimSuffix(scalar.Bytes(), []byte("\n")))
+	return nil
+}
+
+// parseJSONPointer splits an RFC 6901 JSON pointer into its unescaped
+// reference tokens. The empty pointer refers to the whole document.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses a reference token as an index into an array of length n.
+// With allowEnd, "-" and n refer to the position after the last element.
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > n || (index == n && !allowEnd) {
+		return 0, fmt.Errorf("array index %d out of range (length %d)", index, n)
+	}
+	return index, nil
+}
+
+// jsonPointerGet returns the value that tokens refer to in doc
+func jsonPointerGet(doc any, tokens []string) (any, error) {
+	current := doc
+	for i, token := range tokens {
+		switch v := current.(type) {
+		case *jsonObject:
+			value, ok := v.values[token]
+			if !ok {
+				return nil, fmt.Errorf("path /%s does not exist", strings.Join(tokens[:i+1], "/"))
+			}
+			current = value
+		case *jsonArray:
+			index, err := arrayIndex(token, len(v.items), false)
+			if err != nil {
+				return nil, fmt.Errorf("path /%s: %w", strings.Join(tokens[:i+1], "/"), err)
+			}
+			current = v.items[index]
+		default:
+			return nil, fmt.Errorf("path /%s does not exist: /%s is not an object or array",
+				strings.Join(tokens[:i+1], "/"), strings.Join(tokens[:i], "/"))
+		}
+	}
+	return current, nil
+}
+
+// jsonPatchAdd adds value at tokens and returns the new document. An
+// existing object member is replaced; array elements are shifted.
+func jsonPatchAdd(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, err := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		p.set(last, value)
+	case *jsonArray:
+		index, err := arrayIndex(last, len(p.items), true)
+		if err != nil {
+			return nil, err
+		}
+		p.items = append(p.items[:index], append([]any{value}, p.items[index:]...)...)
+	default:
+		return nil, fmt.Errorf("cannot add to /%s: not an object or array", strings.Join(tokens[:len(tokens)-1], "/"))
+	}
+	return doc, nil
+}
+
+// jsonPatchRemove removes the value at tokens, which must exist, and returns
+// the new document and the removed value
+func jsonPatchRemove(doc any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	removed, err := jsonPointerGet(doc, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, _ := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		p.remove(last)
+	case *jsonArray:
+		index, _ := arrayIndex(last, len(p.items), false)
+		p.items = append(p.items[:index], p.items[index+1:]...)
+	}
+	return doc, removed, nil
+}
+
+// jsonPatchReplace replaces the value at tokens, which must exist, keeping
+// the position of an object member
+func jsonPatchReplace(doc any, tokens []string, value any) (any, error) {
+	if _, err := jsonPointerGet(doc, tokens); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parent, _ := jsonPointerGet(doc, tokens[:len(tokens)-1])
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case *jsonObject:
+		p.values[last] = value
+	case *jsonArray:
+		index, _ := arrayIndex(last, len(p.items), false)
+		p.items[index] = value
+	}
+	return doc, nil
+}
+
+// copyOrderedJSON returns a deep copy of a decoded value
+func copyOrderedJSON(value any) any {
+	switch v := value.(type) {
+	case *jsonObject:
+		copied := &jsonObject{keys: append([]string(nil), v.keys...), values: make(map[string]any, len(v.values))}
+		for key, item := range v.values {
+			copied.values[key] = copyOrderedJSON(item)
+		}
+		return copied
+	case *jsonArray:
+		copied := &jsonArray{items: make([]any, len(v.items))}
+		for i, item := range v.items {
+			copied.items[i] = copyOrderedJSON(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// equalOrderedJSON compares decoded values as JSON does: object key order
+// is irrelevant and numbers compare by value
+func equalOrderedJSON(a, b any) bool {
+	switch x := a.(type) {
+	case *jsonObject:
+		y, ok := b.(*jsonObject)
+		if !ok || len(x.keys) != len(y.keys) {
+			return false
+		}
+		for key, value := range x.values {
+			other, ok := y.values[key]
+			if !ok || !equalOrderedJSON(value, other) {
+				return false
+			}
+		}
+		return true
+	case *jsonArray:
+		y, ok := b.(*jsonArray)
+		if !ok || len(x.items) != len(y.items) {
+			return false
+		}
+		for i := range x.items {
+			if !equalOrderedJSON(x.items[i], y.items[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		xf, _, errX := big.ParseFloat(string(x), 10, 256, big.ToNearestEven)
+		yf, _, errY := big.ParseFloat(string(y), 10, 256, big.ToNearestEven)
+		return errX == nil && errY == nil && xf.Cmp(yf) == 0
+	default:
+		return a == b
+	}
+}
+
+// jsonPatchOperation is one operation of an RFC 6902 JSON Patch
+type jsonPatchOperation struct {
+	Op       string
+	Path     string
+	From     string
+	Value    any
+	hasValue bool // Value may legitimately be null
+}
+
+// applyJSONPatch applies the operations in order and returns the patched
+// document. doc may be modified even if an operation fails.
+func applyJSONPatch(doc any, operations []jsonPatchOperation) (any, error) {
+	for i, op := range operations {
+		var err error
+		doc, err = applyJSONPatchOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOperation(doc any, op jsonPatchOperation) (any, error) {
+	tokens, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if !op.hasValue {
+			return nil, fmt.Errorf("missing value")
+		}
+		if value, err = toOrderedJSON(op.Value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("cannot move %s into itself", op.From)
+			}
+			if doc, value, err = jsonPatchRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = jsonPointerGet(doc, from); err != nil {
+				return nil, err
+			}
+			value = copyOrderedJSON(value)
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown op %q (expected add, remove, replace, move, copy or test)", op.Op)
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return jsonPatchAdd(doc, tokens, value)
+	case "remove":
+		doc, _, err = jsonPatchRemove(doc, tokens)
+		return doc, err
+	case "replace":
+		return jsonPatchReplace(doc, tokens, value)
+	default: // test
+		current, err := jsonPointerGet(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !equalOrderedJSON(current, value) {
+			return nil, fmt.Errorf("test failed: value differs")
+		}
+		return doc, nil
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// languageByExtension maps lower-case file extensions to language names
+var languageByExtension = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".pyi":   "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".cjs":   "javascript",
+	".jsx":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".kts":   "kotlin",
+	".scala": "scala",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".cxx":   "cpp",
+	".hpp":   "cpp",
+	".hh":    "cpp",
+	".cs":    "csharp",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".m":     "objective-c",
+	".lua":   "lua",
+	".pl":    "perl",
+	".pm":    "perl",
+	".r":     "r",
+	".dart":  "dart",
+	".ex":    "elixir",
+	".exs":   "elixir",
+	".erl":   "erlang",
+	".hs":    "haskell",
+	".clj":   "clojure",
+	".sh":    "shell",
+	".bash":  "shell",
+	".zsh":   "shell",
+	".ps1":   "powershell",
+	".sql":   "sql",
+	".html":  "html",
+	".htm":   "html",
+	".css":   "css",
+	".scss":  "scss",
+	".vue":   "vue",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".xml":   "xml",
+	".md":    "markdown",
+	".proto": "protobuf",
+	".tf":    "terraform",
+}
+
+// languageByName maps well-known extensionless file names to language names
+var languageByName = map[string]string{
+	"Dockerfile":  "dockerfile",
+	"Makefile":    "makefile",
+	"GNUmakefile": "makefile",
+	"Jenkinsfile": "groovy",
+	"Gemfile":     "ruby",
+	"Rakefile":    "ruby",
+}
+
+// languageByInterpreter maps shebang interpreters, without version suffixes,
+// to language names
+var languageByInterpreter = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"dash":    "shell",
+	"ksh":     "shell",
+	"python":  "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"Rscript": "r",
+	"pwsh":    "powershell",
+}
+
+// detectLanguage returns the programming language of a source file from its
+// extension or name, falling back to the shebang line of extensionless
+// scripts. It returns "" when the language is unknown.
+func detectLanguage(path string, content []byte) string {
+	name := filepath.Base(path)
+	if language, ok := languageByName[name]; ok {
+		return language
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != "" {
+		return languageByExtension[ext]
+	}
+	return shebangLanguage(content)
+}
+
+// shebangLanguage returns the language named by a "#!" line such as
+// "#!/usr/bin/env python3", or "" if there is none
+func shebangLanguage(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip options such as "env -S"
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	// python3 and python3.12 are python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return languageByInterpreter[interpreter]
+}
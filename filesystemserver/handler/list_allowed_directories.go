@@ -9,6 +9,8 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// HandleListAllowedDirectories reports the allowed roots as absolute paths,
+// together with the access mode and the aliases of each root
 func (fs *FilesystemHandler) HandleListAllowedDirectories(
 	ctx context.Context,
 	request mcp.CallToolRequest,
@@ -23,8 +25,12 @@ func (fs *FilesystemHandler) HandleListAllowedDirectories(
 	result.WriteString("Allowed directories:\n\n")
 
 	for _, dir := range displayDirs {
-		resourceURI := pathToResourceURI(dir)
-		result.WriteString(fmt.Sprintf("%s (%s)\n", dir, resourceURI))
+		resourceURI := fs.resourceURI(dir)
+		result.WriteString(fmt.Sprintf("%s (%s) [%s]", fs.displayPath(dir), resourceURI, fs.dirMode(dir)))
+		if aliases := fs.aliasesFor(dir); len(aliases) > 0 {
+			result.WriteString(" " + strings.Join(aliases, " "))
+		}
+		result.WriteString("\n")
 	}
 
 	return &mcp.CallToolResult{
@@ -39,6 +39,7 @@ func TestHandleListAllowedDirectories(t *testing.T) {
 		assert.Contains(t, textContent.Text, tmpDir1)
 		assert.Contains(t, textContent.Text, tmpDir2)
 		assert.Contains(t, textContent.Text, "file://")
+		assert.Contains(t, textContent.Text, "[read-write]")
 	})
 
 	t.Run("single allowed directory", func(t *testing.T) {
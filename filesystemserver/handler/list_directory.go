@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/gobwas/glob"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -51,13 +53,13 @@ func (fs *FilesystemHandler) HandleListDirectory(
 	}
 
 	// Check if it's a directory
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error: %v", err),
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
@@ -76,7 +78,55 @@ func (fs *FilesystemHandler) HandleListDirectory(
 		}, nil
 	}
 
-	entries, err := os.ReadDir(validPath)
+	recursive := request.GetBool("recursive", false)
+	maxDepth := 1
+	if recursive {
+		maxDepth = 0 // 0 means unlimited
+	}
+	if depthArg, err := request.RequireFloat("max_depth"); err == nil {
+		if depthArg < 1 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: max_depth must be at least 1",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		if recursive {
+			maxDepth = int(depthArg)
+		}
+	}
+
+	offset := request.GetInt("offset", 0)
+	if offset < 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: offset cannot be negative",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	filter, err := newListFilter(request.GetString("include", ""), request.GetString("exclude", ""))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	entries, total, walkTruncated, err := fs.listEntries(ctx, validPath, maxDepth, filter, fs.gitignoreFor(request, validPath), offset, fs.maxListEntries)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -88,30 +138,45 @@ func (fs *FilesystemHandler) HandleListDirectory(
 			IsError: true,
 		}, nil
 	}
+	if request.GetBool("classify", false) {
+		fs.forEach(ctx, len(entries), func(i int) {
+			if entries[i].Type == "file" {
+				entries[i].Class = fs.classifyFile(entries[i].Path)
+			}
+		})
+	}
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", validPath))
+	result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", fs.displayPath(validPath)))
 
 	for _, entry := range entries {
-		entryPath := filepath.Join(validPath, entry.Name())
-		resourceURI := pathToResourceURI(entryPath)
+		resourceURI := fs.resourceURI(entry.Path)
+		name := entry.relPath
 
-		if entry.IsDir() {
-			result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", entry.Name(), resourceURI))
+		if entry.Type == "directory" {
+			result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", name, resourceURI))
+		} else if entry.Class != "" {
+			result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes, %s\n",
+				name, resourceURI, entry.Size, entry.Class))
 		} else {
-			info, err := entry.Info()
-			if err == nil {
-				result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n",
-					entry.Name(), resourceURI, info.Size()))
-			} else {
-				result.WriteString(fmt.Sprintf("[FILE] %s (%s)\n", entry.Name(), resourceURI))
-			}
+			result.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n",
+				name, resourceURI, entry.Size))
 		}
 	}
+	truncated := offset+len(entries) < total
+	if truncated {
+		result.WriteString(fmt.Sprintf(
+			"\nNote: truncated: true - showing entries %d-%d of %d (total: %d). Call again with offset: %d for the next page, or narrow the listing with include, exclude or max_depth.\n",
+			offset+1, offset+len(entries), total, total, offset+len(entries),
+		))
+	} else if offset > 0 && len(entries) == 0 {
+		result.WriteString(fmt.Sprintf("\nNote: offset %d is past the last entry (total: %d).\n", offset, total))
+	}
+	result.WriteString(walkTruncatedNote(walkTruncated))
 
 	// Return both text content and embedded resource
-	resourceURI := pathToResourceURI(validPath)
-	return &mcp.CallToolResult{
+	resourceURI := fs.resourceURI(validPath)
+	listing := &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -122,9 +187,121 @@ func (fs *FilesystemHandler) HandleListDirectory(
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Directory: %s", validPath),
+					Text:     fmt.Sprintf("Directory: %s", fs.displayPath(validPath)),
 				},
 			},
 		},
-	}, nil
+	}
+	if truncated {
+		listing.Meta = map[string]any{"truncated": true, "total": total, "next_offset": offset + len(entries)}
+	}
+	return listing, nil
+}
+
+// listEntry is a single entry of a directory listing
+type listEntry struct {
+	FileMatch
+	relPath string // slash-separated path relative to the listed directory
+}
+
+// listFilter selects the entries of a listing using include and exclude
+// glob patterns. A pattern containing a slash is matched against the path
+// relative to the listed directory, any other pattern against the name.
+type listFilter struct {
+	include         glob.Glob
+	exclude         glob.Glob
+	includeHasSlash bool
+	excludeHasSlash bool
+}
+
+func newListFilter(include, exclude string) (*listFilter, error) {
+	filter := &listFilter{
+		includeHasSlash: strings.Contains(include, "/"),
+		excludeHasSlash: strings.Contains(exclude, "/"),
+	}
+	var err error
+	if include != "" {
+		if filter.include, err = glob.Compile(include, '/'); err != nil {
+			return nil, err
+		}
+	}
+	if exclude != "" {
+		if filter.exclude, err = glob.Compile(exclude, '/'); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+func globMatch(g glob.Glob, hasSlash bool, relPath string) bool {
+	if hasSlash {
+		return g.Match(relPath)
+	}
+	return g.Match(path.Base(relPath))
+}
+
+func (f *listFilter) excluded(relPath string) bool {
+	return f.exclude != nil && globMatch(f.exclude, f.excludeHasSlash, relPath)
+}
+
+func (f *listFilter) included(relPath string) bool {
+	return f.include == nil || globMatch(f.include, f.includeHasSlash, relPath)
+}
+
+// listEntries returns the entries below dir up to maxDepth levels deep (0 for
+// unlimited), in lexical order, leaving out those that ignore excludes.
+// Excluded directories are not descended into; include only selects which
+// entries are reported. The first offset entries are skipped and at most limit
+// are returned, but all of them are counted in total. walkTruncated is set if
+// the walk limits cut the listing short.
+func (fs *FilesystemHandler) listEntries(ctx context.Context, dir string, maxDepth int, filter *listFilter, ignore *gitignore, offset, limit int) ([]listEntry, int, string, error) {
+	var entries []listEntry
+	total := 0
+	walkTruncated, err := fs.walkTreeIgnoring(
+		ctx,
+		dir,
+		ignore,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if walkPath == dir {
+				return err
+			}
+			if err != nil {
+				return nil // Skip errors and continue
+			}
+
+			rel, err := filepath.Rel(dir, walkPath)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			depth := strings.Count(rel, "/") + 1
+
+			if filter.excluded(rel) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if filter.included(rel) {
+				total++
+			}
+			if filter.included(rel) && total > offset && len(entries) < limit {
+				entry := listEntry{
+					FileMatch: FileMatch{Path: walkPath, Type: "file", Size: info.Size(), Modified: info.ModTime()},
+					relPath:   rel,
+				}
+				if info.IsDir() {
+					entry.Type, entry.Size = "directory", 0
+				}
+				entries = append(entries, entry)
+			}
+
+			if info.IsDir() && maxDepth > 0 && depth >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		},
+	)
+	return entries, total, walkTruncated, err
 }
@@ -134,3 +134,127 @@ func TestHandleListDirectory(t *testing.T) {
 		require.True(t, res.IsError)
 	})
 }
+
+func TestHandleListDirectory_Recursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsHandler, err := NewFilesystemHandler(resolveAllowedDirs(t, tmpDir))
+	require.NoError(t, err)
+
+	for _, dir := range []string{"src/pkg", "vendor/lib"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0755))
+	}
+	for _, file := range []string{"main.go", "README.md", "src/util.go", "src/pkg/pkg.go", "src/pkg/pkg_test.go", "vendor/lib/lib.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, file), []byte("package x"), 0644))
+	}
+
+	list := func(args map[string]interface{}) string {
+		args["path"] = tmpDir
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := fsHandler.HandleListDirectory(context.Background(), req)
+		require.NoError(t, err)
+		require.False(t, res.IsError, res.Content[0].(mcp.TextContent).Text)
+		return res.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("flat listing with relative paths", func(t *testing.T) {
+		text := list(map[string]interface{}{"recursive": true})
+		assert.Contains(t, text, "[DIR]  src/pkg (")
+		assert.Contains(t, text, "[FILE] src/pkg/pkg.go (")
+		assert.Contains(t, text, "[FILE] vendor/lib/lib.go (")
+		assert.Contains(t, text, "- 9 bytes")
+	})
+
+	t.Run("max_depth", func(t *testing.T) {
+		text := list(map[string]interface{}{"recursive": true, "max_depth": float64(2)})
+		assert.Contains(t, text, "[FILE] src/util.go")
+		assert.Contains(t, text, "[DIR]  src/pkg")
+		assert.NotContains(t, text, "src/pkg/pkg.go")
+	})
+
+	t.Run("include and exclude", func(t *testing.T) {
+		text := list(map[string]interface{}{"recursive": true, "include": "*.go", "exclude": "vendor"})
+		assert.Contains(t, text, "[FILE] main.go")
+		assert.Contains(t, text, "[FILE] src/pkg/pkg_test.go")
+		assert.NotContains(t, text, "README.md")
+		assert.NotContains(t, text, "vendor")
+		assert.NotContains(t, text, "[DIR]")
+
+		text = list(map[string]interface{}{"recursive": true, "include": "src/**", "exclude": "*_test.go"})
+		assert.Contains(t, text, "[FILE] src/util.go")
+		assert.Contains(t, text, "[FILE] src/pkg/pkg.go")
+		assert.NotContains(t, text, "pkg_test.go")
+		assert.NotContains(t, text, "main.go")
+	})
+
+	t.Run("filters without recursion", func(t *testing.T) {
+		text := list(map[string]interface{}{"include": "*.md"})
+		assert.Contains(t, text, "[FILE] README.md")
+		assert.NotContains(t, text, "main.go")
+		assert.NotContains(t, text, "src")
+	})
+}
+
+func TestHandleListDirectory_Classify(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# Notes\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.bin"), []byte("abc\x00def"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.txt"), nil, 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := call(handler.HandleListDirectory, map[string]any{"path": dir, "classify": true})
+	assert.Contains(t, text, "notes.md")
+	assert.Contains(t, text, "- 8 bytes, text\n")
+	assert.Contains(t, text, "- 7 bytes, binary\n")
+	assert.Contains(t, text, "- 0 bytes, text\n")
+	assert.Contains(t, text, "[DIR]  sub")
+
+	text = call(handler.HandleListDirectory, map[string]any{"path": dir})
+	assert.NotContains(t, text, "binary")
+
+	text = call(handler.HandleSearchFiles, map[string]any{"path": dir, "pattern": "*.bin", "classify": true})
+	assert.Contains(t, text, "- 7 bytes, binary, modified")
+}
+
+func TestHandleListDirectory_Truncated(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithMaxListEntries(2))
+	require.NoError(t, err)
+
+	list := func(offset int) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": dir, "offset": offset}
+		result, err := handler.HandleListDirectory(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	result := list(0)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "a.txt")
+	assert.NotContains(t, text, "c.txt")
+	assert.Contains(t, text, "truncated: true - showing entries 1-2 of 5")
+	assert.Equal(t, map[string]any{"truncated": true, "total": 5, "next_offset": 2}, result.Meta)
+
+	result = list(4)
+	text = result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "e.txt")
+	assert.NotContains(t, text, "truncated")
+	assert.Nil(t, result.Meta)
+}
@@ -0,0 +1,434 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFileSystem is an in-memory FileSystem intended for tests. It supports
+// regular files and directories; symbolic and hard links are not supported,
+// so EvalSymlinks only cleans the path and checks that it exists.
+type MemFileSystem struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	name    string
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// NewMemFileSystem returns an empty in-memory filesystem containing only the
+// root directory
+func NewMemFileSystem() *MemFileSystem {
+	// Absolute paths carry a volume name on Windows
+	root := filepath.VolumeName(os.TempDir()) + string(filepath.Separator)
+	return &MemFileSystem{
+		nodes: map[string]*memNode{
+			root: {name: root, mode: fs.ModeDir | 0755, modTime: time.Now()},
+		},
+	}
+}
+
+// memFileInfo implements os.FileInfo for a memNode. Sys returns the node so
+// that sameFile can recognise the same file across calls.
+type memFileInfo struct {
+	node *memNode
+	size int64
+}
+
+func (i memFileInfo) Name() string       { return i.node.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() os.FileMode  { return i.node.mode }
+func (i memFileInfo) ModTime() time.Time { return i.node.modTime }
+func (i memFileInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i memFileInfo) Sys() any           { return i.node }
+
+func (n *memNode) info() os.FileInfo {
+	return memFileInfo{node: n, size: int64(len(n.data))}
+}
+
+func memPathError(op, path string, err error) error {
+	return &fs.PathError{Op: op, Path: path, Err: err}
+}
+
+// lookup returns the node at the cleaned path; callers must hold mu
+func (m *MemFileSystem) lookup(op, name string) (string, *memNode, error) {
+	name = filepath.Clean(name)
+	node, ok := m.nodes[name]
+	if !ok {
+		return name, nil, memPathError(op, name, fs.ErrNotExist)
+	}
+	return name, node, nil
+}
+
+// parentDir checks that the parent of name exists and is a directory;
+// callers must hold mu
+func (m *MemFileSystem) parentDir(op, name string) error {
+	parent, ok := m.nodes[filepath.Dir(name)]
+	if !ok {
+		return memPathError(op, name, fs.ErrNotExist)
+	}
+	if !parent.mode.IsDir() {
+		return memPathError(op, name, errors.New("not a directory"))
+	}
+	return nil
+}
+
+func (m *MemFileSystem) Stat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, node, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(), nil
+}
+
+func (m *MemFileSystem) Lstat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, node, err := m.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(), nil
+}
+
+func (m *MemFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name, node, err := m.lookup("readdirent", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, memPathError("readdirent", name, errors.New("not a directory"))
+	}
+
+	var entries []os.DirEntry
+	for path, child := range m.nodes {
+		if path != name && filepath.Dir(path) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(child.info()))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *MemFileSystem) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name, node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, memPathError("read", name, errors.New("is a directory"))
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+func (m *MemFileSystem) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemFileSystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	node, ok := m.nodes[name]
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, memPathError("open", name, fs.ErrExist)
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, memPathError("open", name, fs.ErrNotExist)
+	case !ok:
+		if err := m.parentDir("open", name); err != nil {
+			return nil, err
+		}
+		node = &memNode{name: filepath.Base(name), mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[name] = node
+	case node.mode.IsDir() && flag&(os.O_WRONLY|os.O_RDWR) != 0:
+		return nil, memPathError("open", name, errors.New("is a directory"))
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		node.data = nil
+		node.modTime = time.Now()
+	}
+	return &memFile{fs: m, node: node, flag: flag}, nil
+}
+
+func (m *MemFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := m.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (m *MemFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path = filepath.Clean(path)
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if node, ok := m.nodes[dir]; ok {
+			if !node.mode.IsDir() {
+				return memPathError("mkdir", dir, errors.New("not a directory"))
+			}
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		m.nodes[missing[i]] = &memNode{
+			name:    filepath.Base(missing[i]),
+			mode:    fs.ModeDir | perm.Perm(),
+			modTime: time.Now(),
+		}
+	}
+	return nil
+}
+
+func (m *MemFileSystem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, node, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if node.mode.IsDir() && m.hasChildren(name) {
+		return memPathError("remove", name, errors.New("directory not empty"))
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+func (m *MemFileSystem) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	for name := range m.nodes {
+		if name == path || isWithin(path, name) {
+			delete(m.nodes, name)
+		}
+	}
+	return nil
+}
+
+func (m *MemFileSystem) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldpath, node, err := m.lookup("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	newpath = filepath.Clean(newpath)
+	if err := m.parentDir("rename", newpath); err != nil {
+		return err
+	}
+	if target, ok := m.nodes[newpath]; ok && target.mode.IsDir() && m.hasChildren(newpath) {
+		return memPathError("rename", newpath, errors.New("directory not empty"))
+	}
+
+	// Move the node and, for directories, everything below it
+	moved := make(map[string]*memNode)
+	for name, child := range m.nodes {
+		if isWithin(oldpath, name) {
+			moved[newpath+strings.TrimPrefix(name, oldpath)] = child
+			delete(m.nodes, name)
+		}
+	}
+	for name, child := range moved {
+		m.nodes[name] = child
+	}
+	delete(m.nodes, oldpath)
+	node.name = filepath.Base(newpath)
+	m.nodes[newpath] = node
+	return nil
+}
+
+func (m *MemFileSystem) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("chmod", name)
+	if err != nil {
+		return err
+	}
+	node.mode = node.mode&fs.ModeType | mode.Perm()
+	return nil
+}
+
+// Chtimes sets the modification time; access times are not tracked
+func (m *MemFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, node, err := m.lookup("chtimes", name)
+	if err != nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+func (m *MemFileSystem) EvalSymlinks(path string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name, _, err := m.lookup("lstat", path)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Symlink is not supported by MemFileSystem
+func (m *MemFileSystem) Symlink(oldname, newname string) error {
+	return memPathError("symlink", newname, errors.ErrUnsupported)
+}
+
+// Readlink is not supported by MemFileSystem
+func (m *MemFileSystem) Readlink(name string) (string, error) {
+	return "", memPathError("readlink", name, errors.ErrUnsupported)
+}
+
+// Link is not supported by MemFileSystem
+func (m *MemFileSystem) Link(oldname, newname string) error {
+	return memPathError("link", newname, errors.ErrUnsupported)
+}
+
+// hasChildren reports whether a directory has entries; callers must hold mu
+func (m *MemFileSystem) hasChildren(dir string) bool {
+	for name := range m.nodes {
+		if name != dir && filepath.Dir(name) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is strictly below dir
+func isWithin(dir, path string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// memFile is an open MemFileSystem file
+type memFile struct {
+	fs     *MemFileSystem
+	node   *memNode
+	flag   int
+	offset int64
+	closed bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&os.O_WRONLY != 0 {
+		return 0, memPathError("read", f.node.name, errors.New("bad file descriptor"))
+	}
+	f.fs.mu.RLock()
+	defer f.fs.mu.RUnlock()
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, memPathError("write", f.node.name, errors.New("bad file descriptor"))
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	if end := f.offset + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset += int64(len(p))
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	f.fs.mu.RLock()
+	size := int64(len(f.node.data))
+	f.fs.mu.RUnlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// Sync has nothing to flush in memory
+func (f *memFile) Sync() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.RLock()
+	defer f.fs.mu.RUnlock()
+	return f.node.info(), nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFileSystem_Handlers(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	fsys := NewMemFileSystem()
+	require.NoError(t, fsys.MkdirAll(filepath.Join(root, "src"), 0755))
+	require.NoError(t, fsys.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{root}, WithFileSystem(fsys))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("read_file", func(t *testing.T) {
+		result := call(handler.HandleReadFile, map[string]any{"path": filepath.Join(root, "src", "main.go")})
+		require.False(t, result.IsError)
+		assert.Equal(t, "package main\n", result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("write_file", func(t *testing.T) {
+		path := filepath.Join(root, "README.md")
+		result := call(handler.HandleWriteFile, map[string]any{"path": path, "content": "# Project\n"})
+		require.False(t, result.IsError)
+
+		data, err := fsys.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "# Project\n", string(data))
+	})
+
+	t.Run("list_directory", func(t *testing.T) {
+		result := call(handler.HandleListDirectory, map[string]any{"path": root})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "[FILE] README.md")
+		assert.Contains(t, text, "[DIR]  src")
+	})
+
+	t.Run("search_files", func(t *testing.T) {
+		result := call(handler.HandleSearchFiles, map[string]any{"path": root, "pattern": "*.go"})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "main.go")
+	})
+
+	t.Run("move_file and delete_file", func(t *testing.T) {
+		src := filepath.Join(root, "src", "main.go")
+		dst := filepath.Join(root, "src", "app.go")
+		result := call(handler.HandleMoveFile, map[string]any{"source": src, "destination": dst})
+		require.False(t, result.IsError)
+
+		_, err := fsys.Stat(src)
+		assert.Error(t, err)
+
+		result = call(handler.HandleDeleteFile, map[string]any{"path": dst})
+		require.False(t, result.IsError)
+		_, err = fsys.Stat(dst)
+		assert.Error(t, err)
+	})
+
+	t.Run("paths outside the root are rejected", func(t *testing.T) {
+		result := call(handler.HandleReadFile, map[string]any{"path": filepath.Join(string(filepath.Separator), "etc", "passwd")})
+		assert.True(t, result.IsError)
+	})
+}
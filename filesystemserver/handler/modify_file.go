@@ -2,6 +2,8 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"regexp"
@@ -42,6 +44,9 @@ func (fs *FilesystemHandler) HandleModifyFile(
 		useRegex = val
 	}
 
+	ifMatchSHA256 := strings.ToLower(strings.TrimSpace(request.GetString("if_match_sha256", "")))
+	backup := request.GetBool("backup", false)
+
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
 		// Get current working directory
@@ -62,6 +67,9 @@ func (fs *FilesystemHandler) HandleModifyFile(
 
 	// Validate path is within allowed directories
 	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -74,8 +82,20 @@ func (fs *FilesystemHandler) HandleModifyFile(
 		}, nil
 	}
 
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Check if it's a directory
-	if info, err := os.Stat(validPath); err == nil && info.IsDir() {
+	if info, err := fs.fsys.Stat(validPath); err == nil && info.IsDir() {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -88,7 +108,7 @@ func (fs *FilesystemHandler) HandleModifyFile(
 	}
 
 	// Check if file exists
-	if _, err := os.Stat(validPath); os.IsNotExist(err) {
+	if _, err := fs.fsys.Stat(validPath); os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -101,7 +121,7 @@ func (fs *FilesystemHandler) HandleModifyFile(
 	}
 
 	// Read file content
-	content, err := os.ReadFile(validPath)
+	content, err := readFileFull(fs.fsys, validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -114,6 +134,14 @@ func (fs *FilesystemHandler) HandleModifyFile(
 		}, nil
 	}
 
+	// Only modify the file if it still has the contents the client expects
+	if ifMatchSHA256 != "" {
+		sum := sha256.Sum256(content)
+		if current := hex.EncodeToString(sum[:]); current != ifMatchSHA256 {
+			return conflictResult(path, ifMatchSHA256, current), nil
+		}
+	}
+
 	originalContent := string(content)
 	modifiedContent := ""
 	replacementCount := 0
@@ -161,8 +189,31 @@ func (fs *FilesystemHandler) HandleModifyFile(
 		}
 	}
 
+	if result := fs.checkQuota(validPath, int64(len(modifiedContent)), true); result != nil {
+		return result, nil
+	}
+
+	// Keep a copy of the current contents for the caller to go back to
+	backupNote := ""
+	if backup {
+		backupPath, err := fs.backupFile(validPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error backing up file: %v; the file was not modified", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		backupNote = "\nBackup: " + fs.displayPath(backupPath)
+	}
+
 	// Write modified content back to file
-	if err := os.WriteFile(validPath, []byte(modifiedContent), 0644); err != nil {
+	defer fs.invalidateCache(validPath)
+	if err := fs.fsys.WriteFile(validPath, []byte(modifiedContent), fs.filePerm); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -175,37 +226,37 @@ func (fs *FilesystemHandler) HandleModifyFile(
 	}
 
 	// Create response
-	resourceURI := pathToResourceURI(validPath)
+	resourceURI := fs.resourceURI(validPath)
 
-	// Get file info for the response
-	info, err := os.Stat(validPath)
+	// Read back what landed for the response
+	written, err := fs.writtenFile(validPath)
 	if err != nil {
 		// File was written but we couldn't get info
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("File modified successfully. Made %d replacement(s).", replacementCount),
+					Text: fmt.Sprintf("File modified successfully. Made %d replacement(s).%s", replacementCount, backupNote),
 				},
 			},
 		}, nil
 	}
 
-	return &mcp.CallToolResult{
+	return withWrittenFiles(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("File modified successfully. Made %d replacement(s) in %s (file size: %d bytes)",
-					replacementCount, path, info.Size()),
+				Text: fmt.Sprintf("File modified successfully. Made %d replacement(s) in %s (file size: %d bytes)%s",
+					replacementCount, path, written.Size, backupNote),
 			},
 			mcp.EmbeddedResource{
 				Type: "resource",
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Modified file: %s (%d bytes)", validPath, info.Size()),
+					Text:     fmt.Sprintf("Modified file: %s (%d bytes)", fs.displayPath(validPath), written.Size),
 				},
 			},
 		},
-	}, nil
+	}, written), nil
 }
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 
+	"github.com/gobwas/glob"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -21,6 +25,7 @@ func (fs *FilesystemHandler) HandleMoveFile(
 	if err != nil {
 		return nil, err
 	}
+	dryRun := request.GetBool("dry_run", false)
 
 	// Handle empty or relative paths for source
 	if source == "." || source == "./" {
@@ -59,6 +64,9 @@ func (fs *FilesystemHandler) HandleMoveFile(
 	}
 
 	validSource, err := fs.validatePath(source)
+	if err == nil {
+		err = fs.authorize(validSource, OpDelete)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -72,7 +80,7 @@ func (fs *FilesystemHandler) HandleMoveFile(
 	}
 
 	// Check if source exists
-	if _, err := os.Stat(validSource); os.IsNotExist(err) {
+	if _, err := fs.fsys.Stat(validSource); os.IsNotExist(err) {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -99,8 +107,24 @@ func (fs *FilesystemHandler) HandleMoveFile(
 		}, nil
 	}
 
+	// Check the reference update before anything is created
+	refs, err := fs.parseReferenceUpdate(request, validSource, filepath.Join(validDestDir, filepath.Base(destination)))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Create parent directory for destination if it doesn't exist
-	if err := os.MkdirAll(validDestDir, 0755); err != nil {
+	if dryRun {
+		// Nothing is created; the destination is validated as it would be
+	} else if _, err := fs.makeDirs(validDestDir, fs.dirPerm); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -113,7 +137,17 @@ func (fs *FilesystemHandler) HandleMoveFile(
 	}
 
 	// Now validate the full destination path
-	validDest, err := fs.validatePath(destination)
+	validateDest := fs.validatePath
+	if dryRun {
+		validateDest = fs.validatePathWithParents
+	}
+	validDest, err := validateDest(destination)
+	if err == nil {
+		err = fs.authorize(validDest, OpWrite)
+	}
+	if err == nil {
+		err = fs.authorizeTree(validSource, OpDelete, validDest)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -126,7 +160,49 @@ func (fs *FilesystemHandler) HandleMoveFile(
 		}, nil
 	}
 
-	if err := os.Rename(validSource, validDest); err != nil {
+	if dryRun {
+		message := fmt.Sprintf("Dry run: would move %s to %s. Nothing was changed.", source, destination)
+		if refs != nil {
+			if err := fs.updateReferences(ctx, refs, true); err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: fmt.Sprintf("Error walking directory: %v", err),
+						},
+					},
+					IsError: true,
+				}, nil
+			}
+			message += "\n\n" + refs.report(fs, true)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: message,
+				},
+			},
+		}, nil
+	}
+
+	// A move within a root leaves its size unchanged, but one to another
+	// root adds the source to the destination's tree
+	srcInfo, err := fs.fsys.Lstat(validSource)
+	if result := fs.checkMoveQuota(validSource, validDest, err == nil && !srcInfo.IsDir()); result != nil {
+		return result, nil
+	}
+
+	defer fs.invalidateCache(validSource)
+	defer fs.invalidateCache(validDest)
+
+	err = fs.fsys.Rename(validSource, validDest)
+	if errors.Is(err, syscall.EXDEV) {
+		// Renaming across filesystems is not possible; fall back to a
+		// verified copy followed by removing the source
+		err = fs.moveAcrossDevices(validSource, validDest, copyProgressNotifier(ctx, request))
+	}
+	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -138,25 +214,224 @@ func (fs *FilesystemHandler) HandleMoveFile(
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validDest)
+	// References are only updated once the move has succeeded
+	message := fmt.Sprintf("Successfully moved %s to %s", source, destination)
+	referencesFailed := false
+	if refs != nil {
+		if err := fs.updateReferences(ctx, refs, false); err != nil {
+			message += fmt.Sprintf("\n\nError updating references: %v", err)
+			referencesFailed = true
+		} else {
+			message += "\n\n" + refs.report(fs, false)
+			referencesFailed = len(refs.failed) > 0
+		}
+	}
+
+	resourceURI := fs.resourceURI(validDest)
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf(
-					"Successfully moved %s to %s",
-					source,
-					destination,
-				),
+				Text: message,
 			},
 			mcp.EmbeddedResource{
 				Type: "resource",
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Moved file: %s", validDest),
+					Text:     fmt.Sprintf("Moved file: %s", fs.displayPath(validDest)),
 				},
 			},
 		},
+		IsError: referencesFailed,
 	}, nil
 }
+
+// referenceUpdate is the find and replace of a path that move_file runs
+// across a directory tree with update_references, and its outcome
+type referenceUpdate struct {
+	root     string
+	pattern  glob.Glob
+	from, to string
+
+	scanned   int
+	updated   []filePreview
+	failed    []string
+	truncated string
+}
+
+// parseReferenceUpdate reads the update_references arguments of move_file.
+// It returns nil if references are not to be updated. By default the
+// reference replaced is the source path relative to references_root, in
+// slash form, and its replacement the destination path.
+func (fs *FilesystemHandler) parseReferenceUpdate(request mcp.CallToolRequest, validSource, validDest string) (*referenceUpdate, error) {
+	if !request.GetBool("update_references", false) {
+		return nil, nil
+	}
+	root := request.GetString("references_root", "")
+	if root == "" {
+		return nil, fmt.Errorf("update_references requires references_root")
+	}
+	validRoot, err := fs.validatePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("references_root: %w", err)
+	}
+	if info, err := fs.fsys.Stat(validRoot); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("references_root is not a directory: %s", root)
+	}
+
+	pattern, err := glob.Compile(request.GetString("reference_pattern", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference_pattern: %w", err)
+	}
+
+	refs := &referenceUpdate{
+		root:    validRoot,
+		pattern: pattern,
+		from:    request.GetString("reference_from", ""),
+		to:      request.GetString("reference_to", ""),
+	}
+	relative := func(path string) string {
+		rel, err := filepath.Rel(validRoot, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ""
+		}
+		return filepath.ToSlash(rel)
+	}
+	if refs.from == "" {
+		refs.from = relative(validSource)
+	}
+	if refs.to == "" {
+		refs.to = relative(validDest)
+	}
+	if refs.from == "" || refs.to == "" {
+		return nil, fmt.Errorf("source and destination must be within references_root, or reference_from and reference_to must be given")
+	}
+	if refs.from == refs.to {
+		return nil, fmt.Errorf("reference_from and reference_to are the same")
+	}
+	return refs, nil
+}
+
+// updateReferences replaces every occurrence of refs.from with refs.to in
+// the text files below refs.root whose names match refs.pattern, as
+// modify_file would, writing each changed file atomically. Files that are
+// too large, binary or not writable are left alone. With dryRun the changes
+// are only computed.
+func (fs *FilesystemHandler) updateReferences(ctx context.Context, refs *referenceUpdate, dryRun bool) error {
+	truncated, err := fs.walkTree(
+		ctx,
+		refs.root,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip errors and continue
+			}
+			if !info.Mode().IsRegular() || !refs.pattern.Match(info.Name()) {
+				return nil
+			}
+			validPath, err := fs.validatePath(walkPath)
+			if err == nil {
+				err = fs.authorize(validPath, OpWrite)
+			}
+			if err != nil {
+				return nil // Skip files that cannot be changed
+			}
+			preview, err := fs.previewReplace(validPath, info, refs.from, refs.to, nil, true)
+			if err != nil {
+				return nil // Skip files that cannot be searched
+			}
+			refs.scanned++
+			if preview.replacements == 0 {
+				return nil
+			}
+			if !dryRun {
+				if err := fs.replaceInFile(validPath, info, refs.from, refs.to); err != nil {
+					refs.failed = append(refs.failed, fmt.Sprintf("%s: %v", fs.displayPath(validPath), err))
+					return nil
+				}
+			}
+			refs.updated = append(refs.updated, preview)
+			return nil
+		},
+	)
+	refs.truncated = truncated
+	return err
+}
+
+// replaceInFile replaces every occurrence of from with to in a file and
+// writes it back atomically, keeping its mode
+func (fs *FilesystemHandler) replaceInFile(path string, info os.FileInfo, from, to string) error {
+	content, err := readFileFull(fs.fsys, path)
+	if err != nil {
+		return err
+	}
+	updated := []byte(strings.ReplaceAll(string(content), from, to))
+	if result := fs.checkQuota(path, int64(len(updated)), true); result != nil {
+		return fmt.Errorf("%s", strings.TrimPrefix(result.Content[0].(mcp.TextContent).Text, "Error: "))
+	}
+	defer fs.invalidateCache(path)
+	return writeFileAtomic(fs.fsys, path, updated, info.Mode().Perm())
+}
+
+// report describes the files whose references were, or with dryRun would
+// be, updated, with the changed lines for a dry run
+func (refs *referenceUpdate) report(fs *FilesystemHandler, dryRun bool) string {
+	var result strings.Builder
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	total := 0
+	for _, preview := range refs.updated {
+		total += preview.replacements
+	}
+	result.WriteString(fmt.Sprintf("%s %d reference(s) to %q in %d of %d file(s) below %s:\n",
+		verb, total, refs.from, len(refs.updated), refs.scanned, fs.displayPath(refs.root)))
+
+	for i, preview := range refs.updated {
+		if i >= MAX_SEARCH_RESULTS {
+			result.WriteString(fmt.Sprintf("\nNote: listing limited to %d files.\n", MAX_SEARCH_RESULTS))
+			break
+		}
+		result.WriteString(fmt.Sprintf("  %s (%d replacement(s))\n", fs.displayPath(preview.path), preview.replacements))
+		if !dryRun {
+			continue
+		}
+		for _, change := range preview.changes {
+			if change.firstLine == change.lastLine {
+				result.WriteString(fmt.Sprintf("    Line %d:\n", change.firstLine))
+			} else {
+				result.WriteString(fmt.Sprintf("    Lines %d-%d:\n", change.firstLine, change.lastLine))
+			}
+			writePrefixedLines(&result, "      - ", change.before)
+			writePrefixedLines(&result, "      + ", change.after)
+		}
+	}
+	if len(refs.failed) > 0 {
+		result.WriteString(fmt.Sprintf("Failed to update %d file(s):\n", len(refs.failed)))
+		for _, failure := range refs.failed {
+			result.WriteString("  " + failure + "\n")
+		}
+	}
+	result.WriteString(walkTruncatedNote(refs.truncated))
+	return result.String()
+}
+
+// moveAcrossDevices moves a file or directory to another filesystem by
+// copying it, verifying large files, and then removing the source
+func (fs *FilesystemHandler) moveAcrossDevices(src, dst string, progress copyProgressFunc) error {
+	info, err := fs.fsys.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = fs.copyDir(src, dst, progress)
+	} else {
+		err = fs.copyFileChecked(src, dst, progress)
+	}
+	if err != nil {
+		return err
+	}
+	return fs.fsys.RemoveAll(src)
+}
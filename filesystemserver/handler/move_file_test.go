@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleMoveFile_UpdateReferences(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "old.md"), []byte("# Old\n"), 0644))
+	index := filepath.Join(dir, "index.md")
+	require.NoError(t, os.WriteFile(index, []byte("See docs/old.md\nand docs/old.md again\n"), 0644))
+	other := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(other, []byte("docs/old.md\n"), 0644))
+
+	move := func(args map[string]any) (string, bool) {
+		args["source"] = filepath.Join(dir, "docs", "old.md")
+		args["destination"] = filepath.Join(dir, "docs", "new", "new.md")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleMoveFile(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("references_root is required", func(t *testing.T) {
+		text, isError := move(map[string]any{"update_references": true})
+		assert.True(t, isError)
+		assert.Contains(t, text, "requires references_root")
+	})
+
+	t.Run("dry run previews without changing anything", func(t *testing.T) {
+		text, isError := move(map[string]any{"update_references": true, "references_root": dir, "reference_pattern": "*.md", "dry_run": true})
+		require.False(t, isError, text)
+		assert.Contains(t, text, `Would update 2 reference(s) to "docs/old.md" in 1 of`)
+		assert.Contains(t, text, "+ See docs/new/new.md")
+		assert.FileExists(t, filepath.Join(dir, "docs", "old.md"))
+		assert.NoDirExists(t, filepath.Join(dir, "docs", "new"))
+	})
+
+	t.Run("move and update", func(t *testing.T) {
+		text, isError := move(map[string]any{"update_references": true, "references_root": dir, "reference_pattern": "*.md"})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Updated 2 reference(s)")
+		assert.FileExists(t, filepath.Join(dir, "docs", "new", "new.md"))
+
+		content, err := os.ReadFile(index)
+		require.NoError(t, err)
+		assert.Equal(t, "See docs/new/new.md\nand docs/new/new.md again\n", string(content))
+		content, err = os.ReadFile(other)
+		require.NoError(t, err)
+		assert.Equal(t, "docs/old.md\n", string(content))
+	})
+}
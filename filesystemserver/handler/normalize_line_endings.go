@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lineEndingChange records how a file's line endings would be rewritten
+type lineEndingChange struct {
+	path    string
+	skipped string // reason the file was left alone, if any
+	changed bool
+}
+
+// HandleNormalizeLineEndings rewrites text files to use LF or CRLF line
+// endings. path may be a single file or a directory, in which case every
+// file below it whose name matches pattern is normalized.
+func (fs *FilesystemHandler) HandleNormalizeLineEndings(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	target, err := request.RequireString("target")
+	if err != nil {
+		return nil, err
+	}
+	pattern := request.GetString("pattern", "*")
+	dryRun := request.GetBool("dry_run", false)
+
+	target = strings.ToLower(target)
+	if target != "lf" && target != "crlf" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported target '%s' (expected 'lf' or 'crlf')", target),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	globPattern, err := glob.Compile(pattern)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Collect the files to normalize
+	var files []string
+	var truncated string
+	if info.IsDir() {
+		truncated, err = fs.walkTree(
+			ctx,
+			validPath,
+			func(walkPath string, entry os.FileInfo, err error) error {
+				if err != nil {
+					return nil // Skip errors and continue
+				}
+				if entry.IsDir() || !entry.Mode().IsRegular() {
+					return nil
+				}
+				if validFile, err := fs.validatePath(walkPath); err != nil || fs.authorize(validFile, OpWrite) != nil {
+					return nil // Skip invalid paths
+				}
+				if globPattern.Match(entry.Name()) {
+					files = append(files, walkPath)
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error walking directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	} else {
+		files = append(files, validPath)
+	}
+
+	var changes []lineEndingChange
+	var written []WrittenFile
+	changed := 0
+	for _, file := range files {
+		change, err := fs.normalizeLineEndings(file, target, dryRun)
+		if err != nil {
+			change = lineEndingChange{path: file, skipped: err.Error()}
+		}
+		if change.changed {
+			changed++
+			if !dryRun {
+				if record, err := fs.writtenFile(file); err == nil {
+					written = append(written, record)
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	var result strings.Builder
+	if dryRun {
+		result.WriteString(fmt.Sprintf("Dry run: %d of %d file(s) would be converted to %s:\n\n", changed, len(files), strings.ToUpper(target)))
+	} else {
+		result.WriteString(fmt.Sprintf("Converted %d of %d file(s) to %s:\n\n", changed, len(files), strings.ToUpper(target)))
+	}
+	for _, change := range changes {
+		switch {
+		case change.skipped != "":
+			result.WriteString(fmt.Sprintf("[SKIPPED]   %s (%s)\n", fs.displayPath(change.path), change.skipped))
+		case change.changed:
+			result.WriteString(fmt.Sprintf("[CHANGED]   %s\n", fs.displayPath(change.path)))
+		default:
+			result.WriteString(fmt.Sprintf("[UNCHANGED] %s\n", fs.displayPath(change.path)))
+		}
+	}
+	result.WriteString(walkTruncatedNote(truncated))
+
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}
+	if len(written) > 0 {
+		res.Meta = map[string]any{"written": written}
+	}
+	return res, nil
+}
+
+// normalizeLineEndings converts a single file to the target line endings,
+// writing it atomically unless dryRun is set. Binary files, detected by a NUL
+// byte, are skipped.
+func (fs *FilesystemHandler) normalizeLineEndings(path, target string, dryRun bool) (lineEndingChange, error) {
+	info, err := fs.fsys.Stat(path)
+	if err != nil {
+		return lineEndingChange{}, err
+	}
+	if info.Size() > MAX_INLINE_SIZE {
+		return lineEndingChange{path: path, skipped: "file too large"}, nil
+	}
+
+	content, err := readFileFull(fs.fsys, path)
+	if err != nil {
+		return lineEndingChange{}, err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return lineEndingChange{path: path, skipped: "binary file"}, nil
+	}
+
+	converted := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if target == "crlf" {
+		converted = bytes.ReplaceAll(converted, []byte("\n"), []byte("\r\n"))
+	}
+	if bytes.Equal(converted, content) {
+		return lineEndingChange{path: path}, nil
+	}
+
+	if !dryRun {
+		if result := fs.checkQuota(path, int64(len(converted)), true); result != nil {
+			return lineEndingChange{}, fmt.Errorf("%s", strings.TrimPrefix(result.Content[0].(mcp.TextContent).Text, "Error: "))
+		}
+		defer fs.invalidateCache(path)
+		if err := writeFileAtomic(fs.fsys, path, converted, info.Mode().Perm()); err != nil {
+			return lineEndingChange{}, err
+		}
+	}
+	return lineEndingChange{path: path, changed: true}, nil
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	mixed := filepath.Join(dir, "mixed.txt")
+	clean := filepath.Join(dir, "clean.txt")
+	binary := filepath.Join(dir, "data.txt")
+	other := filepath.Join(dir, "notes.md")
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	reset := func() {
+		require.NoError(t, os.WriteFile(mixed, []byte("one\r\ntwo\nthree\r\n"), 0640))
+		require.NoError(t, os.WriteFile(clean, []byte("one\ntwo\n"), 0644))
+		require.NoError(t, os.WriteFile(binary, []byte("a\r\n\x00b"), 0644))
+		require.NoError(t, os.WriteFile(other, []byte("x\r\n"), 0644))
+	}
+
+	normalize := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "normalize_line_endings"
+		request.Params.Arguments = args
+		result, err := handler.HandleNormalizeLineEndings(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("directory to lf", func(t *testing.T) {
+		reset()
+		text := normalize(map[string]any{"path": dir, "pattern": "*.txt", "target": "lf"})
+		assert.Contains(t, text, "Converted 1 of 3 file(s) to LF")
+		assert.Contains(t, text, "binary file")
+
+		data, err := os.ReadFile(mixed)
+		require.NoError(t, err)
+		assert.Equal(t, "one\ntwo\nthree\n", string(data))
+
+		info, err := os.Stat(mixed)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+		// Files not matching the pattern are left alone
+		data, err = os.ReadFile(other)
+		require.NoError(t, err)
+		assert.Equal(t, "x\r\n", string(data))
+	})
+
+	t.Run("single file to crlf", func(t *testing.T) {
+		reset()
+		normalize(map[string]any{"path": mixed, "target": "crlf"})
+		data, err := os.ReadFile(mixed)
+		require.NoError(t, err)
+		assert.Equal(t, "one\r\ntwo\r\nthree\r\n", string(data))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		reset()
+		text := normalize(map[string]any{"path": dir, "target": "crlf", "dry_run": true})
+		assert.Contains(t, text, "Dry run: 2 of 4 file(s) would be converted to CRLF")
+		data, err := os.ReadFile(clean)
+		require.NoError(t, err)
+		assert.Equal(t, "one\ntwo\n", string(data))
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package handler
+
+import "os"
+
+// fileOwnership is not supported on this platform; files have no Unix owner
+// and group
+func fileOwnership(info os.FileInfo) (owner, group string, ok bool) {
+	return "", "", false
+}
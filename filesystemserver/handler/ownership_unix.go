@@ -0,0 +1,33 @@
+//go:build unix
+
+package handler
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// fileOwnership returns the owner and group of a file as "name (id)", or
+// just the id when it does not resolve to a name. ok is false when the
+// FileInfo does not come from the operating system.
+func fileOwnership(info os.FileInfo) (owner, group string, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", false
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	owner = uid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username + " (" + uid + ")"
+	}
+
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	group = gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		group = g.Name + " (" + gid + ")"
+	}
+	return owner, group, true
+}
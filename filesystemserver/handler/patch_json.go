@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandlePatchJSON applies RFC 6902 JSON Patch operations to a JSON file and
+// writes it back atomically, keeping its key order, indentation and line
+// endings. Nothing is written unless every operation succeeds.
+func (fs *FilesystemHandler) HandlePatchJSON(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	operations, err := parseJSONPatchOperations(request.GetArguments()["operations"])
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	dryRun := request.GetBool("dry_run", false)
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("cannot patch a directory: %s", path)
+	} else if err == nil && info.Size() > MAX_INLINE_SIZE {
+		err = fmt.Errorf("file is too large to patch (%d bytes, maximum is %d bytes)", info.Size(), MAX_INLINE_SIZE)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	content, err := readFileFull(fs.fsys, validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	doc, err := decodeOrderedJSON(content)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid JSON in %s: %v", path, err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	doc, err = applyJSONPatch(doc, operations)
+	if err == nil {
+		content, err = encodeOrderedJSON(doc, detectJSONStyle(content))
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v; %s was not changed", err, path),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if dryRun {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Dry run: %d operation(s) apply cleanly to %s. The result would be:\n\n%s", len(operations), fs.outputText(ctx, path), content),
+				},
+			},
+		}, nil
+	}
+
+	if result := fs.checkQuota(validPath, int64(len(content)), true); result != nil {
+		return result, nil
+	}
+
+	defer fs.invalidateCache(validPath)
+	if err := writeFileAtomic(fs.fsys, validPath, content, info.Mode().Perm()); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error writing file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Applied %d operation(s) to %s (%d bytes)", len(operations), fs.outputText(ctx, path), len(content)),
+			},
+		},
+	}
+	if written, err := fs.writtenFile(validPath); err == nil {
+		withWrittenFiles(result, written)
+	}
+	return result, nil
+}
+
+// parseJSONPatchOperations reads the operations argument of patch_json, a
+// list of objects with op, path and, depending on op, value or from
+func parseJSONPatchOperations(argument any) ([]jsonPatchOperation, error) {
+	list, ok := argument.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("operations must be a non-empty list of JSON Patch operations")
+	}
+	operations := make([]jsonPatchOperation, len(list))
+	for i, item := range list {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("operation %d is not an object", i+1)
+		}
+		op, _ := fields["op"].(string)
+		path, pathOK := fields["path"].(string)
+		if op == "" || !pathOK {
+			return nil, fmt.Errorf("operation %d needs string op and path members", i+1)
+		}
+		from, _ := fields["from"].(string)
+		if _, hasFrom := fields["from"]; (op == "move" || op == "copy") && !hasFrom {
+			return nil, fmt.Errorf("operation %d (%s) needs a from member", i+1, op)
+		}
+		value, hasValue := fields["value"]
+		operations[i] = jsonPatchOperation{
+			Op:       strings.ToLower(op),
+			Path:     path,
+			From:     from,
+			Value:    value,
+			hasValue: hasValue,
+		}
+	}
+	return operations, nil
+}
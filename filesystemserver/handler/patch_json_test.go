@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePatchJSON(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	original := "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 80,\n        \"host\": \"<local>\"\n    },\n    \"tags\": [\"a\", \"b\"]\n}\n"
+	path := filepath.Join(dir, "config.json")
+
+	patch := func(operations []any, dryRun bool) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "operations": operations, "dry_run": dryRun}
+		result, err := handler.HandlePatchJSON(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("keeps order and formatting", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+		text, isError := patch([]any{
+			map[string]any{"op": "test", "path": "/name", "value": "app"},
+			map[string]any{"op": "replace", "path": "/server/port", "value": 8080},
+			map[string]any{"op": "add", "path": "/tags/-", "value": "c"},
+			map[string]any{"op": "remove", "path": "/tags/0"},
+			map[string]any{"op": "add", "path": "/debug", "value": false},
+		}, false)
+		require.False(t, isError, text)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 8080,\n        \"host\": \"<local>\"\n    },\n    \"tags\": [\n        \"b\",\n        \"c\"\n    ],\n    \"debug\": false\n}\n", string(content))
+	})
+
+	t.Run("failed operations change nothing", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+		text, isError := patch([]any{
+			map[string]any{"op": "replace", "path": "/name", "value": "other"},
+			map[string]any{"op": "replace", "path": "/missing/key", "value": 1},
+		}, false)
+		require.True(t, isError)
+		assert.Contains(t, text, "operation 2")
+		assert.Contains(t, text, "/missing does not exist")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("move and copy", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"a":{"b":1},"c":2}`), 0644))
+		text, isError := patch([]any{
+			map[string]any{"op": "copy", "from": "/a/b", "path": "/d"},
+			map[string]any{"op": "move", "from": "/c", "path": "/a/c"},
+		}, false)
+		require.False(t, isError, text)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":{"b":1,"c":2},"d":1}`, string(content))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+		text, isError := patch([]any{map[string]any{"op": "remove", "path": "/server"}}, true)
+		require.False(t, isError, text)
+		assert.NotContains(t, text, "port")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`{"a": }`), 0644))
+		text, isError := patch([]any{map[string]any{"op": "remove", "path": "/a"}}, false)
+		require.True(t, isError)
+		assert.Contains(t, text, "invalid JSON")
+	})
+}
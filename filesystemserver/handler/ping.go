@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandlePing is a liveness probe reporting the server uptime and whether
+// every allowed directory can currently be accessed, so that a monitor can
+// notice a network filesystem that has gone away
+func (fs *FilesystemHandler) HandlePing(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	now := time.Now()
+
+	// Check the roots concurrently so that one hung mount does not delay
+	// the report on the others
+	statuses := make([]chan string, len(fs.allowedDirs))
+	for i, dir := range fs.allowedDirs {
+		statuses[i] = make(chan string, 1)
+		go func(dir string, status chan<- string) {
+			info, err := fs.fsys.Stat(dir)
+			switch {
+			case err != nil:
+				status <- fmt.Sprintf("unreachable (%v)", err)
+			case !info.IsDir():
+				status <- "unreachable (not a directory)"
+			default:
+				status <- "ok"
+			}
+		}(dir, statuses[i])
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, PING_STAT_TIMEOUT)
+	defer cancel()
+
+	var roots strings.Builder
+	unreachable := 0
+	for i, dir := range fs.allowedDirs {
+		var status string
+		select {
+		case status = <-statuses[i]:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			status = fmt.Sprintf("unreachable (no response within %v)", PING_STAT_TIMEOUT)
+		}
+		if status != "ok" {
+			unreachable++
+		}
+		dir = strings.TrimSuffix(dir, string(filepath.Separator))
+		roots.WriteString(fmt.Sprintf("  %s: %s\n", fs.displayPath(dir), status))
+	}
+
+	var result strings.Builder
+	if unreachable == 0 {
+		result.WriteString("Status: ok\n")
+	} else {
+		result.WriteString(fmt.Sprintf("Status: degraded (%d of %d allowed directories unreachable)\n", unreachable, len(fs.allowedDirs)))
+	}
+	result.WriteString(fmt.Sprintf("Time: %s\n", now.Format(time.RFC3339)))
+	result.WriteString(fmt.Sprintf("Uptime: %s\n", now.Sub(fs.startedAt).Truncate(time.Second)))
+	result.WriteString("Allowed directories:\n")
+	result.WriteString(roots.String())
+	result.WriteString(fs.healthReport())
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlePing(t *testing.T) {
+	base := resolveAllowedDirs(t, t.TempDir())[0]
+	stable := filepath.Join(base, "stable")
+	mount := filepath.Join(base, "mount")
+	require.NoError(t, os.Mkdir(stable, 0755))
+	require.NoError(t, os.Mkdir(mount, 0755))
+
+	handler, err := NewFilesystemHandler([]string{stable, mount})
+	require.NoError(t, err)
+	ping := func() string {
+		result, err := handler.HandlePing(context.Background(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	text := ping()
+	assert.Contains(t, text, "Status: ok\n")
+	assert.Contains(t, text, "Uptime: ")
+	assert.Contains(t, text, stable+": ok\n")
+	assert.Contains(t, text, mount+": ok\n")
+
+	// The directory disappears while the server is running
+	require.NoError(t, os.Remove(mount))
+	text = ping()
+	assert.Contains(t, text, "Status: degraded (1 of 2 allowed directories unreachable)")
+	assert.Contains(t, text, stable+": ok\n")
+	assert.Contains(t, text, mount+": unreachable (")
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithRootQuotas caps the total size of the files below allowed directories.
+// Each key is an allowed directory or the @name of a root alias, and each
+// value the most bytes its tree may hold, including any allowed directories
+// nested in it. Writes, edits, copies, syncs and moves from another root,
+// the trash included, that would take a tree over its quota fail with a
+// quota_exceeded error. This is a guardrail against clients filling the
+// disk, not an exact accounting: files changed outside the server are only
+// seen once the cached usage is invalidated.
+func WithRootQuotas(quotas map[string]int64) Option {
+	return func(fs *FilesystemHandler) {
+		fs.quotaConfig = quotas
+	}
+}
+
+// rootQuotas holds the quotas of the allowed directories together with the
+// cached size of their trees
+type rootQuotas struct {
+	mu     sync.Mutex
+	limits map[string]int64 // quota per root, without trailing separator
+	usage  map[string]int64 // cached tree size per root
+}
+
+// resolveQuotas maps the configured quotas onto the normalized allowed
+// directories
+func (fs *FilesystemHandler) resolveQuotas(configured []string) error {
+	if len(fs.quotaConfig) == 0 {
+		return nil
+	}
+	quotas := &rootQuotas{limits: map[string]int64{}, usage: map[string]int64{}}
+	for key, limit := range fs.quotaConfig {
+		if limit <= 0 {
+			return fmt.Errorf("quota for %s must be positive, got %d", key, limit)
+		}
+		var root string
+		if name, ok := strings.CutPrefix(key, "@"); ok {
+			if root, ok = fs.aliases[name]; !ok {
+				return fmt.Errorf("quota for unknown alias %s", key)
+			}
+		} else {
+			var err error
+			if root, err = fs.allowedDirFor(configured, key); err != nil {
+				return fmt.Errorf("quota: %w", err)
+			}
+			if root == "" {
+				fs.logger.Warn("Skipping quota of an inaccessible allowed directory", "path", key)
+				continue
+			}
+		}
+		quotas.limits[strings.TrimSuffix(root, string(filepath.Separator))] = limit
+	}
+	fs.quotas = quotas
+	return nil
+}
+
+// invalidate drops the cached usage of the roots that contain path
+func (q *rootQuotas) invalidate(path string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for root := range q.usage {
+		if path == root || isWithin(root, path) {
+			delete(q.usage, root)
+		}
+	}
+}
+
+// rootUsage returns the total size of the regular files below root, from the
+// cache when possible. A walk cut short by the walk limits yields the size
+// of the part that was visited.
+func (fs *FilesystemHandler) rootUsage(root string) (int64, error) {
+	fs.quotas.mu.Lock()
+	usage, ok := fs.quotas.usage[root]
+	fs.quotas.mu.Unlock()
+	if ok {
+		return usage, nil
+	}
+
+	usage = 0
+	_, err := fs.walkTree(context.Background(), root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Unreadable entries do not count
+		}
+		if info.Mode().IsRegular() {
+			usage += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	fs.quotas.mu.Lock()
+	fs.quotas.usage[root] = usage
+	fs.quotas.mu.Unlock()
+	return usage, nil
+}
+
+// checkQuota returns a quota_exceeded result if writing incoming bytes to
+// path would take a root that holds it over its quota, and nil otherwise.
+// Every root with a quota is checked, so a nested allowed directory does not
+// escape the quota of the one around it. With replace, the current size of
+// path is subtracted, as for a file that is overwritten.
+func (fs *FilesystemHandler) checkQuota(path string, incoming int64, replace bool) *mcp.CallToolResult {
+	return fs.checkQuotaFrom("", path, incoming, replace)
+}
+
+// checkQuotaFrom is checkQuota for bytes moved from source, which leave the
+// size of the roots that hold both source and path unchanged
+func (fs *FilesystemHandler) checkQuotaFrom(source, path string, incoming int64, replace bool) *mcp.CallToolResult {
+	for _, root := range fs.quotaRoots(path, source) {
+		if result := fs.checkRootQuota(root, path, incoming, replace); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// checkMoveQuota checks the quotas for moving source to dest, measuring
+// source only if a root that holds dest but not source has a quota. With
+// replace, the file at dest is subtracted as for checkQuota.
+func (fs *FilesystemHandler) checkMoveQuota(source, dest string, replace bool) *mcp.CallToolResult {
+	if len(fs.quotaRoots(dest, source)) == 0 {
+		return nil
+	}
+	size, err := fs.treeSize(source)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error measuring %s: %v", fs.displayPath(source), err),
+				},
+			},
+			IsError: true,
+		}
+	}
+	return fs.checkQuotaFrom(source, dest, size, replace)
+}
+
+// quotaRoots returns the roots with a quota whose tree holds path but not
+// source, innermost first
+func (fs *FilesystemHandler) quotaRoots(path, source string) []string {
+	if fs.quotas == nil {
+		return nil
+	}
+	holds := func(root, path string) bool {
+		return path == root || isWithin(root, path)
+	}
+	var roots []string
+	for root := range fs.quotas.limits {
+		if holds(root, path) && (source == "" || !holds(root, source)) {
+			roots = append(roots, root)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+	return roots
+}
+
+// checkRootQuota checks the quota of one root for checkQuota
+func (fs *FilesystemHandler) checkRootQuota(root, path string, incoming int64, replace bool) *mcp.CallToolResult {
+	limit := fs.quotas.limits[root]
+	usage, err := fs.rootUsage(root)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error measuring quota usage: %v", err),
+				},
+			},
+			IsError: true,
+		}
+	}
+	after := usage + incoming
+	if replace {
+		if info, err := fs.fsys.Lstat(path); err == nil && info.Mode().IsRegular() {
+			after -= info.Size()
+		}
+	}
+	if after <= limit {
+		return nil
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf(
+					"Error: quota_exceeded - writing %d bytes to %s would bring %s to %d bytes, over its quota of %d bytes (%d in use)",
+					incoming, fs.displayPath(path), fs.displayPath(root), after, limit, usage,
+				),
+			},
+		},
+		IsError: true,
+	}
+}
+
+// treeSize returns the total size of the regular files at path, which may be
+// a file or a directory
+func (fs *FilesystemHandler) treeSize(path string) (int64, error) {
+	var size int64
+	_, err := fs.walkTree(context.Background(), path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootQuotas(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	other := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.txt"), []byte(strings.Repeat("x", 60)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "big.txt"), []byte(strings.Repeat("y", 50)), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir, other}, WithRootQuotas(map[string]int64{dir: 100}))
+	require.NoError(t, err)
+
+	write := func(path, content string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "content": content}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("writes within the quota", func(t *testing.T) {
+		result := write(filepath.Join(dir, "small.txt"), strings.Repeat("a", 30))
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("writes over the quota are refused", func(t *testing.T) {
+		path := filepath.Join(dir, "large.txt")
+		result := write(path, strings.Repeat("b", 20))
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "quota_exceeded")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("overwrites count the difference", func(t *testing.T) {
+		result := write(filepath.Join(dir, "existing.txt"), strings.Repeat("c", 65))
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("copies are checked", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"source":      filepath.Join(other, "big.txt"),
+			"destination": filepath.Join(dir, "big.txt"),
+		}
+		result, err := handler.HandleCopyFile(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "quota_exceeded")
+	})
+
+	t.Run("other roots are unlimited", func(t *testing.T) {
+		result := write(filepath.Join(other, "more.txt"), strings.Repeat("d", 200))
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("must name an allowed directory", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{dir}, WithRootQuotas(map[string]int64{other: 100}))
+		require.Error(t, err)
+	})
+}
+
+func TestRootQuotas_EditsMovesAndSyncs(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	other := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(strings.Repeat("a\n", 10)+strings.Repeat("x", 75)), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(other, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "src", "big.txt"), []byte(strings.Repeat("y", 50)), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir, other}, WithRootQuotas(map[string]int64{dir: 100}))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("modify_file", func(t *testing.T) {
+		text := call(handler.HandleModifyFile, map[string]any{"path": filepath.Join(dir, "notes.txt"), "find": "x", "replace": "zz"})
+		assert.Contains(t, text, "quota_exceeded")
+		text = call(handler.HandleModifyFile, map[string]any{"path": filepath.Join(dir, "notes.txt"), "find": "x", "replace": "zz", "all_occurrences": false})
+		assert.NotContains(t, text, "quota_exceeded")
+	})
+
+	t.Run("normalize_line_endings", func(t *testing.T) {
+		text := call(handler.HandleNormalizeLineEndings, map[string]any{"path": filepath.Join(dir, "notes.txt"), "target": "crlf"})
+		assert.Contains(t, text, "quota_exceeded")
+		assert.Contains(t, text, "Converted 0 of 1")
+	})
+
+	t.Run("move_file to another root", func(t *testing.T) {
+		text := call(handler.HandleMoveFile, map[string]any{"source": filepath.Join(other, "src", "big.txt"), "destination": filepath.Join(dir, "big.txt")})
+		assert.Contains(t, text, "quota_exceeded")
+		assert.FileExists(t, filepath.Join(other, "src", "big.txt"))
+	})
+
+	t.Run("sync_directories", func(t *testing.T) {
+		text := call(handler.HandleSyncDirectories, map[string]any{"source": filepath.Join(other, "src"), "destination": filepath.Join(dir, "copy")})
+		assert.Contains(t, text, "quota_exceeded")
+		assert.NoDirExists(t, filepath.Join(dir, "copy"))
+	})
+
+	t.Run("moves out of a root are not limited", func(t *testing.T) {
+		text := call(handler.HandleMoveFile, map[string]any{"source": filepath.Join(dir, "notes.txt"), "destination": filepath.Join(other, "notes.txt")})
+		assert.NotContains(t, text, "Error")
+	})
+}
+
+func TestRootQuotas_NestedRootsAndTrash(t *testing.T) {
+	proj := resolveAllowedDirs(t, t.TempDir())[0]
+	vendor := filepath.Join(proj, "vendor")
+	require.NoError(t, os.Mkdir(vendor, 0755))
+	other := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.WriteFile(filepath.Join(proj, "existing.txt"), []byte(strings.Repeat("x", 60)), 0644))
+
+	handler, err := NewFilesystemHandler([]string{proj, vendor, other},
+		WithRootQuotas(map[string]int64{proj: 100}), WithTrashDir(filepath.Join(other, ".trash")))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("a nested root counts against the root around it", func(t *testing.T) {
+		path := filepath.Join(vendor, "lib.txt")
+		result := call(handler.HandleWriteFile, map[string]any{"path": path, "content": strings.Repeat("v", 50)})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "quota_exceeded")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("restoring from the trash in another root is checked", func(t *testing.T) {
+		path := filepath.Join(proj, "a.txt")
+		result := call(handler.HandleWriteFile, map[string]any{"path": path, "content": strings.Repeat("a", 30)})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		result = call(handler.HandleMoveToTrash, map[string]any{"path": path})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		_, trashed, ok := strings.Cut(strings.SplitN(result.Content[0].(mcp.TextContent).Text, "\n", 2)[0], "the trash: ")
+		require.True(t, ok)
+
+		// The space the trashed file freed is taken again
+		result = call(handler.HandleWriteFile, map[string]any{"path": filepath.Join(proj, "b.txt"), "content": strings.Repeat("b", 35)})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		result = call(handler.HandleRestoreFromTrash, map[string]any{"path": trashed})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "quota_exceeded")
+		assert.FileExists(t, trashed)
+		assert.NoFileExists(t, path)
+	})
+}
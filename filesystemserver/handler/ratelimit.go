@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithWriteRateLimit throttles the mutating tools to at most writesPerMinute
+// calls and bytesPerMinute bytes of written content per minute. Either limit
+// may be 0 to leave it unbounded. This is a guardrail against runaway
+// clients, not a security boundary.
+func WithWriteRateLimit(writesPerMinute, bytesPerMinute int64) Option {
+	return func(fs *FilesystemHandler) {
+		if writesPerMinute > 0 || bytesPerMinute > 0 {
+			fs.limiter = newWriteLimiter(writesPerMinute, bytesPerMinute)
+		}
+	}
+}
+
+// tokenBucket holds up to capacity tokens, refilled continuously at rate
+// tokens per second
+type tokenBucket struct {
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int64, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     now,
+	}
+}
+
+// refill adds the tokens accumulated since the last call
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// wait returns how long until n tokens are available
+func (b *tokenBucket) wait(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// writeLimiter enforces the write operation and byte limits
+type writeLimiter struct {
+	mu              sync.Mutex
+	writesPerMinute int64
+	bytesPerMinute  int64
+	ops             *tokenBucket // nil when unbounded
+	bytes           *tokenBucket // nil when unbounded
+	now             func() time.Time
+}
+
+func newWriteLimiter(writesPerMinute, bytesPerMinute int64) *writeLimiter {
+	l := &writeLimiter{
+		writesPerMinute: writesPerMinute,
+		bytesPerMinute:  bytesPerMinute,
+		now:             time.Now,
+	}
+	now := l.now()
+	if writesPerMinute > 0 {
+		l.ops = newTokenBucket(writesPerMinute, now)
+	}
+	if bytesPerMinute > 0 {
+		l.bytes = newTokenBucket(bytesPerMinute, now)
+	}
+	return l
+}
+
+// allow takes one operation and size bytes from the buckets. If either
+// bucket is short nothing is taken, and the returned error says which limit
+// was hit and how long to wait.
+func (l *writeLimiter) allow(size int64) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.bytes != nil && size > l.bytesPerMinute {
+		return 0, fmt.Errorf("write of %d bytes exceeds the limit of %d bytes per minute", size, l.bytesPerMinute)
+	}
+	if l.ops != nil {
+		l.ops.refill(now)
+		if wait := l.ops.wait(1); wait > 0 {
+			return wait, fmt.Errorf("at most %d write operations per minute are allowed", l.writesPerMinute)
+		}
+	}
+	if l.bytes != nil {
+		l.bytes.refill(now)
+		if wait := l.bytes.wait(float64(size)); wait > 0 {
+			return wait, fmt.Errorf("at most %d bytes may be written per minute", l.bytesPerMinute)
+		}
+	}
+
+	if l.ops != nil {
+		l.ops.tokens--
+	}
+	if l.bytes != nil {
+		l.bytes.tokens -= float64(size)
+	}
+	return 0, nil
+}
+
+// dryRunTools are the mutating tools that implement dry_run. Other tools
+// ignore a dry_run argument and make their changes all the same.
+var dryRunTools = map[string]bool{
+	"move_file":                 true,
+	"rename_files":              true,
+	"sync_directories":          true,
+	"patch_json":                true,
+	"normalize_line_endings":    true,
+	"set_permissions_recursive": true,
+}
+
+// isDryRun reports whether a call only reports the changes it would make
+func isDryRun(request mcp.CallToolRequest) bool {
+	return dryRunTools[request.Params.Name] && request.GetBool("dry_run", false)
+}
+
+// LimitWrites wraps the handler of a mutating tool so that it is subject to
+// the write rate limit. The bytes charged are the size of the request's
+// content, see contentSize. Dry runs of the tools that implement them, and
+// calls answered with a confirmation_token instead of running, are not
+// limited.
+func (fs *FilesystemHandler) LimitWrites(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if fs.limiter == nil || isDryRun(request) || fs.awaitsConfirmation(request) {
+			return next(ctx, request)
+		}
+
+		size, _ := contentSize(request.GetArguments())
+		if retryAfter, err := fs.limiter.allow(size); err != nil {
+			text := fmt.Sprintf("Error: rate_limited - %v", err)
+			if retryAfter > 0 {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				text += fmt.Sprintf("; retry after %ds", seconds)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: text,
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// contentSize returns the number of content bytes a mutating request writes:
+// its content argument or, for write_multiple_files, the content of every
+// listed file. It reports false when the request carries no content.
+func contentSize(args map[string]any) (int64, bool) {
+	if content, ok := args["content"].(string); ok {
+		return int64(len(content)), true
+	}
+	files, ok := args["files"].([]any)
+	if !ok {
+		return 0, false
+	}
+	var size int64
+	for _, file := range files {
+		if fields, ok := file.(map[string]any); ok {
+			content, _ := fields["content"].(string)
+			size += int64(len(content))
+		}
+	}
+	return size, true
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newWriteLimiter(2, 100)
+	limiter.now = func() time.Time { return now }
+	limiter.ops.last, limiter.bytes.last = now, now
+
+	_, err := limiter.allow(10)
+	require.NoError(t, err)
+	_, err = limiter.allow(10)
+	require.NoError(t, err)
+
+	// The operation bucket is empty and refills at one token every 30s
+	wait, err := limiter.allow(10)
+	require.Error(t, err)
+	assert.Equal(t, 30*time.Second, wait)
+
+	now = now.Add(30 * time.Second)
+	_, err = limiter.allow(10)
+	require.NoError(t, err)
+
+	// Larger than the byte budget can ever hold
+	_, err = limiter.allow(101)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the limit")
+}
+
+func TestLimitWrites(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithWriteRateLimit(1, 0))
+	require.NoError(t, err)
+
+	write := handler.LimitWrites(handler.HandleWriteFile)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := write(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	path := filepath.Join(dir, "a.txt")
+	result := call(map[string]any{"path": path, "content": "one"})
+	require.False(t, result.IsError)
+
+	result = call(map[string]any{"path": path, "content": "two"})
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Error: rate_limited")
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "retry after 60s")
+
+	// Reads are not throttled
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"path": path}
+	read, err := handler.HandleReadFile(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "one", read.Content[0].(mcp.TextContent).Text)
+}
+
+func TestLimitWrites_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithWriteRateLimit(1, 0))
+	require.NoError(t, err)
+
+	call := func(name string, handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handler.LimitWrites(handle)(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	src := filepath.Join(dir, "a.txt")
+	result := call("write_file", handler.HandleWriteFile, map[string]any{"path": src, "content": "one"})
+	require.False(t, result.IsError)
+
+	// move_file implements dry_run, so its dry runs are not limited
+	for range 3 {
+		result = call("move_file", handler.HandleMoveFile, map[string]any{"source": src, "destination": filepath.Join(dir, "b.txt"), "dry_run": true})
+		require.False(t, result.IsError, "%v", result.Content)
+	}
+
+	// write_file ignores dry_run and writes all the same
+	result = call("write_file", handler.HandleWriteFile, map[string]any{"path": src, "content": "two", "dry_run": true})
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Error: rate_limited")
+}
+
+func TestLimitWrites_ConfirmationRequests(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithWriteRateLimit(1, 0), WithConfirmation(time.Minute))
+	require.NoError(t, err)
+	path := filepath.Join(resolveAllowedDirs(t, dir)[0], "doomed.txt")
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+
+	deleteFile := handler.LimitWrites(handler.RequireConfirmation(handler.HandleDeleteFile))
+	call := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "delete_file"
+		request.Params.Arguments = args
+		result, err := deleteFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	// Asking for a token changes nothing, so only the confirmed call counts
+	var token any
+	for range 3 {
+		result := call(map[string]any{"path": path})
+		require.False(t, result.IsError, "%v", result.Content)
+		token = result.Meta["confirmation_token"]
+	}
+	result := call(map[string]any{"path": path, "confirmation_token": token})
+	require.False(t, result.IsError, "%v", result.Content)
+	assert.NoFileExists(t, path)
+}
@@ -3,8 +3,13 @@ package handler
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -18,6 +23,46 @@ func (fs *FilesystemHandler) HandleReadFile(
 		return nil, err
 	}
 
+	// Extract encoding parameter (optional, default: text)
+	encoding := "text"
+	if encodingParam, err := request.RequireString("encoding"); err == nil && encodingParam != "" {
+		encoding = encodingParam
+	}
+	detect := request.GetBool("detect_language", true)
+	stripBOM := request.GetBool("strip_bom", false)
+	expandTabs := request.GetBool("expand_tabs", false)
+	tabWidth := request.GetInt("tab_width", 4)
+	trimTrailing := request.GetBool("trim_trailing_whitespace", false)
+	allowSpecial := request.GetBool("allow_special", false)
+	truncateToLimit := request.GetBool("truncate_to_limit", false)
+	asLines := request.GetBool("as_lines", false)
+	if encoding != "text" && encoding != "base64" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported encoding '%s' (expected 'text' or 'base64')", encoding),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if expandTabs && (tabWidth < 1 || tabWidth > 16) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: tab_width must be between 1 and 16",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if !expandTabs {
+		tabWidth = 0
+	}
+
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
 		// Get current working directory
@@ -49,14 +94,26 @@ func (fs *FilesystemHandler) HandleReadFile(
 		}, nil
 	}
 
+	if err := fs.refuseSpecialFile(validPath); err != nil && !allowSpecial {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Check if it's a directory
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error: %v", err),
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
@@ -65,7 +122,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 
 	if info.IsDir() {
 		// For directories, return a resource reference instead
-		resourceURI := pathToResourceURI(validPath)
+		resourceURI := fs.resourceURI(validPath)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -77,20 +134,32 @@ func (fs *FilesystemHandler) HandleReadFile(
 					Resource: mcp.TextResourceContents{
 						URI:      resourceURI,
 						MIMEType: "text/plain",
-						Text:     fmt.Sprintf("Directory: %s", validPath),
+						Text:     fmt.Sprintf("Directory: %s", fs.outputPath(ctx, validPath)),
 					},
 				},
 			},
 		}, nil
 	}
 
+	// Reading a special file was explicitly allowed; it has no meaningful
+	// size and may never end, so read a bounded prefix of it
+	if kind := specialFileKind(info.Mode()); kind != "" {
+		return fs.readSpecialFile(validPath, kind)
+	}
+
 	// Determine MIME type
-	mimeType := detectMimeType(validPath)
+	mimeType := fs.detectMimeType(validPath)
+
+	// Return the raw bytes when binary transport was explicitly requested
+	if encoding == "base64" {
+		return fs.readFileBase64(ctx, validPath, mimeType, info)
+	}
 
-	// Check file size
-	if info.Size() > MAX_INLINE_SIZE {
+	// Check file size; a text file may be cut off at the limit on request
+	truncated := info.Size() > MAX_INLINE_SIZE && truncateToLimit && isTextFile(mimeType)
+	if info.Size() > MAX_INLINE_SIZE && !truncated {
 		// File is too large to inline, return a resource reference
-		resourceURI := pathToResourceURI(validPath)
+		resourceURI := fs.resourceURI(validPath)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -102,7 +171,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 					Resource: mcp.TextResourceContents{
 						URI:      resourceURI,
 						MIMEType: "text/plain",
-						Text:     fmt.Sprintf("Large file: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
+						Text:     fmt.Sprintf("Large file: %s (%s, %d bytes)", fs.outputPath(ctx, validPath), mimeType, info.Size()),
 					},
 				},
 			},
@@ -110,7 +179,12 @@ func (fs *FilesystemHandler) HandleReadFile(
 	}
 
 	// Read file content
-	content, err := os.ReadFile(validPath)
+	var content []byte
+	if truncated {
+		content, err = fs.readFilePrefix(validPath, MAX_INLINE_SIZE)
+	} else {
+		content, err = fs.readFileCached(validPath, info)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -125,15 +199,84 @@ func (fs *FilesystemHandler) HandleReadFile(
 
 	// Check if it's a text file
 	if isTextFile(mimeType) {
-		// It's a text file, return as text
-		return &mcp.CallToolResult{
+		// UTF-16 is always returned as UTF-8, while a UTF-8 byte order mark
+		// is only removed on request
+		bom := detectBOM(content)
+		bytesReturned := len(content)
+		if truncated {
+			content = trimPartialCharacter(content, bom)
+			bytesReturned = len(content)
+		}
+		switch {
+		case bom == "utf-16le" || bom == "utf-16be":
+			if content, err = decodeUTF16(content); err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: fmt.Sprintf("Error: %v", err),
+						},
+					},
+					IsError: true,
+				}, nil
+			}
+		case bom == "utf-8" && stripBOM:
+			content = content[len(bomUTF8):]
+		}
+
+		// It's a text file, return as text. Whitespace is only normalized in
+		// the returned copy; the file is left untouched.
+		text := string(content)
+		if tabWidth > 0 || trimTrailing {
+			text = normalizeWhitespace(text, tabWidth, trimTrailing)
+		}
+		if asLines {
+			jsonData, err := json.MarshalIndent(splitFileLines(text), "", "  ")
+			if err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: fmt.Sprintf("Error generating JSON: %v", err),
+						},
+					},
+					IsError: true,
+				}, nil
+			}
+			text = string(jsonData)
+		}
+		result := &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: string(content),
+					Text: text,
 				},
 			},
-		}, nil
+		}
+		// Tag source code with its language, and report a byte order mark,
+		// outside of the content itself
+		meta := map[string]any{}
+		if detect {
+			if language := detectLanguage(validPath, content); language != "" {
+				meta["language"] = language
+			}
+		}
+		if bom != "" {
+			meta["bom"] = bom
+		}
+		if truncated {
+			meta["truncated"] = true
+			meta["total_size"] = info.Size()
+			meta["bytes_returned"] = bytesReturned
+			result.Content = append(result.Content, mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("[Truncated: returned the first %d of %d bytes. Use read_file_chunk with cursor %d to read the rest.]", bytesReturned, info.Size(), bytesReturned),
+			})
+		}
+		if len(meta) > 0 {
+			result.Meta = meta
+		}
+		return result, nil
 	} else if isImageFile(mimeType) {
 		// It's an image file, return as image content
 		if info.Size() <= MAX_BASE64_SIZE {
@@ -141,7 +284,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Image file: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
+						Text: fmt.Sprintf("Image file: %s (%s, %d bytes)", fs.outputPath(ctx, validPath), mimeType, info.Size()),
 					},
 					mcp.ImageContent{
 						Type:     "image",
@@ -152,7 +295,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 			}, nil
 		} else {
 			// Too large for base64, return a reference
-			resourceURI := pathToResourceURI(validPath)
+			resourceURI := fs.resourceURI(validPath)
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -164,7 +307,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 						Resource: mcp.TextResourceContents{
 							URI:      resourceURI,
 							MIMEType: "text/plain",
-							Text:     fmt.Sprintf("Large image: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
+							Text:     fmt.Sprintf("Large image: %s (%s, %d bytes)", fs.outputPath(ctx, validPath), mimeType, info.Size()),
 						},
 					},
 				},
@@ -172,7 +315,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 		}
 	} else {
 		// It's another type of binary file
-		resourceURI := pathToResourceURI(validPath)
+		resourceURI := fs.resourceURI(validPath)
 
 		if info.Size() <= MAX_BASE64_SIZE {
 			// Small enough for base64 encoding
@@ -180,7 +323,7 @@ func (fs *FilesystemHandler) HandleReadFile(
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
+						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes)", fs.outputPath(ctx, validPath), mimeType, info.Size()),
 					},
 					mcp.EmbeddedResource{
 						Type: "resource",
@@ -198,14 +341,14 @@ func (fs *FilesystemHandler) HandleReadFile(
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes). Access it via resource URI: %s", validPath, mimeType, info.Size(), resourceURI),
+						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes). Access it via resource URI: %s", fs.outputPath(ctx, validPath), mimeType, info.Size(), resourceURI),
 					},
 					mcp.EmbeddedResource{
 						Type: "resource",
 						Resource: mcp.TextResourceContents{
 							URI:      resourceURI,
 							MIMEType: "text/plain",
-							Text:     fmt.Sprintf("Binary file: %s (%s, %d bytes)", validPath, mimeType, info.Size()),
+							Text:     fmt.Sprintf("Binary file: %s (%s, %d bytes)", fs.outputPath(ctx, validPath), mimeType, info.Size()),
 						},
 					},
 				},
@@ -213,3 +356,239 @@ func (fs *FilesystemHandler) HandleReadFile(
 		}
 	}
 }
+
+// readFileBase64 returns the raw contents of a file base64-encoded, using an
+// image content block for images and a blob resource for everything else
+func (fs *FilesystemHandler) readFileBase64(ctx context.Context, validPath, mimeType string, info os.FileInfo) (*mcp.CallToolResult, error) {
+	// Apply the size limit to the raw bytes, before encoding
+	if info.Size() > MAX_BASE64_SIZE {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: file is too large for base64 transport (%d bytes, maximum is %d bytes)", info.Size(), MAX_BASE64_SIZE),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	content, err := fs.readFileCached(validPath, info)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	data := base64.StdEncoding.EncodeToString(content)
+	description := mcp.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf("File: %s (%s, %d bytes, base64)", fs.outputPath(ctx, validPath), mimeType, len(content)),
+	}
+
+	if isImageFile(mimeType) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				description,
+				mcp.ImageContent{
+					Type:     "image",
+					Data:     data,
+					MIMEType: mimeType,
+				},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			description,
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.BlobResourceContents{
+					URI:      fs.resourceURI(validPath),
+					MIMEType: mimeType,
+					Blob:     data,
+				},
+			},
+		},
+	}, nil
+}
+
+// readFilePrefix returns at most limit bytes from the start of the named file
+func (fs *FilesystemHandler) readFilePrefix(name string, limit int64) ([]byte, error) {
+	f, err := fs.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	want := info.Size()
+	if limit < want {
+		want = limit
+	}
+	if err == nil && info.Mode().IsRegular() && int64(len(data)) < want {
+		err = shortIOError("read", name, int64(len(data)), want)
+	}
+	return data, err
+}
+
+// trimPartialCharacter drops an incomplete character from the end of a
+// prefix of a text file, so that a cut-off file still decodes cleanly: half
+// a UTF-16 code unit or surrogate pair, or a truncated UTF-8 sequence
+func trimPartialCharacter(content []byte, bom string) []byte {
+	if bom == "utf-16le" || bom == "utf-16be" {
+		content = content[:len(content)&^1]
+		if len(content) >= 2 {
+			last := content[len(content)-2:]
+			unit := uint16(last[0]) | uint16(last[1])<<8
+			if bom == "utf-16be" {
+				unit = uint16(last[0])<<8 | uint16(last[1])
+			}
+			if unit >= 0xD800 && unit <= 0xDBFF {
+				content = content[:len(content)-2]
+			}
+		}
+		return content
+	}
+
+	// A UTF-8 sequence is at most 4 bytes long, so only the last 3 bytes can
+	// start an incomplete one
+	for i := 1; i < utf8.UTFMax && i <= len(content); i++ {
+		if utf8.RuneStart(content[len(content)-i]) {
+			if !utf8.FullRune(content[len(content)-i:]) {
+				content = content[:len(content)-i]
+			}
+			break
+		}
+	}
+	return content
+}
+
+// normalizeWhitespace expands tabs to spaces at tab stops every tabWidth
+// columns, unless tabWidth is 0, and with trimTrailing removes whitespace
+// at the end of each line. Line endings are kept.
+func normalizeWhitespace(text string, tabWidth int, trimTrailing bool) string {
+	lines := strings.SplitAfter(text, "\n")
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, line := range lines {
+		body := strings.TrimSuffix(line, "\n")
+		ending := line[len(body):]
+		if strings.HasSuffix(body, "\r") {
+			body, ending = body[:len(body)-1], "\r"+ending
+		}
+
+		if tabWidth > 0 && strings.Contains(body, "\t") {
+			var expanded strings.Builder
+			column := 0
+			for _, r := range body {
+				if r == '\t' {
+					spaces := tabWidth - column%tabWidth
+					expanded.WriteString(strings.Repeat(" ", spaces))
+					column += spaces
+					continue
+				}
+				expanded.WriteRune(r)
+				column++
+			}
+			body = expanded.String()
+		}
+		if trimTrailing {
+			body = strings.TrimRightFunc(body, unicode.IsSpace)
+		}
+
+		b.WriteString(body)
+		b.WriteString(ending)
+	}
+	return b.String()
+}
+
+// readSpecialFile returns up to MAX_INLINE_SIZE bytes read from a named pipe,
+// device or other special file as text, replacing invalid UTF-8
+func (fs *FilesystemHandler) readSpecialFile(path, kind string) (*mcp.CallToolResult, error) {
+	f, err := fs.fsys.Open(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error opening file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, MAX_INLINE_SIZE))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: strings.ToValidUTF8(string(content), "\uFFFD"),
+			},
+		},
+	}
+	result.Meta = map[string]any{"special_file": kind, "bytes_read": len(content)}
+	return result, nil
+}
+
+// splitFileLines splits text into lines at LF, CRLF and CR line breaks and
+// reports the line ending style. A final line break does not start another
+// line; it sets TrailingNewline instead.
+func splitFileLines(text string) FileLines {
+	result := FileLines{Lines: []string{}}
+	endings := make(map[string]bool)
+	for rest := text; rest != ""; {
+		i := strings.IndexAny(rest, "\r\n")
+		if i < 0 {
+			result.Lines = append(result.Lines, rest)
+			break
+		}
+		ending := rest[i : i+1]
+		if strings.HasPrefix(rest[i:], "\r\n") {
+			ending = "\r\n"
+		}
+		endings[ending] = true
+		result.Lines = append(result.Lines, rest[:i])
+		rest = rest[i+len(ending):]
+		result.TrailingNewline = rest == ""
+	}
+	result.LineCount = len(result.Lines)
+
+	switch {
+	case len(endings) > 1:
+		result.LineEnding = "mixed"
+	case endings["\r\n"]:
+		result.LineEnding = "crlf"
+	case endings["\n"]:
+		result.LineEnding = "lf"
+	case endings["\r"]:
+		result.LineEnding = "cr"
+	default:
+		result.LineEnding = "none"
+	}
+	return result
+}
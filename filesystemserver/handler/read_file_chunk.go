@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleReadFileChunk reads one bounded chunk of a file starting at a byte
+// offset. The response reports the cursor of the next chunk and whether the
+// end of the file was reached, so that clients can page through large files.
+func (fs *FilesystemHandler) HandleReadFileChunk(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := request.GetString("encoding", "text")
+	if encoding != "text" && encoding != "base64" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported encoding '%s' (expected 'text' or 'base64')", encoding),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	chunkSize := int64(DEFAULT_CHUNK_SIZE)
+	if sizeArg, err := request.RequireFloat("chunk_size"); err == nil {
+		if sizeArg < 1 || sizeArg > MAX_CHUNK_SIZE {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: chunk_size must be between 1 and %d bytes", MAX_CHUNK_SIZE),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		chunkSize = int64(sizeArg)
+	}
+
+	var cursor int64
+	if cursorArg, err := request.RequireFloat("cursor"); err == nil {
+		if cursorArg < 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: cursor cannot be negative",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		cursor = int64(cursorArg)
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	f, err := fs.fsys.Open(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error opening file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: path is not a readable file",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if cursor > info.Size() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: cursor %d is beyond the end of the file (%d bytes)", cursor, info.Size()),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	buf := make([]byte, chunkSize)
+	n, err := f.ReadAt(buf, cursor)
+	// ReadAt stops early only at the end of the file, which Stat says is
+	// further on
+	want := info.Size() - cursor
+	if chunkSize < want {
+		want = chunkSize
+	}
+	if err == io.EOF && info.Mode().IsRegular() && int64(n) < want {
+		err = shortIOError("read", path, int64(n), want)
+	}
+	if err != nil && err != io.EOF {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	buf = buf[:n]
+
+	// Text chunks end on a character boundary; the partial character is
+	// returned at the start of the next chunk instead
+	if encoding == "text" && cursor+int64(n) < info.Size() {
+		buf = trimPartialRune(buf)
+	}
+
+	nextCursor := cursor + int64(len(buf))
+	eof := nextCursor >= info.Size()
+	description := mcp.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf(
+			"Chunk of %s: bytes %d-%d of %d\nnext_cursor: %d\neof: %v",
+			fs.outputPath(ctx, validPath),
+			cursor,
+			nextCursor,
+			info.Size(),
+			nextCursor,
+			eof,
+		),
+	}
+
+	if encoding == "base64" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				description,
+				mcp.EmbeddedResource{
+					Type: "resource",
+					Resource: mcp.BlobResourceContents{
+						URI:      fs.resourceURI(validPath),
+						MIMEType: "application/octet-stream",
+						Blob:     base64.StdEncoding.EncodeToString(buf),
+					},
+				},
+			},
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			description,
+			mcp.TextContent{
+				Type: "text",
+				Text: string(buf),
+			},
+		},
+	}, nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of b. Data
+// that is not valid UTF-8 anyway is returned unchanged.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		start := len(b) - i
+		if !utf8.RuneStart(b[start]) {
+			continue
+		}
+		if !utf8.FullRune(b[start:]) {
+			if start == 0 {
+				return b // A single character larger than the chunk
+			}
+			return b[:start]
+		}
+		return b
+	}
+	return b
+}
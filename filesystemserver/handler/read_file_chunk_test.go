@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileChunk(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	content := strings.Repeat("héllo wörld ", 50)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	read := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "read_file_chunk"
+		request.Params.Arguments = args
+		result, err := handler.HandleReadFileChunk(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	nextCursor := regexp.MustCompile(`next_cursor: (\d+)\neof: (true|false)`)
+
+	t.Run("pages through the whole file", func(t *testing.T) {
+		var got strings.Builder
+		cursor, calls := 0, 0
+		for {
+			result := read(map[string]any{"path": path, "cursor": float64(cursor), "chunk_size": float64(7)})
+			require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+			chunk := result.Content[1].(mcp.TextContent).Text
+			assert.LessOrEqual(t, len(chunk), 7)
+			got.WriteString(chunk)
+
+			match := nextCursor.FindStringSubmatch(result.Content[0].(mcp.TextContent).Text)
+			require.NotNil(t, match)
+			cursor, err = strconv.Atoi(match[1])
+			require.NoError(t, err)
+			calls++
+			if match[2] == "true" {
+				break
+			}
+			require.Less(t, calls, len(content), "cursor did not advance")
+		}
+		assert.Equal(t, content, got.String())
+		assert.Equal(t, len(content), cursor)
+	})
+
+	t.Run("cursor beyond the end", func(t *testing.T) {
+		result := read(map[string]any{"path": path, "cursor": float64(len(content) + 1)})
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("oversized chunk", func(t *testing.T) {
+		result := read(map[string]any{"path": path, "chunk_size": float64(MAX_CHUNK_SIZE + 1)})
+		assert.True(t, result.IsError)
+	})
+}
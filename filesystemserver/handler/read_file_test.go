@@ -2,6 +2,8 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -74,3 +76,247 @@ func TestReadfile_NoAccess(t *testing.T) {
 	assert.True(t, result.IsError)
 	assert.Contains(t, fmt.Sprint(result.Content[0]), "access denied - path outside allowed directories")
 }
+
+func TestReadfile_Base64(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte{0x00, 0x01, 0x02, 0xff, 0xfe}
+	err := os.WriteFile(filepath.Join(dir, "data.bin"), content, 0644)
+	require.NoError(t, err)
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "read_file"
+	request.Params.Arguments = map[string]any{
+		"path":     filepath.Join(dir, "data.bin"),
+		"encoding": "base64",
+	}
+
+	result, err := handler.HandleReadFile(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	resource := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.BlobResourceContents)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(content), resource.Blob)
+	assert.Equal(t, "application/octet-stream", resource.MIMEType)
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		request.Params.Arguments = map[string]any{
+			"path":     filepath.Join(dir, "data.bin"),
+			"encoding": "hex",
+		}
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestReadfile_Images(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pixel.png"), png, 0644))
+	bmp := append([]byte("BM"), make([]byte, 64)...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pixel.bmp"), bmp, 0644))
+	large := append(append([]byte{}, png...), make([]byte, MAX_BASE64_SIZE)...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "large.png"), large, 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	read := func(name string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "read_file"
+		request.Params.Arguments = map[string]any{"path": filepath.Join(dir, name)}
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	t.Run("displayable image as image content", func(t *testing.T) {
+		result := read("pixel.png")
+		require.Len(t, result.Content, 2)
+		image, ok := result.Content[1].(mcp.ImageContent)
+		require.True(t, ok, "expected image content, got %T", result.Content[1])
+		assert.Equal(t, "image/png", image.MIMEType)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(png), image.Data)
+	})
+
+	t.Run("other image as binary", func(t *testing.T) {
+		result := read("pixel.bmp")
+		require.Len(t, result.Content, 2)
+		resource, ok := result.Content[1].(mcp.EmbeddedResource)
+		require.True(t, ok, "expected embedded resource, got %T", result.Content[1])
+		blob := resource.Resource.(mcp.BlobResourceContents)
+		assert.Equal(t, "image/bmp", blob.MIMEType)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(bmp), blob.Blob)
+	})
+
+	t.Run("image over size limit", func(t *testing.T) {
+		result := read("large.png")
+		for _, content := range result.Content {
+			_, isImage := content.(mcp.ImageContent)
+			assert.False(t, isImage)
+		}
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "too large")
+	})
+}
+
+func TestReadfile_Language(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":   "package main\n",
+		"deploy":    "#!/usr/bin/env python3\nprint('hi')\n",
+		"run":       "#!/bin/bash\necho hi\n",
+		"notes.txt": "plain text\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	read := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	for name, language := range map[string]string{"main.go": "go", "deploy": "python", "run": "shell"} {
+		result := read(map[string]any{"path": filepath.Join(dir, name)})
+		assert.Equal(t, files[name], result.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, language, result.Meta["language"], name)
+	}
+
+	assert.Nil(t, read(map[string]any{"path": filepath.Join(dir, "notes.txt")}).Meta)
+	assert.Nil(t, read(map[string]any{"path": filepath.Join(dir, "main.go"), "detect_language": false}).Meta)
+}
+
+func TestReadfile_BOM(t *testing.T) {
+	dir := t.TempDir()
+	utf8Path := filepath.Join(dir, "utf8.txt")
+	utf16Path := filepath.Join(dir, "utf16.txt")
+	require.NoError(t, os.WriteFile(utf8Path, []byte("\xEF\xBB\xBFhello"), 0644))
+	require.NoError(t, os.WriteFile(utf16Path, []byte("\xFF\xFEh\x00i\x00"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	read := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	result := read(map[string]any{"path": utf8Path})
+	assert.Equal(t, "\uFEFFhello", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, "utf-8", result.Meta["bom"])
+
+	result = read(map[string]any{"path": utf8Path, "strip_bom": true})
+	assert.Equal(t, "hello", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, "utf-8", result.Meta["bom"])
+
+	result = read(map[string]any{"path": utf16Path})
+	assert.Equal(t, "hi", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, "utf-16le", result.Meta["bom"])
+}
+
+func TestReadfile_NormalizeWhitespace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	original := "func main() {\r\n\tx :=\t1  \r\n}\t\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	read := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := read(map[string]any{"path": path, "expand_tabs": true, "trim_trailing_whitespace": true})
+	require.False(t, result.IsError)
+	assert.Equal(t, "func main() {\r\n    x :=    1\r\n}\n", result.Content[0].(mcp.TextContent).Text)
+
+	result = read(map[string]any{"path": path, "expand_tabs": true, "tab_width": 2})
+	require.False(t, result.IsError)
+	assert.Equal(t, "func main() {\r\n  x :=  1  \r\n} \n", result.Content[0].(mcp.TextContent).Text)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+
+	result = read(map[string]any{"path": path, "expand_tabs": true, "tab_width": 0})
+	assert.True(t, result.IsError)
+}
+
+func TestReadfile_AsLines(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    FileLines
+	}{
+		{"lf", "a\n\nb\n", FileLines{Lines: []string{"a", "", "b"}, LineCount: 3, LineEnding: "lf", TrailingNewline: true}},
+		{"crlf without final newline", "a\r\nb", FileLines{Lines: []string{"a", "b"}, LineCount: 2, LineEnding: "crlf"}},
+		{"mixed", "a\r\nb\nc\r", FileLines{Lines: []string{"a", "b", "c"}, LineCount: 3, LineEnding: "mixed", TrailingNewline: true}},
+		{"single line", "a", FileLines{Lines: []string{"a"}, LineCount: 1, LineEnding: "none"}},
+		{"empty", "", FileLines{Lines: []string{}, LineEnding: "none"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "file.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"path": path, "as_lines": true}
+			result, err := handler.HandleReadFile(context.Background(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+
+			var got FileLines
+			require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestReadfile_TruncateToLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	// A multi-byte character straddles the limit
+	content := strings.Repeat("a", MAX_INLINE_SIZE-1) + "é" + "tail"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	read := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	result := read(map[string]any{"path": path})
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "too large to display inline")
+	assert.Nil(t, result.Meta)
+
+	result = read(map[string]any{"path": path, "truncate_to_limit": true})
+	require.Len(t, result.Content, 2)
+	assert.Equal(t, content[:MAX_INLINE_SIZE-1], result.Content[0].(mcp.TextContent).Text)
+	assert.Contains(t, result.Content[1].(mcp.TextContent).Text, "read_file_chunk")
+	assert.Equal(t, true, result.Meta["truncated"])
+	assert.Equal(t, int64(len(content)), result.Meta["total_size"])
+	assert.Equal(t, MAX_INLINE_SIZE-1, result.Meta["bytes_returned"])
+}
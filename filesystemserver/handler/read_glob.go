@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleReadGlob reads every file below a directory that matches a glob
+// pattern in one call. Matches are read in walk order until the aggregate
+// byte budget is used up; the matches after that are reported as skipped.
+func (fs *FilesystemHandler) HandleReadGlob(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := request.RequireString("pattern")
+	if err != nil {
+		return nil, err
+	}
+
+	maxTotalBytes := int64(MAX_BATCH_READ_SIZE)
+	if maxTotalArg, err := request.RequireFloat("max_total_bytes"); err == nil {
+		maxTotalBytes = int64(maxTotalArg)
+		if maxTotalBytes <= 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: max_total_bytes must be positive",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	filter, err := parseContentFilter(request)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// A pattern with a slash is matched against the path relative to the
+	// root, any other pattern against the file name
+	globPattern, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	hasSlash := strings.Contains(pattern, "/")
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info, err := fs.fsys.Stat(validPath); err != nil || !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: path must be a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Collect the matches, at most MAX_SEARCH_RESULTS of them; the files the
+	// filter skips are left out before they count against that
+	var matches []string
+	moreMatches := false
+	truncated, err := fs.walkTreeIgnoring(
+		ctx,
+		validPath,
+		fs.gitignoreFor(request, validPath),
+		func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(validPath, walkPath)
+			if err != nil || !globMatch(globPattern, hasSlash, filepath.ToSlash(rel)) {
+				return nil
+			}
+			if filter.skip(fs, walkPath, info) {
+				return nil
+			}
+			if len(matches) >= MAX_SEARCH_RESULTS {
+				moreMatches = true
+				return filepath.SkipAll
+			}
+			matches = append(matches, walkPath)
+			return nil
+		},
+	)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error searching for matches: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Check each match and its share of the budget in order, then read the
+	// accepted files concurrently
+	results := make([]GlobReadResult, len(matches))
+	validPaths := make([]string, len(matches))
+	var reads []int
+	var totalBytes int64
+	budgetExceeded := false
+	for i, match := range matches {
+		results[i].Path = fs.outputPath(ctx, match)
+		if budgetExceeded {
+			results[i].Skipped = true
+			results[i].Error = fmt.Sprintf("budget_exceeded (max_total_bytes is %d)", maxTotalBytes)
+			continue
+		}
+
+		validMatch, err := fs.validatePath(match)
+		if err == nil {
+			err = fs.refuseSpecialFile(validMatch)
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		info, err := fs.fsys.Stat(validMatch)
+		mimeType := fs.detectMimeType(validMatch)
+		switch {
+		case err != nil:
+			results[i].Error = err.Error()
+			continue
+		case info.IsDir():
+			results[i].Error = "symbolic link to a directory"
+			continue
+		case !isTextFile(mimeType):
+			results[i].Error = fmt.Sprintf("binary file (%s); use read_file to read it", mimeType)
+			continue
+		case info.Size() > MAX_INLINE_SIZE:
+			results[i].Error = fmt.Sprintf("file is too large to read inline (%d bytes); use read_file_chunk", info.Size())
+			continue
+		case totalBytes+info.Size() > maxTotalBytes:
+			budgetExceeded = true
+			results[i].Skipped = true
+			results[i].Error = fmt.Sprintf("budget_exceeded (max_total_bytes is %d)", maxTotalBytes)
+			continue
+		}
+		totalBytes += info.Size()
+		validPaths[i] = validMatch
+		reads = append(reads, i)
+	}
+	fs.forEach(ctx, len(reads), func(j int) {
+		i := reads[j]
+		content, err := readFileFull(fs.fsys, validPaths[i])
+		if err != nil {
+			results[i].Error = fs.outputText(ctx, fmt.Sprintf("reading file: %v", err))
+			return
+		}
+		results[i].Content = string(content)
+	})
+
+	read := 0
+	for _, result := range results {
+		if result.Error == "" {
+			read++
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error generating JSON: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	summary := fmt.Sprintf("Read %d of %d file(s) matching '%s' under %s (%d bytes)", read, len(matches), pattern, fs.outputText(ctx, path), totalBytes)
+	if moreMatches {
+		summary += fmt.Sprintf("\nNote: only the first %d matches are returned; narrow the pattern to see the rest.", MAX_SEARCH_RESULTS)
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: summary + "\n" + walkTruncatedNote(truncated) + filter.note() + "\n" + string(jsonData),
+			},
+		},
+	}, nil
+}
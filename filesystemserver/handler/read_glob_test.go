@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleReadGlob(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs", "guide"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "index.md"), []byte("# Index"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "guide", "setup.md"), []byte("# Setup"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "guide", "notes.txt"), []byte("notes"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "logo.png"), []byte("\x89PNG\r\n\x1a\n"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	readGlob := func(args map[string]any) (string, []GlobReadResult) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleReadGlob(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+
+		var results []GlobReadResult
+		require.NoError(t, json.Unmarshal([]byte(text[strings.Index(text, "["):]), &results))
+		return text, results
+	}
+
+	text, results := readGlob(map[string]any{"path": filepath.Join(dir, "docs"), "pattern": "*.md"})
+	assert.Contains(t, text, "Read 2 of 2 file(s)")
+	require.Len(t, results, 2)
+	assert.Equal(t, filepath.Join(dir, "docs", "guide", "setup.md"), results[0].Path)
+	assert.Equal(t, "# Setup", results[0].Content)
+	assert.Equal(t, "# Index", results[1].Content)
+
+	t.Run("relative path patterns", func(t *testing.T) {
+		_, results := readGlob(map[string]any{"path": filepath.Join(dir, "docs"), "pattern": "guide/*"})
+		require.Len(t, results, 2)
+		assert.Equal(t, "notes", results[0].Content)
+	})
+
+	t.Run("binary files are not inlined", func(t *testing.T) {
+		_, results := readGlob(map[string]any{"path": dir, "pattern": "*.png"})
+		require.Len(t, results, 1)
+		assert.Contains(t, results[0].Error, "binary file")
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		text, results := readGlob(map[string]any{"path": dir, "pattern": "*.md", "max_total_bytes": 10})
+		assert.Contains(t, text, "Read 1 of 2 file(s)")
+		require.Len(t, results, 2)
+		assert.False(t, results[0].Skipped)
+		assert.True(t, results[1].Skipped)
+		assert.Contains(t, results[1].Error, "budget_exceeded")
+	})
+}
@@ -44,17 +44,48 @@ func (fs *FilesystemHandler) HandleReadMultipleFiles(
 		}, nil
 	}
 
-	// Process each file
-	var results []mcp.Content
-	for _, path := range pathsSlice {
+	// Extract optional max_total_bytes parameter
+	maxTotalBytes := int64(MAX_BATCH_READ_SIZE)
+	if maxTotalArg, err := request.RequireFloat("max_total_bytes"); err == nil {
+		maxTotalBytes = int64(maxTotalArg)
+		if maxTotalBytes <= 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: max_total_bytes must be positive",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// Check each file and its share of the budget in order, then read the
+	// accepted files concurrently. Each file's output goes to its own entry
+	// so that the results keep the order of the paths.
+	entries := make([][]mcp.Content, len(pathsSlice))
+	var reads []batchRead
+	var totalBytes int64
+	budgetExceeded := false
+	for i, path := range pathsSlice {
+		// Once the aggregate budget is exhausted the remaining files are not read
+		if budgetExceeded {
+			entries[i] = append(entries[i], mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Skipped '%s': budget_exceeded (max_total_bytes is %d)", path, maxTotalBytes)),
+			})
+			continue
+		}
+
 		// Handle empty or relative paths like "." or "./" by converting to absolute path
 		if path == "." || path == "./" {
 			// Get current working directory
 			cwd, err := os.Getwd()
 			if err != nil {
-				results = append(results, mcp.TextContent{
+				entries[i] = append(entries[i], mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error resolving current directory for path '%s': %v", path, err),
+					Text: fs.outputText(ctx, fmt.Sprintf("Error resolving current directory for path '%s': %v", path, err)),
 				})
 				continue
 			}
@@ -63,122 +94,169 @@ func (fs *FilesystemHandler) HandleReadMultipleFiles(
 
 		validPath, err := fs.validatePath(path)
 		if err != nil {
-			results = append(results, mcp.TextContent{
+			entries[i] = append(entries[i], mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Error with path '%s': %v", path, err),
+				Text: fs.outputText(ctx, fmt.Sprintf("Error with path '%s': %v", path, err)),
+			})
+			continue
+		}
+
+		if err := fs.refuseSpecialFile(validPath); err != nil {
+			entries[i] = append(entries[i], mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Error with path '%s': %v", path, err)),
 			})
 			continue
 		}
 
 		// Check if it's a directory
-		info, err := os.Stat(validPath)
+		info, err := fs.fsys.Stat(validPath)
 		if err != nil {
-			results = append(results, mcp.TextContent{
+			entries[i] = append(entries[i], mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Error accessing '%s': %v", path, err),
+				Text: fs.outputText(ctx, fmt.Sprintf("Error accessing '%s': %v", path, fs.displayError(err))),
 			})
 			continue
 		}
 
 		if info.IsDir() {
 			// For directories, return a resource reference instead
-			resourceURI := pathToResourceURI(validPath)
-			results = append(results, mcp.TextContent{
+			resourceURI := fs.resourceURI(validPath)
+			entries[i] = append(entries[i], mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("'%s' is a directory. Use list_directory tool or resource URI: %s", path, resourceURI),
+				Text: fs.outputText(ctx, fmt.Sprintf("'%s' is a directory. Use list_directory tool or resource URI: %s", path, resourceURI)),
 			})
 			continue
 		}
 
 		// Determine MIME type
-		mimeType := detectMimeType(validPath)
+		mimeType := fs.detectMimeType(validPath)
 
 		// Check file size
 		if info.Size() > MAX_INLINE_SIZE {
 			// File is too large to inline, return a resource reference
-			resourceURI := pathToResourceURI(validPath)
-			results = append(results, mcp.TextContent{
+			resourceURI := fs.resourceURI(validPath)
+			entries[i] = append(entries[i], mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("File '%s' is too large to display inline (%d bytes). Access it via resource URI: %s",
-					path, info.Size(), resourceURI),
+				Text: fs.outputText(ctx, fmt.Sprintf("File '%s' is too large to display inline (%d bytes). Access it via resource URI: %s",
+					path, info.Size(), resourceURI)),
 			})
 			continue
 		}
 
-		// Read file content
-		content, err := os.ReadFile(validPath)
-		if err != nil {
-			results = append(results, mcp.TextContent{
+		// Check the aggregate budget before reading
+		if totalBytes+info.Size() > maxTotalBytes {
+			budgetExceeded = true
+			entries[i] = append(entries[i], mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Error reading file '%s': %v", path, err),
+				Text: fs.outputText(ctx, fmt.Sprintf("Skipped '%s': budget_exceeded (max_total_bytes is %d)", path, maxTotalBytes)),
 			})
 			continue
 		}
+		totalBytes += info.Size()
+
+		reads = append(reads, batchRead{index: i, path: path, validPath: validPath, info: info, mimeType: mimeType})
+	}
+	fs.forEach(ctx, len(reads), func(j int) {
+		r := reads[j]
+		entries[r.index] = fs.readBatchFile(ctx, r.path, r.validPath, r.info, r.mimeType)
+	})
 
-		// Add file header
+	var results []mcp.Content
+	for _, entry := range entries {
+		results = append(results, entry...)
+	}
+
+	return &mcp.CallToolResult{
+		Content: results,
+	}, nil
+}
+
+// batchRead is a file that read_multiple_files reads once the budget has
+// been checked
+type batchRead struct {
+	index     int
+	path      string
+	validPath string
+	info      os.FileInfo
+	mimeType  string
+}
+
+// readBatchFile returns the output of read_multiple_files for one file
+func (fs *FilesystemHandler) readBatchFile(ctx context.Context, path, validPath string, info os.FileInfo, mimeType string) []mcp.Content {
+	// Read file content
+	content, err := readFileFull(fs.fsys, validPath)
+	if err != nil {
+		return []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Error reading file '%s': %v", path, err)),
+			},
+		}
+	}
+
+	// Add file header
+	results := []mcp.Content{
+		mcp.TextContent{
+			Type: "text",
+			Text: fs.outputText(ctx, fmt.Sprintf("--- File: %s ---", path)),
+		},
+	}
+
+	// Check if it's a text file
+	if isTextFile(mimeType) {
+		// It's a text file, return as text
 		results = append(results, mcp.TextContent{
 			Type: "text",
-			Text: fmt.Sprintf("--- File: %s ---", path),
+			Text: string(content),
 		})
+	} else if isImageFile(mimeType) {
+		// It's an image file, return as image content
+		if info.Size() <= MAX_BASE64_SIZE {
+			results = append(results, mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Image file: %s (%s, %d bytes)", path, mimeType, info.Size())),
+			})
+			results = append(results, mcp.ImageContent{
+				Type:     "image",
+				Data:     base64.StdEncoding.EncodeToString(content),
+				MIMEType: mimeType,
+			})
+		} else {
+			// Too large for base64, return a reference
+			resourceURI := fs.resourceURI(validPath)
+			results = append(results, mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Image file '%s' is too large to display inline (%d bytes). Access it via resource URI: %s",
+					path, info.Size(), resourceURI)),
+			})
+		}
+	} else {
+		// It's another type of binary file
+		resourceURI := fs.resourceURI(validPath)
 
-		// Check if it's a text file
-		if isTextFile(mimeType) {
-			// It's a text file, return as text
+		if info.Size() <= MAX_BASE64_SIZE {
+			// Small enough for base64 encoding
 			results = append(results, mcp.TextContent{
 				Type: "text",
-				Text: string(content),
+				Text: fs.outputText(ctx, fmt.Sprintf("Binary file: %s (%s, %d bytes)", path, mimeType, info.Size())),
 			})
-		} else if isImageFile(mimeType) {
-			// It's an image file, return as image content
-			if info.Size() <= MAX_BASE64_SIZE {
-				results = append(results, mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Image file: %s (%s, %d bytes)", path, mimeType, info.Size()),
-				})
-				results = append(results, mcp.ImageContent{
-					Type:     "image",
-					Data:     base64.StdEncoding.EncodeToString(content),
+			results = append(results, mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.BlobResourceContents{
+					URI:      resourceURI,
 					MIMEType: mimeType,
-				})
-			} else {
-				// Too large for base64, return a reference
-				resourceURI := pathToResourceURI(validPath)
-				results = append(results, mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Image file '%s' is too large to display inline (%d bytes). Access it via resource URI: %s",
-						path, info.Size(), resourceURI),
-				})
-			}
+					Blob:     base64.StdEncoding.EncodeToString(content),
+				},
+			})
 		} else {
-			// It's another type of binary file
-			resourceURI := pathToResourceURI(validPath)
-
-			if info.Size() <= MAX_BASE64_SIZE {
-				// Small enough for base64 encoding
-				results = append(results, mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Binary file: %s (%s, %d bytes)", path, mimeType, info.Size()),
-				})
-				results = append(results, mcp.EmbeddedResource{
-					Type: "resource",
-					Resource: mcp.BlobResourceContents{
-						URI:      resourceURI,
-						MIMEType: mimeType,
-						Blob:     base64.StdEncoding.EncodeToString(content),
-					},
-				})
-			} else {
-				// Too large for base64, return a reference
-				results = append(results, mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Binary file '%s' (%s, %d bytes). Access it via resource URI: %s",
-						path, mimeType, info.Size(), resourceURI),
-				})
-			}
+			// Too large for base64, return a reference
+			results = append(results, mcp.TextContent{
+				Type: "text",
+				Text: fs.outputText(ctx, fmt.Sprintf("Binary file '%s' (%s, %d bytes). Access it via resource URI: %s",
+					path, mimeType, info.Size(), resourceURI)),
+			})
 		}
 	}
-
-	return &mcp.CallToolResult{
-		Content: results,
-	}, nil
+	return results
 }
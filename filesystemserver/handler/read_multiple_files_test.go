@@ -239,3 +239,38 @@ func TestHandleReadMultipleFiles(t *testing.T) {
 		assert.Contains(t, textContent.Text, otherFile)
 	})
 }
+
+func TestHandleReadMultipleFiles_Budget(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsHandler, err := NewFilesystemHandler(resolveAllowedDirs(t, tmpDir))
+	require.NoError(t, err)
+
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", 10)), 0644))
+		paths = append(paths, path)
+	}
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]interface{}{
+				"paths":           paths,
+				"max_total_bytes": 15,
+			},
+		},
+	}
+
+	res, err := fsHandler.HandleReadMultipleFiles(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+
+	var texts []string
+	for _, content := range res.Content {
+		texts = append(texts, content.(mcp.TextContent).Text)
+	}
+	all := strings.Join(texts, "\n")
+	assert.Contains(t, all, "--- File: "+paths[0]+" ---")
+	assert.Contains(t, all, "Skipped '"+paths[1]+"': budget_exceeded")
+	assert.Contains(t, all, "Skipped '"+paths[2]+"': budget_exceeded")
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errReadOnly is reported for every change refused in read-only mode
+var errReadOnly = fmt.Errorf("read_only - the server is in read-only mode")
+
+// WithReadOnly puts the server in read-only mode: the tools that change the
+// filesystem are left out of the tool list, calls to them fail, and no path
+// is authorized for OpWrite or OpDelete, whichever tool asks. A reload can
+// turn the mode on and off again.
+func WithReadOnly() Option {
+	return func(fs *FilesystemHandler) {
+		fs.readOnly = true
+	}
+}
+
+// hiddenWhenReadOnly reports whether read-only mode leaves tool out of the
+// tool list. reload_config is kept: it changes the server's configuration
+// rather than the filesystem, and is how read-only mode is turned off again.
+func hiddenWhenReadOnly(tool mcp.Tool) bool {
+	if tool.Name == "reload_config" {
+		return false
+	}
+	return tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint
+}
+
+// RefuseInReadOnly wraps the handler of a tool that changes the filesystem
+// so that calls to it fail in read-only mode, before any path is resolved.
+// It must run inside HoldConfig.
+func (fs *FilesystemHandler) RefuseInReadOnly(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if fs.readOnly {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %v; %s is not available", errReadOnly, request.Params.Name),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		return next(ctx, request)
+	}
+}
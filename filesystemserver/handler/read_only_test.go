@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+
+	readOnly := true
+	handler, err := NewFilesystemHandler([]string{dir}, WithReadOnly(), WithConfigReload(func() ([]string, []Option, error) {
+		if readOnly {
+			return []string{dir}, []Option{WithReadOnly()}, nil
+		}
+		return []string{dir}, nil, nil
+	}))
+	require.NoError(t, err)
+
+	callTool := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("tools that change the filesystem are hidden", func(t *testing.T) {
+		tools := []mcp.Tool{
+			mcp.NewTool("read_file", mcp.WithReadOnlyHintAnnotation(true)),
+			mcp.NewTool("write_file", mcp.WithReadOnlyHintAnnotation(false)),
+			mcp.NewTool("reload_config", mcp.WithReadOnlyHintAnnotation(false)),
+		}
+		var names []string
+		for _, tool := range handler.FilterTools(context.Background(), tools) {
+			names = append(names, tool.Name)
+		}
+		assert.Equal(t, []string{"read_file", "reload_config"}, names)
+	})
+
+	t.Run("calls to them are refused", func(t *testing.T) {
+		result := callTool(handler.RefuseInReadOnly(handler.HandleWriteFile), "write_file", map[string]any{"path": path, "content": "changed"})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "read_only")
+	})
+
+	t.Run("paths are not authorized for changes", func(t *testing.T) {
+		result := callTool(handler.HandleDeleteFile, "delete_file", map[string]any{"path": path})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "read_only")
+		assert.FileExists(t, path)
+
+		result = callTool(handler.HandleReadFile, "read_file", map[string]any{"path": path})
+		require.False(t, result.IsError)
+	})
+
+	t.Run("a reload turns the mode off", func(t *testing.T) {
+		readOnly = false
+		result, err := handler.Reload()
+		require.NoError(t, err)
+		assert.True(t, result.ReadOnlyChanged)
+		assert.True(t, result.ToolsChanged())
+
+		written := callTool(handler.RefuseInReadOnly(handler.HandleWriteFile), "write_file", map[string]any{"path": path, "content": "changed"})
+		require.False(t, written.IsError)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "changed", string(content))
+	})
+}
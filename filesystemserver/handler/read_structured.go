@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// structuredFormats maps file extensions to the format used to parse them
+var structuredFormats = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+}
+
+// HandleReadStructured parses a JSON, YAML or TOML file and returns the
+// decoded value as JSON, or the parse error with its line and column
+func (fs *FilesystemHandler) HandleReadStructured(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	format := strings.ToLower(request.GetString("format", ""))
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Autodetect the format from the file extension
+	if format == "" {
+		format = structuredFormats[strings.ToLower(filepath.Ext(validPath))]
+		if format == "" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: cannot detect the format of %s from its extension; pass format (json, yaml or toml)", path),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: Cannot read a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info.Size() > MAX_INLINE_SIZE {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: file is too large to parse (%d bytes, maximum is %d bytes)", info.Size(), MAX_INLINE_SIZE),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	content, err := readFileFull(fs.fsys, validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	value, err := parseStructured(content, format)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid %s in %s: %v", strings.ToUpper(format), path, err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	jsonData, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error converting %s to JSON: %v", format, err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+	}, nil
+}
+
+// parseStructured decodes content in the given format. Parse errors report
+// the line and column of the problem where the decoder provides them.
+func parseStructured(content []byte, format string) (any, error) {
+	var value any
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, jsonErrorPosition(content, err)
+		}
+		// Reject trailing data after the top-level value, including stray
+		// closing brackets, which More does not report
+		offset := decoder.InputOffset()
+		if _, err := decoder.Token(); err != io.EOF {
+			rest := content[offset:]
+			offset += int64(len(rest) - len(bytes.TrimLeft(rest, " \t\r\n")))
+			line, col := offsetPosition(content, offset)
+			return nil, fmt.Errorf("line %d, column %d: unexpected data after top-level value", line, col)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(content, &value); err != nil {
+			return nil, err
+		}
+		value = normalizeYAML(value)
+	case "toml":
+		var table map[string]any
+		if _, err := toml.Decode(string(content), &table); err != nil {
+			var parseErr toml.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("line %d, column %d: %s", parseErr.Position.Line, parseErr.Position.Col, parseErr.Message)
+			}
+			return nil, err
+		}
+		value = table
+	default:
+		return nil, fmt.Errorf("unsupported format '%s' (expected json, yaml or toml)", format)
+	}
+	return value, nil
+}
+
+// jsonErrorPosition adds the line and column to JSON decoding errors that
+// only carry a byte offset
+func jsonErrorPosition(content []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := offsetPosition(content, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, err)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		line, col := offsetPosition(content, int64(len(content)))
+		return fmt.Errorf("line %d, column %d: unexpected end of input", line, col)
+	}
+	return err
+}
+
+// offsetPosition converts a byte offset into a 1-based line and column
+func offsetPosition(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// normalizeYAML converts maps with non-string keys, which YAML allows but
+// JSON does not, into maps keyed by the formatted key
+func normalizeYAML(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStructured(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	read := func(name, content string, args map[string]any) *mcp.CallToolResult {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		if args == nil {
+			args = map[string]any{}
+		}
+		args["path"] = path
+
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "read_structured"
+		request.Params.Arguments = args
+		result, err := handler.HandleReadStructured(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("json", func(t *testing.T) {
+		result := read("config.json", `{"name": "app", "port": 8080}`, nil)
+		require.False(t, result.IsError)
+		assert.JSONEq(t, `{"name": "app", "port": 8080}`, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("json syntax error reports position", func(t *testing.T) {
+		result := read("broken.json", "{\n  \"name\": \"app\",\n  \"port\": ,\n}", nil)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "line 3, column")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		result := read("config.yml", "name: app\nports:\n  - 80\n  - 443\n", nil)
+		require.False(t, result.IsError)
+		assert.JSONEq(t, `{"name": "app", "ports": [80, 443]}`, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("toml syntax error reports position", func(t *testing.T) {
+		result := read("config.toml", "[server]\nport = \n", nil)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "line 2, column")
+	})
+
+	t.Run("explicit format overrides the extension", func(t *testing.T) {
+		result := read("settings.conf", "[server]\nport = 80\n", map[string]any{"format": "toml"})
+		require.False(t, result.IsError)
+		assert.JSONEq(t, `{"server": {"port": 80}}`, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("unknown extension without format", func(t *testing.T) {
+		result := read("settings.conf", "x", nil)
+		require.True(t, result.IsError)
+	})
+}
+
+func TestParseStructured_TrailingData(t *testing.T) {
+	for _, content := range []string{`{"a":1}}`, `[1]]`, "{}\n{}", `1 2`} {
+		_, err := parseStructured([]byte(content), "json")
+		require.Error(t, err, content)
+		assert.Contains(t, err.Error(), "unexpected data after top-level value", content)
+	}
+	_, err := parseStructured([]byte("{\"a\": 1}\n\n"), "json")
+	assert.NoError(t, err)
+
+	_, err = parseStructured([]byte(`{"a":1}}`), "json")
+	assert.Contains(t, err.Error(), "line 1, column 8")
+}
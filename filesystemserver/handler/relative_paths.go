@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithRelativeOutputPaths makes tool results report paths below an allowed
+// directory relative to it, e.g. src/main.go instead of
+// /home/me/proj/src/main.go. Paths below an aliased directory are reported
+// with its alias, as @proj/src/main.go, so that they stay unambiguous with
+// several allowed directories. Calls can override it with relative_paths.
+func WithRelativeOutputPaths() Option {
+	return func(fs *FilesystemHandler) {
+		fs.relativeOutput = true
+	}
+}
+
+// contentTools are the tools whose results carry file contents or matched
+// lines. RelativizePaths must not rewrite text that came from a file, so
+// these tools format their own paths with outputPath instead, and only their
+// errors are rewritten as a whole.
+var contentTools = map[string]bool{
+	"read_file":                  true,
+	"read_file_chunk":            true,
+	"sniff_file":                 true,
+	"follow_file":                true,
+	"scan_log":                   true,
+	"read_structured":            true,
+	"extract_text":               true,
+	"read_multiple_files":        true,
+	"read_glob":                  true,
+	"patch_json":                 true,
+	"search_and_replace_preview": true,
+	"search_within_files":        true,
+}
+
+// relativeOutputKey is the context key under which RelativizePaths tells a
+// content tool whether to report relative paths
+type relativeOutputKey struct{}
+
+// RelativizePaths is a tool handler middleware that rewrites the absolute
+// paths in a result relative to their allowed directory when the request
+// sets relative_paths, or the server enables it by default. Text content and
+// the text of embedded resources are rewritten; resource URIs are left as
+// they are. Results compressed by CompressResults were rewritten before
+// compression. The results of content tools are left alone: those tools
+// relativize the paths they report themselves.
+func (fs *FilesystemHandler) RelativizePaths(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if contentTools[request.Params.Name] {
+			ctx = context.WithValue(ctx, relativeOutputKey{}, fs.wantsRelativePaths(request))
+		}
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.Meta != nil && result.Meta["content_encoding"] != nil {
+			return result, err
+		}
+		fs.relativizeResult(request, result)
+		return result, nil
+	}
+}
+
+// wantsRelativePaths reports whether the result of request should report
+// relative paths. Root-relative mode already hides the host paths.
+func (fs *FilesystemHandler) wantsRelativePaths(request mcp.CallToolRequest) bool {
+	return !fs.rootRelative && request.GetBool("relative_paths", fs.relativeOutput)
+}
+
+// relativizeResult rewrites the paths in result in place if the request asks
+// for relative paths. Successful results of content tools are not touched.
+func (fs *FilesystemHandler) relativizeResult(request mcp.CallToolRequest, result *mcp.CallToolResult) {
+	if !fs.wantsRelativePaths(request) || contentTools[request.Params.Name] && !result.IsError {
+		return
+	}
+	for i, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			c.Text = fs.relativizeText(c.Text)
+			result.Content[i] = c
+		case mcp.EmbeddedResource:
+			if text, ok := c.Resource.(mcp.TextResourceContents); ok {
+				text.Text = fs.relativizeText(text.Text)
+				c.Resource = text
+				result.Content[i] = c
+			}
+		}
+	}
+}
+
+// outputPath returns the validated path as a content tool reports it:
+// relative to its allowed directory if the call asks for relative paths,
+// otherwise as displayPath shows it
+func (fs *FilesystemHandler) outputPath(ctx context.Context, path string) string {
+	if relative, _ := ctx.Value(relativeOutputKey{}).(bool); relative {
+		return fs.relativizeText(path)
+	}
+	return fs.displayPath(path)
+}
+
+// outputText returns text that a content tool reports and that holds paths
+// but no file contents, such as a path given by the client or an error,
+// with its paths relativized if the call asks for relative paths
+func (fs *FilesystemHandler) outputText(ctx context.Context, text string) string {
+	if relative, _ := ctx.Value(relativeOutputKey{}).(bool); relative {
+		return fs.relativizeText(text)
+	}
+	return text
+}
+
+// relativizeText replaces every absolute path in text that lies below an
+// allowed directory with the path relative to that directory, or "." for
+// the directory itself, prefixed with the directory's alias if it has one.
+// Nested allowed directories are matched innermost first. Paths are only
+// replaced as whole components, so /srv/data does not match /srv/database
+// or /backup/srv/data, and JSON-escaped Windows paths are handled too.
+func (fs *FilesystemHandler) relativizeText(text string) string {
+	roots := slices.Clone(fs.allowedDirs)
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+
+	for _, root := range roots {
+		base := strings.TrimSuffix(root, string(filepath.Separator))
+		if base == "" || strings.HasSuffix(base, ":") {
+			// Every absolute path is below a filesystem root
+			continue
+		}
+		prefix := ""
+		if aliases := fs.aliasesFor(root); len(aliases) > 0 {
+			prefix = aliases[0]
+		}
+		text = replacePathPrefix(text, base, string(filepath.Separator), prefix)
+		if filepath.Separator == '\\' {
+			escaped := strings.ReplaceAll(base, `\`, `\\`)
+			text = replacePathPrefix(text, escaped, `\\`, prefix)
+		}
+	}
+	return text
+}
+
+// replacePathPrefix replaces the occurrences of the directory base in text
+// that start a path: base followed by sep becomes prefix/ (or nothing
+// without a prefix), and base on its own becomes prefix (or ".")
+func replacePathPrefix(text, base, sep, prefix string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(text, base)
+		if i < 0 {
+			sb.WriteString(text)
+			return sb.String()
+		}
+		sb.WriteString(text[:i])
+		rest := text[i+len(base):]
+		switch {
+		case i > 0 && isPathByte(text[i-1]):
+			sb.WriteString(base)
+		case strings.HasPrefix(rest, sep):
+			if prefix != "" {
+				sb.WriteString(prefix + "/")
+			}
+			rest = rest[len(sep):]
+		case rest == "" || !isPathByte(rest[0]):
+			if prefix != "" {
+				sb.WriteString(prefix)
+			} else {
+				sb.WriteString(".")
+			}
+		default:
+			sb.WriteString(base)
+		}
+		text = rest
+	}
+}
+
+// isPathByte reports whether b can be part of a file name, so that a
+// directory name directly next to it is not a whole path component
+func isPathByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' ||
+		b == '.' || b == '-' || b == '_' || b == '/' || b == '\\' || b == '~' || b >= 0x80
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelativizePaths(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "proj", "src"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "proj", "src", "main.go"), []byte("package main"), 0644))
+	proj := filepath.Join(dir, "proj")
+	docs := filepath.Join(dir, "docs")
+
+	handler, err := NewFilesystemHandler([]string{proj, docs},
+		WithRootAliases(map[string]string{"docs": docs}), WithRelativeOutputPaths())
+	require.NoError(t, err)
+
+	t.Run("text", func(t *testing.T) {
+		in := strings.Join([]string{
+			filepath.Join(proj, "src", "main.go"),
+			"(" + proj + ")",
+			filepath.Join(docs, "guide.md"),
+			proj + "-old/x",
+			"/backup" + proj,
+			"file://" + filepath.Join(proj, "a.txt"),
+		}, "\n")
+		want := strings.Join([]string{
+			filepath.Join("src", "main.go"),
+			"(.)",
+			"@docs/guide.md",
+			proj + "-old/x",
+			"/backup" + proj,
+			"file://" + filepath.Join(proj, "a.txt"),
+		}, "\n")
+		assert.Equal(t, want, handler.relativizeText(in))
+	})
+
+	search := handler.RelativizePaths(handler.HandleSearchFiles)
+	call := func(args map[string]any) string {
+		args["path"] = proj
+		args["pattern"] = "*.go"
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := search(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("tool results", func(t *testing.T) {
+		text := call(map[string]any{})
+		assert.Contains(t, text, "[FILE] "+filepath.Join("src", "main.go")+" (file://")
+		assert.NotContains(t, text, "[FILE] "+proj)
+	})
+
+	t.Run("per-call override", func(t *testing.T) {
+		text := call(map[string]any{"relative_paths": false})
+		assert.Contains(t, text, "[FILE] "+filepath.Join(proj, "src", "main.go"))
+	})
+}
+
+func TestRelativizePaths_FileContents(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	content := "data_dir=" + filepath.Join(dir, "data") + "\nroot=" + dir + "\n"
+	path := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithRelativeOutputPaths())
+	require.NoError(t, err)
+
+	call := func(name string, next func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handler.RelativizePaths(next)(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result
+	}
+
+	t.Run("read_file returns the exact bytes", func(t *testing.T) {
+		result := call("read_file", handler.HandleReadFile, map[string]any{"path": path})
+		assert.Equal(t, content, result.Content[0].(mcp.TextContent).Text)
+	})
+
+	t.Run("search_within_files keeps match lines", func(t *testing.T) {
+		result := call("search_within_files", handler.HandleSearchWithinFiles, map[string]any{"path": dir, "substring": "data_dir"})
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "File: app.conf (")
+		assert.Contains(t, text, "data_dir="+filepath.Join(dir, "data"))
+	})
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithConfigReload enables reload_config. load is called on every reload to
+// read the configuration again; it returns the allowed directories and the
+// options to apply, as they would be passed to NewFilesystemHandler.
+func WithConfigReload(load func() ([]string, []Option, error)) Option {
+	return func(fs *FilesystemHandler) {
+		fs.reload = load
+	}
+}
+
+// WithLogLevel lets reloads change the level of the logger: level is the
+// level the logger filters by, and a reload that applies this option sets it
+// to want. The level at startup is left to the caller.
+func WithLogLevel(level *slog.LevelVar, want slog.Level) Option {
+	return func(fs *FilesystemHandler) {
+		fs.logLevel = level
+		fs.wantLogLevel = want
+	}
+}
+
+// ReloadResult reports the allowed directories a reload added and removed,
+// the tools it turned on and off, and whether it switched read-only mode
+type ReloadResult struct {
+	Added           []string
+	Removed         []string
+	EnabledTools    []string
+	DisabledTools   []string
+	ReadOnlyChanged bool
+	ReadOnly        bool
+}
+
+// ToolsChanged reports whether the reload changed the tool list, which
+// clients should then be told about
+func (r *ReloadResult) ToolsChanged() bool {
+	return len(r.EnabledTools) > 0 || len(r.DisabledTools) > 0 || r.ReadOnlyChanged
+}
+
+// Reload reads the configuration again and swaps in its allowed directories,
+// aliases, relative output paths, quotas, templates and trash directories,
+// write rate limits, file modes, walk and concurrency limits, disabled tools,
+// read-only mode, directory access modes, the deny list, the symlink policy
+// and, with WithLogLevel, the log level. The new configuration is validated completely
+// before anything changes, so a failed reload leaves the server as it was.
+// Tool calls in progress finish with the old configuration; calls that
+// arrive during the swap wait for it. A call abandoned after its timeout_ms
+// may never finish, so the reload fails instead while one is running.
+// Settings outside this list, such as the read cache, the audit log and
+// root-relative paths, keep their startup values.
+func (fs *FilesystemHandler) Reload() (*ReloadResult, error) {
+	if fs.reload == nil {
+		return nil, fmt.Errorf("configuration reloading is not enabled")
+	}
+	allowedDirs, opts, err := fs.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the new configuration the same way as at startup, on the same
+	// filesystem and with the same logger
+	opts = append([]Option{WithFileSystem(fs.fsys), WithLogger(fs.logger)}, opts...)
+	next, err := NewFilesystemHandler(allowedDirs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// Only configuration is taken over; anything the new handler started,
+	// such as a health check, is stopped
+	defer next.Close()
+	if next.rootRelative != fs.rootRelative {
+		return nil, fmt.Errorf("root_relative_paths cannot be changed without a restart")
+	}
+
+	if err := fs.holdOffCalls(); err != nil {
+		return nil, err
+	}
+	defer fs.resumeCalls()
+	fs.configMu.Lock()
+	defer fs.configMu.Unlock()
+
+	result := &ReloadResult{}
+	for _, dir := range next.allowedDirs {
+		if !slices.Contains(fs.allowedDirs, dir) {
+			result.Added = append(result.Added, strings.TrimSuffix(dir, string(filepath.Separator)))
+		}
+	}
+	for _, dir := range fs.allowedDirs {
+		if !slices.Contains(next.allowedDirs, dir) {
+			result.Removed = append(result.Removed, strings.TrimSuffix(dir, string(filepath.Separator)))
+		}
+	}
+
+	for name := range next.disabledTools {
+		if !fs.disabledTools[name] {
+			result.DisabledTools = append(result.DisabledTools, name)
+		}
+	}
+	for name := range fs.disabledTools {
+		if !next.disabledTools[name] {
+			result.EnabledTools = append(result.EnabledTools, name)
+		}
+	}
+	slices.Sort(result.DisabledTools)
+	slices.Sort(result.EnabledTools)
+	result.ReadOnlyChanged = next.readOnly != fs.readOnly
+	result.ReadOnly = next.readOnly
+
+	fs.allowedDirs = next.allowedDirs
+	fs.aliases = next.aliases
+	fs.relativeOutput = next.relativeOutput
+	fs.respectGitignore = next.respectGitignore
+	fs.quotaConfig, fs.quotas = next.quotaConfig, next.quotas
+	fs.templatesDir = next.templatesDir
+	fs.trashDir = next.trashDir
+	fs.backupDir = next.backupDir
+	fs.limiter = next.limiter
+	fs.confirmations = next.confirmations
+	fs.filePerm, fs.dirPerm = next.filePerm, next.dirPerm
+	fs.maxWalkDepth, fs.maxWalkEntries = next.maxWalkDepth, next.maxWalkEntries
+	fs.maxConcurrency = next.maxConcurrency
+	fs.maxCallTimeout = next.maxCallTimeout
+	fs.maxListEntries = next.maxListEntries
+	fs.budget.setLimit(next.budget.limit)
+	fs.disabledTools = next.disabledTools
+	fs.readOnly = next.readOnly
+	fs.accessConfig, fs.readOnlyRoots = next.accessConfig, next.readOnlyRoots
+	fs.deniedConfig, fs.denied = next.deniedConfig, next.denied
+	fs.symlinkPolicy = next.symlinkPolicy
+	if next.logLevel != nil {
+		next.logLevel.Set(next.wantLogLevel)
+	}
+
+	fs.logger.Info("Configuration reloaded", "directories", fs.allowedDirs, "added", result.Added, "removed", result.Removed,
+		"enabled_tools", result.EnabledTools, "disabled_tools", result.DisabledTools, "read_only", fs.readOnly)
+	return result, nil
+}
+
+// HoldConfig wraps a tool handler so that the configuration cannot be
+// swapped by a reload while the call runs: the call holds it until the
+// handler returns. reload_config itself is not held, as it waits for the
+// calls in progress. A call with timeout_ms that LimitCallDuration abandons
+// keeps holding the configuration, but is counted as abandoned so that a
+// reload fails rather than wait for a handler stuck on a hung filesystem,
+// and with it every later call.
+func (fs *FilesystemHandler) HoldConfig(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "reload_config" {
+			return next(ctx, request)
+		}
+		call := fs.enterCall()
+		defer fs.leaveCall(call)
+		fs.configMu.RLock()
+		defer fs.configMu.RUnlock()
+
+		if _, limited := request.GetArguments()["timeout_ms"]; limited {
+			returned := make(chan struct{})
+			defer close(returned)
+			stop := context.AfterFunc(ctx, func() {
+				grace := time.NewTimer(CALL_TIMEOUT_GRACE)
+				defer grace.Stop()
+				select {
+				case <-grace.C:
+					fs.abandonCall(call)
+				case <-returned:
+				}
+			})
+			defer stop()
+		}
+		return next(ctx, request)
+	}
+}
+
+// heldCall is a tool call that holds the configuration
+type heldCall struct {
+	abandoned bool
+	finished  bool
+}
+
+// enterCall registers a tool call, waiting for a reload in progress
+func (fs *FilesystemHandler) enterCall() *heldCall {
+	fs.callsMu.Lock()
+	defer fs.callsMu.Unlock()
+	for fs.reloading {
+		fs.callsCond.Wait()
+	}
+	fs.calls++
+	return &heldCall{}
+}
+
+// abandonCall marks a call that has not returned after its deadline
+func (fs *FilesystemHandler) abandonCall(call *heldCall) {
+	fs.callsMu.Lock()
+	defer fs.callsMu.Unlock()
+	if call.finished || call.abandoned {
+		return
+	}
+	call.abandoned = true
+	fs.abandoned++
+	fs.callsCond.Broadcast()
+}
+
+// leaveCall unregisters a call once its handler has returned
+func (fs *FilesystemHandler) leaveCall(call *heldCall) {
+	fs.callsMu.Lock()
+	defer fs.callsMu.Unlock()
+	call.finished = true
+	fs.calls--
+	if call.abandoned {
+		fs.abandoned--
+	}
+	fs.callsCond.Broadcast()
+}
+
+// holdOffCalls keeps new calls out and waits for the calls in progress to
+// finish, so that a reload can swap the configuration. It fails if one of
+// them was abandoned, as that may never happen.
+func (fs *FilesystemHandler) holdOffCalls() error {
+	fs.callsMu.Lock()
+	defer fs.callsMu.Unlock()
+	for fs.reloading {
+		fs.callsCond.Wait()
+	}
+	fs.reloading = true
+	for fs.calls > 0 && fs.abandoned == 0 {
+		fs.callsCond.Wait()
+	}
+	if fs.abandoned > 0 {
+		fs.reloading = false
+		fs.callsCond.Broadcast()
+		return fmt.Errorf("%d call(s) abandoned after timeout_ms are still running; reload again once they finish", fs.abandoned)
+	}
+	return nil
+}
+
+// resumeCalls lets calls in again after a reload
+func (fs *FilesystemHandler) resumeCalls() {
+	fs.callsMu.Lock()
+	defer fs.callsMu.Unlock()
+	fs.reloading = false
+	fs.callsCond.Broadcast()
+}
+
+// HandleReloadConfig re-reads the configuration file and reports the
+// allowed directories that were added and removed
+func (fs *FilesystemHandler) HandleReloadConfig(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	result, err := fs.Reload()
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: reload failed, the previous configuration is still in effect: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Clients that listed the tools before should fetch them again
+	if result.ToolsChanged() {
+		if srv := server.ServerFromContext(ctx); srv != nil {
+			srv.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+		}
+	}
+
+	fs.configMu.RLock()
+	defer fs.configMu.RUnlock()
+
+	var sb strings.Builder
+	sb.WriteString("Configuration reloaded.\n")
+	if len(result.Added) == 0 && len(result.Removed) == 0 {
+		sb.WriteString("Allowed directories are unchanged.\n")
+	}
+	for _, dir := range result.Added {
+		sb.WriteString(fmt.Sprintf("Added: %s\n", fs.displayPath(dir)))
+	}
+	for _, dir := range result.Removed {
+		sb.WriteString(fmt.Sprintf("Removed: %s\n", fs.displayPath(dir)))
+	}
+	for _, name := range result.EnabledTools {
+		sb.WriteString(fmt.Sprintf("Enabled tool: %s\n", name))
+	}
+	for _, name := range result.DisabledTools {
+		sb.WriteString(fmt.Sprintf("Disabled tool: %s\n", name))
+	}
+	if result.ReadOnlyChanged {
+		if result.ReadOnly {
+			sb.WriteString("Read-only mode: on\n")
+		} else {
+			sb.WriteString("Read-only mode: off\n")
+		}
+	}
+	sb.WriteString(fmt.Sprintf("Allowed directories: %d\n", len(fs.allowedDirs)))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: sb.String(),
+			},
+		},
+	}, nil
+}
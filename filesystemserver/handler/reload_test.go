@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleReloadConfig(t *testing.T) {
+	first := resolveAllowedDirs(t, t.TempDir())[0]
+	second := resolveAllowedDirs(t, t.TempDir())[0]
+
+	var dirs []string
+	var loadErr error
+	handler, err := NewFilesystemHandler([]string{first}, WithConfigReload(func() ([]string, []Option, error) {
+		return dirs, []Option{WithWalkLimits(7, 0)}, loadErr
+	}))
+	require.NoError(t, err)
+
+	reload := func() (string, bool) {
+		result, err := handler.HoldConfig(handler.HandleReloadConfig)(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "reload_config"},
+		})
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("swaps the allowed directories", func(t *testing.T) {
+		dirs = []string{second}
+		text, isError := reload()
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Added: "+second)
+		assert.Contains(t, text, "Removed: "+first)
+		assert.Equal(t, 7, handler.maxWalkDepth)
+
+		_, err := handler.validatePath(second)
+		require.NoError(t, err)
+		_, err = handler.validatePath(first)
+		require.Error(t, err)
+	})
+
+	t.Run("failed reloads change nothing", func(t *testing.T) {
+		dirs = []string{"/does/not/exist"}
+		text, isError := reload()
+		require.True(t, isError)
+		assert.Contains(t, text, "previous configuration is still in effect")
+
+		dirs, loadErr = []string{first}, errors.New("parse error")
+		text, isError = reload()
+		require.True(t, isError)
+		assert.Contains(t, text, "parse error")
+
+		_, err := handler.validatePath(second)
+		require.NoError(t, err)
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		other, err := NewFilesystemHandler([]string{first})
+		require.NoError(t, err)
+		_, err = other.Reload()
+		require.Error(t, err)
+	})
+}
+
+func TestReload_ToolsAndLogLevel(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	level := new(slog.LevelVar)
+	var disabled []string
+	handler, err := NewFilesystemHandler([]string{dir}, WithConfigReload(func() ([]string, []Option, error) {
+		return []string{dir}, []Option{WithDisabledTools(disabled), WithLogLevel(level, slog.LevelDebug)}, nil
+	}))
+	require.NoError(t, err)
+
+	tools := []mcp.Tool{{Name: "read_file"}, {Name: "delete_file"}}
+	call := handler.HoldConfig(handler.RefuseDisabledTools(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{}, nil
+	}))
+	callTool := func(name string) *mcp.CallToolResult {
+		result, err := call(context.Background(), mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name}})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.Len(t, handler.FilterTools(context.Background(), tools), 2)
+
+	disabled = []string{"delete_file"}
+	result, err := handler.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delete_file"}, result.DisabledTools)
+	assert.True(t, result.ToolsChanged())
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	listed := handler.FilterTools(context.Background(), tools)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "read_file", listed[0].Name)
+	assert.True(t, callTool("delete_file").IsError)
+	assert.False(t, callTool("read_file").IsError)
+
+	disabled = nil
+	result, err = handler.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delete_file"}, result.EnabledTools)
+	assert.False(t, callTool("delete_file").IsError)
+}
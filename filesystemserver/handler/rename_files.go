@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// renameMapping describes a single planned rename within a batch
+type renameMapping struct {
+	OldName  string
+	NewName  string
+	OldPath  string
+	NewPath  string
+	Conflict string // reason the rename cannot be performed, if any
+}
+
+func (fs *FilesystemHandler) HandleRenameFiles(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	match, err := request.RequireString("match")
+	if err != nil {
+		return nil, err
+	}
+	template, err := request.RequireString("template")
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract match_type parameter (optional, default: glob)
+	matchType := "glob"
+	if matchTypeParam, err := request.RequireString("match_type"); err == nil && matchTypeParam != "" {
+		matchType = matchTypeParam
+	}
+
+	// Extract dry_run parameter (optional, default: false)
+	dryRun := false
+	if dryRunParam, err := request.RequireBool("dry_run"); err == nil {
+		dryRun = dryRunParam
+	}
+
+	// Compile the matcher
+	var matchName func(name string) ([]int, bool)
+	var re *regexp.Regexp
+	switch matchType {
+	case "glob":
+		g, err := glob.Compile(match)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		matchName = func(name string) ([]int, bool) {
+			return []int{0, len(name)}, g.Match(name)
+		}
+	case "regex":
+		re, err = regexp.Compile(match)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: Invalid regular expression: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		matchName = func(name string) ([]int, bool) {
+			loc := re.FindStringSubmatchIndex(name)
+			return loc, loc != nil
+		}
+	default:
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported match_type '%s' (expected 'glob' or 'regex')", matchType),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Handle empty or relative paths like "." or "./" by converting to absolute path
+	if path == "." || path == "./" {
+		// Get current working directory
+		cwd, err := os.Getwd()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error resolving current directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		path = cwd
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Check if it's a directory
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if !info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: Path is not a directory",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	entries, err := fs.fsys.ReadDir(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading directory: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Compute every target name before touching the filesystem
+	var mappings []renameMapping
+	today := time.Now().Format("2006-01-02")
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		loc, ok := matchName(name)
+		if !ok {
+			continue
+		}
+
+		newName := template
+		if re != nil {
+			newName = string(re.ExpandString(nil, template, name, loc))
+		}
+		newName = expandRenamePlaceholders(newName, name, today)
+		if newName == name {
+			continue
+		}
+
+		mapping := renameMapping{
+			OldName: name,
+			NewName: newName,
+			OldPath: filepath.Join(validPath, name),
+		}
+		if newName == "" || strings.ContainsAny(newName, `/\`) {
+			mapping.Conflict = "target name must be a plain file name"
+		} else if err := fs.authorize(mapping.OldPath, OpDelete); err != nil {
+			mapping.Conflict = err.Error()
+		} else if validNew, err := fs.validatePath(filepath.Join(validPath, newName)); err != nil {
+			mapping.Conflict = err.Error()
+		} else if err := fs.authorize(validNew, OpWrite); err != nil {
+			mapping.Conflict = err.Error()
+		} else if err := fs.authorizeTree(mapping.OldPath, OpDelete, validNew); err != nil {
+			mapping.Conflict = err.Error()
+		} else {
+			mapping.NewPath = validNew
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	if len(mappings) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("No files in %s match '%s'", path, match),
+				},
+			},
+		}, nil
+	}
+
+	conflicts := fs.detectRenameCollisions(mappings)
+
+	var result strings.Builder
+	if dryRun || conflicts > 0 {
+		if conflicts > 0 {
+			result.WriteString(fmt.Sprintf("Error: %d collision(s) detected, no files were renamed:\n\n", conflicts))
+		} else {
+			result.WriteString(fmt.Sprintf("Dry run: would rename %d file(s) in %s:\n\n", len(mappings), fs.displayPath(validPath)))
+		}
+		for _, m := range mappings {
+			if m.Conflict != "" {
+				result.WriteString(fmt.Sprintf("%s -> %s (%s)\n", m.OldName, m.NewName, m.Conflict))
+			} else {
+				result.WriteString(fmt.Sprintf("%s -> %s\n", m.OldName, m.NewName))
+			}
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: result.String(),
+				},
+			},
+			IsError: conflicts > 0,
+		}, nil
+	}
+
+	// Perform the renames, stopping at the first failure
+	for i, m := range mappings {
+		fs.invalidateCache(m.OldPath)
+		fs.invalidateCache(m.NewPath)
+		if err := fs.fsys.Rename(m.OldPath, m.NewPath); err != nil {
+			result.WriteString(fmt.Sprintf("Error renaming %s -> %s: %v\n", m.OldName, m.NewName, err))
+			result.WriteString(fmt.Sprintf("Renamed %d of %d file(s) before the failure:\n\n", i, len(mappings)))
+			for _, done := range mappings[:i] {
+				result.WriteString(fmt.Sprintf("%s -> %s\n", done.OldName, done.NewName))
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: result.String(),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	result.WriteString(fmt.Sprintf("Renamed %d file(s) in %s:\n\n", len(mappings), fs.displayPath(validPath)))
+	for _, m := range mappings {
+		result.WriteString(fmt.Sprintf("%s -> %s\n", m.OldName, m.NewName))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// expandRenamePlaceholders substitutes {name}, {ext}, {filename} and {date}
+// in a rename template
+func expandRenamePlaceholders(template, filename, date string) string {
+	ext := filepath.Ext(filename)
+	return strings.NewReplacer(
+		"{name}", strings.TrimSuffix(filename, ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{filename}", filename,
+		"{date}", date,
+	).Replace(template)
+}
+
+// detectRenameCollisions marks mappings whose target collides with another
+// target in the batch or with an existing file that is not being renamed,
+// and returns the number of mappings that cannot be performed
+func (fs *FilesystemHandler) detectRenameCollisions(mappings []renameMapping) int {
+	sources := make(map[string]bool, len(mappings))
+	for _, m := range mappings {
+		sources[m.OldPath] = true
+	}
+
+	targets := make(map[string]int, len(mappings))
+	for _, m := range mappings {
+		if m.NewPath != "" {
+			targets[m.NewPath]++
+		}
+	}
+
+	conflicts := 0
+	for i := range mappings {
+		m := &mappings[i]
+		if m.Conflict == "" {
+			if targets[m.NewPath] > 1 {
+				m.Conflict = "multiple files map to this name"
+			} else if _, err := fs.fsys.Lstat(m.NewPath); err == nil && !sources[m.NewPath] {
+				m.Conflict = "target already exists"
+			} else if sources[m.NewPath] {
+				m.Conflict = "target is renamed by the same batch"
+			}
+		}
+		if m.Conflict != "" {
+			conflicts++
+		}
+	}
+	return conflicts
+}
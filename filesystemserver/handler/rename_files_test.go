@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleRenameFiles(t *testing.T) {
+	setup := func(t *testing.T, names ...string) (string, *FilesystemHandler) {
+		dir := t.TempDir()
+		for _, name := range names {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+		}
+		handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+		require.NoError(t, err)
+		return dir, handler
+	}
+
+	rename := func(t *testing.T, handler *FilesystemHandler, args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "rename_files"
+		request.Params.Arguments = args
+		result, err := handler.HandleRenameFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("regex capture groups", func(t *testing.T) {
+		dir, handler := setup(t, "draft_a.txt", "draft_b.txt", "keep.txt")
+		result := rename(t, handler, map[string]any{
+			"path":       dir,
+			"match":      `^draft_(.*)$`,
+			"match_type": "regex",
+			"template":   "final_$1",
+		})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "draft_a.txt -> final_a.txt")
+		assert.FileExists(t, filepath.Join(dir, "final_a.txt"))
+		assert.FileExists(t, filepath.Join(dir, "final_b.txt"))
+		assert.FileExists(t, filepath.Join(dir, "keep.txt"))
+	})
+
+	t.Run("glob with placeholders", func(t *testing.T) {
+		dir, handler := setup(t, "a.md", "b.md")
+		result := rename(t, handler, map[string]any{
+			"path":     dir,
+			"match":    "*.md",
+			"template": "{name}.{ext}.bak",
+		})
+		require.False(t, result.IsError)
+		assert.FileExists(t, filepath.Join(dir, "a.md.bak"))
+		assert.FileExists(t, filepath.Join(dir, "b.md.bak"))
+	})
+
+	t.Run("dry run does not rename", func(t *testing.T) {
+		dir, handler := setup(t, "a.md")
+		result := rename(t, handler, map[string]any{
+			"path":     dir,
+			"match":    "*.md",
+			"template": "{name}.txt",
+			"dry_run":  true,
+		})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Dry run")
+		assert.FileExists(t, filepath.Join(dir, "a.md"))
+		assert.NoFileExists(t, filepath.Join(dir, "a.txt"))
+	})
+
+	t.Run("collisions abort the batch", func(t *testing.T) {
+		dir, handler := setup(t, "a.md", "b.md")
+		result := rename(t, handler, map[string]any{
+			"path":     dir,
+			"match":    "*.md",
+			"template": "same.md",
+		})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "collision")
+		assert.FileExists(t, filepath.Join(dir, "a.md"))
+		assert.FileExists(t, filepath.Join(dir, "b.md"))
+	})
+
+	t.Run("existing target is a collision", func(t *testing.T) {
+		dir, handler := setup(t, "a.md", "a.txt")
+		result := rename(t, handler, map[string]any{
+			"path":     dir,
+			"match":    "*.md",
+			"template": "{name}.txt",
+		})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "target already exists")
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func (fs *FilesystemHandler) HandleResolvePath(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle empty or relative paths like "." or "./" by converting to absolute
+	// path. In root-relative mode "." already names the root, and the working
+	// directory is not shown.
+	if (path == "." || path == "./") && !fs.rootRelative {
+		// Get current working directory
+		cwd, err := os.Getwd()
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error resolving current directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		path = cwd
+	}
+
+	abs, err := filepath.Abs(fs.fromRootRelative(path))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: invalid path: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Requested path: %s\n", path))
+	result.WriteString(fmt.Sprintf("Absolute path: %s\n", fs.displayPath(abs)))
+
+	// Run the path through the same validation used by every other tool
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		result.WriteString(fmt.Sprintf("Status: rejected\nReason: %v\n", err))
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: result.String(),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	exists := true
+	if _, err := fs.fsys.Lstat(validPath); os.IsNotExist(err) {
+		exists = false
+	}
+
+	result.WriteString(fmt.Sprintf("Resolved path: %s\n", fs.displayPath(validPath)))
+	result.WriteString(fmt.Sprintf("Allowed root: %s\n", fs.displayPath(fs.rootForPath(validPath))))
+	result.WriteString(fmt.Sprintf("Exists: %v\n", exists))
+	result.WriteString("Status: allowed\n")
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
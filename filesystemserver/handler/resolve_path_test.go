@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "file.txt"), []byte("x"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	resolve := func(path string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "resolve_path"
+		request.Params.Arguments = map[string]any{"path": path}
+		result, err := handler.HandleResolvePath(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("dot-dot segments are cleaned", func(t *testing.T) {
+		result := resolve(filepath.Join(dir, "sub", "..", "sub", "file.txt"))
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "file.txt")
+		assert.Contains(t, text, "Status: allowed")
+		assert.Contains(t, text, "Exists: true")
+	})
+
+	t.Run("new file reports that it does not exist", func(t *testing.T) {
+		result := resolve(filepath.Join(dir, "new.txt"))
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Exists: false")
+	})
+
+	t.Run("escaping path is rejected", func(t *testing.T) {
+		result := resolve(filepath.Join(dir, "..", filepath.Base(outside)))
+		require.True(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Status: rejected")
+		assert.Contains(t, text, "access denied")
+	})
+}
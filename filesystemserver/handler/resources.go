@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -16,6 +15,9 @@ func (fs *FilesystemHandler) HandleReadResource(
 	ctx context.Context,
 	request mcp.ReadResourceRequest,
 ) ([]mcp.ResourceContents, error) {
+	fs.configMu.RLock()
+	defer fs.configMu.RUnlock()
+
 	uri := request.Params.URI
 
 	// Check if it's a file:// URI
@@ -33,24 +35,27 @@ func (fs *FilesystemHandler) HandleReadResource(
 	}
 
 	// Get file info
-	fileInfo, err := os.Stat(validPath)
+	fileInfo, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return nil, err
 	}
 
 	// If it's a directory, return a listing
 	if fileInfo.IsDir() {
-		entries, err := os.ReadDir(validPath)
+		entries, err := fs.fsys.ReadDir(validPath)
 		if err != nil {
 			return nil, err
 		}
 
 		var result strings.Builder
-		result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", validPath))
+		result.WriteString(fmt.Sprintf("Directory listing for: %s\n\n", fs.displayPath(validPath)))
 
 		for _, entry := range entries {
 			entryPath := filepath.Join(validPath, entry.Name())
-			entryURI := pathToResourceURI(entryPath)
+			if fs.hiddenFromListings(entryPath) {
+				continue
+			}
+			entryURI := fs.resourceURI(entryPath)
 
 			if entry.IsDir() {
 				result.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", entry.Name(), entryURI))
@@ -75,7 +80,7 @@ func (fs *FilesystemHandler) HandleReadResource(
 	}
 
 	// It's a file, determine how to handle it
-	mimeType := detectMimeType(validPath)
+	mimeType := fs.detectMimeType(validPath)
 
 	// Check file size
 	if fileInfo.Size() > MAX_INLINE_SIZE {
@@ -90,7 +95,7 @@ func (fs *FilesystemHandler) HandleReadResource(
 	}
 
 	// Read the file content
-	content, err := os.ReadFile(validPath)
+	content, err := readFileFull(fs.fsys, validPath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// WithRootAccess sets the access mode of allowed directories. Each key is an
+// allowed directory or the @name of a root alias, and each value "ro" or
+// "rw". Below a read-only directory no path is authorized for OpWrite or
+// OpDelete, so every tool that would change it fails; directories without
+// an entry are writable. A writable directory nested in a read-only one
+// stays writable, as the most specific allowed directory decides. Read-only
+// mode, see WithReadOnly, overrides "rw".
+func WithRootAccess(modes map[string]string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.accessConfig = modes
+	}
+}
+
+// resolveRootAccess maps the configured access modes onto the normalized
+// allowed directories
+func (fs *FilesystemHandler) resolveRootAccess(configured []string) error {
+	if len(fs.accessConfig) == 0 {
+		return nil
+	}
+	readOnlyRoots := map[string]bool{}
+	for key, mode := range fs.accessConfig {
+		if mode != "ro" && mode != "rw" {
+			return fmt.Errorf("access mode for %s must be \"ro\" or \"rw\", got %q", key, mode)
+		}
+		var root string
+		if name, ok := strings.CutPrefix(key, "@"); ok {
+			if root, ok = fs.aliases[name]; !ok {
+				return fmt.Errorf("access mode for unknown alias %s", key)
+			}
+		} else {
+			var err error
+			if root, err = fs.allowedDirFor(configured, key); err != nil {
+				return fmt.Errorf("access mode: %w", err)
+			}
+			if root == "" {
+				fs.logger.Warn("Skipping access mode of an inaccessible allowed directory", "path", key)
+				continue
+			}
+		}
+		if mode == "ro" {
+			readOnlyRoots[strings.TrimSuffix(root, string(filepath.Separator))] = true
+		}
+	}
+	fs.readOnlyRoots = readOnlyRoots
+	return nil
+}
+
+// dirMode reports the access mode of an allowed directory, given without its
+// trailing separator
+func (fs *FilesystemHandler) dirMode(dir string) string {
+	if fs.readOnly || fs.readOnlyRoots[dir] {
+		return "read-only"
+	}
+	return "read-write"
+}
+
+// authorizeRootAccess refuses changes below a read-only allowed directory
+func (fs *FilesystemHandler) authorizeRootAccess(path string, op Operation) error {
+	if op == OpRead {
+		return nil
+	}
+	if fs.readOnly {
+		return errReadOnly
+	}
+	if root := fs.rootForPath(path); fs.readOnlyRoots[root] {
+		return fmt.Errorf("read_only - %s is read-only", fs.displayPath(root))
+	}
+	return nil
+}
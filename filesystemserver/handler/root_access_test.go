@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootAccess(t *testing.T) {
+	dirs := resolveAllowedDirs(t, t.TempDir(), t.TempDir())
+	docs, scratch := dirs[0], dirs[1]
+	guide := filepath.Join(docs, "guide.md")
+	require.NoError(t, os.WriteFile(guide, []byte("# Guide\n"), 0644))
+
+	handler, err := NewFilesystemHandler(dirs,
+		WithRootAliases(map[string]string{"docs": docs}),
+		WithRootAccess(map[string]string{"@docs": "ro", scratch: "rw"}))
+	require.NoError(t, err)
+
+	callTool := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("read-only directory can be read but not changed", func(t *testing.T) {
+		result := callTool(handler.HandleReadFile, map[string]any{"path": guide})
+		require.False(t, result.IsError)
+
+		result = callTool(handler.HandleWriteFile, map[string]any{"path": filepath.Join(docs, "new.md"), "content": "x"})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "read_only")
+		assert.NoFileExists(t, filepath.Join(docs, "new.md"))
+	})
+
+	t.Run("files cannot be moved out of a read-only directory", func(t *testing.T) {
+		result := callTool(handler.HandleMoveFile, map[string]any{"source": guide, "destination": filepath.Join(scratch, "guide.md")})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "read_only")
+		assert.FileExists(t, guide)
+	})
+
+	t.Run("writable directory accepts changes", func(t *testing.T) {
+		result := callTool(handler.HandleCopyFile, map[string]any{"source": guide, "destination": filepath.Join(scratch, "guide.md")})
+		require.False(t, result.IsError)
+		assert.FileExists(t, filepath.Join(scratch, "guide.md"))
+	})
+
+	t.Run("modes are listed", func(t *testing.T) {
+		text := callTool(handler.HandleListAllowedDirectories, nil).Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "[read-only]")
+		assert.Contains(t, text, "[read-write]")
+	})
+
+	t.Run("unknown modes are rejected", func(t *testing.T) {
+		_, err := NewFilesystemHandler(dirs, WithRootAccess(map[string]string{docs: "write"}))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DEFAULT_LOG_PATTERN matches the lines scan_log reports when no pattern is given
+const DEFAULT_LOG_PATTERN = `error|fatal|panic`
+
+// HandleScanLog reports the last lines of a log file that match a severity
+// pattern, each with a few lines of surrounding context, to answer "why did
+// this fail" without reading the whole log
+func (fs *FilesystemHandler) HandleScanLog(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	pattern := request.GetString("pattern", DEFAULT_LOG_PATTERN)
+	maxMatches := request.GetInt("max_matches", 20)
+	contextLines := request.GetInt("context_lines", 2)
+
+	if maxMatches < 1 || maxMatches > MAX_SEARCH_RESULTS {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: max_matches must be between 1 and %d", MAX_SEARCH_RESULTS),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if contextLines < 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: context_lines cannot be negative",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	match, err := compileLineMatcher(pattern, request.GetBool("fixed", false), request.GetBool("case_sensitive", false))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid regular expression: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("cannot scan a directory: %s", path)
+	} else if err == nil {
+		if mimeType := fs.detectMimeType(validPath); !isTextFile(mimeType) {
+			err = fmt.Errorf("not a text file (%s)", mimeType)
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// The first pass finds the matching lines, the second collects them along
+	// with their context, so that only the reported lines are kept in memory
+	var matches []int
+	total := 0
+	err = fs.scanLines(ctx, validPath, func(number int, line string) {
+		if match(line) {
+			total++
+			matches = append(matches, number)
+			if len(matches) > maxMatches {
+				matches = matches[1:]
+			}
+		}
+	})
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if len(matches) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("No lines matching %q in %s", pattern, fs.outputPath(ctx, validPath)),
+				},
+			},
+		}, nil
+	}
+
+	matched := make(map[int]bool, len(matches))
+	wanted := make(map[int]bool)
+	for _, number := range matches {
+		matched[number] = true
+		for n := number - contextLines; n <= number+contextLines; n++ {
+			wanted[n] = true
+		}
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf(
+		"Found %d matching line(s) in %s; showing the last %d with %d line(s) of context:\n",
+		total, fs.outputPath(ctx, validPath), len(matches), contextLines,
+	))
+	previous := 0
+	err = fs.scanLines(ctx, validPath, func(number int, line string) {
+		if !wanted[number] {
+			return
+		}
+		// Separate groups of lines that are not adjacent
+		if number != previous+1 {
+			result.WriteString("--\n")
+		}
+		previous = number
+		marker := " "
+		if matched[number] {
+			marker = ">"
+		}
+		result.WriteString(fmt.Sprintf("%s %d: %s\n", marker, number, line))
+	})
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// scanLines calls fn with each line of a file and its 1-based number, without
+// the line ending
+func (fs *FilesystemHandler) scanLines(ctx context.Context, path string, fn func(number int, line string)) error {
+	file, err := fs.fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for number := 1; ; number++ {
+		if number%10000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			fn(number, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// compileLineMatcher returns a function reporting whether a line matches
+// pattern. With fixed, pattern is a literal string found with strings.Contains
+// rather than a regular expression, so that characters such as . and ( need
+// no escaping and no regular expression is run.
+func compileLineMatcher(pattern string, fixed, caseSensitive bool) (func(string) bool, error) {
+	switch {
+	case fixed && caseSensitive:
+		return func(line string) bool { return strings.Contains(line, pattern) }, nil
+	case fixed:
+		lower := strings.ToLower(pattern)
+		return func(line string) bool { return strings.Contains(strings.ToLower(line), lower) }, nil
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
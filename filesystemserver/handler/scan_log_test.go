@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleScanLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build.log")
+	log := "compiling a\nERROR: first failure\ncompiling b\ncompiling c\ncompiling d\ncompiling e\nwarning: unused\npanic: boom\ngoroutine 1\nexit status 2\n"
+	require.NoError(t, os.WriteFile(path, []byte(log), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	scan := func(args map[string]any) *mcp.CallToolResult {
+		args["path"] = path
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleScanLog(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("default pattern with context", func(t *testing.T) {
+		result := scan(map[string]any{"context_lines": 1})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Found 2 matching line(s)")
+		assert.Contains(t, text, ":\n  1: compiling a\n> 2: ERROR: first failure\n  3: compiling b\n--\n")
+		assert.Contains(t, text, "  7: warning: unused\n> 8: panic: boom\n  9: goroutine 1\n")
+		assert.NotContains(t, text, "compiling d")
+	})
+
+	t.Run("only the last matches are shown", func(t *testing.T) {
+		result := scan(map[string]any{"max_matches": 1, "context_lines": 0})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Found 2 matching line(s)")
+		assert.Contains(t, text, "> 8: panic: boom\n")
+		assert.NotContains(t, text, "first failure")
+	})
+
+	t.Run("custom case-sensitive pattern", func(t *testing.T) {
+		result := scan(map[string]any{"pattern": "error", "case_sensitive": true})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No lines matching")
+
+		result = scan(map[string]any{"pattern": "^warning"})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "> 7: warning: unused")
+	})
+
+	t.Run("fixed string", func(t *testing.T) {
+		result := scan(map[string]any{"pattern": "status (2", "fixed": true})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No lines matching")
+
+		result = scan(map[string]any{"pattern": "Panic: b", "fixed": true, "context_lines": 0})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "> 8: panic: boom")
+
+		result = scan(map[string]any{"pattern": "(", "fixed": true})
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		assert.True(t, scan(map[string]any{"pattern": "("}).IsError)
+		assert.True(t, scan(map[string]any{"max_matches": 0}).IsError)
+		assert.True(t, scan(map[string]any{"context_lines": -1}).IsError)
+	})
+}
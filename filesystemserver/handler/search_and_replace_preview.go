@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// replacement is a single match of a find pattern and the text that would
+// replace it
+type replacement struct {
+	start, end int
+	text       string
+}
+
+// lineChange is a run of consecutive lines touched by one or more replacements
+type lineChange struct {
+	firstLine, lastLine int // 1-based, inclusive
+	before, after       string
+}
+
+// filePreview holds the changes a replace operation would make to one file
+type filePreview struct {
+	path         string
+	replacements int
+	changes      []lineChange
+}
+
+// HandleSearchAndReplacePreview reports, line by line, what a find and replace
+// would change in a file or in every matching file below a directory, using
+// the same matching rules as modify_file. No file is modified.
+func (fs *FilesystemHandler) HandleSearchAndReplacePreview(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	find, err := request.RequireString("find")
+	if err != nil {
+		return nil, err
+	}
+	replace, err := request.RequireString("replace")
+	if err != nil {
+		return nil, err
+	}
+	allOccurrences := request.GetBool("all_occurrences", true)
+	useRegex := request.GetBool("regex", false)
+	pattern := request.GetString("pattern", "*")
+
+	if find == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: find cannot be empty",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var re *regexp.Regexp
+	if useRegex {
+		re, err = regexp.Compile(find)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: Invalid regular expression: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	globPattern, err := glob.Compile(pattern)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// A single file is previewed as given; it is an error if it cannot be
+	// searched, whereas unsearchable files below a directory are skipped
+	var previews []filePreview
+	var truncated string
+	scanned := 0
+	if !info.IsDir() {
+		preview, err := fs.previewReplace(validPath, info, find, replace, re, allOccurrences)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		scanned = 1
+		if preview.replacements > 0 {
+			previews = append(previews, preview)
+		}
+	} else {
+		truncated, err = fs.walkTree(
+			ctx,
+			validPath,
+			func(walkPath string, entry os.FileInfo, err error) error {
+				if err != nil {
+					return nil // Skip errors and continue
+				}
+				if entry.IsDir() || !entry.Mode().IsRegular() || !globPattern.Match(entry.Name()) {
+					return nil
+				}
+				if _, err := fs.validatePath(walkPath); err != nil {
+					return nil // Skip invalid paths
+				}
+				preview, err := fs.previewReplace(walkPath, entry, find, replace, re, allOccurrences)
+				if err != nil {
+					return nil // Skip files that cannot be searched
+				}
+				scanned++
+				if preview.replacements > 0 {
+					previews = append(previews, preview)
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error walking directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	total := 0
+	for _, preview := range previews {
+		total += preview.replacements
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf(
+		"Preview: %d replacement(s) in %d of %d file(s). No files were modified.\n",
+		total, len(previews), scanned,
+	))
+
+	shown := 0
+	for _, preview := range previews {
+		if shown >= MAX_SEARCH_RESULTS {
+			result.WriteString(fmt.Sprintf("\nNote: preview limited to %d changed line groups.\n", MAX_SEARCH_RESULTS))
+			break
+		}
+		result.WriteString(fmt.Sprintf("\nFile: %s (%d replacement(s))\n", fs.outputPath(ctx, preview.path), preview.replacements))
+		for _, change := range preview.changes {
+			if shown >= MAX_SEARCH_RESULTS {
+				break
+			}
+			shown++
+			if change.firstLine == change.lastLine {
+				result.WriteString(fmt.Sprintf("  Line %d:\n", change.firstLine))
+			} else {
+				result.WriteString(fmt.Sprintf("  Lines %d-%d:\n", change.firstLine, change.lastLine))
+			}
+			writePrefixedLines(&result, "    - ", change.before)
+			writePrefixedLines(&result, "    + ", change.after)
+		}
+	}
+	result.WriteString(walkTruncatedNote(truncated))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// previewReplace computes the line-level changes that replacing find with
+// replace would make to a file. Files that are too large or not text are
+// rejected, as they are by search_within_files.
+func (fs *FilesystemHandler) previewReplace(
+	path string, info os.FileInfo, find, replace string, re *regexp.Regexp, all bool,
+) (filePreview, error) {
+	if info.IsDir() {
+		return filePreview{}, errors.New("path is a directory")
+	}
+	if info.Size() > MAX_SEARCHABLE_SIZE {
+		return filePreview{}, fmt.Errorf("file too large (%d bytes, limit %d bytes)", info.Size(), MAX_SEARCHABLE_SIZE)
+	}
+	if mimeType := fs.detectMimeType(path); !isTextFile(mimeType) {
+		return filePreview{}, fmt.Errorf("not a text file (%s)", mimeType)
+	}
+
+	content, err := readFileFull(fs.fsys, path)
+	if err != nil {
+		return filePreview{}, err
+	}
+
+	replacements := findReplacements(string(content), find, replace, re, all)
+	return filePreview{
+		path:         path,
+		replacements: len(replacements),
+		changes:      groupLineChanges(string(content), replacements),
+	}, nil
+}
+
+// findReplacements locates the matches that modify_file would replace, in
+// order, together with their replacement text. Regex replacements expand
+// $1-style references exactly as regexp.ReplaceAllString does.
+func findReplacements(content, find, replace string, re *regexp.Regexp, all bool) []replacement {
+	n := 1
+	if all {
+		n = -1
+	}
+
+	var replacements []replacement
+	if re != nil {
+		for _, match := range re.FindAllStringSubmatchIndex(content, n) {
+			text := string(re.ExpandString(nil, replace, content, match))
+			replacements = append(replacements, replacement{start: match[0], end: match[1], text: text})
+		}
+		return replacements
+	}
+
+	for offset := 0; offset <= len(content); {
+		index := strings.Index(content[offset:], find)
+		if index < 0 {
+			break
+		}
+		start := offset + index
+		replacements = append(replacements, replacement{start: start, end: start + len(find), text: replace})
+		if !all {
+			break
+		}
+		offset = start + len(find)
+	}
+	return replacements
+}
+
+// groupLineChanges merges replacements that touch the same lines and renders
+// the before and after text of each group of lines
+func groupLineChanges(content string, replacements []replacement) []lineChange {
+	// lineStarts[i] is the byte offset at which line i+1 begins
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset })
+	}
+	lineEnd := func(line int) int {
+		if line < len(lineStarts) {
+			return lineStarts[line] - 1 // Excluding the newline
+		}
+		return len(content)
+	}
+
+	var changes []lineChange
+	for i := 0; i < len(replacements); {
+		first := lineOf(replacements[i].start)
+		last := lineOf(max(replacements[i].start, replacements[i].end-1))
+
+		// Pull in following replacements that start on a line already covered
+		j := i + 1
+		for j < len(replacements) && lineOf(replacements[j].start) <= last {
+			last = max(last, lineOf(max(replacements[j].start, replacements[j].end-1)))
+			j++
+		}
+
+		spanStart, spanEnd := lineStarts[first-1], max(lineEnd(last), replacements[j-1].end)
+		var after strings.Builder
+		cursor := spanStart
+		for _, r := range replacements[i:j] {
+			after.WriteString(content[cursor:r.start])
+			after.WriteString(r.text)
+			cursor = r.end
+		}
+		after.WriteString(content[cursor:spanEnd])
+
+		changes = append(changes, lineChange{
+			firstLine: first,
+			lastLine:  last,
+			before:    content[spanStart:spanEnd],
+			after:     after.String(),
+		})
+		i = j
+	}
+	return changes
+}
+
+// writePrefixedLines writes each line of text to b, preceded by prefix
+func writePrefixedLines(b *strings.Builder, prefix, text string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		b.WriteString(prefix)
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteString("\n")
+	}
+}
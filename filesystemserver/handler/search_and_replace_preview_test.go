@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSearchAndReplacePreview(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	notes := filepath.Join(dir, "notes.txt")
+	original := "package main\n\nfunc oldName() {}\n\nvar a, b = oldName, oldName\n"
+	require.NoError(t, os.WriteFile(main, []byte(original), 0644))
+	require.NoError(t, os.WriteFile(notes, []byte("call oldName\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.bin"), []byte("oldName\x00\x01\x02"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	preview := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "search_and_replace_preview"
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchAndReplacePreview(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("directory with pattern", func(t *testing.T) {
+		result := preview(map[string]any{"path": dir, "find": "oldName", "replace": "newName", "pattern": "*.go"})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Preview: 3 replacement(s) in 1 of 1 file(s)")
+		assert.Contains(t, text, "  Line 3:\n    - func oldName() {}\n    + func newName() {}\n")
+		assert.Contains(t, text, "  Line 5:\n    - var a, b = oldName, oldName\n    + var a, b = newName, newName\n")
+		assert.NotContains(t, text, "notes.txt")
+
+		data, err := os.ReadFile(main)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(data))
+	})
+
+	t.Run("skips binary files", func(t *testing.T) {
+		result := preview(map[string]any{"path": dir, "find": "oldName", "replace": "newName"})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "in 2 of 2 file(s)")
+		assert.NotContains(t, text, "data.bin")
+	})
+
+	t.Run("regex spanning lines", func(t *testing.T) {
+		result := preview(map[string]any{"path": main, "find": `\{\}\n\nvar (\w+)`, "replace": "{}\nvar ${1}", "regex": true})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Preview: 1 replacement(s) in 1 of 1 file(s)")
+		assert.Contains(t, text, "  Lines 3-5:\n    - func oldName() {}\n    - \n    - var a, b = oldName, oldName\n    + func oldName() {}\n    + var a, b = oldName, oldName\n")
+	})
+
+	t.Run("first occurrence only", func(t *testing.T) {
+		result := preview(map[string]any{"path": main, "find": "oldName", "replace": "x", "all_occurrences": false})
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Preview: 1 replacement(s)")
+		assert.Contains(t, text, "  Line 3:")
+		assert.NotContains(t, text, "  Line 5:")
+	})
+
+	t.Run("binary file given directly", func(t *testing.T) {
+		result := preview(map[string]any{"path": filepath.Join(dir, "data.bin"), "find": "oldName", "replace": "x"})
+		assert.True(t, result.IsError)
+	})
+}
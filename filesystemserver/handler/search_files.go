@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"archive/zip"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gobwas/glob"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -24,6 +26,89 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 		return nil, err
 	}
 
+	// Extract optional type filters
+	opts := searchFilesOptions{maxResults: MAX_SEARCH_RESULTS}
+	if filesOnly, err := request.RequireBool("files_only"); err == nil {
+		opts.filesOnly = filesOnly
+	}
+	if dirsOnly, err := request.RequireBool("dirs_only"); err == nil {
+		opts.dirsOnly = dirsOnly
+	}
+	opts.searchArchives = request.GetBool("search_archives", false)
+	if opts.filesOnly && opts.dirsOnly {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: files_only and dirs_only cannot both be set",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Extract optional max_results parameter
+	if maxResultsArg, err := request.RequireFloat("max_results"); err == nil {
+		opts.maxResults = int(maxResultsArg)
+		if opts.maxResults <= 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: "Error: max_results must be positive",
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// Extract optional modification time and size filters
+	for _, arg := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"modified_after", &opts.modifiedAfter},
+		{"modified_before", &opts.modifiedBefore},
+	} {
+		value := request.GetString(arg.name, "")
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %s must be an RFC3339 timestamp: %v", arg.name, err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		*arg.target = t
+	}
+
+	opts.minSize, opts.maxSize = -1, -1
+	if minSize, err := request.RequireFloat("min_size"); err == nil {
+		opts.minSize = int64(minSize)
+	}
+	if maxSize, err := request.RequireFloat("max_size"); err == nil {
+		opts.maxSize = int64(maxSize)
+	}
+	if opts.minSize < -1 || opts.maxSize < -1 || (opts.maxSize >= 0 && opts.minSize > opts.maxSize) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: min_size and max_size must be non-negative and min_size must not exceed max_size",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
 		// Get current working directory
@@ -56,13 +141,13 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 	}
 
 	// Check if it's a directory
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error: %v", err),
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
@@ -81,7 +166,8 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 		}, nil
 	}
 
-	results, err := searchFiles(validPath, pattern, fs)
+	opts.ignore = fs.gitignoreFor(request, validPath)
+	results, limited, truncated, err := searchFiles(ctx, validPath, pattern, opts, fs)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -94,13 +180,20 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 			IsError: true,
 		}, nil
 	}
+	if request.GetBool("classify", false) {
+		fs.forEach(ctx, len(results), func(i int) {
+			if results[i].Type == "file" && archiveOf(results[i].Path) == results[i].Path {
+				results[i].Class = fs.classifyFile(results[i].Path)
+			}
+		})
+	}
 
 	if len(results) == 0 {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path),
+					Text: fmt.Sprintf("No files found matching pattern '%s' in %s", pattern, path) + walkTruncatedNote(truncated),
 				},
 			},
 		}, nil
@@ -111,20 +204,25 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 	formattedResults.WriteString(fmt.Sprintf("Found %d results:\n\n", len(results)))
 
 	for _, result := range results {
-		resourceURI := pathToResourceURI(result)
-		info, err := os.Stat(result)
-		if err == nil {
-			if info.IsDir() {
-				formattedResults.WriteString(fmt.Sprintf("[DIR]  %s (%s)\n", result, resourceURI))
-			} else {
-				formattedResults.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes\n",
-					result, resourceURI, info.Size()))
-			}
+		resourceURI := fs.resourceURI(archiveOf(result.Path))
+		if result.Type == "directory" {
+			formattedResults.WriteString(fmt.Sprintf("[DIR]  %s (%s) - modified %s\n",
+				fs.displayPath(result.Path), resourceURI, result.Modified.Format(time.RFC3339)))
+		} else if result.Class != "" {
+			formattedResults.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes, %s, modified %s\n",
+				fs.displayPath(result.Path), resourceURI, result.Size, result.Class, result.Modified.Format(time.RFC3339)))
 		} else {
-			formattedResults.WriteString(fmt.Sprintf("%s (%s)\n", result, resourceURI))
+			formattedResults.WriteString(fmt.Sprintf("[FILE] %s (%s) - %d bytes, modified %s\n",
+				fs.displayPath(result.Path), resourceURI, result.Size, result.Modified.Format(time.RFC3339)))
 		}
 	}
 
+	// If results were limited, note this in the output
+	if limited {
+		formattedResults.WriteString(fmt.Sprintf("\nNote: Results limited to %d matches. There may be more matches.", opts.maxResults))
+	}
+	formattedResults.WriteString(walkTruncatedNote(truncated))
+
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
@@ -135,30 +233,130 @@ func (fs *FilesystemHandler) HandleSearchFiles(
 	}, nil
 }
 
-func searchFiles(rootPath, pattern string, fs *FilesystemHandler) ([]string, error) {
-	var results []string
-	globPattern := glob.MustCompile(pattern)
+// searchFilesOptions holds the optional filters applied by searchFiles. Zero
+// times and negative sizes disable the corresponding filter. With
+// searchArchives the entries of zip archives are matched too.
+type searchFilesOptions struct {
+	filesOnly      bool
+	dirsOnly       bool
+	maxResults     int
+	searchArchives bool
+	ignore         *gitignore
+
+	modifiedAfter  time.Time
+	modifiedBefore time.Time
+	minSize        int64
+	maxSize        int64
+}
+
+// matches reports whether an entry passes the type, time and size filters.
+// Size filters only match files, since directories have no content size.
+func (o searchFilesOptions) matches(info os.FileInfo) bool {
+	if o.filesOnly && info.IsDir() {
+		return false
+	}
+	if o.dirsOnly && !info.IsDir() {
+		return false
+	}
+	if !o.modifiedAfter.IsZero() && !info.ModTime().After(o.modifiedAfter) {
+		return false
+	}
+	if !o.modifiedBefore.IsZero() && !info.ModTime().Before(o.modifiedBefore) {
+		return false
+	}
+	if o.minSize >= 0 || o.maxSize >= 0 {
+		if info.IsDir() {
+			return false
+		}
+		if o.minSize >= 0 && info.Size() < o.minSize {
+			return false
+		}
+		if o.maxSize >= 0 && info.Size() > o.maxSize {
+			return false
+		}
+	}
+	return true
+}
 
-	err := filepath.Walk(
+// searchFiles walks rootPath and returns the entries whose name matches the
+// glob pattern, recording type, size and modification time from the walk.
+// Matching entries of zip archives are reported as archive.zip::entry when
+// opts.searchArchives is set. limited is set if the search stopped at
+// opts.maxResults with entries left to look at, and truncated if the walk
+// limits cut it short.
+func searchFiles(ctx context.Context, rootPath, pattern string, opts searchFilesOptions, fs *FilesystemHandler) (results []FileMatch, limited bool, truncated string, err error) {
+	globPattern, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, false, "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	full := func() bool {
+		return opts.maxResults > 0 && len(results) >= opts.maxResults
+	}
+
+	truncated, err = fs.walkTreeIgnoring(
+		ctx,
 		rootPath,
+		opts.ignore,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return nil // Skip errors and continue
 			}
 
+			// Stop walking once we've reached the maximum number of results
+			if full() {
+				limited = true
+				return filepath.SkipAll
+			}
+
 			// Try to validate path
-			if _, err := fs.validatePath(path); err != nil {
+			validPath, err := fs.validatePath(path)
+			if err != nil {
 				return nil // Skip invalid paths
 			}
 
-			if globPattern.Match(info.Name()) {
-				results = append(results, path)
+			if opts.matches(info) && globPattern.Match(info.Name()) {
+				match := FileMatch{
+					Path:     path,
+					Type:     "file",
+					Modified: info.ModTime(),
+				}
+				if info.IsDir() {
+					match.Type = "directory"
+				} else {
+					match.Size = info.Size()
+				}
+				results = append(results, match)
+			}
+
+			// Archives that cannot be read are skipped like other errors
+			if opts.searchArchives && info.Mode().IsRegular() && isZipArchive(path) {
+				fs.walkZipArchive(validPath, func(entry *zip.File) bool {
+					if full() {
+						limited = true
+						return false
+					}
+					entryInfo := entry.FileInfo()
+					if opts.matches(entryInfo) && globPattern.Match(entryInfo.Name()) {
+						match := FileMatch{
+							Path:     archiveEntryPath(path, strings.TrimSuffix(entry.Name, "/")),
+							Type:     "file",
+							Modified: entryInfo.ModTime(),
+						}
+						if entryInfo.IsDir() {
+							match.Type = "directory"
+						} else {
+							match.Size = entryInfo.Size()
+						}
+						results = append(results, match)
+					}
+					return true
+				})
 			}
 			return nil
 		},
 	)
 	if err != nil {
-		return nil, err
+		return nil, false, "", err
 	}
-	return results, nil
+	return results, limited, truncated, nil
 }
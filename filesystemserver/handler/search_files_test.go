@@ -2,9 +2,11 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
@@ -75,3 +77,150 @@ func TestSearchFiles_Pattern(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchFiles_Filters(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte("hello"), 0644)
+		require.NoError(t, err)
+	}
+	err := os.MkdirAll(filepath.Join(dir, "d.txt"), 0755)
+	require.NoError(t, err)
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	search := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "search_files"
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchFiles(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("metadata is included", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": "a.txt"})
+		assert.Contains(t, text, "5 bytes, modified")
+	})
+
+	t.Run("files only", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": "*.txt", "files_only": true})
+		assert.Contains(t, text, "Found 3 results")
+		assert.NotContains(t, text, "[DIR]")
+	})
+
+	t.Run("dirs only", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": "*.txt", "dirs_only": true})
+		assert.Contains(t, text, "Found 1 results")
+		assert.Contains(t, text, "[DIR]")
+	})
+
+	t.Run("max results", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": "*.txt", "max_results": 2})
+		assert.Contains(t, text, "Found 2 results")
+		assert.Contains(t, text, "Results limited to 2 matches")
+	})
+
+	t.Run("exactly max results", func(t *testing.T) {
+		text := search(map[string]any{"path": dir, "pattern": "*.txt", "max_results": 4})
+		assert.Contains(t, text, "Found 4 results")
+		assert.NotContains(t, text, "Results limited")
+	})
+}
+
+func TestSearchFiles_TimeAndSizeFilters(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.js")
+	large := filepath.Join(dir, "large.js")
+	small := filepath.Join(dir, "small.js")
+	require.NoError(t, os.WriteFile(old, make([]byte, 2048), 0644))
+	require.NoError(t, os.WriteFile(large, make([]byte, 2048), 0644))
+	require.NoError(t, os.WriteFile(small, []byte("x"), 0644))
+
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(old, lastWeek, lastWeek))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	search := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "search_files"
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("recent large files", func(t *testing.T) {
+		result := search(map[string]any{
+			"path":           dir,
+			"pattern":        "*.js",
+			"modified_after": time.Now().Add(-24 * time.Hour).Format(time.RFC3339),
+			"min_size":       1024,
+		})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Found 1 results")
+		assert.Contains(t, text, "large.js")
+	})
+
+	t.Run("modified before and max size", func(t *testing.T) {
+		result := search(map[string]any{
+			"path":            dir,
+			"pattern":         "*",
+			"modified_before": time.Now().Add(-24 * time.Hour).Format(time.RFC3339),
+			"max_size":        4096,
+		})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Found 1 results")
+		assert.Contains(t, text, "old.js")
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		result := search(map[string]any{"path": dir, "pattern": "*", "modified_after": "yesterday"})
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestSearchFiles_WalkLimits(t *testing.T) {
+	// dir/a/b/c/deep.txt, plus ten files at the top level
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "c", "deep.txt"), nil, 0644))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", i)), nil, 0644))
+	}
+
+	search := func(opts ...Option) string {
+		handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), opts...)
+		require.NoError(t, err)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": dir, "pattern": "*.txt"}
+		result, err := handler.HandleSearchFiles(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("within the limits", func(t *testing.T) {
+		text := search()
+		assert.Contains(t, text, "Found 11 results")
+		assert.NotContains(t, text, "truncated")
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		text := search(WithWalkLimits(2, 0))
+		assert.Contains(t, text, "Found 10 results")
+		assert.NotContains(t, text, "deep.txt")
+		assert.Contains(t, text, "truncated: true (max_walk_depth limit of 2 reached)")
+	})
+
+	t.Run("entry limit", func(t *testing.T) {
+		text := search(WithWalkLimits(0, 5))
+		assert.Contains(t, text, "truncated: true (max_walk_entries limit of 5 reached)")
+	})
+}
@@ -1,13 +1,22 @@
 package handler
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -20,21 +29,36 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 	if err != nil {
 		return nil, err
 	}
-	substring, err := request.RequireString("substring")
-	if err != nil {
-		return nil, err
+	// Either a literal substring or a regular expression is searched for
+	substring := request.GetString("substring", "")
+	pattern := request.GetString("pattern", "")
+	if (substring == "") == (pattern == "") {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: exactly one of substring and pattern must be given",
+				},
+			},
+			IsError: true,
+		}, nil
 	}
-	if substring == "" {
+	matcher, err := newContentMatcher(substring, pattern, request.GetBool("case_insensitive", false))
+	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: "Error: substring cannot be empty",
+					Text: fmt.Sprintf("Error: Invalid pattern: %v", err),
 				},
 			},
 			IsError: true,
 		}, nil
 	}
+	query := substring
+	if pattern != "" {
+		query = pattern
+	}
 
 	// Extract optional depth parameter
 	maxDepth := 0 // 0 means unlimited
@@ -70,6 +94,47 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 		}
 	}
 
+	// Extract optional context parameters, like grep -B and -A
+	contextBefore := request.GetInt("context_before", 0)
+	contextAfter := request.GetInt("context_after", 0)
+	if contextBefore < 0 || contextBefore > MAX_CONTEXT_LINES || contextAfter < 0 || contextAfter > MAX_CONTEXT_LINES {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: context_before and context_after must be between 0 and %d", MAX_CONTEXT_LINES),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	searchArchives := request.GetBool("search_archives", false)
+	names, err := newListFilter(request.GetString("include", ""), request.GetString("exclude", ""))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	filter, err := parseContentFilter(request)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
 		// Get current working directory
@@ -101,9 +166,8 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 		}, nil
 	}
 
-	// Check if the path is a directory
-	info, err := os.Stat(validPath)
-	if err != nil {
+	// The path may be a single file, which is searched on its own
+	if _, err := fs.fsys.Stat(validPath); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -115,20 +179,9 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 		}, nil
 	}
 
-	if !info.IsDir() {
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: "Error: search path must be a directory",
-				},
-			},
-			IsError: true,
-		}, nil
-	}
-
 	// Perform the search
-	results, err := searchWithinFiles(validPath, substring, maxDepth, maxResults, fs)
+	results, truncated, indexNote, err := searchWithinFiles(ctx, validPath, matcher, maxDepth, maxResults, contextBefore, contextAfter, searchArchives,
+		request.GetBool("use_index", false), names, fs.gitignoreFor(request, validPath), filter, fs)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -146,7 +199,7 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("No occurrences of '%s' found in files under %s", substring, path),
+					Text: fmt.Sprintf("No occurrences of '%s' found in files under %s", query, fs.outputText(ctx, path)) + walkTruncatedNote(truncated) + filter.note() + indexNote,
 				},
 			},
 		}, nil
@@ -154,42 +207,31 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 
 	// Format search results
 	var formattedResults strings.Builder
-	formattedResults.WriteString(fmt.Sprintf("Found %d occurrences of '%s':\n\n", len(results), substring))
+	formattedResults.WriteString(fmt.Sprintf("Found %d occurrences of '%s':\n\n", len(results), query))
 
-	// Group results by file for easier readability
+	// Group results by file for easier readability, keeping the walk order
+	var filePaths []string
 	fileResultsMap := make(map[string][]SearchResult)
 	for _, result := range results {
+		if _, ok := fileResultsMap[result.FilePath]; !ok {
+			filePaths = append(filePaths, result.FilePath)
+		}
 		fileResultsMap[result.FilePath] = append(fileResultsMap[result.FilePath], result)
 	}
 
 	// Display results grouped by file
-	for filePath, fileResults := range fileResultsMap {
-		resourceURI := pathToResourceURI(filePath)
-		formattedResults.WriteString(fmt.Sprintf("File: %s (%s)\n", filePath, resourceURI))
-
+	for _, filePath := range filePaths {
+		fileResults := fileResultsMap[filePath]
+		resourceURI := fs.resourceURI(archiveOf(filePath))
+		formattedResults.WriteString(fmt.Sprintf("File: %s (%s)\n", fs.outputPath(ctx, filePath), resourceURI))
+
+		if contextBefore > 0 || contextAfter > 0 {
+			writeMatchesWithContext(&formattedResults, fileResults, matcher)
+			formattedResults.WriteString("\n")
+			continue
+		}
 		for _, result := range fileResults {
-			// Truncate line content if too long (keeping context around the match)
-			lineContent := result.LineContent
-			if len(lineContent) > 100 {
-				// Find the substring position
-				substrPos := strings.Index(strings.ToLower(lineContent), strings.ToLower(substring))
-
-				// Calculate start and end positions for context
-				contextStart := max(0, substrPos-30)
-				contextEnd := min(len(lineContent), substrPos+len(substring)+30)
-
-				if contextStart > 0 {
-					lineContent = "..." + lineContent[contextStart:contextEnd]
-				} else {
-					lineContent = lineContent[:contextEnd]
-				}
-
-				if contextEnd < len(result.LineContent) {
-					lineContent += "..."
-				}
-			}
-
-			formattedResults.WriteString(fmt.Sprintf("  Line %d: %s\n", result.LineNumber, lineContent))
+			formattedResults.WriteString(fmt.Sprintf("  Line %d: %s\n", result.LineNumber, shortenLine(result.LineContent, matcher.find(result.LineContent))))
 		}
 		formattedResults.WriteString("\n")
 	}
@@ -198,8 +240,27 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 	if len(results) >= maxResults {
 		formattedResults.WriteString(fmt.Sprintf("\nNote: Results limited to %d matches. There may be more occurrences.", maxResults))
 	}
+	formattedResults.WriteString(walkTruncatedNote(truncated))
+	formattedResults.WriteString(filter.note())
+	formattedResults.WriteString(indexNote)
+
+	// The matches are also returned as objects, for clients that process them
+	matches := make([]SearchMatch, len(results))
+	for i, result := range results {
+		matches[i] = SearchMatch{
+			Path:   fs.outputPath(ctx, result.FilePath),
+			URI:    result.ResourceURI,
+			Line:   result.LineNumber,
+			Column: result.Column,
+			Text:   result.LineContent,
+			Match:  result.Match,
+			Before: result.Before,
+			After:  result.After,
+		}
+	}
 
 	return &mcp.CallToolResult{
+		Result: mcp.Result{Meta: map[string]any{"matches": matches}},
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
@@ -209,33 +270,155 @@ func (fs *FilesystemHandler) HandleSearchWithinFiles(
 	}, nil
 }
 
-// searchWithinFiles searches for a substring within file contents
+// contentMatcher finds what a content search looks for in a line: a literal
+// substring or an RE2 regular expression, optionally ignoring case
+type contentMatcher struct {
+	literal string
+	re      *regexp.Regexp
+	// required is text that every matching line contains, which the content
+	// index looks up; it is empty for case-insensitive searches
+	required string
+}
+
+// newContentMatcher searches for substring, or for pattern if substring is
+// empty. Case-insensitive literal searches are turned into a quoted regular
+// expression.
+func newContentMatcher(substring, pattern string, ignoreCase bool) (*contentMatcher, error) {
+	if pattern == "" {
+		if !ignoreCase {
+			return &contentMatcher{literal: substring, required: substring}, nil
+		}
+		pattern = regexp.QuoteMeta(substring)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matcher := &contentMatcher{re: re}
+	if !ignoreCase {
+		matcher.required, _ = re.LiteralPrefix()
+	}
+	return matcher, nil
+}
+
+// find returns the start and end byte offsets of the first match in line, or
+// nil if line does not match
+func (m *contentMatcher) find(line string) []int {
+	if m.re != nil {
+		return m.re.FindStringIndex(line)
+	}
+	if i := strings.Index(line, m.literal); i >= 0 {
+		return []int{i, i + len(m.literal)}
+	}
+	return nil
+}
+
+// searchWithinFiles searches file contents for the lines matcher matches. The
+// walk collects the files to search, which are then searched concurrently; the
+// results keep the walk order. With searchArchives the entries of zip archives
+// are searched as well. Only files that names includes are searched, and
+// directories it excludes are not descended into; a rootPath naming a file is
+// searched regardless. Entries that ignore excludes are left out. Files that
+// filter skips are counted by it rather than searched. truncated is set if the
+// walk limits or a timeout_ms deadline cut the search short. With useIndex the
+// files are taken from the content index instead of the walk when it can
+// answer, and indexNote says so.
 func searchWithinFiles(
-	rootPath, substring string, maxDepth int, maxResults int, fs *FilesystemHandler,
-) ([]SearchResult, error) {
-	var results []SearchResult
+	ctx context.Context, rootPath string, matcher *contentMatcher, maxDepth int, maxResults int,
+	contextBefore, contextAfter int, searchArchives, useIndex bool, names *listFilter, ignore *gitignore, filter *contentFilter, fs *FilesystemHandler,
+) (results []SearchResult, truncated, indexNote string, err error) {
+	var files []string
+	if candidates, built, ok := fs.indexCandidates(rootPath, matcher.required); useIndex && !searchArchives && ok {
+		files = fs.indexedFilesToSearch(rootPath, candidates, maxDepth, names, ignore, filter)
+		indexNote = fmt.Sprintf("\nNote: searched %d files that the content index built at %s lists as candidates; files created or changed since then may be missed (leave out use_index to walk the tree).",
+			len(files), built.Format(time.RFC3339))
+	} else {
+		files, truncated, err = fs.walkFilesToSearch(ctx, rootPath, maxDepth, searchArchives, names, ignore, filter)
+		if err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	// Files are started in walk order, so once the files already searched
+	// hold maxResults matches, later files cannot contribute
+	matches := make([][]SearchResult, len(files))
+	var mu sync.Mutex
 	resultCount := 0
+	fs.forEach(ctx, len(files), func(i int) {
+		mu.Lock()
+		done := resultCount >= maxResults
+		mu.Unlock()
+		if done {
+			return
+		}
+
+		if searchArchives && isZipArchive(files[i]) {
+			matches[i] = fs.searchArchive(files[i], matcher, maxResults, contextBefore, contextAfter)
+		} else {
+			matches[i] = fs.searchFile(files[i], matcher, maxResults, contextBefore, contextAfter)
+		}
+
+		mu.Lock()
+		resultCount += len(matches[i])
+		mu.Unlock()
+	})
+	// Past a timeout_ms deadline the files searched so far are reported
+	if ctx.Err() != nil {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, "", "", ctx.Err()
+		}
+		truncated = callStoppedReason(ctx)
+	}
+
+	for _, fileMatches := range matches {
+		results = append(results, fileMatches...)
+	}
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results, truncated, indexNote, nil
+}
+
+// walkFilesToSearch walks rootPath for the files searchWithinFiles searches
+func (fs *FilesystemHandler) walkFilesToSearch(
+	ctx context.Context, rootPath string, maxDepth int, searchArchives bool, names *listFilter, ignore *gitignore, filter *contentFilter,
+) ([]string, string, error) {
+	var files []string
 	currentDepth := 0
 
 	// Walk the directory tree
-	err := filepath.Walk(
+	truncated, err := fs.walkTreeIgnoring(
+		ctx,
 		rootPath,
+		ignore,
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return nil // Skip errors and continue
 			}
 
-			// Check if we've reached the maximum number of results
-			if resultCount >= maxResults {
-				return filepath.SkipDir
-			}
-
 			// Try to validate path
 			validPath, err := fs.validatePath(path)
 			if err != nil {
 				return nil // Skip invalid paths
 			}
 
+			// Apply include and exclude to the path below the search root
+			if rel, err := filepath.Rel(rootPath, path); err == nil && rel != "." {
+				rel = filepath.ToSlash(rel)
+				if names.excluded(rel) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !info.IsDir() && !names.included(rel) {
+					return nil
+				}
+			}
+
 			// Skip directories, only search files
 			if info.IsDir() {
 				// Calculate depth for this directory
@@ -258,65 +441,247 @@ func searchWithinFiles(
 				return nil
 			}
 
-			// Skip files that are too large
-			if info.Size() > MAX_SEARCHABLE_SIZE {
+			// Archives are bounded by the size of their entries instead
+			if searchArchives && info.Mode().IsRegular() && isZipArchive(path) {
+				files = append(files, validPath)
 				return nil
 			}
 
-			// Determine MIME type and skip non-text files
-			mimeType := detectMimeType(validPath)
-			if !isTextFile(mimeType) {
+			// Skip files that are too large
+			if info.Size() > MAX_SEARCHABLE_SIZE || filter.skip(fs, validPath, info) {
 				return nil
 			}
+			files = append(files, validPath)
+			return nil
+		},
+	)
+	return files, truncated, err
+}
 
-			// Open the file and search for the substring
-			file, err := os.Open(validPath)
-			if err != nil {
-				return nil // Skip files that can't be opened
+// indexedFilesToSearch applies to the candidates of the content index what
+// walkFilesToSearch applies during the walk: the depth limit, include and
+// exclude, the deny list, the ignore files and filter. The candidates are
+// checked again since the configuration and the files may have changed since
+// the index was built.
+func (fs *FilesystemHandler) indexedFilesToSearch(rootPath string, candidates []string, maxDepth int, names *listFilter, ignore *gitignore, filter *contentFilter) []string {
+	var files []string
+	for _, path := range candidates {
+		if rel, err := filepath.Rel(rootPath, path); err == nil && rel != "." {
+			if maxDepth > 0 && strings.Count(rel, string(filepath.Separator)) >= maxDepth {
+				continue
 			}
-			defer file.Close()
-
-			// Create a scanner to read the file line by line
-			scanner := bufio.NewScanner(file)
-			lineNum := 0
-
-			// Scan each line
-			for scanner.Scan() {
-				lineNum++
-				line := scanner.Text()
-
-				// Check if the line contains the substring
-				if strings.Contains(line, substring) {
-					// Add to results
-					results = append(results, SearchResult{
-						FilePath:    validPath,
-						LineNumber:  lineNum,
-						LineContent: line,
-						ResourceURI: pathToResourceURI(validPath),
-					})
-					resultCount++
-
-					// Check if we've reached the maximum results
-					if resultCount >= maxResults {
-						return filepath.SkipDir
-					}
-				}
+			rel = filepath.ToSlash(rel)
+			if !names.included(rel) || names.excluded(rel) || slices.ContainsFunc(parentDirs(rel), names.excluded) {
+				continue
 			}
+		}
+		if fs.hiddenFromListings(path) || ignore.ignored(path, false) {
+			continue
+		}
+		validPath, err := fs.validatePath(path)
+		if err != nil {
+			continue
+		}
+		info, err := fs.fsys.Lstat(validPath)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > MAX_SEARCHABLE_SIZE || filter.skip(fs, validPath, info) {
+			continue
+		}
+		files = append(files, validPath)
+	}
+	return files
+}
 
-			// Check for scanner errors
-			if err := scanner.Err(); err != nil {
-				return nil // Skip files with scanning errors
-			}
+// parentDirs returns the directories above a slash-separated relative path,
+// outermost first
+func parentDirs(rel string) []string {
+	var dirs []string
+	for i, c := range rel {
+		if c == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+	return dirs
+}
 
-			return nil
-		},
-	)
+// searchFile returns up to maxResults lines of a text file that matcher
+// matches, each with up to contextBefore and contextAfter surrounding
+// lines. Files that are not text or cannot be read have no matches.
+func (fs *FilesystemHandler) searchFile(validPath string, matcher *contentMatcher, maxResults, contextBefore, contextAfter int) []SearchResult {
+	// Determine MIME type and skip non-text files
+	mimeType := fs.detectMimeType(validPath)
+	if !isTextFile(mimeType) {
+		return nil
+	}
 
+	// Open the file and search it
+	file, err := fs.fsys.Open(validPath)
 	if err != nil {
-		return nil, err
+		return nil // Skip files that can't be opened
 	}
+	defer file.Close()
 
-	return results, nil
+	return fs.searchReader(file, validPath, fs.resourceURI(validPath), matcher, maxResults, contextBefore, contextAfter)
+}
+
+// searchArchive searches the text entries of a zip archive like searchFile,
+// reporting matches under archive.zip::entry paths. Entries larger than
+// MAX_SEARCHABLE_SIZE and nested archives are skipped, and scanning stops
+// once MAX_ARCHIVE_SCAN_SIZE bytes have been decompressed.
+func (fs *FilesystemHandler) searchArchive(validPath string, matcher *contentMatcher, maxResults, contextBefore, contextAfter int) []SearchResult {
+	var results []SearchResult
+	var scanned int64
+	resourceURI := fs.resourceURI(validPath)
+	fs.walkZipArchive(validPath, func(entry *zip.File) bool {
+		if entry.FileInfo().IsDir() || isZipArchive(entry.Name) {
+			return true
+		}
+		data, err := readZipEntry(entry, min64(MAX_SEARCHABLE_SIZE, MAX_ARCHIVE_SCAN_SIZE-scanned))
+		if err != nil {
+			return true // Skip entries that are too large or corrupt
+		}
+		scanned += int64(len(data))
+		if isTextFile(mimetype.Detect(data).String()) {
+			entryPath := archiveEntryPath(validPath, entry.Name)
+			results = append(results, fs.searchReader(bytes.NewReader(data), entryPath, resourceURI, matcher, maxResults-len(results), contextBefore, contextAfter)...)
+		}
+		return len(results) < maxResults && scanned < MAX_ARCHIVE_SCAN_SIZE
+	})
+	return results
+}
+
+// searchReader returns up to maxResults lines read from r that matcher
+// matches, reported under filePath and resourceURI, with their context
+// lines
+func (fs *FilesystemHandler) searchReader(r io.Reader, filePath, resourceURI string, matcher *contentMatcher, maxResults, contextBefore, contextAfter int) []SearchResult {
+	// Create a scanner to read the input line by line
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	// previous holds the last contextBefore lines, and waiting the matches
+	// whose following lines are still being read
+	var previous []ContextLine
+	var waiting []int
+
+	// Scan each line
+	var results []SearchResult
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		for len(waiting) > 0 && len(results[waiting[0]].After) >= contextAfter {
+			waiting = waiting[1:]
+		}
+		for _, i := range waiting {
+			results[i].After = append(results[i].After, ContextLine{Number: lineNum, Text: line})
+		}
+
+		// Once maxResults is reached, only the context of the last matches
+		// is still read
+		if len(results) >= maxResults {
+			if len(waiting) == 0 {
+				break
+			}
+			continue
+		}
+
+		if loc := matcher.find(line); loc != nil {
+			results = append(results, SearchResult{
+				FilePath:    filePath,
+				LineNumber:  lineNum,
+				LineContent: line,
+				ResourceURI: resourceURI,
+				Column:      loc[0] + 1,
+				Match:       line[loc[0]:loc[1]],
+				Before:      slices.Clone(previous),
+			})
+			if contextAfter > 0 {
+				waiting = append(waiting, len(results)-1)
+			}
+			if len(results) >= maxResults && len(waiting) == 0 {
+				break
+			}
+		}
+
+		if contextBefore > 0 {
+			if len(previous) == contextBefore {
+				previous = previous[1:]
+			}
+			previous = append(previous, ContextLine{Number: lineNum, Text: line})
+		}
+	}
+
+	// Files with scanning errors keep the matches found before the error
+	return results
+}
+
+// shortenLine truncates a line longer than 100 bytes to the text around the
+// match at loc, as returned by contentMatcher.find, or to its start if loc is
+// nil
+func shortenLine(line string, loc []int) string {
+	if len(line) <= 100 {
+		return line
+	}
+
+	// Context lines need not contain a match
+	if loc == nil {
+		return line[:100] + "..."
+	}
+
+	// Calculate start and end positions for context
+	contextStart := max(0, loc[0]-30)
+	contextEnd := min(len(line), loc[1]+30)
+
+	shortened := line[:contextEnd]
+	if contextStart > 0 {
+		shortened = "..." + line[contextStart:contextEnd]
+	}
+	if contextEnd < len(line) {
+		shortened += "..."
+	}
+	return shortened
+}
+
+// writeMatchesWithContext writes the matches of one file with their context
+// lines like grep -C: matched lines are marked with '>' and a colon after the
+// line number, context lines with a dash, overlapping or adjacent contexts
+// are merged into one block and separate blocks are divided by "--"
+func writeMatchesWithContext(sb *strings.Builder, results []SearchResult, matcher *contentMatcher) {
+	type numberedLine struct {
+		text  string
+		match bool
+	}
+	lines := make(map[int]numberedLine)
+	var numbers []int
+	add := func(number int, text string, match bool) {
+		if existing, ok := lines[number]; ok {
+			match = match || existing.match
+		} else {
+			numbers = append(numbers, number)
+		}
+		lines[number] = numberedLine{text: text, match: match}
+	}
+	for _, result := range results {
+		for _, line := range result.Before {
+			add(line.Number, line.Text, false)
+		}
+		add(result.LineNumber, result.LineContent, true)
+		for _, line := range result.After {
+			add(line.Number, line.Text, false)
+		}
+	}
+	slices.Sort(numbers)
+
+	for i, number := range numbers {
+		if i > 0 && number > numbers[i-1]+1 {
+			sb.WriteString("  --\n")
+		}
+		line := lines[number]
+		if line.match {
+			sb.WriteString(fmt.Sprintf("> %d: %s\n", number, shortenLine(line.text, matcher.find(line.text))))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %d- %s\n", number, shortenLine(line.text, matcher.find(line.text))))
+		}
+	}
 }
 
 // Helper function since Go < 1.21 doesn't have min/max functions
@@ -334,3 +699,11 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// min64 is min for int64 values
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
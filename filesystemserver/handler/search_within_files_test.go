@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSearchWithinFiles_Context(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	content := "one\ntwo\nneedle three\nfour\nneedle five\nsix\nseven\neight\nnine\nneedle ten\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "haystack.txt"), []byte(content), 0644))
+
+	search := func(args map[string]any) (string, bool) {
+		args["path"] = dir
+		args["substring"] = "needle"
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchWithinFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("overlapping contexts are merged", func(t *testing.T) {
+		text, isError := search(map[string]any{"context_before": 1, "context_after": 1})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Found 3 occurrences")
+		assert.Contains(t, text, "  2- two\n> 3: needle three\n  4- four\n> 5: needle five\n  6- six\n  --\n  9- nine\n> 10: needle ten\n")
+	})
+
+	t.Run("context after the last match is read", func(t *testing.T) {
+		text, isError := search(map[string]any{"context_after": 2, "max_results": 1})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "> 3: needle three\n  4- four\n  5- needle five\n")
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		text, isError := search(map[string]any{})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "  Line 3: needle three\n  Line 5: needle five\n  Line 10: needle ten\n")
+	})
+
+	t.Run("context is bounded", func(t *testing.T) {
+		_, isError := search(map[string]any{"context_before": MAX_CONTEXT_LINES + 1})
+		assert.True(t, isError)
+	})
+}
+
+func TestHandleSearchWithinFiles_SkipFilters(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "small.txt"), []byte("needle\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "big.txt"), []byte("needle\n"+strings.Repeat("x", 100)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.txt"), []byte("needle\n\x00\x01"), 0644))
+
+	search := func(args map[string]any) (string, bool) {
+		args["path"] = dir
+		args["substring"] = "needle"
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchWithinFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("skipped files are counted by reason", func(t *testing.T) {
+		text, isError := search(map[string]any{"skip_binary": true, "skip_larger_than": 50})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "small.txt")
+		assert.NotContains(t, text, "File: "+filepath.Join(dir, "big.txt"))
+		assert.NotContains(t, text, "File: "+filepath.Join(dir, "blob.txt"))
+		assert.Contains(t, text, "Skipped 2 file(s): 1 binary (skip_binary), 1 larger than 50 bytes (skip_larger_than)")
+	})
+
+	t.Run("nothing is skipped by default", func(t *testing.T) {
+		text, isError := search(map[string]any{})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "big.txt")
+		assert.NotContains(t, text, "Skipped")
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		_, isError := search(map[string]any{"skip_larger_than": -1})
+		assert.True(t, isError)
+	})
+}
+
+func TestHandleSearchWithinFiles_IncludeExclude(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("func handler() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("the handler\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "lib", "lib.go"), []byte("func handler() {}\n"), 0644))
+
+	search := func(args map[string]any) string {
+		if _, ok := args["path"]; !ok {
+			args["path"] = dir
+		}
+		args["substring"] = "handler"
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchWithinFiles(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text
+	}
+
+	t.Run("include and exclude select the files", func(t *testing.T) {
+		text := search(map[string]any{"include": "*.go", "exclude": "vendor"})
+		assert.Contains(t, text, "Found 1 occurrences")
+		assert.Contains(t, text, "main.go")
+		assert.Contains(t, text, "Line 1: func handler() {}")
+	})
+
+	t.Run("a single file can be searched", func(t *testing.T) {
+		text := search(map[string]any{"path": filepath.Join(dir, "notes.md")})
+		assert.Contains(t, text, "Found 1 occurrences")
+		assert.Contains(t, text, "notes.md")
+	})
+}
+
+func TestHandleSearchWithinFiles_Pattern(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "server.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc ListHandler() {}\nfunc helper() {}\nfunc SaveHandler() {}\n"), 0644))
+
+	search := func(args map[string]any) *mcp.CallToolResult {
+		args["path"] = dir
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSearchWithinFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("regular expressions match", func(t *testing.T) {
+		result := search(map[string]any{"pattern": `func \w+Handler\(`, "context_before": 1})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Found 2 occurrences")
+
+		matches := result.Meta["matches"].([]SearchMatch)
+		require.Len(t, matches, 2)
+		assert.Equal(t, SearchMatch{
+			Path:   path,
+			URI:    "file://" + path,
+			Line:   3,
+			Column: 1,
+			Text:   "func ListHandler() {}",
+			Match:  "func ListHandler(",
+			Before: []ContextLine{{Number: 2, Text: ""}},
+		}, matches[0])
+		assert.Equal(t, 5, matches[1].Line)
+	})
+
+	t.Run("case can be ignored", func(t *testing.T) {
+		result := search(map[string]any{"substring": "HELPER", "case_insensitive": true})
+		matches := result.Meta["matches"].([]SearchMatch)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "helper", matches[0].Match)
+		assert.Equal(t, 6, matches[0].Column)
+
+		result = search(map[string]any{"substring": "HELPER"})
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "No occurrences")
+	})
+
+	t.Run("substring and pattern are exclusive", func(t *testing.T) {
+		assert.True(t, search(map[string]any{"substring": "a", "pattern": "b"}).IsError)
+		assert.True(t, search(map[string]any{}).IsError)
+		assert.True(t, search(map[string]any{"pattern": "("}).IsError)
+	})
+}
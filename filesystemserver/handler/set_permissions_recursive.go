@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// permissionChange is one entry of a set_permissions_recursive call
+type permissionChange struct {
+	path    string
+	isDir   bool
+	from    os.FileMode
+	to      os.FileMode
+	skipped string // reason the entry was left alone, if any
+	err     error
+}
+
+// HandleSetPermissionsRecursive applies file_mode to the regular files and
+// dir_mode to the directories of a tree, optionally only to the entries
+// matching pattern. Symbolic links and special files are never changed.
+// Directories are changed last, deepest first, so that a mode that removes
+// search permission does not cut the walk short.
+func (fs *FilesystemHandler) HandleSetPermissionsRecursive(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	dryRun := request.GetBool("dry_run", false)
+
+	if runtime.GOOS == "windows" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: unsupported - Windows has no Unix permission bits; set_permissions_recursive is not available on this server",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	fileMode, fileErr := parsePermission(request.GetString("file_mode", ""))
+	dirMode, dirErr := parsePermission(request.GetString("dir_mode", ""))
+	if err := errors.Join(fileErr, dirErr); err != nil || (fileMode == 0 && dirMode == 0) {
+		if err == nil {
+			err = fmt.Errorf("at least one of file_mode and dir_mode is required")
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var pattern glob.Glob
+	patternArg := request.GetString("pattern", "")
+	if patternArg != "" {
+		if pattern, err = glob.Compile(patternArg, '/'); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+	patternHasSlash := strings.Contains(patternArg, "/")
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
+	if err == nil {
+		_, err = fs.fsys.Stat(validPath)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var changes []permissionChange
+	truncated, err := fs.walkTree(
+		ctx,
+		validPath,
+		func(walkPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip errors and continue
+			}
+			if pattern != nil {
+				rel, err := filepath.Rel(validPath, walkPath)
+				if err != nil || !globMatch(pattern, patternHasSlash, filepath.ToSlash(rel)) {
+					return nil
+				}
+			}
+
+			change := permissionChange{path: walkPath, isDir: info.IsDir(), from: info.Mode().Perm()}
+			switch {
+			case info.IsDir():
+				change.to = dirMode
+			case info.Mode().IsRegular():
+				change.to = fileMode
+			case info.Mode()&os.ModeSymlink != 0:
+				change.skipped = "symbolic link"
+			default:
+				change.skipped = specialFileKind(info.Mode())
+			}
+			if change.skipped == "" && change.to == 0 {
+				return nil // No mode given for this kind of entry
+			}
+			if change.skipped == "" {
+				if validEntry, err := fs.validatePath(walkPath); err != nil {
+					change.skipped = err.Error()
+				} else if err := fs.authorize(validEntry, OpWrite); err != nil {
+					change.skipped = err.Error()
+				}
+			}
+			changes = append(changes, change)
+			return nil
+		},
+	)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error walking directory: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Files first, then directories from the deepest up
+	order := make([]int, 0, len(changes))
+	for i, change := range changes {
+		if !change.isDir {
+			order = append(order, i)
+		}
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].isDir {
+			order = append(order, i)
+		}
+	}
+	changed, failed := 0, 0
+	for _, i := range order {
+		change := &changes[i]
+		if change.skipped != "" || change.from == change.to {
+			continue
+		}
+		if !dryRun {
+			change.err = fs.fsys.Chmod(change.path, change.to)
+			fs.invalidateCache(change.path)
+		}
+		if change.err != nil {
+			failed++
+		} else {
+			changed++
+		}
+	}
+
+	var result strings.Builder
+	if dryRun {
+		result.WriteString(fmt.Sprintf("Dry run: %d of %d entries would change under %s:\n\n", changed, len(changes), fs.displayPath(validPath)))
+	} else {
+		result.WriteString(fmt.Sprintf("Changed %d of %d entries under %s:\n\n", changed, len(changes), fs.displayPath(validPath)))
+	}
+	listed := changes
+	if len(listed) > MAX_SEARCH_RESULTS {
+		listed = listed[:MAX_SEARCH_RESULTS]
+	}
+	for _, change := range listed {
+		switch {
+		case change.skipped != "":
+			result.WriteString(fmt.Sprintf("[SKIPPED]   %s (%s)\n", fs.displayPath(change.path), change.skipped))
+		case change.err != nil:
+			result.WriteString(fmt.Sprintf("[FAILED]    %s (%v)\n", fs.displayPath(change.path), change.err))
+		case change.from != change.to:
+			result.WriteString(fmt.Sprintf("[CHANGED]   %s %04o -> %04o\n", fs.displayPath(change.path), change.from, change.to))
+		default:
+			result.WriteString(fmt.Sprintf("[UNCHANGED] %s %04o\n", fs.displayPath(change.path), change.from))
+		}
+	}
+	if len(changes) > len(listed) {
+		result.WriteString(fmt.Sprintf("\nNote: Listing limited to %d entries.\n", MAX_SEARCH_RESULTS))
+	}
+	result.WriteString(walkTruncatedNote(truncated))
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+		IsError: failed > 0,
+	}, nil
+}
+
+// parsePermission parses an octal permission such as "0755". An empty
+// string yields 0, meaning no permission was given.
+func parsePermission(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode '%s' (expected an octal permission such as '0755')", s)
+	}
+	return os.FileMode(mode), nil
+}
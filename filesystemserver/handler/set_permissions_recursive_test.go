@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSetPermissionsRecursive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	tree := filepath.Join(dir, "tree")
+	require.NoError(t, os.MkdirAll(filepath.Join(tree, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "a.sh"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "sub", "b.txt"), []byte("b"), 0644))
+	require.NoError(t, os.Symlink("a.sh", filepath.Join(tree, "link")))
+
+	set := func(args map[string]any) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSetPermissionsRecursive(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+	mode := func(path string) os.FileMode {
+		info, err := os.Lstat(path)
+		require.NoError(t, err)
+		return info.Mode().Perm()
+	}
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		text, isError := set(map[string]any{"path": tree, "file_mode": "0600", "dry_run": true})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Dry run: 2 of 3 entries would change")
+		assert.Equal(t, os.FileMode(0644), mode(filepath.Join(tree, "a.sh")))
+	})
+
+	t.Run("files and directories get their own modes", func(t *testing.T) {
+		text, isError := set(map[string]any{"path": tree, "file_mode": "0600", "dir_mode": "0700"})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "[SKIPPED]")
+		assert.Equal(t, os.FileMode(0600), mode(filepath.Join(tree, "sub", "b.txt")))
+		assert.Equal(t, os.FileMode(0700), mode(filepath.Join(tree, "sub")))
+		assert.Equal(t, os.FileMode(0700), mode(tree))
+	})
+
+	t.Run("pattern limits the change", func(t *testing.T) {
+		text, isError := set(map[string]any{"path": tree, "file_mode": "0755", "pattern": "*.sh"})
+		require.False(t, isError, text)
+		assert.Equal(t, os.FileMode(0755), mode(filepath.Join(tree, "a.sh")))
+		assert.Equal(t, os.FileMode(0600), mode(filepath.Join(tree, "sub", "b.txt")))
+	})
+
+	t.Run("invalid modes", func(t *testing.T) {
+		text, isError := set(map[string]any{"path": tree, "file_mode": "0999"})
+		assert.True(t, isError)
+		assert.Contains(t, text, "invalid mode")
+
+		text, isError = set(map[string]any{"path": tree})
+		assert.True(t, isError)
+		assert.Contains(t, text, "at least one of file_mode and dir_mode")
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+)
+
+// shortIOError reports a read or write that transferred fewer bytes than
+// expected without the file system returning an error, as flaky network
+// mounts sometimes do. The operation can be retried.
+func shortIOError(op, name string, got, want int64) error {
+	return fmt.Errorf("short_io - %s of %s transferred %d of %d bytes; the call can be retried", op, name, got, want)
+}
+
+// checkedWriter counts the bytes written through it to the named file and
+// turns a write that reports fewer bytes than it was given, but no error,
+// into a short_io error
+type checkedWriter struct {
+	w    io.Writer
+	name string
+	n    int64
+}
+
+func (c *checkedWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if err == nil && n < len(p) {
+		err = shortIOError("write", c.name, c.n, c.n-int64(n)+int64(len(p)))
+	}
+	return n, err
+}
+
+// readFileFull reads the named file like ReadFile and fails with a short_io
+// error if fewer bytes were read than its size. Files that report a size of
+// zero, such as those under /proc, are read as they are.
+func readFileFull(fsys FileSystem, name string) ([]byte, error) {
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().IsRegular() && int64(len(data)) < info.Size() {
+		return nil, shortIOError("read", name, int64(len(data)), info.Size())
+	}
+	return data, nil
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shortFileSystem drops the last byte of every read and write without
+// reporting an error, like a flaky network mount
+type shortFileSystem struct {
+	*MemFileSystem
+}
+
+func (s *shortFileSystem) ReadFile(name string) ([]byte, error) {
+	data, err := s.MemFileSystem.ReadFile(name)
+	if len(data) > 0 {
+		data = data[:len(data)-1]
+	}
+	return data, err
+}
+
+func (s *shortFileSystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := s.MemFileSystem.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &shortFile{File: f}, nil
+}
+
+type shortFile struct {
+	File
+}
+
+func (f *shortFile) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return f.File.Write(p[:len(p)-1])
+}
+
+func TestShortIO(t *testing.T) {
+	mem := NewMemFileSystem()
+	root := filepath.Join(filepath.VolumeName(os.TempDir())+string(filepath.Separator), "data")
+	require.NoError(t, mem.MkdirAll(root, 0755))
+	require.NoError(t, mem.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{root}, WithFileSystem(&shortFileSystem{mem}))
+	require.NoError(t, err)
+
+	t.Run("read", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(root, "a.txt")}
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "short_io - read")
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "4 of 5 bytes")
+	})
+
+	t.Run("write", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(root, "b.txt"), "content": "world"}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "short_io - write")
+	})
+
+	t.Run("atomic write", func(t *testing.T) {
+		name := filepath.Join(root, "a.txt")
+		err := writeFileAtomic(handler.fsys, name, []byte("replaced"), 0644)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "short_io")
+
+		content, err := mem.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+	})
+}
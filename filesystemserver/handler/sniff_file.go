@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleSniffFile reads only the first bytes of a file and reports the MIME
+// type detected from them and whether they look binary, as the cheapest way
+// to find out what a file is before reading it
+func (fs *FilesystemHandler) HandleSniffFile(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	size := request.GetInt("bytes", DEFAULT_SNIFF_SIZE)
+	if size < 1 || size > MAX_SNIFF_SIZE {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: bytes must be between 1 and %d", MAX_SNIFF_SIZE),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.refuseSpecialFile(validPath); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	f, err := fs.fsys.Open(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error opening file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: path is not a readable file",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	buf := make([]byte, size)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	buf = buf[:n]
+
+	mimeType := mimetype.Detect(buf).String()
+	magic := buf[:min(len(buf), 16)]
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf(
+					"Sniffed %s: first %d of %d bytes\nmime_type: %s\nbinary: %v\nmagic: % x",
+					fs.outputPath(ctx, validPath),
+					n,
+					info.Size(),
+					mimeType,
+					bytes.IndexByte(buf, 0) >= 0,
+					magic,
+				),
+			},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.BlobResourceContents{
+					URI:      fs.resourceURI(validPath),
+					MIMEType: mimeType,
+					Blob:     base64.StdEncoding.EncodeToString(buf),
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSniffFile(t *testing.T) {
+	dir := t.TempDir()
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 1024)...)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "image.dat"), png, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello world\n"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	sniff := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleSniffFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("binary file", func(t *testing.T) {
+		result := sniff(map[string]any{"path": filepath.Join(dir, "image.dat")})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "first 512 of 1040 bytes")
+		assert.Contains(t, text, "mime_type: image/png")
+		assert.Contains(t, text, "binary: true")
+		assert.Contains(t, text, "magic: 89 50 4e 47 0d 0a 1a 0a")
+
+		blob := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.BlobResourceContents)
+		data, err := base64.StdEncoding.DecodeString(blob.Blob)
+		require.NoError(t, err)
+		assert.Equal(t, png[:512], data)
+	})
+
+	t.Run("short text file", func(t *testing.T) {
+		result := sniff(map[string]any{"path": filepath.Join(dir, "notes.txt"), "bytes": 4})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "first 4 of 12 bytes")
+		assert.Contains(t, text, "binary: false")
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		assert.True(t, sniff(map[string]any{"path": filepath.Join(dir, "notes.txt"), "bytes": 0}).IsError)
+	})
+}
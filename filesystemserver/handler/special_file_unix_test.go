@@ -0,0 +1,64 @@
+//go:build unix
+
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecialFilesAreRefused(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	fifo := filepath.Join(dir, "pipe")
+	require.NoError(t, syscall.Mkfifo(fifo, 0644))
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	for name, handle := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"read_file":       handler.HandleReadFile,
+		"read_file_chunk": handler.HandleReadFileChunk,
+		"sniff_file":      handler.HandleSniffFile,
+		"write_file":      handler.HandleWriteFile,
+	} {
+		t.Run(name, func(t *testing.T) {
+			result := call(handle, map[string]any{"path": fifo, "content": "x"})
+			require.True(t, result.IsError)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "not_a_regular_file")
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "named pipe")
+		})
+	}
+
+	t.Run("copy_file source", func(t *testing.T) {
+		result := call(handler.HandleCopyFile, map[string]any{"source": fifo, "destination": filepath.Join(dir, "copy")})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "not_a_regular_file")
+	})
+
+	t.Run("allow_special", func(t *testing.T) {
+		done := make(chan *mcp.CallToolResult)
+		go func() {
+			done <- call(handler.HandleReadFile, map[string]any{"path": fifo, "allow_special": true})
+		}()
+		result := call(handler.HandleWriteFile, map[string]any{"path": fifo, "content": "through the pipe", "allow_special": true})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		read := <-done
+		require.False(t, read.IsError, read.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, "through the pipe", read.Content[0].(mcp.TextContent).Text)
+	})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandleStatMultiple reports the metadata of several paths at once. Each path
+// gets its own entry; a missing or invalid path does not fail the batch.
+func (fs *FilesystemHandler) HandleStatMultiple(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	paths, err := request.RequireStringSlice("paths")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: No paths specified",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if len(paths) > MAX_SEARCH_RESULTS {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Too many paths requested. Maximum is %d paths per request.", MAX_SEARCH_RESULTS),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	stats := make([]PathStat, len(paths))
+	fs.forEach(ctx, len(paths), func(i int) {
+		stats[i] = fs.statPath(paths[i])
+	})
+
+	jsonData, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error generating JSON: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(jsonData),
+			},
+		},
+	}, nil
+}
+
+// statPath describes a single path for stat_multiple. Paths below missing
+// directories are reported as not existing rather than as errors.
+func (fs *FilesystemHandler) statPath(path string) PathStat {
+	stat := PathStat{Path: path}
+
+	validPath, err := fs.validatePathWithParents(path)
+	if err != nil {
+		stat.Error = err.Error()
+		return stat
+	}
+	info, err := fs.fsys.Stat(validPath)
+	if os.IsNotExist(err) {
+		return stat
+	}
+	if err != nil {
+		stat.Error = err.Error()
+		return stat
+	}
+
+	size, modified := info.Size(), info.ModTime()
+	stat.Exists, stat.Size, stat.Modified = true, &size, &modified
+	switch {
+	case info.IsDir():
+		stat.Type = "directory"
+	case info.Mode().IsRegular():
+		stat.Type = "file"
+	default:
+		stat.Type = "other"
+	}
+	return stat
+}
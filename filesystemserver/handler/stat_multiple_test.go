@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleStatMultiple(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"paths": []any{
+			filepath.Join(dir, "a.txt"),
+			filepath.Join(dir, "sub"),
+			filepath.Join(dir, "missing", "deep.txt"),
+			"/etc/passwd",
+		},
+	}
+	result, err := handler.HandleStatMultiple(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	var stats []PathStat
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &stats))
+	require.Len(t, stats, 4)
+
+	assert.True(t, stats[0].Exists)
+	assert.Equal(t, "file", stats[0].Type)
+	require.NotNil(t, stats[0].Size)
+	assert.Equal(t, int64(5), *stats[0].Size)
+	assert.NotNil(t, stats[0].Modified)
+
+	assert.True(t, stats[1].Exists)
+	assert.Equal(t, "directory", stats[1].Type)
+
+	assert.False(t, stats[2].Exists)
+	assert.Empty(t, stats[2].Error)
+	assert.Nil(t, stats[2].Size)
+
+	assert.False(t, stats[3].Exists)
+	assert.Contains(t, stats[3].Error, "access denied")
+}
@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errPrivilegeNotHeld is ERROR_PRIVILEGE_NOT_HELD, returned on Windows when
+// the process may not create symbolic links
+const errPrivilegeNotHeld = syscall.Errno(1314)
+
+// errNotSameDevice is ERROR_NOT_SAME_DEVICE, the Windows counterpart of
+// EXDEV for a hard link across volumes
+const errNotSameDevice = syscall.Errno(17)
+
+// HandleCreateSymlink creates a symbolic link at link_path pointing to
+// target. The target is resolved relative to the link's directory and must
+// stay within the allowed directories.
+func (fs *FilesystemHandler) HandleCreateSymlink(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	target, err := request.RequireString("target")
+	if err != nil {
+		return nil, err
+	}
+	linkPath, err := request.RequireString("link_path")
+	if err != nil {
+		return nil, err
+	}
+
+	validLink, err := fs.validateLinkPath(linkPath)
+	if err == nil {
+		err = fs.authorize(validLink, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error with link path: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if _, err := fs.fsys.Lstat(validLink); err == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: link path already exists: %s", linkPath),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Absolute targets are client paths and must be mapped onto the real
+	// filesystem; relative targets are stored as given
+	linkTarget := target
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		linkTarget, err = filepath.Abs(fs.fromRootRelative(target))
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: invalid target: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// The target must resolve inside the allowed directories, following any
+	// symlinks it already passes through
+	resolvedTarget := linkTarget
+	if !filepath.IsAbs(resolvedTarget) {
+		resolvedTarget = filepath.Join(filepath.Dir(validLink), linkTarget)
+	}
+	if _, err := fs.validatePath(resolvedTarget); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: link target escapes the allowed directories: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.fsys.Symlink(linkTarget, validLink); err != nil {
+		message := fmt.Sprintf("Error creating symlink: %v", err)
+		if runtime.GOOS == "windows" && errors.Is(err, errPrivilegeNotHeld) {
+			message = "Error creating symlink: Windows requires Developer Mode or the SeCreateSymbolicLinkPrivilege privilege to create symbolic links"
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: message,
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	resourceURI := fs.resourceURI(validLink)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Created symlink %s -> %s", linkPath, target),
+			},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.TextResourceContents{
+					URI:      resourceURI,
+					MIMEType: "text/plain",
+					Text:     fmt.Sprintf("Symlink: %s", fs.displayPath(validLink)),
+				},
+			},
+		},
+	}, nil
+}
+
+// HandleCreateHardlink creates a hard link at link_path to the existing
+// regular file target. Both must be within the allowed directories, and
+// since a hard link is another name for the same data, writable there.
+func (fs *FilesystemHandler) HandleCreateHardlink(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	target, err := request.RequireString("target")
+	if err != nil {
+		return nil, err
+	}
+	linkPath, err := request.RequireString("link_path")
+	if err != nil {
+		return nil, err
+	}
+
+	validTarget, err := fs.validatePath(target)
+	if err == nil {
+		err = fs.authorize(validTarget, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error with target: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validTarget)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("target does not exist: %s", target)
+	case info.IsDir():
+		err = fmt.Errorf("cannot hard link a directory: %s", target)
+	default:
+		err = fs.refuseSpecialFile(validTarget)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validLink, err := fs.validateLinkPath(linkPath)
+	if err == nil {
+		err = fs.authorize(validLink, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error with link path: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if _, err := fs.fsys.Lstat(validLink); err == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: link path already exists: %s", linkPath),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if err := fs.fsys.Link(validTarget, validLink); err != nil {
+		message := fmt.Sprintf("Error creating hard link: %v", err)
+		if errors.Is(err, syscall.EXDEV) || (runtime.GOOS == "windows" && errors.Is(err, errNotSameDevice)) {
+			message = fmt.Sprintf("Error: cross_device - %s and %s are on different filesystems, and a hard link cannot span filesystems; use copy_file instead", target, linkPath)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: message,
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	resourceURI := fs.resourceURI(validLink)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Created hard link %s => %s (%d bytes)", linkPath, target, info.Size()),
+			},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.TextResourceContents{
+					URI:      resourceURI,
+					MIMEType: "text/plain",
+					Text:     fmt.Sprintf("File: %s (%d bytes)", fs.displayPath(validLink), info.Size()),
+				},
+			},
+		},
+	}, nil
+}
+
+// HandleReadSymlink reports where an existing symbolic link points
+func (fs *FilesystemHandler) HandleReadSymlink(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+
+	validLink, err := fs.validateLinkPath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Lstat(validLink)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %s is not a symbolic link", path),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	target, err := fs.fsys.Readlink(validLink)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading symlink: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Link: %s\n", fs.displayPath(validLink)))
+
+	result.WriteString(fmt.Sprintf("Target: %s\n", fs.displayLinkTarget(target)))
+
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(validLink), target)
+	}
+	if realTarget, err := fs.validatePath(resolved); err != nil {
+		result.WriteString(fmt.Sprintf("Resolved: not accessible (%v)\n", err))
+	} else if _, err := fs.fsys.Stat(realTarget); err != nil {
+		result.WriteString(fmt.Sprintf("Resolved: %s (dangling)\n", fs.displayPath(realTarget)))
+	} else {
+		result.WriteString(fmt.Sprintf("Resolved: %s\n", fs.displayPath(realTarget)))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// displayLinkTarget formats a raw symlink target for output. When host paths
+// are hidden, absolute targets are only revealed if they are inside the
+// allowed directories.
+func (fs *FilesystemHandler) displayLinkTarget(target string) string {
+	if !fs.rootRelative || !filepath.IsAbs(target) {
+		return target
+	}
+	if fs.rootForPath(target) == "" {
+		return "(outside allowed directories)"
+	}
+	return fs.displayPath(target)
+}
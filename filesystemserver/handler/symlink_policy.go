@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+)
+
+// SymlinkPolicy decides how symbolic links that resolve outside the allowed
+// directories are treated
+type SymlinkPolicy string
+
+const (
+	// SymlinkMetadata lets clients see and inspect escaping links, with
+	// get_file_info or read_symlink, but never follows them. This is the
+	// default.
+	SymlinkMetadata SymlinkPolicy = "metadata"
+	// SymlinkReject hides escaping links completely: they are left out of
+	// listings and cannot even be inspected, for multi-tenant servers
+	SymlinkReject SymlinkPolicy = "reject"
+	// SymlinkFollow follows escaping links as if their targets lay within
+	// the allowed directory holding the link, for local monorepos with
+	// vendored symlinks. Only the link has to lie within an allowed
+	// directory, so a link to / exposes the whole filesystem.
+	SymlinkFollow SymlinkPolicy = "follow"
+)
+
+// WithSymlinkPolicy sets how symbolic links that resolve outside the
+// allowed directories are treated; see SymlinkPolicy
+func WithSymlinkPolicy(policy SymlinkPolicy) Option {
+	return func(fs *FilesystemHandler) {
+		fs.symlinkPolicy = policy
+	}
+}
+
+// validateSymlinkPolicy rejects an unknown policy
+func (fs *FilesystemHandler) validateSymlinkPolicy() error {
+	switch fs.symlinkPolicy {
+	case SymlinkMetadata, SymlinkReject, SymlinkFollow:
+		return nil
+	}
+	return fmt.Errorf("unknown symlink policy %q (expected metadata, reject or follow)", fs.symlinkPolicy)
+}
+
+// escapingLink reports whether path is a symbolic link that resolves
+// outside the allowed directories. Dangling links do not escape.
+func (fs *FilesystemHandler) escapingLink(path string) bool {
+	info, err := fs.fsys.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := fs.fsys.EvalSymlinks(path)
+	return err == nil && !fs.isPathInAllowedDirs(target)
+}
+
+// hiddenFromListings reports whether a walk or listing leaves path out: it
+// is on the deny list or, under SymlinkReject, an escaping link
+func (fs *FilesystemHandler) hiddenFromListings(path string) bool {
+	if fs.isDenied(path) {
+		return true
+	}
+	return fs.symlinkPolicy == SymlinkReject && fs.escapingLink(path)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymlinkPolicy(t *testing.T) {
+	dirs := resolveAllowedDirs(t, t.TempDir(), t.TempDir())
+	dir, outside := dirs[0], dirs[1]
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "vendor")))
+
+	callTool := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+	newHandler := func(policy SymlinkPolicy) *FilesystemHandler {
+		handler, err := NewFilesystemHandler([]string{dir}, WithSymlinkPolicy(policy))
+		require.NoError(t, err)
+		return handler
+	}
+	link := filepath.Join(dir, "link.txt")
+
+	t.Run("metadata lists and inspects links without following them", func(t *testing.T) {
+		handler := newHandler(SymlinkMetadata)
+		assert.True(t, callTool(handler.HandleReadFile, map[string]any{"path": link}).IsError)
+		assert.True(t, callTool(handler.HandleReadFile, map[string]any{"path": filepath.Join(dir, "vendor", "secret.txt")}).IsError)
+
+		result := callTool(handler.HandleGetFileInfo, map[string]any{"path": link, "follow": false})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "symlink")
+
+		text := callTool(handler.HandleListDirectory, map[string]any{"path": dir}).Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "link.txt")
+	})
+
+	t.Run("reject hides links completely", func(t *testing.T) {
+		handler := newHandler(SymlinkReject)
+		assert.True(t, callTool(handler.HandleReadFile, map[string]any{"path": link}).IsError)
+		assert.True(t, callTool(handler.HandleGetFileInfo, map[string]any{"path": link, "follow": false}).IsError)
+
+		text := callTool(handler.HandleListDirectory, map[string]any{"path": dir}).Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "main.go")
+		assert.NotContains(t, text, "link.txt")
+		assert.NotContains(t, text, "vendor")
+	})
+
+	t.Run("follow reads through links", func(t *testing.T) {
+		handler := newHandler(SymlinkFollow)
+		for _, path := range []string{link, filepath.Join(dir, "vendor", "secret.txt")} {
+			result := callTool(handler.HandleReadFile, map[string]any{"path": path})
+			require.False(t, result.IsError, path)
+			assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "secret")
+		}
+	})
+
+	t.Run("unknown policies are rejected", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{dir}, WithSymlinkPolicy("ignore"))
+		assert.Error(t, err)
+	})
+}
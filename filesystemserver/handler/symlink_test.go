@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymlinkTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires extra privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "target.txt"), []byte("hello"), 0644))
+
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("creates relative link", func(t *testing.T) {
+		link := filepath.Join(dir, "link.txt")
+		result := call(handler.HandleCreateSymlink, map[string]any{"target": "target.txt", "link_path": link})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		target, err := os.Readlink(link)
+		require.NoError(t, err)
+		assert.Equal(t, "target.txt", target)
+
+		result = call(handler.HandleReadSymlink, map[string]any{"path": link})
+		require.False(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "Target: target.txt")
+		assert.Contains(t, text, "target.txt\n")
+		assert.NotContains(t, text, "dangling")
+	})
+
+	t.Run("rejects existing link path", func(t *testing.T) {
+		result := call(handler.HandleCreateSymlink, map[string]any{"target": "target.txt", "link_path": filepath.Join(dir, "target.txt")})
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "already exists")
+	})
+
+	t.Run("rejects escaping targets", func(t *testing.T) {
+		for _, target := range []string{outside, "../" + filepath.Base(outside), "../../etc/passwd"} {
+			link := filepath.Join(dir, "escape")
+			result := call(handler.HandleCreateSymlink, map[string]any{"target": target, "link_path": link})
+			assert.True(t, result.IsError, target)
+			_, err := os.Lstat(link)
+			assert.True(t, os.IsNotExist(err), target)
+		}
+	})
+
+	t.Run("rejects link outside allowed directories", func(t *testing.T) {
+		result := call(handler.HandleCreateSymlink, map[string]any{"target": filepath.Join(dir, "target.txt"), "link_path": filepath.Join(outside, "link")})
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("read_symlink requires a link", func(t *testing.T) {
+		result := call(handler.HandleReadSymlink, map[string]any{"path": filepath.Join(dir, "target.txt")})
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestCreateHardlink(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	outside := t.TempDir()
+	target := filepath.Join(dir, "data.bin")
+	require.NoError(t, os.WriteFile(target, []byte("shared"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	link := func(target, linkPath string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"target": target, "link_path": linkPath}
+		result, err := handler.HandleCreateHardlink(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("links the same data", func(t *testing.T) {
+		linkPath := filepath.Join(dir, "copy.bin")
+		result := link(target, linkPath)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		targetInfo, err := os.Stat(target)
+		require.NoError(t, err)
+		linkInfo, err := os.Lstat(linkPath)
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(targetInfo, linkInfo))
+	})
+
+	t.Run("refuses directories", func(t *testing.T) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+		result := link(filepath.Join(dir, "sub"), filepath.Join(dir, "sub-link"))
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "cannot hard link a directory")
+	})
+
+	t.Run("refuses existing link paths", func(t *testing.T) {
+		result := link(target, target)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "already exists")
+	})
+
+	t.Run("stays within the allowed directories", func(t *testing.T) {
+		result := link(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt"))
+		assert.True(t, result.IsError)
+		assert.NoFileExists(t, filepath.Join(dir, "secret.txt"))
+
+		result = link(target, filepath.Join(outside, "data.bin"))
+		assert.True(t, result.IsError)
+		assert.NoFileExists(t, filepath.Join(outside, "data.bin"))
+	})
+}
@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// syncAction is a single step of a directory sync
+type syncAction struct {
+	kind string // "mkdir", "copy", "update" or "delete"
+	rel  string // slash-separated path relative to the synced roots
+}
+
+// HandleSyncDirectories makes destination match source: new and changed
+// files are copied, and files that exist only in destination are deleted
+// when delete is set. Each file is written atomically.
+func (fs *FilesystemHandler) HandleSyncDirectories(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	source, err := request.RequireString("source")
+	if err != nil {
+		return nil, err
+	}
+	destination, err := request.RequireString("destination")
+	if err != nil {
+		return nil, err
+	}
+	deleteExtra := request.GetBool("delete", false)
+	compareContent := request.GetBool("compare_content", false)
+	dryRun := request.GetBool("dry_run", false)
+
+	filter, err := newListFilter("", request.GetString("exclude", ""))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Invalid glob pattern: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validSource, err := fs.validatePath(source)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = fs.fsys.Stat(validSource); err == nil && !info.IsDir() {
+			err = fmt.Errorf("source is not a directory: %s", source)
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error with source path: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// The destination is created, along with its parents, if needed
+	validDest, err := fs.validatePathWithParents(destination)
+	if err == nil {
+		err = fs.authorize(validDest, OpWrite)
+	}
+	if err == nil {
+		if info, statErr := fs.fsys.Stat(validDest); statErr == nil && !info.IsDir() {
+			err = fmt.Errorf("destination is not a directory: %s", destination)
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error with destination path: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if validSource == validDest || isWithin(validSource, validDest) || isWithin(validDest, validSource) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: source and destination must not contain each other",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// A partial scan could delete or skip the wrong files, so syncing a tree
+	// that exceeds the walk limits is refused
+	sourceTree, truncated, err := fs.scanTree(ctx, validSource, filter)
+	if err == nil && truncated != "" {
+		err = fmt.Errorf("truncated: %s", truncated)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading source: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	destTree := map[string]treeEntry{}
+	if _, err := fs.fsys.Stat(validDest); err == nil {
+		if destTree, truncated, err = fs.scanTree(ctx, validDest, filter); err == nil && truncated != "" {
+			err = fmt.Errorf("truncated: %s", truncated)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error reading destination: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	actions, extras, err := fs.planSync(ctx, sourceTree, destTree, compareContent, deleteExtra)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error comparing directories: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	copied, deleted, failed := 0, 0, 0
+	var lines []string
+	if !dryRun {
+		if result := fs.checkQuota(validDest, syncGrowth(actions, sourceTree, destTree), false); result != nil {
+			return result, nil
+		}
+		if _, err := fs.makeDirs(validDest, fs.dirPerm); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error creating destination directory: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+	for _, action := range actions {
+		if ctx.Err() != nil {
+			break
+		}
+		label := strings.ToUpper(action.kind)
+		if !dryRun {
+			src := sourceTree[strings.TrimSuffix(action.rel, "/")]
+			if err := fs.applySyncAction(action, src, validDest, destTree); err != nil {
+				failed++
+				lines = append(lines, fmt.Sprintf("[FAILED] %s (%s: %v)", action.rel, action.kind, err))
+				continue
+			}
+		}
+		switch action.kind {
+		case "copy", "update":
+			copied++
+		case "delete":
+			deleted++
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s", label, action.rel))
+	}
+	for _, rel := range extras {
+		lines = append(lines, fmt.Sprintf("[SKIPPED] %s (not in source)", rel))
+	}
+
+	var result strings.Builder
+	if dryRun {
+		result.WriteString(fmt.Sprintf(
+			"Dry run: syncing %s to %s would copy %d file(s), delete %d and skip %d.\n",
+			fs.displayPath(validSource), fs.displayPath(validDest), copied, deleted, len(extras),
+		))
+	} else {
+		result.WriteString(fmt.Sprintf(
+			"Synced %s to %s: copied %d file(s), deleted %d, skipped %d, failed %d.\n",
+			fs.displayPath(validSource), fs.displayPath(validDest), copied, deleted, len(extras), failed,
+		))
+	}
+	if ctx.Err() != nil {
+		result.WriteString("Note: the sync was cancelled before it completed.\n")
+	}
+	if len(lines) > 0 {
+		result.WriteString("\n")
+	}
+	for i, line := range lines {
+		if i == MAX_SEARCH_RESULTS {
+			result.WriteString(fmt.Sprintf("... and %d more\n", len(lines)-i))
+			break
+		}
+		result.WriteString(line + "\n")
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+		IsError: failed > 0,
+	}, nil
+}
+
+// planSync lists the actions that make the destination tree match the
+// source tree, in an order that creates directories before their contents.
+// Paths that exist only in the destination are deleted with deleteExtra and
+// returned as extras otherwise. A path whose type differs between the trees
+// is deleted and recreated.
+func (fs *FilesystemHandler) planSync(
+	ctx context.Context, source, dest map[string]treeEntry, compareContent, deleteExtra bool,
+) ([]syncAction, []string, error) {
+	var deletes, writes []syncAction
+	var extras []string
+
+	for _, rel := range sortedKeys(dest) {
+		if _, ok := source[rel]; ok || !parentIsDir(rel, source) {
+			continue // Covered by the deletion or replacement of a parent
+		}
+		if deleteExtra {
+			deletes = append(deletes, syncAction{kind: "delete", rel: treeName(rel, dest[rel].info)})
+		} else {
+			extras = append(extras, treeName(rel, dest[rel].info))
+		}
+	}
+
+	for _, rel := range sortedKeys(source) {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		s := source[rel]
+		d, exists := dest[rel]
+		if exists && s.info.IsDir() != d.info.IsDir() {
+			deletes = append(deletes, syncAction{kind: "delete", rel: treeName(rel, d.info)})
+			exists = false
+		}
+
+		switch {
+		case s.info.IsDir():
+			if !exists {
+				writes = append(writes, syncAction{kind: "mkdir", rel: rel + "/"})
+			}
+		case !exists:
+			writes = append(writes, syncAction{kind: "copy", rel: rel})
+		default:
+			same, err := fs.sameFile(s, d, compareContent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !same {
+				writes = append(writes, syncAction{kind: "update", rel: rel})
+			}
+		}
+	}
+	return append(deletes, writes...), extras, nil
+}
+
+// syncGrowth returns by how many bytes the actions change the size of the
+// destination tree: the files copied, less the files they replace and the
+// files deleted
+func syncGrowth(actions []syncAction, source, dest map[string]treeEntry) int64 {
+	var growth int64
+	for _, action := range actions {
+		rel := strings.TrimSuffix(action.rel, "/")
+		switch action.kind {
+		case "copy", "update":
+			growth += source[rel].info.Size()
+			if d, ok := dest[rel]; ok && d.info.Mode().IsRegular() {
+				growth -= d.info.Size()
+			}
+		case "delete":
+			for name, d := range dest {
+				if (name == rel || strings.HasPrefix(name, rel+"/")) && d.info.Mode().IsRegular() {
+					growth -= d.info.Size()
+				}
+			}
+		}
+	}
+	return growth
+}
+
+// applySyncAction performs one sync step below destRoot. src is the source
+// entry for copies and directories and dest the scanned destination tree.
+// Every destination path is validated before it is changed, so that links
+// in the destination cannot redirect the sync outside the allowed
+// directories.
+func (fs *FilesystemHandler) applySyncAction(action syncAction, src treeEntry, destRoot string, dest map[string]treeEntry) error {
+	rel := strings.TrimSuffix(action.rel, "/")
+	op := OpWrite
+	if action.kind == "delete" {
+		op = OpDelete
+	}
+	dst, err := fs.validatePath(filepath.Join(destRoot, filepath.FromSlash(rel)))
+	if err == nil {
+		err = fs.authorize(dst, op)
+	}
+	if err != nil {
+		return err
+	}
+	defer fs.invalidateCache(dst)
+
+	switch action.kind {
+	case "delete":
+		if err := fs.authorizeTree(dst, OpDelete, ""); err != nil {
+			return err
+		}
+		return fs.removeScanned(dst, rel, dest)
+	case "mkdir":
+		if err := fs.fsys.MkdirAll(dst, src.info.Mode().Perm()); err != nil {
+			return err
+		}
+		return fs.fsys.Chmod(dst, src.info.Mode().Perm())
+	default:
+		in, err := fs.fsys.Open(src.path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		err = writeAtomic(fs.fsys, dst, src.info.Mode().Perm(), func(w io.Writer) error {
+			_, err := io.Copy(w, in)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		// Keep the modification time so that the next sync sees the file as unchanged
+		return fs.fsys.Chtimes(dst, time.Now(), src.info.ModTime())
+	}
+}
+
+// removeScanned deletes the destination entry rel at dst and the entries
+// below it that the scan listed, deepest first. The scan leaves out
+// excluded entries and those outside the allowed directories, so a
+// directory that still holds any of them is kept.
+func (fs *FilesystemHandler) removeScanned(dst, rel string, dest map[string]treeEntry) error {
+	var names []string
+	for name := range dest {
+		if strings.HasPrefix(name, rel+"/") {
+			names = append(names, name)
+		}
+	}
+	// Reverse lexical order places every entry before its parent directory
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	names = append(names, rel)
+
+	for _, name := range names {
+		path := dest[name].path
+		if name == rel {
+			path = dst
+		}
+		if dest[name].info.IsDir() {
+			entries, err := fs.fsys.ReadDir(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if len(entries) > 0 {
+				continue
+			}
+		}
+		if err := fs.fsys.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// parentIsDir reports whether the parent of rel is a directory in tree,
+// treating the root as one
+func parentIsDir(rel string, tree map[string]treeEntry) bool {
+	parent := filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel)))
+	if parent == "." {
+		return true
+	}
+	entry, ok := tree[parent]
+	return ok && entry.info.IsDir()
+}
+
+// sortedKeys returns the keys of a tree in lexical order, which places every
+// directory before its contents
+func sortedKeys(tree map[string]treeEntry) []string {
+	keys := make([]string, 0, len(tree))
+	for rel := range tree {
+		keys = append(keys, rel)
+	}
+	sort.Strings(keys)
+	return keys
+}
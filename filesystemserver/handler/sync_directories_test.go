@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSyncDirectories(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	source := filepath.Join(dir, "source")
+	dest := filepath.Join(dir, "dest")
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	write(filepath.Join(source, "same.txt"), "same")
+	write(filepath.Join(dest, "same.txt"), "same")
+	write(filepath.Join(source, "changed.txt"), "new contents")
+	write(filepath.Join(dest, "changed.txt"), "old")
+	write(filepath.Join(source, "sub", "new.txt"), "new")
+	write(filepath.Join(source, "cache.tmp"), "excluded")
+	write(filepath.Join(dest, "extra.txt"), "extra")
+	write(filepath.Join(dest, "old", "gone.txt"), "gone")
+
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+	sync := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "sync_directories"
+		request.Params.Arguments = args
+		result, err := handler.HandleSyncDirectories(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		text := sync(map[string]any{"source": source, "destination": dest, "exclude": "*.tmp", "delete": true, "dry_run": true})
+		assert.Contains(t, text, "would copy 2 file(s), delete 2 and skip 0")
+		assert.Contains(t, text, "[DELETE] extra.txt\n[DELETE] old/\n")
+		assert.Contains(t, text, "[UPDATE] changed.txt\n[MKDIR] sub/\n[COPY] sub/new.txt\n")
+		assert.Equal(t, "old", read(filepath.Join(dest, "changed.txt")))
+	})
+
+	t.Run("without delete", func(t *testing.T) {
+		text := sync(map[string]any{"source": source, "destination": dest, "exclude": "*.tmp"})
+		assert.Contains(t, text, "copied 2 file(s), deleted 0, skipped 2, failed 0")
+		assert.Contains(t, text, "[SKIPPED] extra.txt (not in source)")
+		assert.Equal(t, "new contents", read(filepath.Join(dest, "changed.txt")))
+		assert.Equal(t, "new", read(filepath.Join(dest, "sub", "new.txt")))
+		assert.NoFileExists(t, filepath.Join(dest, "cache.tmp"))
+
+		info, err := os.Stat(filepath.Join(dest, "sub", "new.txt"))
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(mtime), "modification time is preserved")
+	})
+
+	t.Run("with delete", func(t *testing.T) {
+		text := sync(map[string]any{"source": source, "destination": dest, "exclude": "*.tmp", "delete": true})
+		assert.Contains(t, text, "copied 0 file(s), deleted 2, skipped 0, failed 0")
+		assert.NoFileExists(t, filepath.Join(dest, "extra.txt"))
+		assert.NoDirExists(t, filepath.Join(dest, "old"))
+	})
+
+	t.Run("new destination", func(t *testing.T) {
+		text := sync(map[string]any{"source": source, "destination": filepath.Join(dir, "backup", "copy")})
+		assert.Contains(t, text, "copied 4 file(s)")
+		assert.Equal(t, "excluded", read(filepath.Join(dir, "backup", "copy", "cache.tmp")))
+	})
+
+	t.Run("nested destination", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"source": source, "destination": filepath.Join(source, "inner")}
+		result, err := handler.HandleSyncDirectories(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestHandleSyncDirectories_DeleteKeepsUnscannedEntries(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	source := filepath.Join(dir, "source")
+	dest := filepath.Join(dir, "dest")
+	for path, content := range map[string]string{
+		filepath.Join(source, "keep.txt"):          "keep",
+		filepath.Join(dest, "keep.txt"):            "keep",
+		filepath.Join(dest, "secret", "notes.txt"): "notes",
+		filepath.Join(dest, "secret", ".env"):      "TOKEN=1",
+		filepath.Join(dest, "logs", "app.txt"):     "app",
+		filepath.Join(dest, "logs", "app.log"):     "excluded",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithDeniedPaths([]string{"**/.env"}))
+	require.NoError(t, err)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"source": source, "destination": dest, "exclude": "*.log", "delete": true}
+	result, err := handler.HandleSyncDirectories(context.Background(), request)
+	require.NoError(t, err)
+	text := result.Content[0].(mcp.TextContent).Text
+
+	// The denied file fails the deletion of its directory as a whole
+	assert.True(t, result.IsError)
+	assert.Contains(t, text, "[FAILED] secret/ (delete: ")
+	assert.FileExists(t, filepath.Join(dest, "secret", ".env"))
+	assert.FileExists(t, filepath.Join(dest, "secret", "notes.txt"))
+
+	// Excluded files are kept, and so is the directory holding them
+	assert.Contains(t, text, "[DELETE] logs/")
+	assert.NoFileExists(t, filepath.Join(dest, "logs", "app.txt"))
+	assert.FileExists(t, filepath.Join(dest, "logs", "app.log"))
+	assert.FileExists(t, filepath.Join(dest, "keep.txt"))
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Byte order marks written ahead of the content when write_bom is set, and
+// recognized when files are read
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// encodeText converts UTF-8 content to the named character encoding,
+// optionally prefixed with a byte order mark. An empty name means UTF-8.
+func encodeText(content, name string, writeBOM bool) ([]byte, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = "utf-8"
+	}
+
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unsupported encoding '%s'", name)
+	}
+	canonical, _ := ianaindex.IANA.Name(enc)
+
+	var bom []byte
+	if writeBOM {
+		switch canonical {
+		case "UTF-8":
+			bom = bomUTF8
+		case "UTF-16LE":
+			bom = bomUTF16LE
+		case "UTF-16BE":
+			bom = bomUTF16BE
+		case "UTF-16":
+			// Plain UTF-16 is big-endian when a BOM is present
+			bom = bomUTF16BE
+		default:
+			return nil, fmt.Errorf("write_bom is only supported for UTF-8 and UTF-16 encodings, not '%s'", name)
+		}
+	}
+	if canonical == "UTF-16" {
+		enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+
+	// Characters the target encoding cannot represent are an error rather
+	// than being silently replaced
+	encoded, err := enc.NewEncoder().Bytes([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode content as %s: %v", name, err)
+	}
+
+	return append(append([]byte{}, bom...), encoded...), nil
+}
+
+// detectBOM returns the encoding announced by a byte order mark at the start
+// of content, or an empty string if there is none
+func detectBOM(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return "utf-8"
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return "utf-16le"
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return "utf-16be"
+	}
+	return ""
+}
+
+// decodeUTF16 converts UTF-16 content that starts with a byte order mark to
+// UTF-8, without the mark
+func decodeUTF16(content []byte) ([]byte, error) {
+	decoded, err := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode UTF-16 content: %v", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// WithOperationTimeout bounds every filesystem operation to timeout, so that
+// a stale network mount produces a timeout error instead of hanging the
+// server. An operation that times out keeps running in the background until
+// the operating system returns, and a file it opens then is closed. A timeout
+// of 0 disables the limit.
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(fs *FilesystemHandler) {
+		fs.opTimeout = timeout
+	}
+}
+
+// operationTimeoutError reports a filesystem operation that did not complete
+// within the configured operation timeout
+type operationTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *operationTimeoutError) Error() string {
+	return fmt.Sprintf("timeout: no response within %v", e.timeout)
+}
+
+// Timeout marks the error as a timeout, like os.ErrDeadlineExceeded
+func (e *operationTimeoutError) Timeout() bool { return true }
+
+// withTimeout runs fn in a goroutine and waits at most timeout for it to
+// return. On timeout the result of fn is discarded.
+func withTimeout[T any](timeout time.Duration, op, name string, fn func() (T, error)) (T, error) {
+	return withTimeoutRelease(timeout, op, name, fn, nil)
+}
+
+// withTimeoutRelease is withTimeout for operations whose result holds a
+// resource: if fn succeeds after the timeout, release is called with its
+// result, since no caller will.
+func withTimeoutRelease[T any](timeout time.Duration, op, name string, fn func() (T, error), release func(T)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		if release != nil {
+			go func() {
+				if r := <-done; r.err == nil {
+					release(r.value)
+				}
+			}()
+		}
+		var zero T
+		return zero, &os.PathError{Op: op, Path: name, Err: &operationTimeoutError{timeout}}
+	}
+}
+
+// withTimeoutErr is withTimeout for operations that only return an error
+func withTimeoutErr(timeout time.Duration, op, name string, fn func() error) error {
+	_, err := withTimeout(timeout, op, name, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// closeFile closes a file that was opened too late to be used
+func closeFile(f File) {
+	f.Close()
+}
+
+// timeoutFileSystem is a FileSystem whose operations, including those on
+// the files it opens, fail with a timeout error after a fixed duration
+type timeoutFileSystem struct {
+	fsys    FileSystem
+	timeout time.Duration
+}
+
+func (t timeoutFileSystem) Stat(name string) (os.FileInfo, error) {
+	return withTimeout(t.timeout, "stat", name, func() (os.FileInfo, error) { return t.fsys.Stat(name) })
+}
+
+func (t timeoutFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return withTimeout(t.timeout, "lstat", name, func() (os.FileInfo, error) { return t.fsys.Lstat(name) })
+}
+
+func (t timeoutFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return withTimeout(t.timeout, "readdir", name, func() ([]os.DirEntry, error) { return t.fsys.ReadDir(name) })
+}
+
+func (t timeoutFileSystem) ReadFile(name string) ([]byte, error) {
+	return withTimeout(t.timeout, "read", name, func() ([]byte, error) { return t.fsys.ReadFile(name) })
+}
+
+func (t timeoutFileSystem) Open(name string) (File, error) {
+	f, err := withTimeoutRelease(t.timeout, "open", name, func() (File, error) { return t.fsys.Open(name) }, closeFile)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutFile{f: f, name: name, timeout: t.timeout}, nil
+}
+
+func (t timeoutFileSystem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := withTimeoutRelease(t.timeout, "open", name, func() (File, error) { return t.fsys.OpenFile(name, flag, perm) }, closeFile)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutFile{f: f, name: name, timeout: t.timeout}, nil
+}
+
+func (t timeoutFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return withTimeoutErr(t.timeout, "write", name, func() error { return t.fsys.WriteFile(name, data, perm) })
+}
+
+func (t timeoutFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return withTimeoutErr(t.timeout, "mkdir", path, func() error { return t.fsys.MkdirAll(path, perm) })
+}
+
+func (t timeoutFileSystem) Remove(name string) error {
+	return withTimeoutErr(t.timeout, "remove", name, func() error { return t.fsys.Remove(name) })
+}
+
+func (t timeoutFileSystem) RemoveAll(path string) error {
+	return withTimeoutErr(t.timeout, "remove", path, func() error { return t.fsys.RemoveAll(path) })
+}
+
+func (t timeoutFileSystem) Rename(oldpath, newpath string) error {
+	return withTimeoutErr(t.timeout, "rename", oldpath, func() error { return t.fsys.Rename(oldpath, newpath) })
+}
+
+func (t timeoutFileSystem) Chmod(name string, mode os.FileMode) error {
+	return withTimeoutErr(t.timeout, "chmod", name, func() error { return t.fsys.Chmod(name, mode) })
+}
+
+func (t timeoutFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	return withTimeoutErr(t.timeout, "chtimes", name, func() error { return t.fsys.Chtimes(name, atime, mtime) })
+}
+
+func (t timeoutFileSystem) EvalSymlinks(path string) (string, error) {
+	return withTimeout(t.timeout, "lstat", path, func() (string, error) { return t.fsys.EvalSymlinks(path) })
+}
+
+func (t timeoutFileSystem) Symlink(oldname, newname string) error {
+	return withTimeoutErr(t.timeout, "symlink", newname, func() error { return t.fsys.Symlink(oldname, newname) })
+}
+
+func (t timeoutFileSystem) Readlink(name string) (string, error) {
+	return withTimeout(t.timeout, "readlink", name, func() (string, error) { return t.fsys.Readlink(name) })
+}
+
+func (t timeoutFileSystem) Link(oldname, newname string) error {
+	return withTimeoutErr(t.timeout, "link", newname, func() error { return t.fsys.Link(oldname, newname) })
+}
+
+// onDisk reports whether the handler works on the real disk, possibly
+// through an operation timeout, rather than on another FileSystem
+func (fs *FilesystemHandler) onDisk() bool {
+	fsys := fs.fsys
+	if t, ok := fsys.(timeoutFileSystem); ok {
+		fsys = t.fsys
+	}
+	_, ok := fsys.(OSFileSystem)
+	return ok
+}
+
+// timeoutFile bounds each operation on an open file. A large read or write
+// must complete within a single timeout. Reads and writes go through a
+// private buffer, so that an operation still running after its timeout can
+// never touch the caller's slice.
+type timeoutFile struct {
+	f       File
+	name    string
+	timeout time.Duration
+}
+
+func (t *timeoutFile) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	n, err := withTimeout(t.timeout, "read", t.name, func() (int, error) { return t.f.Read(buf) })
+	copy(p, buf[:n])
+	return n, err
+}
+
+func (t *timeoutFile) ReadAt(p []byte, off int64) (int, error) {
+	buf := make([]byte, len(p))
+	n, err := withTimeout(t.timeout, "read", t.name, func() (int, error) { return t.f.ReadAt(buf, off) })
+	copy(p, buf[:n])
+	return n, err
+}
+
+func (t *timeoutFile) Write(p []byte) (int, error) {
+	buf := append([]byte(nil), p...)
+	return withTimeout(t.timeout, "write", t.name, func() (int, error) { return t.f.Write(buf) })
+}
+
+func (t *timeoutFile) Seek(offset int64, whence int) (int64, error) {
+	return withTimeout(t.timeout, "seek", t.name, func() (int64, error) { return t.f.Seek(offset, whence) })
+}
+
+func (t *timeoutFile) Close() error {
+	return withTimeoutErr(t.timeout, "close", t.name, t.f.Close)
+}
+
+func (t *timeoutFile) Sync() error {
+	return withTimeoutErr(t.timeout, "sync", t.name, t.f.Sync)
+}
+
+func (t *timeoutFile) Stat() (os.FileInfo, error) {
+	return withTimeout(t.timeout, "stat", t.name, t.f.Stat)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingFileSystem blocks Stat of one path until the test ends, like a
+// stale network mount
+type hangingFileSystem struct {
+	*MemFileSystem
+	hang    string
+	release chan struct{}
+}
+
+func (h *hangingFileSystem) Stat(name string) (os.FileInfo, error) {
+	if name == h.hang {
+		<-h.release
+	}
+	return h.MemFileSystem.Stat(name)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	mem := NewMemFileSystem()
+	root := filepath.Join(filepath.VolumeName(os.TempDir())+string(filepath.Separator), "data")
+	require.NoError(t, mem.MkdirAll(root, 0755))
+	require.NoError(t, mem.WriteFile(filepath.Join(root, "ok.txt"), []byte("fine"), 0644))
+	require.NoError(t, mem.WriteFile(filepath.Join(root, "stale.txt"), []byte("stuck"), 0644))
+
+	fsys := &hangingFileSystem{MemFileSystem: mem, hang: filepath.Join(root, "stale.txt"), release: make(chan struct{})}
+	t.Cleanup(func() { close(fsys.release) })
+
+	handler, err := NewFilesystemHandler([]string{root}, WithFileSystem(fsys), WithOperationTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	read := func(name string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(root, name)}
+		result, err := handler.HandleReadFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := read("ok.txt")
+	require.False(t, result.IsError)
+	assert.Equal(t, "fine", result.Content[0].(mcp.TextContent).Text)
+
+	start := time.Now()
+	result = read("stale.txt")
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "timeout: no response within 50ms")
+}
+
+// slowOpenFileSystem blocks Open until released and reports when the files
+// it opened are closed
+type slowOpenFileSystem struct {
+	*MemFileSystem
+	release chan struct{}
+	closed  chan struct{}
+}
+
+type closeReportingFile struct {
+	File
+	closed chan struct{}
+}
+
+func (f closeReportingFile) Close() error {
+	close(f.closed)
+	return f.File.Close()
+}
+
+func (s *slowOpenFileSystem) Open(name string) (File, error) {
+	<-s.release
+	f, err := s.MemFileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return closeReportingFile{File: f, closed: s.closed}, nil
+}
+
+func TestOperationTimeout_LateOpenIsClosed(t *testing.T) {
+	mem := NewMemFileSystem()
+	name := filepath.Join(filepath.VolumeName(os.TempDir())+string(filepath.Separator), "slow.txt")
+	require.NoError(t, mem.WriteFile(name, []byte("late"), 0644))
+	fsys := &slowOpenFileSystem{MemFileSystem: mem, release: make(chan struct{}), closed: make(chan struct{})}
+
+	_, err := timeoutFileSystem{fsys: fsys, timeout: 10 * time.Millisecond}.Open(name)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout")
+
+	close(fsys.release)
+	select {
+	case <-fsys.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the file opened after the timeout was not closed")
+	}
+}
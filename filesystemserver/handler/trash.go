@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// trashStampLayout names the directory that holds the items trashed by one
+// call, so that the trash sorts by the time items were deleted
+const trashStampLayout = "20060102T150405.000000000Z"
+
+// WithTrashDir sets the directory that move_to_trash moves files into. It
+// must lie within the allowed directories and is created when first used.
+func WithTrashDir(dir string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.trashDir = dir
+	}
+}
+
+// resolveTrashDir validates the configured trash directory and replaces it
+// with its real path
+func (fs *FilesystemHandler) resolveTrashDir() error {
+	if fs.trashDir == "" {
+		return nil
+	}
+	validPath, err := fs.confinePathWithParents(fs.trashDir)
+	if err != nil {
+		return fmt.Errorf("trash directory: %w", err)
+	}
+	if info, err := fs.fsys.Stat(validPath); err == nil && !info.IsDir() {
+		return fmt.Errorf("trash directory is not a directory: %s", fs.trashDir)
+	}
+	if fs.rootForPath(validPath) == validPath {
+		return fmt.Errorf("trash directory cannot be an allowed directory itself: %s", fs.trashDir)
+	}
+	fs.trashDir = validPath
+	return nil
+}
+
+// trashPath returns where a file at path is kept in the trash when it is
+// trashed at time t: below a directory named after t and a key for its
+// allowed directory, at its path relative to that directory
+func (fs *FilesystemHandler) trashPath(path string, t time.Time) string {
+	root := fs.rootForPath(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return filepath.Join(fs.trashDir, t.UTC().Format(trashStampLayout), trashRootKey(root), rel)
+}
+
+// trashRootKey names an allowed directory within the trash by its base name
+// and a digest of its path, so that the trash does not repeat host paths and
+// directories with the same name are kept apart
+func trashRootKey(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Base(root) + "-" + hex.EncodeToString(sum[:4])
+}
+
+// originalPath is the inverse of trashPath: it returns the original location
+// and the trash time of an item in the trash. The allowed directory it was
+// trashed from must still be configured.
+func (fs *FilesystemHandler) originalPath(trashed string) (string, time.Time, error) {
+	rel, err := filepath.Rel(fs.trashDir, trashed)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	parts := strings.SplitN(rel, string(filepath.Separator), 3)
+	if len(parts) < 3 {
+		return "", time.Time{}, fmt.Errorf("not an item in the trash: %s", fs.displayPath(trashed))
+	}
+	deleted, err := time.Parse(trashStampLayout, parts[0])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("not an item in the trash: %s", fs.displayPath(trashed))
+	}
+	for _, dir := range fs.allowedDirs {
+		root := strings.TrimSuffix(dir, string(filepath.Separator))
+		if trashRootKey(root) == parts[1] {
+			return filepath.Join(root, parts[2]), deleted, nil
+		}
+	}
+	return "", time.Time{}, fmt.Errorf("the allowed directory %s was trashed from is no longer configured", parts[1])
+}
+
+// relocate renames src to dst, copying across filesystems when needed
+func (fs *FilesystemHandler) relocate(src, dst string) error {
+	err := fs.fsys.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		err = fs.moveAcrossDevices(src, dst, nil)
+	}
+	return err
+}
+
+// trashNotConfigured is the result of the trash tools when no trash
+// directory is configured
+func (fs *FilesystemHandler) trashNotConfigured() *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: "Error: no trash directory is configured",
+			},
+		},
+		IsError: true,
+	}
+}
+
+// HandleMoveToTrash moves a file or directory into the trash directory
+// instead of deleting it, so that it can be restored with restore_from_trash
+func (fs *FilesystemHandler) HandleMoveToTrash(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	if fs.trashDir == "" {
+		return fs.trashNotConfigured(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpDelete)
+	}
+	if err == nil {
+		switch {
+		case fs.rootForPath(validPath) == validPath:
+			err = fmt.Errorf("cannot trash an allowed directory: %s", path)
+		case validPath == fs.trashDir || isWithin(fs.trashDir, validPath):
+			err = fmt.Errorf("%s is already in the trash; use empty_trash to delete it", path)
+		case isWithin(validPath, fs.trashDir):
+			err = fmt.Errorf("cannot trash %s as it contains the trash directory", path)
+		}
+	}
+	if err == nil {
+		_, err = fs.fsys.Lstat(validPath)
+	}
+	if err == nil {
+		err = fs.authorizeTree(validPath, OpDelete, "")
+	}
+	if err == nil {
+		err = fs.authorize(fs.trashDir, OpWrite)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	trashed := fs.trashPath(validPath, time.Now())
+	if result := fs.checkMoveQuota(validPath, trashed, false); result != nil {
+		return result, nil
+	}
+	defer fs.invalidateCache(validPath)
+	defer fs.invalidateCache(trashed)
+	if _, err = fs.makeDirs(filepath.Dir(trashed), fs.dirPerm); err == nil {
+		err = fs.relocate(validPath, trashed)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error moving to trash: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf(
+					"Moved %s to the trash: %s\nUse restore_from_trash with this path to restore it.",
+					path,
+					fs.displayPath(trashed),
+				),
+			},
+		},
+	}, nil
+}
+
+// HandleRestoreFromTrash moves an item from the trash back to its original
+// location. The original location must not have been reused.
+func (fs *FilesystemHandler) HandleRestoreFromTrash(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	if fs.trashDir == "" {
+		return fs.trashNotConfigured(), nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	var original string
+	if err == nil && !isWithin(fs.trashDir, validPath) {
+		err = fmt.Errorf("not an item in the trash: %s", path)
+	}
+	if err == nil {
+		err = fs.authorize(validPath, OpDelete)
+	}
+	if err == nil {
+		if _, err = fs.fsys.Lstat(validPath); err == nil {
+			original, _, err = fs.originalPath(validPath)
+		}
+	}
+	if err == nil {
+		// The original location is validated like any other destination
+		original, err = fs.validatePathWithParents(original)
+	}
+	if err == nil {
+		err = fs.authorize(original, OpWrite)
+	}
+	if err == nil {
+		if _, statErr := fs.fsys.Lstat(original); statErr == nil {
+			err = fmt.Errorf("original location already exists: %s", fs.displayPath(original))
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// The original location may lie in another root than the trash
+	if result := fs.checkMoveQuota(validPath, original, false); result != nil {
+		return result, nil
+	}
+	defer fs.invalidateCache(validPath)
+	defer fs.invalidateCache(original)
+	if _, err = fs.makeDirs(filepath.Dir(original), fs.dirPerm); err == nil {
+		err = fs.relocate(validPath, original)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error restoring from trash: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Remove the directories left empty in the trash
+	for dir := filepath.Dir(validPath); isWithin(fs.trashDir, dir); dir = filepath.Dir(dir) {
+		if entries, err := fs.fsys.ReadDir(dir); err != nil || len(entries) > 0 || fs.fsys.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Restored %s to %s", path, fs.displayPath(original)),
+			},
+		},
+	}, nil
+}
+
+// HandleEmptyTrash permanently deletes the items in the trash, optionally
+// only those trashed longer ago than a given duration
+func (fs *FilesystemHandler) HandleEmptyTrash(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	if fs.trashDir == "" {
+		return fs.trashNotConfigured(), nil
+	}
+
+	var olderThan time.Duration
+	if value := request.GetString("older_than", ""); value != "" {
+		var err error
+		olderThan, err = time.ParseDuration(value)
+		if err != nil || olderThan < 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: older_than must be a duration such as \"168h\", got %q", value),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	if err := fs.authorize(fs.trashDir, OpDelete); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	entries, err := fs.fsys.ReadDir(fs.trashDir)
+	if err != nil && !os.IsNotExist(err) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading trash: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Each entry holds the items of one move_to_trash call; entries that were
+	// not created by move_to_trash are left alone
+	removed, kept := 0, 0
+	var failures []string
+	cutoff := time.Now().Add(-olderThan)
+	for _, entry := range entries {
+		deleted, err := time.Parse(trashStampLayout, entry.Name())
+		if err != nil {
+			continue
+		}
+		if olderThan > 0 && deleted.After(cutoff) {
+			kept++
+			continue
+		}
+		if err := fs.fsys.RemoveAll(filepath.Join(fs.trashDir, entry.Name())); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		removed++
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Emptied trash: permanently deleted %d item(s), kept %d.\n", removed, kept))
+	for _, failure := range failures {
+		result.WriteString(fmt.Sprintf("[FAILED] %s\n", failure))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+		IsError: len(failures) > 0,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	trash := filepath.Join(dir, ".trash")
+	handler, err := NewFilesystemHandler([]string{dir}, WithTrashDir(trash))
+	require.NoError(t, err)
+
+	call := func(fn func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := fn(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	file := filepath.Join(dir, "docs", "notes.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte("keep me"), 0644))
+
+	text, isError := call(handler.HandleMoveToTrash, map[string]any{"path": file})
+	require.False(t, isError, text)
+	assert.NoFileExists(t, file)
+	trashed := strings.TrimSpace(strings.SplitN(strings.SplitN(text, "the trash: ", 2)[1], "\n", 2)[0])
+	assert.True(t, strings.HasPrefix(trashed, trash), trashed)
+	assert.True(t, strings.HasSuffix(trashed, filepath.Join(trashRootKey(dir), "docs", "notes.txt")), trashed)
+	assert.NotContains(t, strings.TrimPrefix(trashed, trash), dir, "the host path is not repeated in the trash")
+	assert.FileExists(t, trashed)
+
+	t.Run("trash cannot be trashed", func(t *testing.T) {
+		text, isError := call(handler.HandleMoveToTrash, map[string]any{"path": trashed})
+		assert.True(t, isError)
+		assert.Contains(t, text, "already in the trash")
+
+		text, isError = call(handler.HandleMoveToTrash, map[string]any{"path": dir})
+		assert.True(t, isError)
+		assert.Contains(t, text, "allowed directory")
+	})
+
+	t.Run("restore refuses to overwrite", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("new"), 0644))
+		text, isError := call(handler.HandleRestoreFromTrash, map[string]any{"path": trashed})
+		assert.True(t, isError)
+		assert.Contains(t, text, "already exists")
+		require.NoError(t, os.Remove(file))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Dir(file)))
+		text, isError := call(handler.HandleRestoreFromTrash, map[string]any{"path": trashed})
+		require.False(t, isError, text)
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "keep me", string(content))
+
+		// The emptied directories are removed from the trash
+		entries, err := os.ReadDir(trash)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, isError := call(handler.HandleMoveToTrash, map[string]any{"path": file})
+		require.False(t, isError)
+
+		text, isError := call(handler.HandleEmptyTrash, map[string]any{"older_than": "1h"})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "deleted 0 item(s), kept 1")
+
+		text, isError = call(handler.HandleEmptyTrash, map[string]any{})
+		require.False(t, isError, text)
+		assert.Contains(t, text, "deleted 1 item(s), kept 0")
+		entries, err := os.ReadDir(trash)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("trash access is authorized", func(t *testing.T) {
+		refusing, err := NewFilesystemHandler([]string{dir}, WithTrashDir(trash), WithAuthorizer(AuthorizerFunc(func(path string, op Operation) error {
+			if op != OpRead && (path == trash || isWithin(trash, path)) {
+				return errors.New("the trash is read-only")
+			}
+			return nil
+		})))
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(file, []byte("keep me"), 0644))
+		text, isError := call(refusing.HandleMoveToTrash, map[string]any{"path": file})
+		assert.True(t, isError)
+		assert.Contains(t, text, "read-only")
+		assert.FileExists(t, file)
+
+		text, isError = call(refusing.HandleEmptyTrash, map[string]any{})
+		assert.True(t, isError)
+		assert.Contains(t, text, "read-only")
+	})
+
+	t.Run("items of a removed allowed directory are not restored", func(t *testing.T) {
+		other := resolveAllowedDirs(t, t.TempDir())[0]
+		_, _, err := handler.originalPath(handler.trashPath(filepath.Join(other, "a.txt"), time.Now()))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no longer configured")
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		unconfigured, err := NewFilesystemHandler([]string{dir})
+		require.NoError(t, err)
+		text, isError := call(unconfigured.HandleMoveToTrash, map[string]any{"path": file})
+		assert.True(t, isError)
+		assert.Contains(t, text, "no trash directory")
+	})
+}
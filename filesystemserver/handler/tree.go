@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -49,6 +50,20 @@ func (fs *FilesystemHandler) HandleTree(
 		followSymlinks = followParam
 	}
 
+	// Extract format parameter (optional, default: json)
+	format := strings.ToLower(request.GetString("format", "json"))
+	if format != "json" && format != "ascii" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported format %q; use json or ascii", format),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Validate the path is within allowed directories
 	validPath, err := fs.validatePath(path)
 	if err != nil {
@@ -64,13 +79,13 @@ func (fs *FilesystemHandler) HandleTree(
 	}
 
 	// Check if it's a directory
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Error: %v", err),
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
 				},
 			},
 			IsError: true,
@@ -90,7 +105,9 @@ func (fs *FilesystemHandler) HandleTree(
 	}
 
 	// Build the tree structure
-	tree, err := fs.buildTree(validPath, depth, 0, followSymlinks)
+	guard := &walkGuard{ctx: ctx, maxDepth: fs.maxWalkDepth, maxEntries: fs.maxWalkEntries, skip: fs.hiddenFromListings,
+		ignore: fs.gitignoreFor(request, validPath)}
+	tree, err := fs.buildTree(validPath, depth, 0, followSymlinks, guard)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -103,6 +120,28 @@ func (fs *FilesystemHandler) HandleTree(
 		}, nil
 	}
 
+	if format == "ascii" {
+		var sb strings.Builder
+		writeASCIITree(&sb, tree, "", true, true)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Directory tree for %s (max depth: %d):\n\n%s", fs.displayPath(validPath), depth, sb.String()) +
+						walkTruncatedNote(guard.truncated),
+				},
+				mcp.EmbeddedResource{
+					Type: "resource",
+					Resource: mcp.TextResourceContents{
+						URI:      fs.resourceURI(validPath),
+						MIMEType: "text/plain",
+						Text:     sb.String(),
+					},
+				},
+			},
+		}, nil
+	}
+
 	// Convert to JSON
 	jsonData, err := json.MarshalIndent(tree, "", "  ")
 	if err != nil {
@@ -118,14 +157,15 @@ func (fs *FilesystemHandler) HandleTree(
 	}
 
 	// Create resource URI for the directory
-	resourceURI := pathToResourceURI(validPath)
+	resourceURI := fs.resourceURI(validPath)
 
 	// Return the result
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Directory tree for %s (max depth: %d):\n\n%s", validPath, depth, string(jsonData)),
+				Text: fmt.Sprintf("Directory tree for %s (max depth: %d):\n\n%s", fs.displayPath(validPath), depth, string(jsonData)) +
+					walkTruncatedNote(guard.truncated),
 			},
 			mcp.EmbeddedResource{
 				Type: "resource",
@@ -139,8 +179,11 @@ func (fs *FilesystemHandler) HandleTree(
 	}, nil
 }
 
-// buildTree builds a tree representation of the filesystem starting at the given path
-func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth int, followSymlinks bool) (*FileNode, error) {
+// buildTree builds a tree representation of the filesystem starting at the
+// given path. Children are omitted once the walk limits of guard are reached.
+func (fs *FilesystemHandler) buildTree(
+	path string, maxDepth int, currentDepth int, followSymlinks bool, guard *walkGuard,
+) (*FileNode, error) {
 	// Validate the path
 	validPath, err := fs.validatePath(path)
 	if err != nil {
@@ -148,7 +191,7 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 	}
 
 	// Get file info
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +199,7 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 	// Create the node
 	node := &FileNode{
 		Name:     filepath.Base(validPath),
-		Path:     validPath,
+		Path:     fs.displayPath(validPath),
 		Modified: info.ModTime(),
 	}
 
@@ -167,14 +210,30 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 		// If we haven't reached the max depth, process children
 		if currentDepth < maxDepth {
 			// Read directory entries
-			entries, err := os.ReadDir(validPath)
+			entries, err := fs.fsys.ReadDir(validPath)
 			if err != nil {
 				return nil, err
 			}
 
+			if len(entries) > 0 && guard.maxDepth > 0 && currentDepth >= guard.maxDepth {
+				guard.truncated = fmt.Sprintf("max_walk_depth limit of %d reached", guard.maxDepth)
+				entries = nil
+			}
+
 			// Process each entry
 			for _, entry := range entries {
+				if guard.stopped() {
+					break
+				}
 				entryPath := filepath.Join(validPath, entry.Name())
+				if guard.skip(entryPath) || guard.ignore.ignored(entryPath, entry.IsDir()) {
+					continue
+				}
+				guard.entries++
+				if guard.maxEntries > 0 && guard.entries > guard.maxEntries {
+					guard.truncated = fmt.Sprintf("max_walk_entries limit of %d reached", guard.maxEntries)
+					break
+				}
 
 				// Handle symlinks
 				if entry.Type()&os.ModeSymlink != 0 {
@@ -184,23 +243,23 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 					}
 
 					// Resolve symlink
-					linkDest, err := filepath.EvalSymlinks(entryPath)
+					linkDest, err := fs.fsys.EvalSymlinks(entryPath)
 					if err != nil {
 						// Skip invalid symlinks
 						continue
 					}
 
-					// Validate the symlink destination is within allowed directories
-					if !fs.isPathInAllowedDirs(linkDest) {
-						// Skip symlinks pointing outside allowed directories
+					// Links pointing outside allowed directories are skipped,
+					// unless the symlink policy follows them through the link
+					if fs.isPathInAllowedDirs(linkDest) {
+						entryPath = linkDest
+					} else if fs.symlinkPolicy != SymlinkFollow {
 						continue
 					}
-
-					entryPath = linkDest
 				}
 
 				// Recursively build child node
-				childNode, err := fs.buildTree(entryPath, maxDepth, currentDepth+1, followSymlinks)
+				childNode, err := fs.buildTree(entryPath, maxDepth, currentDepth+1, followSymlinks, guard)
 				if err != nil {
 					// Skip entries with errors
 					continue
@@ -217,3 +276,31 @@ func (fs *FilesystemHandler) buildTree(path string, maxDepth int, currentDepth i
 
 	return node, nil
 }
+
+// writeASCIITree renders node and its children in the style of the tree
+// command, drawing branches with ├──, └── and │. Directories end in a slash
+// and files show their size.
+func writeASCIITree(sb *strings.Builder, node *FileNode, prefix string, last, root bool) {
+	name := node.Name
+	if node.Type == "directory" {
+		name += "/"
+	} else {
+		name += fmt.Sprintf(" (%d bytes)", node.Size)
+	}
+
+	childPrefix := prefix
+	switch {
+	case root:
+		sb.WriteString(name + "\n")
+	case last:
+		sb.WriteString(prefix + "└── " + name + "\n")
+		childPrefix += "    "
+	default:
+		sb.WriteString(prefix + "├── " + name + "\n")
+		childPrefix += "│   "
+	}
+
+	for i, child := range node.Children {
+		writeASCIITree(sb, child, childPrefix, i == len(node.Children)-1, false)
+	}
+}
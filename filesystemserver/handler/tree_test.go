@@ -206,6 +206,47 @@ func TestHandleTree(t *testing.T) {
 		require.True(t, res.IsError)
 	})
 
+	t.Run("tree in ascii format", func(t *testing.T) {
+		req := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]interface{}{
+					"path":   tmpDir,
+					"format": "ascii",
+				},
+			},
+		}
+
+		res, err := fsHandler.HandleTree(ctx, req)
+		require.NoError(t, err)
+		require.False(t, res.IsError)
+
+		resource := res.Content[1].(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+		assert.Equal(t, "text/plain", resource.MIMEType)
+		expected := filepath.Base(tmpDir) + "/\n" +
+			"├── emptydir/\n" +
+			"├── file1.txt (8 bytes)\n" +
+			"└── subdir1/\n" +
+			"    ├── file2.txt (8 bytes)\n" +
+			"    └── subdir2/\n" +
+			"        └── file3.txt (8 bytes)\n"
+		assert.Equal(t, expected, resource.Text)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		req := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Arguments: map[string]interface{}{
+					"path":   tmpDir,
+					"format": "xml",
+				},
+			},
+		}
+
+		res, err := fsHandler.HandleTree(ctx, req)
+		require.NoError(t, err)
+		require.True(t, res.IsError)
+	})
+
 	t.Run("path is in a non-allowed directory", func(t *testing.T) {
 		otherDir := t.TempDir()
 
@@ -9,8 +9,66 @@ const (
 	MAX_BASE64_SIZE = 1 * 1024 * 1024
 	// Maximum number of search results to return (prevent excessive output)
 	MAX_SEARCH_RESULTS = 1000
+	// Maximum number of context lines shown before or after a search match
+	MAX_CONTEXT_LINES = 50
 	// Maximum file size in bytes to search within (10MB)
 	MAX_SEARCHABLE_SIZE = 10 * 1024 * 1024
+	// Maximum aggregate size of the files read by a single batch call (20MB)
+	MAX_BATCH_READ_SIZE = 20 * 1024 * 1024
+	// Maximum number of files that can be followed at the same time
+	MAX_FOLLOWS = 10
+	// Maximum number of paths that can be watched at the same time
+	MAX_WATCHES = 10
+	// Default number of follows and watches that may be active at once
+	DEFAULT_RESOURCE_BUDGET = 32
+	// Maximum number of bytes read from a followed file per poll (1MB)
+	MAX_FOLLOW_READ_SIZE = 1 * 1024 * 1024
+	// Interval between checks of a followed file for new content
+	FOLLOW_POLL_INTERVAL = 500 * time.Millisecond
+	// Files of at least this size are copied in chunks with progress and a
+	// checksum verification of the destination (64MB)
+	VERIFIED_COPY_THRESHOLD = 64 * 1024 * 1024
+	// Chunk size used by verified copies (4MB)
+	COPY_CHUNK_SIZE = 4 * 1024 * 1024
+	// Maximum size of a file hashed by find_duplicates (512MB)
+	MAX_HASH_SIZE = 512 * 1024 * 1024
+	// Maximum uncompressed size of the entries searched inside one zip
+	// archive when a search follows into archives (100MB)
+	MAX_ARCHIVE_SCAN_SIZE = 100 * 1024 * 1024
+	// Maximum size of a document extract_text reads (50MB)
+	MAX_EXTRACT_SIZE = 50 * 1024 * 1024
+	// Default size of a read_file_chunk chunk (64KB)
+	DEFAULT_CHUNK_SIZE = 64 * 1024
+	// Maximum size of a read_file_chunk chunk (1MB)
+	MAX_CHUNK_SIZE = 1 * 1024 * 1024
+	// Default number of bytes read by sniff_file
+	DEFAULT_SNIFF_SIZE = 512
+	// Maximum number of bytes read by sniff_file (64KB)
+	MAX_SNIFF_SIZE = 64 * 1024
+	// Number of bytes read from each file to classify it as text or binary
+	CLASSIFY_SNIFF_SIZE = 4 * 1024
+	// Default depth below the starting directory at which walks stop
+	// descending
+	DEFAULT_MAX_WALK_DEPTH = 128
+	// Default number of entries after which a walk stops
+	DEFAULT_MAX_WALK_ENTRIES = 1000000
+	// Time ping waits for an allowed directory to respond before reporting
+	// it as unreachable
+	PING_STAT_TIMEOUT = 2 * time.Second
+	// Default upper bound of the timeout_ms a tool call may ask for
+	DEFAULT_MAX_CALL_TIMEOUT = 10 * time.Minute
+	// Time a tool call past its timeout_ms is given to return the partial
+	// results it has before a timeout error is returned instead
+	CALL_TIMEOUT_GRACE = 250 * time.Millisecond
+	// Default time a confirmation token of a destructive tool stays valid
+	DEFAULT_CONFIRMATION_TTL = 5 * time.Minute
+	// Default period at which the content index is brought up to date
+	DEFAULT_INDEX_REFRESH_INTERVAL = 5 * time.Minute
+	// Number of files past which the content index stops growing and
+	// searches walk the tree instead
+	DEFAULT_INDEX_MAX_FILES = 200000
+	// Format version of the saved content index
+	CONTENT_INDEX_VERSION = 2
 )
 
 type FileInfo struct {
@@ -20,7 +78,109 @@ type FileInfo struct {
 	Accessed    time.Time `json:"accessed"`
 	IsDirectory bool      `json:"isDirectory"`
 	IsFile      bool      `json:"isFile"`
+	Type        string    `json:"type"`                 // "file", "directory" or "symlink"
+	LinkTarget  string    `json:"linkTarget,omitempty"` // target of a symlink that was not followed
 	Permissions string    `json:"permissions"`
+	ModeOctal   string    `json:"modeOctal"`       // permission and special bits, e.g. "0755"
+	ModeString  string    `json:"modeString"`      // e.g. "drwxr-xr-x"
+	Owner       string    `json:"owner,omitempty"` // "name (uid)"; not available on Windows
+	Group       string    `json:"group,omitempty"` // "name (gid)"; not available on Windows
+}
+
+// DirectorySummary describes the contents of a directory tree for
+// get_file_info with recursive. Directories excludes the summarized directory
+// itself; symlinks are counted but never followed.
+type DirectorySummary struct {
+	Files           int
+	Directories     int
+	Symlinks        int
+	SkippedSymlinks int // broken or pointing outside the allowed directories
+	TotalBytes      int64
+	Newest, Oldest  time.Time
+	NewestPath      string
+	OldestPath      string
+	Extensions      map[string]*ExtensionSummary
+}
+
+// ExtensionSummary counts the files of a DirectorySummary with one extension
+type ExtensionSummary struct {
+	Files int
+	Bytes int64
+}
+
+// DirectoryManifest is the result of directory_manifest. Paths are relative
+// to the directory, use forward slashes and are sorted, so that identical
+// trees give byte-for-byte identical manifests.
+type DirectoryManifest struct {
+	Files           []ManifestEntry `json:"files"`
+	TotalSize       int64           `json:"total_size"`
+	AggregateSHA256 string          `json:"aggregate_sha256,omitempty"`
+	Skipped         []ManifestSkip  `json:"skipped,omitempty"`
+	Truncated       string          `json:"truncated,omitempty"`
+}
+
+// ManifestEntry is one file of a DirectoryManifest
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ManifestSkip is a path directory_manifest could not hash, and why
+type ManifestSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// PathStat is the metadata stat_multiple reports for one requested path.
+// Size and Modified are only set for paths that exist.
+type PathStat struct {
+	Path     string     `json:"path"`
+	Exists   bool       `json:"exists"`
+	Type     string     `json:"type,omitempty"` // "file", "directory" or "other"
+	Size     *int64     `json:"size,omitempty"`
+	Modified *time.Time `json:"mtime,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// GlobReadResult is one file read by read_glob. Content is empty for files
+// that were not read, with Error saying why; Skipped marks the files left out
+// because the byte budget was used up.
+type GlobReadResult struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Error   string `json:"error,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// FileLines is the content of a text file read with as_lines. Lines hold no
+// line terminators; LineEnding is "lf", "crlf", "cr", "mixed" or "none" for a
+// file without line breaks.
+type FileLines struct {
+	Lines           []string `json:"lines"`
+	LineCount       int      `json:"line_count"`
+	LineEnding      string   `json:"line_ending"`
+	TrailingNewline bool     `json:"trailing_newline"`
+}
+
+// FileWriteResult is the outcome write_multiple_files reports for one file.
+// Bytes and SHA256 are only set for files that were written.
+type FileWriteResult struct {
+	Path     string     `json:"path"`
+	Success  bool       `json:"success"`
+	Bytes    int        `json:"bytes,omitempty"`
+	SHA256   string     `json:"sha256,omitempty"`
+	Modified *time.Time `json:"mtime,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// WrittenFile records what a write or edit tool left on disk, as read back
+// after the write
+type WrittenFile struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Modified time.Time `json:"mtime"`
 }
 
 // FileNode represents a node in the file tree
@@ -33,10 +193,47 @@ type FileNode struct {
 	Children []*FileNode `json:"children,omitempty"`
 }
 
+// FileMatch represents a single search_files match along with the metadata
+// gathered while walking the directory tree
+type FileMatch struct {
+	Path     string
+	Type     string // "file" or "directory"
+	Size     int64
+	Modified time.Time
+	Class    string // "text" or "binary" for classified files
+}
+
 // SearchResult represents a single match in a file
 type SearchResult struct {
 	FilePath    string
 	LineNumber  int
 	LineContent string
 	ResourceURI string
+	// Column is the 1-based byte offset of the first match in the line, and
+	// Match the text it matched
+	Column int
+	Match  string
+	// Before and After hold the lines around the match when context was
+	// requested
+	Before []ContextLine
+	After  []ContextLine
+}
+
+// SearchMatch is a match of search_within_files as listed under matches in
+// the result's _meta
+type SearchMatch struct {
+	Path   string        `json:"path"`
+	URI    string        `json:"uri"`
+	Line   int           `json:"line"`
+	Column int           `json:"column"`
+	Text   string        `json:"text"`
+	Match  string        `json:"match"`
+	Before []ContextLine `json:"before,omitempty"`
+	After  []ContextLine `json:"after,omitempty"`
+}
+
+// ContextLine is a numbered line shown around a search match
+type ContextLine struct {
+	Number int    `json:"line"`
+	Text   string `json:"text"`
 }
@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// pathWatcher reports changes to a file, or to the entries of a directory,
+// through notify as they are made, using the operating system's file change
+// notifications. A file is watched through its parent directory so that
+// editors that save by replacing the file are noticed.
+type pathWatcher struct {
+	id        string
+	session   string // the client session that started the watch
+	path      string
+	file      bool // path is a file rather than a directory
+	recursive bool
+	skip      func(path string) bool
+	dirsBelow func(dir string) ([]string, error)
+	notify    func(path, op string) error
+	logger    *slog.Logger
+
+	watcher  *fsnotify.Watcher
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPathWatcher starts watching path through dirs, the directories the
+// caller listed: path itself, or for a recursive watch the tree below it.
+// While it runs, the watcher lists the directories below a new subdirectory
+// with dirsBelow and drops the events for paths that skip leaves out.
+func newPathWatcher(session, path string, file, recursive bool, dirs []string, skip func(path string) bool, dirsBelow func(dir string) ([]string, error), notify func(path, op string) error, logger *slog.Logger) (*pathWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &pathWatcher{
+		session:   session,
+		path:      path,
+		file:      file,
+		recursive: recursive && !file,
+		skip:      skip,
+		dirsBelow: dirsBelow,
+		notify:    notify,
+		logger:    logger,
+		watcher:   watcher,
+		stop:      make(chan struct{}),
+	}
+	if file {
+		dirs = []string{filepath.Dir(path)}
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// run delivers events until the watcher is closed or notify reports that the
+// client session is gone. onExit is called when run returns.
+func (w *pathWatcher) run(onExit func()) {
+	defer onExit()
+	defer w.watcher.Close()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warn("File watch error", "watch_id", w.id, "error", err)
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Permission and timestamp changes leave the content as it was
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if w.file && event.Name != w.path || w.skip(event.Name) {
+				continue
+			}
+			if w.recursive && event.Has(fsnotify.Create) {
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					if err := w.add(event.Name); err != nil {
+						w.logger.Warn("Cannot watch new directory", "watch_id", w.id, "path", event.Name, "error", err)
+					}
+				}
+			}
+			if err := w.notify(event.Name, strings.ToLower(event.Op.String())); errors.Is(err, server.ErrSessionNotFound) {
+				return
+			}
+		}
+	}
+}
+
+// add watches a directory created below a recursive watch and the
+// directories below it
+func (w *pathWatcher) add(dir string) error {
+	dirs, err := w.dirsBelow(dir)
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if err := w.watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops the watcher. Unlike a follower it does not wait for its
+// goroutine, which may be waiting for the configuration held by the caller.
+func (w *pathWatcher) Close() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	return nil
+}
+
+// removeWatch forgets a watcher once it has stopped and returns its share of
+// the resource budget
+func (fs *FilesystemHandler) removeWatch(w *pathWatcher) {
+	fs.watchesMu.Lock()
+	if fs.watches[w.id] == w {
+		delete(fs.watches, w.id)
+	}
+	fs.watchesMu.Unlock()
+	fs.unregisterCloser(w)
+	fs.budget.release("watch")
+}
+
+func (fs *FilesystemHandler) HandleWatchPath(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	recursive := request.GetBool("recursive", false)
+
+	// Changes are delivered as notifications, which requires a client session
+	mcpServer := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if mcpServer == nil || session == nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: watch_path requires an active client session to deliver notifications",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Change notifications come from the operating system
+	if !fs.onDisk() {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: watch_path is only available on the operating system's filesystem",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", fs.displayError(err)),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// The tree of a recursive watch is listed within this call, which holds
+	// the configuration already, and within the walk limits
+	dirs := []string{validPath}
+	truncated := ""
+	if recursive && info.IsDir() {
+		dirs, truncated, err = fs.watchedDirs(ctx, validPath)
+		if err == nil && ctx.Err() != nil {
+			err = fmt.Errorf("%s", truncated)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// The watcher's goroutine runs outside tool calls, so it holds the
+	// configuration itself while it consults it
+	skip := func(path string) bool {
+		fs.configMu.RLock()
+		defer fs.configMu.RUnlock()
+		return fs.rootForPath(path) == "" || fs.hiddenFromListings(path)
+	}
+	dirsBelow := func(dir string) ([]string, error) {
+		fs.configMu.RLock()
+		defer fs.configMu.RUnlock()
+		dirs, _, err := fs.watchedDirs(context.Background(), dir)
+		return dirs, err
+	}
+	var id string
+	sessionID := session.SessionID()
+	notify := func(path, op string) error {
+		fs.configMu.RLock()
+		uri := fs.resourceURI(path)
+		fs.configMu.RUnlock()
+		return mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": uri,
+			"_meta": map[string]any{
+				"watch_id": id,
+				"event":    op,
+			},
+		})
+	}
+
+	watcher, err := newPathWatcher(sessionID, validPath, !info.IsDir(), recursive, dirs, skip, dirsBelow, notify, fs.logger)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	fs.watchesMu.Lock()
+	if len(fs.watches) >= MAX_WATCHES {
+		fs.watchesMu.Unlock()
+		watcher.watcher.Close()
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: too many active watches (maximum is %d). Use stop_watch to stop one first.", MAX_WATCHES),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if err := fs.budget.acquire("watch"); err != nil {
+		fs.watchesMu.Unlock()
+		watcher.watcher.Close()
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	fs.watchSeq++
+	id = fmt.Sprintf("watch-%d", fs.watchSeq)
+	watcher.id = id
+	if fs.watches == nil {
+		fs.watches = make(map[string]*pathWatcher)
+	}
+	fs.watches[id] = watcher
+	fs.watchesMu.Unlock()
+
+	fs.registerCloser(watcher)
+	go watcher.run(func() { fs.removeWatch(watcher) })
+
+	var result strings.Builder
+	kind := "directory"
+	if watcher.file {
+		kind = "file"
+	} else if watcher.recursive {
+		kind = "directory tree"
+	}
+	result.WriteString(fmt.Sprintf("Watching %s %s (watch_id: %s)\n", kind, fs.displayPath(validPath), id))
+	result.WriteString("Changes are delivered as notifications/resources/updated notifications carrying the URI of the changed path. Use stop_watch to stop watching.\n")
+	if truncated != "" {
+		result.WriteString(fmt.Sprintf("Note: truncated: true (%s). Only the directories listed before the limit are watched.\n", truncated))
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: result.String(),
+			},
+		},
+	}, nil
+}
+
+// watchedDirs lists dir and the directories below it for a recursive watch,
+// leaving out those that listings hide or that lie outside the allowed
+// directories. truncated is set if the walk limits or the context cut the
+// listing short.
+func (fs *FilesystemHandler) watchedDirs(ctx context.Context, dir string) (dirs []string, truncated string, err error) {
+	truncated, err = fs.walkTree(ctx, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && fs.rootForPath(path) == "" {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, truncated, err
+}
+
+func (fs *FilesystemHandler) HandleStopWatch(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("watch_id")
+	if err != nil {
+		return nil, err
+	}
+
+	// A watch can only be stopped by the session that started it
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+
+	fs.watchesMu.Lock()
+	watcher, ok := fs.watches[id]
+	ok = ok && watcher.session == sessionID
+	if ok {
+		delete(fs.watches, id)
+	}
+	fs.watchesMu.Unlock()
+
+	if !ok {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: no active watch with id %s", id),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	watcher.Close()
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Stopped watching %s (watch_id: %s)", fs.displayPath(watcher.path), id),
+			},
+		},
+	}, nil
+}
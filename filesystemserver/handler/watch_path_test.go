@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathWatcher(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "watched.txt"), []byte("v1"), 0644))
+
+	type change struct{ path, op string }
+	start := func(t *testing.T, path string, recursive bool) chan change {
+		changes := make(chan change, 100)
+		skip := func(path string) bool { return filepath.Base(path) == ".env" }
+		dirsBelow := func(dir string) ([]string, error) {
+			var dirs []string
+			err := walk(OSFileSystem{}, dir, func(path string, info os.FileInfo, err error) error {
+				if err == nil && info.IsDir() {
+					dirs = append(dirs, path)
+				}
+				return nil
+			})
+			return dirs, err
+		}
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		dirs := []string{path}
+		if recursive {
+			dirs, err = dirsBelow(path)
+			require.NoError(t, err)
+		}
+		watcher, err := newPathWatcher("", path, !info.IsDir(), recursive, dirs, skip, dirsBelow, func(path, op string) error {
+			changes <- change{path, op}
+			return nil
+		}, slog.New(slog.DiscardHandler))
+		require.NoError(t, err)
+		go watcher.run(func() {})
+		t.Cleanup(func() { watcher.Close() })
+		return changes
+	}
+	next := func(t *testing.T, changes chan change) change {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a change")
+			return change{}
+		}
+	}
+
+	t.Run("file changes are reported", func(t *testing.T) {
+		path := filepath.Join(dir, "watched.txt")
+		changes := start(t, path, false)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0644))
+		require.NoError(t, os.WriteFile(path, []byte("v2"), 0644))
+
+		c := next(t, changes)
+		assert.Equal(t, path, c.path)
+		assert.Contains(t, c.op, "write")
+	})
+
+	t.Run("recursive watches report new subdirectories", func(t *testing.T) {
+		changes := start(t, dir, true)
+		sub := filepath.Join(dir, "sub")
+		require.NoError(t, os.Mkdir(sub, 0755))
+		assert.Equal(t, sub, next(t, changes).path)
+
+		// Skipped paths are not reported
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".env"), []byte("TOKEN=x"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(sub, "new.txt"), []byte("x"), 0644))
+		assert.Equal(t, filepath.Join(sub, "new.txt"), next(t, changes).path)
+	})
+}
+
+func TestHandleWatchPath_RequiresSession(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "watch_path"
+	request.Params.Arguments = map[string]any{"path": dir}
+	result, err := handler.HandleWatchPath(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "requires an active client session")
+}
+
+// testSession is a client session that discards its notifications
+type testSession struct {
+	id string
+}
+
+func (s testSession) Initialize()       {}
+func (s testSession) Initialized() bool { return true }
+func (s testSession) SessionID() string { return s.id }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 100)
+}
+
+// sessionCaller returns a function that calls a tool of handler through an
+// MCP server, as the client of a session
+func sessionCaller(t *testing.T, handler *FilesystemHandler) func(session, name string, args map[string]any) mcp.CallToolResult {
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(false))
+	mcpServer.AddTool(mcp.NewTool("watch_path"), handler.HandleWatchPath)
+	mcpServer.AddTool(mcp.NewTool("stop_watch"), handler.HandleStopWatch)
+	mcpServer.AddTool(mcp.NewTool("follow_file"), handler.HandleFollowFile)
+	mcpServer.AddTool(mcp.NewTool("stop_follow"), handler.HandleStopFollow)
+	registered := map[string]bool{}
+
+	return func(session, name string, args map[string]any) mcp.CallToolResult {
+		s := testSession{id: session}
+		if !registered[session] {
+			require.NoError(t, mcpServer.RegisterSession(context.Background(), s))
+			t.Cleanup(func() { mcpServer.UnregisterSession(context.Background(), session) })
+			registered[session] = true
+		}
+		message, err := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "tools/call",
+			"params":  map[string]any{"name": name, "arguments": args},
+		})
+		require.NoError(t, err)
+		response := mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), s), message)
+		result, ok := response.(mcp.JSONRPCResponse)
+		require.True(t, ok, "%v", response)
+		return result.Result.(mcp.CallToolResult)
+	}
+}
+
+func TestHandleStopWatch_OtherSession(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	defer handler.Close()
+	call := sessionCaller(t, handler)
+
+	result := call("owner", "watch_path", map[string]any{"path": dir})
+	require.False(t, result.IsError, "%v", result.Content)
+
+	result = call("intruder", "stop_watch", map[string]any{"watch_id": "watch-1"})
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "no active watch")
+
+	result = call("owner", "stop_watch", map[string]any{"watch_id": "watch-1"})
+	assert.False(t, result.IsError, "%v", result.Content)
+}
+
+func TestHandleWatchPath_PendingReload(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0755))
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+	defer handler.Close()
+	call := sessionCaller(t, handler)
+
+	// Hold the configuration as a tool call does, and have a reload wait for
+	// it, which blocks any further read lock
+	handler.configMu.RLock()
+	reloaded := make(chan struct{})
+	go func() {
+		handler.configMu.Lock()
+		handler.configMu.Unlock()
+		close(reloaded)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan mcp.CallToolResult, 1)
+	go func() { done <- call("owner", "watch_path", map[string]any{"path": dir, "recursive": true}) }()
+	select {
+	case result := <-done:
+		assert.False(t, result.IsError, "%v", result.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch_path deadlocked with a pending reload")
+	}
+	handler.configMu.RUnlock()
+	<-reloaded
+}
+
+func TestHandleWatchPath_WalkLimits(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name, "sub"), 0755))
+	}
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir), WithWalkLimits(0, 3))
+	require.NoError(t, err)
+	defer handler.Close()
+	call := sessionCaller(t, handler)
+
+	result := call("owner", "watch_path", map[string]any{"path": dir, "recursive": true})
+	require.False(t, result.IsError, "%v", result.Content)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "truncated: true (max_walk_entries limit of 3 reached)")
+}
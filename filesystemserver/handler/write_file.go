@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -21,6 +24,29 @@ func (fs *FilesystemHandler) HandleWriteFile(
 	if err != nil {
 		return nil, err
 	}
+	expectedSHA256 := strings.ToLower(strings.TrimSpace(request.GetString("expected_sha256", "")))
+	rollbackOnMismatch := request.GetBool("rollback_on_mismatch", false)
+	ifMatchSHA256 := strings.ToLower(strings.TrimSpace(request.GetString("if_match_sha256", "")))
+	encodingName := request.GetString("encoding", "")
+	writeBOM := request.GetBool("write_bom", false)
+	ensureNewline := request.GetBool("ensure_trailing_newline", false)
+	allowSpecial := request.GetBool("allow_special", false)
+	applyEditorConfigRules := request.GetBool("apply_editorconfig", false)
+	backup := request.GetBool("backup", false)
+	durable := request.GetBool("durable", false)
+
+	mode := request.GetString("mode", "overwrite")
+	if mode != "overwrite" && mode != "append" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: unsupported mode '%s' (expected 'overwrite' or 'append')", mode),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
 
 	// Handle empty or relative paths like "." or "./" by converting to absolute path
 	if path == "." || path == "./" {
@@ -41,6 +67,9 @@ func (fs *FilesystemHandler) HandleWriteFile(
 	}
 
 	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -53,8 +82,20 @@ func (fs *FilesystemHandler) HandleWriteFile(
 		}, nil
 	}
 
+	if err := fs.refuseSpecialFile(validPath); err != nil && !allowSpecial {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Check if it's a directory
-	if info, err := os.Stat(validPath); err == nil && info.IsDir() {
+	if info, err := fs.fsys.Stat(validPath); err == nil && info.IsDir() {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -66,9 +107,70 @@ func (fs *FilesystemHandler) HandleWriteFile(
 		}, nil
 	}
 
+	// Bring the content in line with the project's .editorconfig; an explicit
+	// encoding or write_bom takes precedence over its charset
+	var editorConfigApplied []string
+	if applyEditorConfigRules {
+		props, err := fs.editorConfigFor(validPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error reading .editorconfig: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		content, editorConfigApplied = applyEditorConfig(content, props)
+		if name, bom, ok := editorConfigCharset(props["charset"]); ok && encodingName == "" && !writeBOM {
+			encodingName, writeBOM = name, bom
+			editorConfigApplied = append(editorConfigApplied, "charset="+props["charset"])
+		}
+	}
+
+	if ensureNewline && content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	// Content arrives as UTF-8; convert it to the requested encoding before
+	// anything is touched so an unsupported encoding is reported first
+	data, err := encodeText(content, encodingName, writeBOM)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Only overwrite the file if it still has the contents the client expects
+	if ifMatchSHA256 != "" {
+		current, err := fs.fileSHA256(validPath)
+		if err != nil && !os.IsNotExist(err) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error hashing existing file: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+		if current != ifMatchSHA256 {
+			return conflictResult(path, ifMatchSHA256, current), nil
+		}
+	}
+
 	// Create parent directories if they don't exist
 	parentDir := filepath.Dir(validPath)
-	if err := os.MkdirAll(parentDir, 0755); err != nil {
+	if _, err := fs.makeDirs(parentDir, fs.dirPerm); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -80,7 +182,79 @@ func (fs *FilesystemHandler) HandleWriteFile(
 		}, nil
 	}
 
-	if err := os.WriteFile(validPath, []byte(content), 0644); err != nil {
+	// Keep the previous contents so that a failed verification can be undone
+	var previous []byte
+	existed := false
+	if expectedSHA256 != "" && rollbackOnMismatch {
+		existing, err := readFileFull(fs.fsys, validPath)
+		if err == nil {
+			previous = existing
+			existed = true
+		} else if !os.IsNotExist(err) {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error reading existing file for rollback: %v", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	if mode == "append" {
+		if info, err := fs.fsys.Stat(validPath); err == nil && info.Size() > 0 {
+			// Appended content never starts with a byte order mark
+			data, _ = encodeText(content, encodingName, false)
+
+			// Keep the previous last line from being glued to the new content
+			if ensureNewline {
+				newline, _ := encodeText("\n", encodingName, false)
+				endsWithNewline, err := fs.fileEndsWith(validPath, info.Size(), newline)
+				if err != nil {
+					return &mcp.CallToolResult{
+						Content: []mcp.Content{
+							mcp.TextContent{
+								Type: "text",
+								Text: fmt.Sprintf("Error reading existing file: %v", err),
+							},
+						},
+						IsError: true,
+					}, nil
+				}
+				if !endsWithNewline {
+					data = append(newline, data...)
+				}
+			}
+		}
+	}
+
+	if result := fs.checkQuota(validPath, int64(len(data)), mode != "append"); result != nil {
+		return result, nil
+	}
+
+	// Keep a copy of the current contents for the caller to go back to
+	backupPath := ""
+	if backup {
+		backupPath, err = fs.backupFile(validPath)
+		if err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Error backing up file: %v; the file was not written", err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	// Never serve the previous contents from the read cache
+	defer fs.invalidateCache(validPath)
+
+	if err := fs.writeOrAppend(validPath, data, mode == "append", durable); err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
@@ -92,35 +266,188 @@ func (fs *FilesystemHandler) HandleWriteFile(
 		}, nil
 	}
 
+	// A pipe or device cannot be read back, so there is nothing to verify
+	if info, err := fs.fsys.Stat(validPath); err == nil && specialFileKind(info.Mode()) != "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Successfully wrote %d bytes to %s (%s)", len(data), path, specialFileKind(info.Mode())),
+				},
+			},
+		}, nil
+	}
+
+	// Read the file back and hash what actually landed on disk
+	digest, err := fs.fileSHA256(validPath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error verifying written file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if expectedSHA256 != "" && digest != expectedSHA256 {
+		message := fmt.Sprintf("Error: checksum mismatch for %s (expected sha256 %s, got %s)", path, expectedSHA256, digest)
+		if rollbackOnMismatch {
+			var rollbackErr error
+			if existed {
+				rollbackErr = fs.fsys.WriteFile(validPath, previous, fs.filePerm)
+			} else {
+				rollbackErr = fs.fsys.Remove(validPath)
+			}
+			if rollbackErr != nil {
+				message += fmt.Sprintf("; rollback failed: %v", rollbackErr)
+			} else {
+				message += "; the write was rolled back"
+			}
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: message,
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
 	// Get file info for the response
-	info, err := os.Stat(validPath)
+	info, err := fs.fsys.Stat(validPath)
 	if err != nil {
 		// File was written but we couldn't get info
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf("Successfully wrote to %s", path),
+					Text: fmt.Sprintf("Successfully wrote to %s\nSHA-256: %s", path, digest),
 				},
 			},
 		}, nil
 	}
 
-	resourceURI := pathToResourceURI(validPath)
-	return &mcp.CallToolResult{
+	verb, written := "wrote", info.Size()
+	if mode == "append" {
+		verb, written = "appended", int64(len(data))
+	}
+
+	message := fmt.Sprintf("Successfully %s %d bytes to %s", verb, written, path)
+	if backupPath != "" {
+		message += "\nBackup: " + fs.displayPath(backupPath)
+	}
+	if durable {
+		message += "\nDurable: flushed to disk"
+	}
+	if applyEditorConfigRules {
+		if len(editorConfigApplied) == 0 {
+			message += "\n.editorconfig: no matching rules"
+		} else {
+			message += "\n.editorconfig: " + strings.Join(editorConfigApplied, ", ")
+		}
+	}
+
+	resourceURI := fs.resourceURI(validPath)
+	return withWrittenFiles(&mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Successfully wrote %d bytes to %s", info.Size(), path),
+				Text: message,
 			},
 			mcp.EmbeddedResource{
 				Type: "resource",
 				Resource: mcp.TextResourceContents{
 					URI:      resourceURI,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("File: %s (%d bytes)", validPath, info.Size()),
+					Text:     fmt.Sprintf("File: %s (%d bytes)", fs.displayPath(validPath), info.Size()),
 				},
 			},
 		},
-	}, nil
+	}, WrittenFile{
+		Path:     fs.displayPath(validPath),
+		Size:     info.Size(),
+		SHA256:   digest,
+		Modified: info.ModTime(),
+	}), nil
+}
+
+// writeOrAppend writes data to the named file, replacing its contents or,
+// with appendData, adding data at its end. The file is created if needed, in
+// which case it gets the configured file mode; existing files keep theirs.
+// A regular file that does not end up with the expected size fails with a
+// short_io error. With durable the data is synced to disk before the file is
+// closed, as is the directory entry of a file that was created.
+func (fs *FilesystemHandler) writeOrAppend(name string, data []byte, appendData, durable bool) error {
+	before, statErr := fs.fsys.Stat(name)
+	created := os.IsNotExist(statErr)
+	want := int64(len(data))
+	if appendData && statErr == nil {
+		want += before.Size()
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendData {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := fs.fsys.OpenFile(name, flag, fs.filePerm)
+	if err != nil {
+		return err
+	}
+	n, err := f.Write(data)
+	if err == nil && n < len(data) {
+		err = shortIOError("write", name, int64(n), int64(len(data)))
+	}
+	if err == nil && durable {
+		// Pipes and devices cannot be synced
+		if info, statErr := f.Stat(); statErr == nil && info.Mode().IsRegular() {
+			err = f.Sync()
+		}
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if info, err := fs.fsys.Stat(name); err == nil && info.Mode().IsRegular() && info.Size() < want {
+		return shortIOError("write", name, info.Size()-(want-int64(len(data))), int64(len(data)))
+	}
+
+	// The create mode is subject to the umask
+	if created && runtime.GOOS != "windows" {
+		if err := fs.fsys.Chmod(name, fs.filePerm); err != nil {
+			return err
+		}
+	}
+	if durable && created {
+		if err := syncDir(fs.fsys, filepath.Dir(name)); err != nil {
+			return fmt.Errorf("syncing directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// fileEndsWith reports whether the named file, of the given size, ends with
+// suffix
+func (fs *FilesystemHandler) fileEndsWith(name string, size int64, suffix []byte) (bool, error) {
+	if size < int64(len(suffix)) {
+		return false, nil
+	}
+	f, err := fs.fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	tail := make([]byte, len(suffix))
+	if _, err := f.ReadAt(tail, size-int64(len(suffix))); err != nil {
+		return false, err
+	}
+	return bytes.Equal(tail, suffix), nil
 }
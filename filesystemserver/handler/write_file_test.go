@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile_ExpectedSHA256(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("hello"))
+	digest := hex.EncodeToString(sum[:])
+
+	write := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "write_file"
+		request.Params.Arguments = args
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("matching digest succeeds and is reported", func(t *testing.T) {
+		path := filepath.Join(dir, "match.txt")
+		result := write(map[string]any{"path": path, "content": "hello", "expected_sha256": digest})
+		require.False(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "SHA-256: "+digest)
+	})
+
+	t.Run("mismatch fails and rolls back", func(t *testing.T) {
+		path := filepath.Join(dir, "rollback.txt")
+		require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+
+		result := write(map[string]any{
+			"path":                 path,
+			"content":              "changed",
+			"expected_sha256":      digest,
+			"rollback_on_mismatch": true,
+		})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "checksum mismatch")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data))
+	})
+
+	t.Run("mismatch on a new file removes it", func(t *testing.T) {
+		path := filepath.Join(dir, "new.txt")
+		result := write(map[string]any{
+			"path":                 path,
+			"content":              "changed",
+			"expected_sha256":      digest,
+			"rollback_on_mismatch": true,
+		})
+		require.True(t, result.IsError)
+		assert.NoFileExists(t, path)
+	})
+}
+
+func TestWriteFile_IfMatchSHA256(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	sum := sha256.Sum256([]byte("hello"))
+	digest := hex.EncodeToString(sum[:])
+
+	t.Run("stale digest is a conflict", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "write_file"
+		request.Params.Arguments = map[string]any{"path": path, "content": "new", "if_match_sha256": "deadbeef"}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "conflict")
+		assert.Contains(t, text, digest)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("modify_file checks the precondition", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "modify_file"
+		args := map[string]any{"path": path, "find": "hello", "replace": "bye", "if_match_sha256": "deadbeef"}
+		request.Params.Arguments = args
+		result, err := handler.HandleModifyFile(context.Background(), request)
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+
+		args["if_match_sha256"] = digest
+		result, err = handler.HandleModifyFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	})
+
+	t.Run("matching digest writes", func(t *testing.T) {
+		sum := sha256.Sum256([]byte("bye"))
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "write_file"
+		request.Params.Arguments = map[string]any{"path": path, "content": "new", "if_match_sha256": hex.EncodeToString(sum[:])}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	})
+}
+
+func TestWriteFile_Encoding(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	write := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "write_file"
+		request.Params.Arguments = args
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		bom      bool
+		want     []byte
+	}{
+		{name: "default", want: []byte("hé")},
+		{name: "utf-8 with bom", encoding: "utf-8", bom: true, want: []byte("\xef\xbb\xbfhé")},
+		{name: "utf-16le with bom", encoding: "UTF-16LE", bom: true, want: []byte{0xff, 0xfe, 'h', 0, 0xe9, 0}},
+		{name: "windows-1252", encoding: "windows-1252", want: []byte{'h', 0xe9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "out.txt")
+			args := map[string]any{"path": path, "content": "hé"}
+			if tt.encoding != "" {
+				args["encoding"] = tt.encoding
+			}
+			if tt.bom {
+				args["write_bom"] = true
+			}
+			result := write(args)
+			require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+
+	t.Run("rejects unsupported encodings", func(t *testing.T) {
+		path := filepath.Join(dir, "bad.txt")
+		for _, args := range []map[string]any{
+			{"path": path, "content": "x", "encoding": "klingon"},
+			{"path": path, "content": "x", "encoding": "windows-1252", "write_bom": true},
+			{"path": path, "content": "€", "encoding": "iso-8859-1"},
+		} {
+			result := write(args)
+			assert.True(t, result.IsError)
+		}
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestWriteFile_Append(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := NewFilesystemHandler(resolveAllowedDirs(t, dir))
+	require.NoError(t, err)
+
+	write := func(args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = "write_file"
+		request.Params.Arguments = args
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		return result
+	}
+
+	t.Run("appends and creates", func(t *testing.T) {
+		path := filepath.Join(dir, "log.txt")
+		write(map[string]any{"path": path, "content": "one", "mode": "append"})
+		result := write(map[string]any{"path": path, "content": "two", "mode": "append"})
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Successfully appended 3 bytes")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "onetwo", string(data))
+	})
+
+	t.Run("ensure_trailing_newline", func(t *testing.T) {
+		path := filepath.Join(dir, "records.csv")
+		require.NoError(t, os.WriteFile(path, []byte("a,b"), 0644))
+
+		write(map[string]any{"path": path, "content": "c,d", "mode": "append", "ensure_trailing_newline": true})
+		write(map[string]any{"path": path, "content": "e,f\n", "mode": "append", "ensure_trailing_newline": true})
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "a,b\nc,d\ne,f\n", string(data))
+	})
+
+	t.Run("byte order mark only at the start", func(t *testing.T) {
+		path := filepath.Join(dir, "bom.txt")
+		args := map[string]any{"path": path, "content": "x", "mode": "append", "encoding": "utf-8", "write_bom": true}
+		write(args)
+		write(args)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "\xef\xbb\xbfxx", string(data))
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(dir, "x.txt"), "content": "x", "mode": "prepend"}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestWriteFile_EditorConfig(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".editorconfig"), []byte(
+		"root = true\n\n[*]\nend_of_line = lf\ninsert_final_newline = true\ntrim_trailing_whitespace = true\n\n[*.{bat,cmd}]\nend_of_line = crlf\n\n[*.md]\ntrim_trailing_whitespace = false\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "legacy"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "legacy", ".editorconfig"), []byte(
+		"[file{1..3}.txt]\ninsert_final_newline = false\n"), 0644))
+
+	write := func(path, content string) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": path, "content": content, "apply_editorconfig": true}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "a\nb\n", write(filepath.Join(dir, "main.go"), "a  \r\nb\t"))
+	assert.Equal(t, "echo\r\noff\r\n", write(filepath.Join(dir, "run.bat"), "echo \noff"))
+	assert.Equal(t, "line  \nnext\n", write(filepath.Join(dir, "notes.md"), "line  \r\nnext"))
+	assert.Equal(t, "x\ny", write(filepath.Join(dir, "legacy", "file2.txt"), "x\ny\n\n"))
+	assert.Equal(t, "x\n", write(filepath.Join(dir, "legacy", "file7.txt"), "x"))
+
+	t.Run("off by default", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"path": filepath.Join(dir, "raw.txt"), "content": "a \r\n"}
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		data, err := os.ReadFile(filepath.Join(dir, "raw.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "a \r\n", string(data))
+	})
+}
+
+func TestWriteFile_Durable(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	write := func(args map[string]any) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler.HandleWriteFile(context.Background(), request)
+		require.NoError(t, err)
+		text := result.Content[0].(mcp.TextContent).Text
+		require.False(t, result.IsError, text)
+		return text
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		path := filepath.Join(dir, "handoff.json")
+		text := write(map[string]any{"path": path, "content": "{}", "durable": true})
+		assert.Contains(t, text, "Durable:")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{}", string(data))
+	})
+
+	t.Run("append", func(t *testing.T) {
+		path := filepath.Join(dir, "handoff.json")
+		write(map[string]any{"path": path, "content": "\n", "mode": "append", "durable": true})
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(data))
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		text := write(map[string]any{"path": filepath.Join(dir, "fast.txt"), "content": "x"})
+		assert.NotContains(t, text, "Durable:")
+	})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithTemplatesDir sets the directory that write_from_template reads its
+// templates from. It must lie within the allowed directories.
+func WithTemplatesDir(dir string) Option {
+	return func(fs *FilesystemHandler) {
+		fs.templatesDir = dir
+	}
+}
+
+// resolveTemplatesDir validates the configured templates directory and
+// replaces it with its real path
+func (fs *FilesystemHandler) resolveTemplatesDir() error {
+	if fs.templatesDir == "" {
+		return nil
+	}
+	validPath, err := fs.confinePath(fs.templatesDir)
+	if err != nil {
+		return fmt.Errorf("templates directory: %w", err)
+	}
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return fmt.Errorf("templates directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("templates directory is not a directory: %s", fs.templatesDir)
+	}
+	fs.templatesDir = validPath
+	return nil
+}
+
+// HandleWriteFromTemplate renders a template from the templates directory
+// with text/template and writes the result atomically. The template is fully
+// rendered before anything is written, so a failed render leaves the
+// destination untouched.
+func (fs *FilesystemHandler) HandleWriteFromTemplate(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("template")
+	if err != nil {
+		return nil, err
+	}
+	path, err := request.RequireString("path")
+	if err != nil {
+		return nil, err
+	}
+	variables, _ := request.GetArguments()["variables"].(map[string]any)
+
+	if fs.templatesDir == "" {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: "Error: no templates directory is configured",
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Template names are relative to the templates directory and may not
+	// leave it, even through a symbolic link
+	templatePath, err := fs.validatePath(filepath.Join(fs.templatesDir, filepath.FromSlash(name)))
+	if err == nil && !isWithin(fs.templatesDir, templatePath) {
+		err = fmt.Errorf("template %s is outside the templates directory", name)
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	source, err := readFileFull(fs.fsys, templatePath)
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error reading template: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(source))
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error parsing template: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, variables); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error rendering template: %v. Nothing was written.", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	validPath, err := fs.validatePath(path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
+	if err == nil {
+		if info, statErr := fs.fsys.Stat(validPath); statErr == nil && info.IsDir() {
+			err = fmt.Errorf("cannot write to a directory: %s", path)
+		}
+	}
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if result := fs.checkQuota(validPath, int64(rendered.Len()), true); result != nil {
+		return result, nil
+	}
+
+	defer fs.invalidateCache(validPath)
+	if err := writeFileAtomic(fs.fsys, validPath, rendered.Bytes(), fs.filePerm); err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error writing file: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Rendered template %s to %s (%d bytes)", name, path, rendered.Len()),
+			},
+			mcp.EmbeddedResource{
+				Type: "resource",
+				Resource: mcp.TextResourceContents{
+					URI:      fs.resourceURI(validPath),
+					MIMEType: "text/plain",
+					Text:     fmt.Sprintf("Rendered file: %s (%d bytes)", fs.displayPath(validPath), rendered.Len()),
+				},
+			},
+		},
+	}
+	if written, err := fs.writtenFile(validPath); err == nil {
+		withWrittenFiles(result, written)
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWriteFromTemplate(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	templates := filepath.Join(dir, "templates")
+	require.NoError(t, os.Mkdir(templates, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templates, "main.go.tmpl"), []byte("package {{.Package}}\n\n// {{.Name}} was generated\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("{{.}}"), 0644))
+
+	handler, err := NewFilesystemHandler([]string{dir}, WithTemplatesDir(templates))
+	require.NoError(t, err)
+	render := func(name, path string, variables map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"template": name, "path": path, "variables": variables}
+		result, err := handler.HandleWriteFromTemplate(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("renders the template", func(t *testing.T) {
+		dest := filepath.Join(dir, "main.go")
+		result := render("main.go.tmpl", dest, map[string]any{"Package": "main", "Name": "widget"})
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "(38 bytes)")
+
+		content, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "package main\n\n// widget was generated\n", string(content))
+	})
+
+	t.Run("missing variable writes nothing", func(t *testing.T) {
+		dest := filepath.Join(dir, "partial.go")
+		result := render("main.go.tmpl", dest, map[string]any{"Package": "main"})
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Name")
+		assert.NoFileExists(t, dest)
+	})
+
+	t.Run("templates outside the directory are rejected", func(t *testing.T) {
+		result := render("../secret.txt", filepath.Join(dir, "out.txt"), nil)
+		require.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "outside the templates directory")
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		unconfigured, err := NewFilesystemHandler([]string{dir})
+		require.NoError(t, err)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"template": "main.go.tmpl", "path": filepath.Join(dir, "x.go")}
+		result, err := unconfigured.HandleWriteFromTemplate(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("templates directory must be allowed", func(t *testing.T) {
+		_, err := NewFilesystemHandler([]string{templates}, WithTemplatesDir(dir))
+		assert.Error(t, err)
+	})
+}
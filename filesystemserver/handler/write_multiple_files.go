@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// batchWrite is one file of a write_multiple_files call, carried from
+// validation through the write and, for all_or_nothing batches, a rollback
+type batchWrite struct {
+	path      string
+	validPath string
+	data      []byte
+	perm      os.FileMode
+	ifMatch   string
+	previous  []byte
+	existed   bool
+	created   []string
+	err       error
+	undoErr   error // why an all_or_nothing rollback could not restore the file
+}
+
+// HandleWriteMultipleFiles writes several files in one call, each one
+// atomically. A file that fails is reported and the others are still
+// written, unless all_or_nothing is set: then every file is validated before
+// anything is written, and files already written are restored if a later
+// write fails.
+func (fs *FilesystemHandler) HandleWriteMultipleFiles(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+) (*mcp.CallToolResult, error) {
+	writes, err := parseBatchWrites(request.GetArguments()["files"])
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	if len(writes) > MAX_SEARCH_RESULTS {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error: Too many files requested. Maximum is %d files per request.", MAX_SEARCH_RESULTS),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+	allOrNothing := request.GetBool("all_or_nothing", false)
+
+	seen := make(map[string]bool, len(writes))
+	failed := false
+	for i := range writes {
+		fs.prepareBatchWrite(&writes[i], seen, allOrNothing)
+		failed = failed || writes[i].err != nil
+	}
+
+	aborted := ""
+	if allOrNothing && failed {
+		aborted = "not written because another file failed validation"
+	} else {
+		for i := range writes {
+			if writes[i].err != nil {
+				continue
+			}
+			fs.commitBatchWrite(&writes[i])
+			if writes[i].err != nil && allOrNothing {
+				aborted = fmt.Sprintf("rolled back because writing %s failed", writes[i].path)
+				fs.rollbackBatchWrites(writes[:i])
+				break
+			}
+		}
+	}
+
+	results := make([]FileWriteResult, len(writes))
+	var records []WrittenFile
+	written, undoFailed := 0, 0
+	for i, w := range writes {
+		results[i] = FileWriteResult{Path: w.path}
+		switch {
+		case w.err != nil:
+			results[i].Error = w.err.Error()
+		case w.undoErr != nil:
+			results[i].Error = fmt.Sprintf("%s, but this file was written and could not be restored: %v", aborted, w.undoErr)
+			undoFailed++
+		case aborted != "":
+			results[i].Error = aborted
+		default:
+			results[i].Success = true
+			results[i].Bytes = len(w.data)
+			sum := sha256.Sum256(w.data)
+			results[i].SHA256 = hex.EncodeToString(sum[:])
+			if record, err := fs.writtenFile(w.validPath); err == nil {
+				results[i].SHA256 = record.SHA256
+				results[i].Modified = &record.Modified
+				records = append(records, record)
+			}
+			written++
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Error generating JSON: %v", err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	summary := fmt.Sprintf("Wrote %d of %d files", written, len(writes))
+	switch {
+	case undoFailed > 0:
+		summary = fmt.Sprintf("Error: all_or_nothing batch failed and %d of the %d files could not be restored; they keep the content written by this call", undoFailed, len(writes))
+	case aborted != "":
+		summary = fmt.Sprintf("Error: all_or_nothing batch failed; none of the %d files were written", len(writes))
+	}
+	result := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: summary + "\n\n" + string(jsonData),
+			},
+		},
+		IsError: aborted != "",
+	}
+	if len(records) > 0 {
+		result.Meta = map[string]any{"written": records}
+	}
+	return result, nil
+}
+
+// prepareBatchWrite checks that w can be written and records the error on w
+// if not. With keepPrevious the current contents of an existing file are kept
+// so that the write can be undone.
+func (fs *FilesystemHandler) prepareBatchWrite(w *batchWrite, seen map[string]bool, keepPrevious bool) {
+	validPath, err := fs.validatePathWithParents(w.path)
+	if err == nil {
+		err = fs.authorize(validPath, OpWrite)
+	}
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.validPath = validPath
+	if seen[validPath] {
+		w.err = fmt.Errorf("%s is listed more than once", w.path)
+		return
+	}
+	seen[validPath] = true
+
+	w.perm = fs.filePerm
+	info, err := fs.fsys.Stat(validPath)
+	switch {
+	case err == nil && info.IsDir():
+		w.err = fmt.Errorf("cannot write to a directory")
+		return
+	case err == nil && specialFileKind(info.Mode()) != "":
+		w.err = fs.refuseSpecialFile(validPath)
+		return
+	case err == nil:
+		w.perm = info.Mode().Perm()
+		w.existed = true
+	case !os.IsNotExist(err):
+		w.err = err
+		return
+	}
+
+	if w.ifMatch != "" {
+		current, err := fs.fileSHA256(validPath)
+		if err != nil && !os.IsNotExist(err) {
+			w.err = fmt.Errorf("hashing existing file: %w", err)
+			return
+		}
+		if current != w.ifMatch {
+			if current == "" {
+				current = "none (file does not exist)"
+			}
+			w.err = fmt.Errorf("conflict - file has changed (if_match_sha256 %s, current sha256 %s)", w.ifMatch, current)
+			return
+		}
+	}
+
+	if result := fs.checkQuota(validPath, int64(len(w.data)), true); result != nil {
+		w.err = fmt.Errorf("%s", strings.TrimPrefix(result.Content[0].(mcp.TextContent).Text, "Error: "))
+		return
+	}
+
+	if keepPrevious && w.existed {
+		w.previous, w.err = readFileFull(fs.fsys, validPath)
+	}
+}
+
+// commitBatchWrite writes a prepared file, creating its parent directories.
+// The quota is checked again because earlier files in the batch count
+// against it.
+func (fs *FilesystemHandler) commitBatchWrite(w *batchWrite) {
+	if result := fs.checkQuota(w.validPath, int64(len(w.data)), true); result != nil {
+		w.err = fmt.Errorf("%s", strings.TrimPrefix(result.Content[0].(mcp.TextContent).Text, "Error: "))
+		return
+	}
+
+	defer fs.invalidateCache(w.validPath)
+	w.created, w.err = fs.makeDirs(filepath.Dir(w.validPath), fs.dirPerm)
+	if w.err != nil {
+		w.err = fmt.Errorf("creating parent directories: %w", w.err)
+		return
+	}
+	if err := writeFileAtomic(fs.fsys, w.validPath, w.data, w.perm); err != nil {
+		w.err = fmt.Errorf("writing file: %w", err)
+	}
+}
+
+// rollbackBatchWrites restores the files of an all_or_nothing batch that
+// were written before a later file failed, latest first, and removes the
+// directories that were created for them. A file that cannot be restored
+// records why in its undoErr. Directories that are not empty, because
+// something else was created in them meanwhile, are left in place.
+func (fs *FilesystemHandler) rollbackBatchWrites(writes []batchWrite) {
+	for i := len(writes) - 1; i >= 0; i-- {
+		w := &writes[i]
+		if w.err != nil {
+			continue
+		}
+		if w.existed {
+			w.undoErr = writeFileAtomic(fs.fsys, w.validPath, w.previous, w.perm)
+		} else {
+			w.undoErr = fs.fsys.Remove(w.validPath)
+		}
+		for _, dir := range w.created {
+			fs.fsys.Remove(dir)
+		}
+		fs.invalidateCache(w.validPath)
+	}
+}
+
+// parseBatchWrites reads the files argument of write_multiple_files, a list
+// of objects with path and content and the optional encoding, write_bom,
+// ensure_trailing_newline and if_match_sha256 members
+func parseBatchWrites(argument any) ([]batchWrite, error) {
+	list, ok := argument.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("files must be a non-empty list of {path, content} objects")
+	}
+	writes := make([]batchWrite, len(list))
+	for i, item := range list {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("file %d is not an object", i+1)
+		}
+		path, _ := fields["path"].(string)
+		content, contentOK := fields["content"].(string)
+		if path == "" || !contentOK {
+			return nil, fmt.Errorf("file %d needs string path and content members", i+1)
+		}
+		encodingName, _ := fields["encoding"].(string)
+		writeBOM, _ := fields["write_bom"].(bool)
+		ensureNewline, _ := fields["ensure_trailing_newline"].(bool)
+		ifMatch, _ := fields["if_match_sha256"].(string)
+
+		if ensureNewline && content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		data, err := encodeText(content, encodingName, writeBOM)
+		if err != nil {
+			return nil, fmt.Errorf("file %d (%s): %w", i+1, path, err)
+		}
+		writes[i] = batchWrite{
+			path:    path,
+			data:    data,
+			ifMatch: strings.ToLower(strings.TrimSpace(ifMatch)),
+		}
+	}
+	return writes, nil
+}
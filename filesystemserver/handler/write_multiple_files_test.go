@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWriteMultipleFiles(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir}, WithRootQuotas(map[string]int64{dir: 100}))
+	require.NoError(t, err)
+
+	write := func(files []any, allOrNothing bool) (string, bool) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"files": files, "all_or_nothing": allOrNothing}
+		result, err := handler.HandleWriteMultipleFiles(context.Background(), request)
+		require.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	t.Run("writes every file", func(t *testing.T) {
+		text, isError := write([]any{
+			map[string]any{"path": filepath.Join(dir, "a.txt"), "content": "alpha"},
+			map[string]any{"path": filepath.Join(dir, "src", "b.txt"), "content": "beta", "ensure_trailing_newline": true},
+		}, false)
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Wrote 2 of 2 files")
+
+		content, err := os.ReadFile(filepath.Join(dir, "src", "b.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "beta\n", string(content))
+	})
+
+	t.Run("failures do not stop the batch", func(t *testing.T) {
+		text, isError := write([]any{
+			map[string]any{"path": filepath.Join(t.TempDir(), "outside.txt"), "content": "x"},
+			map[string]any{"path": filepath.Join(dir, "c.txt"), "content": "gamma"},
+		}, false)
+		require.False(t, isError, text)
+		assert.Contains(t, text, "Wrote 1 of 2 files")
+		assert.FileExists(t, filepath.Join(dir, "c.txt"))
+	})
+
+	t.Run("all or nothing validates first", func(t *testing.T) {
+		text, isError := write([]any{
+			map[string]any{"path": filepath.Join(dir, "d.txt"), "content": "delta"},
+			map[string]any{"path": filepath.Join(dir, "a.txt"), "content": "x", "if_match_sha256": strings.Repeat("0", 64)},
+		}, true)
+		require.True(t, isError)
+		assert.Contains(t, text, "conflict")
+		assert.NoFileExists(t, filepath.Join(dir, "d.txt"))
+	})
+
+	t.Run("all or nothing rolls back", func(t *testing.T) {
+		// Each file fits the quota on its own but not together
+		text, isError := write([]any{
+			map[string]any{"path": filepath.Join(dir, "a.txt"), "content": strings.Repeat("a", 40)},
+			map[string]any{"path": filepath.Join(dir, "new", "e.txt"), "content": strings.Repeat("e", 60)},
+		}, true)
+		require.True(t, isError)
+		assert.Contains(t, text, "quota_exceeded")
+
+		content, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "alpha", string(content))
+		assert.NoDirExists(t, filepath.Join(dir, "new"))
+	})
+
+	t.Run("duplicate paths", func(t *testing.T) {
+		text, _ := write([]any{
+			map[string]any{"path": filepath.Join(dir, "f.txt"), "content": "1"},
+			map[string]any{"path": filepath.Join(dir, "f.txt"), "content": "2"},
+		}, false)
+		assert.Contains(t, text, "listed more than once")
+	})
+}
+
+// stuckFileSystem refuses to remove one file
+type stuckFileSystem struct {
+	*MemFileSystem
+	stuck string
+}
+
+func (s *stuckFileSystem) Remove(name string) error {
+	if name == s.stuck {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+	}
+	return s.MemFileSystem.Remove(name)
+}
+
+func TestHandleWriteMultipleFiles_RollbackFailure(t *testing.T) {
+	mem := NewMemFileSystem()
+	root := filepath.Join(filepath.VolumeName(os.TempDir())+string(filepath.Separator), "data")
+	require.NoError(t, mem.MkdirAll(root, 0755))
+	fsys := &stuckFileSystem{MemFileSystem: mem, stuck: filepath.Join(root, "a.txt")}
+
+	handler, err := NewFilesystemHandler([]string{root}, WithFileSystem(fsys), WithRootQuotas(map[string]int64{root: 100}))
+	require.NoError(t, err)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"files": []any{
+			map[string]any{"path": filepath.Join(root, "a.txt"), "content": strings.Repeat("a", 40)},
+			map[string]any{"path": filepath.Join(root, "b.txt"), "content": strings.Repeat("b", 40)},
+			map[string]any{"path": filepath.Join(root, "c.txt"), "content": strings.Repeat("c", 40)},
+		},
+		"all_or_nothing": true,
+	}
+	result, err := handler.HandleWriteMultipleFiles(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	assert.Contains(t, text, "1 of the 3 files could not be restored")
+	assert.NotContains(t, text, "none of the")
+	assert.Contains(t, text, "could not be restored: remove")
+
+	_, err = mem.Stat(filepath.Join(root, "a.txt"))
+	assert.NoError(t, err, "the stuck file keeps the content written")
+	_, err = mem.Stat(filepath.Join(root, "b.txt"))
+	assert.True(t, os.IsNotExist(err), "the other file is rolled back")
+}
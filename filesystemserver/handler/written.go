@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// writtenFile reads back the size, SHA-256 digest and modification time of a
+// file that was just written
+func (fs *FilesystemHandler) writtenFile(validPath string) (WrittenFile, error) {
+	info, err := fs.fsys.Stat(validPath)
+	if err != nil {
+		return WrittenFile{}, err
+	}
+	digest, err := fs.fileSHA256(validPath)
+	if err != nil {
+		return WrittenFile{}, err
+	}
+	return WrittenFile{
+		Path:     fs.displayPath(validPath),
+		Size:     info.Size(),
+		SHA256:   digest,
+		Modified: info.ModTime(),
+	}, nil
+}
+
+// String formats the record as the lines appended to the text of a write
+// result
+func (w WrittenFile) String() string {
+	return fmt.Sprintf("Size: %d bytes\nSHA-256: %s\nModified: %s", w.Size, w.SHA256, w.Modified.Format(time.RFC3339Nano))
+}
+
+// withWrittenFiles adds the records of the files a tool wrote to its result:
+// for a single file its lines are appended to the first text content, and
+// every record is listed under written in _meta, so that all write tools
+// report what landed in the same shape
+func withWrittenFiles(result *mcp.CallToolResult, written ...WrittenFile) *mcp.CallToolResult {
+	if len(written) == 1 {
+		if text, ok := result.Content[0].(mcp.TextContent); ok {
+			text.Text += "\n" + written[0].String()
+			result.Content[0] = text
+		}
+	}
+	if result.Meta == nil {
+		result.Meta = make(map[string]any)
+	}
+	result.Meta["written"] = written
+	return result
+}
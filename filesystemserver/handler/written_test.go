@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteToolsReportWrittenFile(t *testing.T) {
+	dir := resolveAllowedDirs(t, t.TempDir())[0]
+	handler, err := NewFilesystemHandler([]string{dir})
+	require.NoError(t, err)
+
+	// checkWritten verifies the record of a single written file against the
+	// file on disk
+	checkWritten := func(t *testing.T, result *mcp.CallToolResult, path string) {
+		require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+		records, ok := result.Meta["written"].([]WrittenFile)
+		require.True(t, ok)
+		require.Len(t, records, 1)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		sum := sha256.Sum256(content)
+		assert.Equal(t, int64(len(content)), records[0].Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), records[0].SHA256)
+		assert.True(t, info.ModTime().Equal(records[0].Modified))
+
+		text := result.Content[0].(mcp.TextContent).Text
+		assert.Contains(t, text, "SHA-256: "+records[0].SHA256)
+		assert.Contains(t, text, "Modified: ")
+	}
+
+	call := func(handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handle(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	path := filepath.Join(dir, "config.json")
+	t.Run("write_file", func(t *testing.T) {
+		result := call(handler.HandleWriteFile, map[string]any{"path": path, "content": `{"name": "a"}`})
+		checkWritten(t, result, path)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "Size: 13 bytes")
+	})
+
+	t.Run("modify_file", func(t *testing.T) {
+		result := call(handler.HandleModifyFile, map[string]any{"path": path, "find": `"a"`, "replace": `"bb"`})
+		checkWritten(t, result, path)
+	})
+
+	t.Run("patch_json", func(t *testing.T) {
+		result := call(handler.HandlePatchJSON, map[string]any{"path": path, "operations": []any{
+			map[string]any{"op": "replace", "path": "/name", "value": "c"},
+		}})
+		checkWritten(t, result, path)
+	})
+
+	t.Run("write_multiple_files", func(t *testing.T) {
+		result := call(handler.HandleWriteMultipleFiles, map[string]any{"files": []any{
+			map[string]any{"path": filepath.Join(dir, "a.txt"), "content": "alpha"},
+			map[string]any{"path": filepath.Join(dir, "b.txt"), "content": "beta"},
+		}})
+		require.False(t, result.IsError)
+		records := result.Meta["written"].([]WrittenFile)
+		require.Len(t, records, 2)
+		assert.Equal(t, int64(4), records[1].Size)
+		assert.Contains(t, result.Content[0].(mcp.TextContent).Text, `"mtime"`)
+	})
+}
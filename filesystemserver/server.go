@@ -6,19 +6,66 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// Version is the version the server reports to clients, in its logs and with
+// --version. Release builds set it with
+// -ldflags "-X github.com/bobmcallan/mcp-filesystem-server/filesystemserver.Version=..."
 var Version = "dev"
 
-func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
+// serverName is the name the server reports to clients
+const serverName = "secure-filesystem-server"
 
-	h, err := handler.NewFilesystemHandler(allowedDirs)
+// FilesystemServer wraps the MCP server together with the handler backing its
+// tools, so that handler resources can be released on shutdown.
+type FilesystemServer struct {
+	*server.MCPServer
+	handler *handler.FilesystemHandler
+}
+
+// Close releases resources held by the filesystem handler, such as active
+// file watchers.
+func (s *FilesystemServer) Close() error {
+	return s.handler.Close()
+}
+
+// Reload re-reads the configuration, see handler.FilesystemHandler.Reload.
+// It fails unless the server was created with handler.WithConfigReload.
+// Clients are told when the reload turned tools on or off.
+func (s *FilesystemServer) Reload() (*handler.ReloadResult, error) {
+	result, err := s.handler.Reload()
+	if err == nil && result.ToolsChanged() {
+		s.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+	}
+	return result, err
+}
+
+// NewFilesystemServer creates the MCP server for the given allowed directories.
+func NewFilesystemServer(allowedDirs []string, opts ...handler.Option) (*server.MCPServer, error) {
+	s, err := New(allowedDirs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return s.MCPServer, nil
+}
+
+// New creates a FilesystemServer for the given allowed directories. Options
+// are passed through to the filesystem handler.
+func New(allowedDirs []string, opts ...handler.Option) (*FilesystemServer, error) {
+
+	opts = append([]handler.Option{handler.WithServerInfo(serverName, Version)}, opts...)
+	h, err := handler.NewFilesystemHandler(allowedDirs, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	s := server.NewMCPServer(
-		"secure-filesystem-server",
+		serverName,
 		Version,
 		server.WithResourceCapabilities(true, true),
+		server.WithToolHandlerMiddleware(h.LimitCallDuration),
+		server.WithToolHandlerMiddleware(h.HoldConfig),
+		server.WithToolHandlerMiddleware(h.RefuseDisabledTools),
+		server.WithToolHandlerMiddleware(h.RelativizePaths),
+		server.WithToolFilter(h.FilterTools),
 	)
 
 	// Register resource handlers
@@ -28,19 +75,217 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithResourceDescription("Access to files and directories on the local file system"),
 	), h.HandleReadResource)
 
+	// Mutating tools are refused in read-only mode, rate limited and recorded
+	// in the audit log; rate limited calls are audited too
+	mutating := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return h.RefuseInReadOnly(h.AuditWrites(h.LimitWrites(next)))
+	}
+
+	// Hints that let clients tell read-only tools from destructive ones, for
+	// example to ask for confirmation first
+	readOnly := toolHints(true, false, true)
+	additive := toolHints(false, false, false)   // Creates or moves, never discards data
+	overwriting := toolHints(false, true, true)  // May discard data; repeating a call changes nothing
+	destructive := toolHints(false, true, false) // May discard data
+
+	// Tools that can return large text may compress it on request
+	acceptEncoding := mcp.WithString("accept_encoding",
+		mcp.Description("Set to 'gzip' to receive large text results gzip-compressed and base64-encoded, marked with content_encoding 'gzip' in the result's _meta (only if the server enables compression)"),
+		mcp.Enum("gzip"),
+	)
+
+	// Every tool call can be given a deadline of its own
+	callTimeout := mcp.WithNumber("timeout_ms",
+		mcp.Description("Abandon the call with a timeout error after this many milliseconds, up to the server's maximum; directory walks stop at the deadline and return their partial results marked as truncated"),
+	)
+	relativePaths := mcp.WithBoolean("relative_paths",
+		mcp.Description("Report paths relative to the allowed directory they fall under, e.g. src/main.go, or @alias/src/main.go for an aliased directory (default: the server's relative_paths setting)"),
+	)
+
+	// The walking tools can leave out what a repository ignores
+	respectGitignore := mcp.WithBoolean("respect_gitignore",
+		mcp.Description("Leave out files and directories excluded by .gitignore and .ignore files, and .git directories, such as node_modules or build output (default: the server's respect_gitignore setting)"),
+	)
+
+	// The content tools can leave out files not worth reading as they walk
+	skipBinary := mcp.WithBoolean("skip_binary",
+		mcp.Description("Skip files that look binary, i.e. have a NUL byte in their first 4KB (default: false). Skipped files are counted in the result"),
+	)
+	skipLargerThan := mcp.WithNumber("skip_larger_than",
+		mcp.Description("Skip files larger than this many bytes (default: no limit). Skipped files are counted in the result"),
+	)
+
+	// Destructive tools may have to be called twice when the server asks
+	// for confirmation
+	confirmationToken := mcp.WithString("confirmation_token",
+		mcp.Description("Token from a previous identical call, when the server requires destructive calls to be confirmed; without it such a call only describes what it would do and returns a token"),
+	)
+
 	// Register tool handlers
 	s.AddTool(mcp.NewTool(
 		"read_file",
-		mcp.WithDescription("Read the complete contents of a file from the file system."),
+		readOnly,
+		mcp.WithDescription("Read the complete contents of a file from the file system. PNG, JPEG, GIF and WebP images are returned as image content; other binary files as base64 with their MIME type."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file to read"),
+			mcp.Required(),
+		),
+		mcp.WithString("encoding",
+			mcp.Description("How to return the contents: 'text' (default) or 'base64' for the raw bytes of binary files such as images or PDFs"),
+			mcp.Enum("text", "base64"),
+		),
+		mcp.WithBoolean("detect_language",
+			mcp.Description("Report the programming language of a text file, from its extension or shebang line, as 'language' in the result's _meta (default: true)"),
+		),
+		mcp.WithBoolean("expand_tabs",
+			mcp.Description("Replace tabs in the returned text with spaces, aligned to tab stops every tab_width columns (default: false). The file is not modified"),
+		),
+		mcp.WithNumber("tab_width",
+			mcp.Description("Columns between tab stops for expand_tabs, 1 to 16 (default: 4)"),
+		),
+		mcp.WithBoolean("trim_trailing_whitespace",
+			mcp.Description("Remove whitespace at the end of each returned line (default: false). The file is not modified"),
+		),
+		mcp.WithBoolean("strip_bom",
+			mcp.Description("Remove a UTF-8 byte order mark from the returned text (default: false). UTF-16 files with a byte order mark are always returned as UTF-8. A mark found is reported as 'bom' in the result's _meta"),
+		),
+		mcp.WithBoolean("allow_special",
+			mcp.Description("Read a named pipe, device or socket instead of refusing with a not_a_regular_file error; at most the inline size limit is read (default: false)"),
+		),
+		mcp.WithBoolean("truncate_to_limit",
+			mcp.Description("For a text file over the inline size limit, return its first part instead of a resource reference, with truncated, total_size and bytes_returned in the result metadata; read_file_chunk can page through the rest (default: false)"),
+		),
+		mcp.WithBoolean("as_lines",
+			mcp.Description("Return a text file as JSON: lines (without line terminators), line_count, line_ending (lf, crlf, cr, mixed or none) and trailing_newline (default: false)"),
+		),
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleReadFile))
+
+	s.AddTool(mcp.NewTool(
+		"read_file_chunk",
+		readOnly,
+		mcp.WithDescription("Read a large file piece by piece. Returns the chunk starting at cursor together with next_cursor and an eof flag; call again with next_cursor until eof is true."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file to read"),
 			mcp.Required(),
 		),
-	), h.HandleReadFile)
+		mcp.WithNumber("cursor",
+			mcp.Description("Byte offset to start reading at (default: 0)"),
+		),
+		mcp.WithNumber("chunk_size",
+			mcp.Description("Maximum number of bytes to return, up to 1MB (default: 64KB)"),
+		),
+		mcp.WithString("encoding",
+			mcp.Description("'text' (default) returns the chunk as text, ending on a character boundary; 'base64' returns the raw bytes"),
+			mcp.Enum("text", "base64"),
+		),
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleReadFileChunk))
+
+	s.AddTool(mcp.NewTool(
+		"sniff_file",
+		readOnly,
+		mcp.WithDescription("Find out what a file is without reading it: returns its first bytes base64-encoded, the MIME type detected from them and whether they look binary (contain a NUL byte)."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file to sniff"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("bytes",
+			mcp.Description("Number of bytes to read from the start of the file, up to 64KB (default: 512)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleSniffFile)
+
+	s.AddTool(mcp.NewTool(
+		"follow_file",
+		toolHints(true, false, false),
+		mcp.WithDescription("Follow a file like `tail -f`: new lines appended to the file are streamed as notifications/message notifications until stop_follow is called. Truncated or rotated files are re-read from the start."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file to follow"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("last_lines",
+			mcp.Description("Number of existing lines from the end of the file to return before following (default: 0)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleFollowFile)
+
+	s.AddTool(mcp.NewTool(
+		"scan_log",
+		readOnly,
+		mcp.WithDescription("Find out why something failed: returns the last lines of a log file matching a severity pattern (by default error, fatal or panic, case-insensitive), each with surrounding context lines."),
+		mcp.WithString("path",
+			mcp.Description("Path to the log file"),
+			mcp.Required(),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Regular expression matched against each line (default: error|fatal|panic)"),
+		),
+		mcp.WithBoolean("fixed",
+			mcp.Description("Treat pattern as a literal string rather than a regular expression, so characters such as . and ( match themselves (default: false)"),
+		),
+		mcp.WithBoolean("case_sensitive",
+			mcp.Description("Match the pattern case-sensitively (default: false)"),
+		),
+		mcp.WithNumber("max_matches",
+			mcp.Description("Number of matching lines to return, counted from the end of the file (default: 20)"),
+		),
+		mcp.WithNumber("context_lines",
+			mcp.Description("Number of lines to show before and after each match (default: 2)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleScanLog)
+
+	s.AddTool(mcp.NewTool(
+		"stop_follow",
+		readOnly,
+		mcp.WithDescription("Stop following a file previously followed with follow_file."),
+		mcp.WithString("follow_id",
+			mcp.Description("Identifier returned by follow_file"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleStopFollow)
+
+	s.AddTool(mcp.NewTool(
+		"watch_path",
+		toolHints(true, false, false),
+		mcp.WithDescription("Watch a file or directory for changes made outside the conversation: every change is reported as a notifications/resources/updated notification with the file:// URI of the changed path, until stop_watch is called. Directories report changes to their entries."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file or directory to watch"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Also watch every directory below a watched directory, including ones created later (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleWatchPath)
+
+	s.AddTool(mcp.NewTool(
+		"stop_watch",
+		readOnly,
+		mcp.WithDescription("Stop watching a path previously watched with watch_path."),
+		mcp.WithString("watch_id",
+			mcp.Description("Identifier returned by watch_path"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleStopWatch)
 
 	s.AddTool(mcp.NewTool(
 		"write_file",
-		mcp.WithDescription("Create a new file or overwrite an existing file with new content."),
+		destructive,
+		mcp.WithDescription("Create a new file, overwrite an existing file with new content, or append content to a file."),
 		mcp.WithString("path",
 			mcp.Description("Path where to write the file"),
 			mcp.Required(),
@@ -49,28 +294,135 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Content to write to the file"),
 			mcp.Required(),
 		),
-	), h.HandleWriteFile)
+		mcp.WithString("expected_sha256",
+			mcp.Description("Hex SHA-256 digest the written file must match; the call fails if the file read back differs"),
+		),
+		mcp.WithString("if_match_sha256",
+			mcp.Description("Only write if the current file contents have this hex SHA-256 digest; otherwise a conflict error with the current digest is returned"),
+		),
+		mcp.WithBoolean("rollback_on_mismatch",
+			mcp.Description("Restore the previous contents (or remove a new file) when expected_sha256 does not match (default: false)"),
+		),
+		mcp.WithString("encoding",
+			mcp.Description("Character encoding to write the content in, e.g. 'utf-8', 'utf-16le', 'windows-1252' (default: 'utf-8')"),
+		),
+		mcp.WithBoolean("write_bom",
+			mcp.Description("Prefix the file with a byte order mark; only valid for UTF-8 and UTF-16 encodings (default: false)"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("'overwrite' (default) replaces the file's contents, 'append' adds the content to the end of the file, creating it if needed"),
+			mcp.Enum("overwrite", "append"),
+		),
+		mcp.WithBoolean("ensure_trailing_newline",
+			mcp.Description("Make sure the content ends with a newline and, when appending, that the existing file ends with one before the content is added (default: false)"),
+		),
+		mcp.WithBoolean("allow_special",
+			mcp.Description("Write to a named pipe or device instead of refusing with a not_a_regular_file error; the result is not read back or hashed (default: false)"),
+		),
+		mcp.WithBoolean("apply_editorconfig",
+			mcp.Description("Apply the end_of_line, trim_trailing_whitespace, insert_final_newline and charset rules of the nearest .editorconfig files to the content before writing (default: false)"),
+		),
+		mcp.WithBoolean("backup",
+			mcp.Description("Copy an existing file to path~, or to the configured backup directory with a timestamp, before writing it; the backup path is returned (default: false)"),
+		),
+		mcp.WithBoolean("durable",
+			mcp.Description("Flush the data to disk, and on Unix the directory entry of a new file, before returning, so the write survives a crash or power loss (default: false, which is faster)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleWriteFile))
+
+	s.AddTool(mcp.NewTool(
+		"write_multiple_files",
+		destructive,
+		mcp.WithDescription("Create or overwrite several files in one call, each written atomically. Per-file results are returned; a failing file does not stop the others unless all_or_nothing is set."),
+		mcp.WithArray("files",
+			mcp.Description("Files to write: objects with path and content, and optionally encoding, write_bom, ensure_trailing_newline and if_match_sha256 as for write_file"),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithBoolean("all_or_nothing",
+			mcp.Description("Validate every file before writing any, and restore the files already written if a later one fails (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleWriteMultipleFiles))
 
 	s.AddTool(mcp.NewTool(
 		"list_directory",
-		mcp.WithDescription("Get a detailed listing of all files and directories in a specified path."),
+		readOnly,
+		mcp.WithDescription("Get a detailed listing of all files and directories in a specified path, optionally as a flat recursive listing filtered by glob patterns."),
 		mcp.WithString("path",
 			mcp.Description("Path of the directory to list"),
 			mcp.Required(),
 		),
-	), h.HandleListDirectory)
+		mcp.WithBoolean("recursive",
+			mcp.Description("List the whole directory tree as a flat list of paths relative to path (default: false)"),
+		),
+		mcp.WithNumber("max_depth",
+			mcp.Description("Maximum depth of a recursive listing, 1 being the directory's own entries (default: unlimited)"),
+		),
+		mcp.WithString("include",
+			mcp.Description("Only list entries matching this glob; patterns containing '/' match the relative path, others the name ('**' crosses directories)"),
+		),
+		mcp.WithString("exclude",
+			mcp.Description("Skip entries matching this glob; excluded directories are not descended into"),
+		),
+		mcp.WithBoolean("classify",
+			mcp.Description("Tag each regular file as text or binary by checking its first 4KB for NUL bytes (default: false)"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of entries to skip, for fetching the next page of a truncated listing (default: 0)"),
+		),
+		respectGitignore,
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleListDirectory))
 
 	s.AddTool(mcp.NewTool(
 		"create_directory",
+		additive,
 		mcp.WithDescription("Create a new directory or ensure a directory exists."),
 		mcp.WithString("path",
 			mcp.Description("Path of the directory to create"),
 			mcp.Required(),
 		),
-	), h.HandleCreateDirectory)
+		mcp.WithBoolean("parents",
+			mcp.Description("Create missing parent directories as needed (default: true)"),
+		),
+		mcp.WithBoolean("fail_if_exists",
+			mcp.Description("Fail if the directory already exists instead of succeeding (default: false)"),
+		),
+		mcp.WithString("mode",
+			mcp.Description("Octal permissions for newly created directories, e.g. '0750' (default: '0755'; ignored on Windows)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleCreateDirectory))
+
+	s.AddTool(mcp.NewTool(
+		"write_from_template",
+		overwriting,
+		mcp.WithDescription("Render a Go text/template from the configured templates directory with the given variables and write the result atomically. A template that references a missing variable fails without writing anything."),
+		mcp.WithString("template",
+			mcp.Description("Name of the template, relative to the templates directory"),
+			mcp.Required(),
+		),
+		mcp.WithString("path",
+			mcp.Description("Path where to write the rendered file"),
+			mcp.Required(),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("Values available to the template, e.g. {\"Name\": \"widget\"} for {{.Name}}"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleWriteFromTemplate))
 
 	s.AddTool(mcp.NewTool(
 		"copy_file",
+		overwriting,
 		mcp.WithDescription("Copy files and directories."),
 		mcp.WithString("source",
 			mcp.Description("Source path of the file or directory"),
@@ -80,10 +432,13 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Destination path"),
 			mcp.Required(),
 		),
-	), h.HandleCopyFile)
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleCopyFile))
 
 	s.AddTool(mcp.NewTool(
 		"move_file",
+		destructive,
 		mcp.WithDescription("Move or rename files and directories."),
 		mcp.WithString("source",
 			mcp.Description("Source path of the file or directory"),
@@ -93,10 +448,58 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Destination path"),
 			mcp.Required(),
 		),
-	), h.HandleMoveFile)
+		mcp.WithBoolean("update_references",
+			mcp.Description("After the move, replace every occurrence of the old path with the new one in the text files below references_root (default: false)"),
+		),
+		mcp.WithString("references_root",
+			mcp.Description("Directory whose files are searched for references; required with update_references"),
+		),
+		mcp.WithString("reference_from",
+			mcp.Description("Text to replace (default: the source path relative to references_root, with '/' separators)"),
+		),
+		mcp.WithString("reference_to",
+			mcp.Description("Replacement text (default: the destination path relative to references_root, with '/' separators)"),
+		),
+		mcp.WithString("reference_pattern",
+			mcp.Description("Glob pattern matched against the names of the files to update, e.g. '*.go' (default: '*')"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Validate the move and preview the reference updates line by line without changing anything (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleMoveFile))
+
+	s.AddTool(mcp.NewTool(
+		"rename_files",
+		destructive,
+		mcp.WithDescription("Batch-rename the files in a directory whose names match a glob or regular expression, using a template for the new names. All target names are computed first and the batch is aborted if any collide."),
+		mcp.WithString("path",
+			mcp.Description("Directory containing the files to rename"),
+			mcp.Required(),
+		),
+		mcp.WithString("match",
+			mcp.Description("Pattern matched against file names"),
+			mcp.Required(),
+		),
+		mcp.WithString("template",
+			mcp.Description("New name template. Supports regex capture groups ($1, ${name}) and the placeholders {name}, {ext}, {filename} and {date}"),
+			mcp.Required(),
+		),
+		mcp.WithString("match_type",
+			mcp.Description("How to interpret match: 'glob' (default) or 'regex'"),
+			mcp.Enum("glob", "regex"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Preview the renames without performing them (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleRenameFiles))
 
 	s.AddTool(mcp.NewTool(
 		"search_files",
+		readOnly,
 		mcp.WithDescription("Recursively search for files and directories matching a pattern."),
 		mcp.WithString("path",
 			mcp.Description("Starting path for the search"),
@@ -106,35 +509,204 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 			mcp.Description("Search pattern to match against file names"),
 			mcp.Required(),
 		),
-	), h.HandleSearchFiles)
+		mcp.WithBoolean("files_only",
+			mcp.Description("Only return files (default: false)"),
+		),
+		mcp.WithBoolean("dirs_only",
+			mcp.Description("Only return directories (default: false)"),
+		),
+		mcp.WithNumber("max_results",
+			mcp.Description("Maximum number of results to return (default: 1000)"),
+		),
+		mcp.WithString("modified_after",
+			mcp.Description("Only return entries modified after this RFC3339 timestamp"),
+		),
+		mcp.WithString("modified_before",
+			mcp.Description("Only return entries modified before this RFC3339 timestamp"),
+		),
+		mcp.WithNumber("min_size",
+			mcp.Description("Only return files of at least this many bytes"),
+		),
+		mcp.WithNumber("max_size",
+			mcp.Description("Only return files of at most this many bytes"),
+		),
+		mcp.WithBoolean("search_archives",
+			mcp.Description("Also match the entries of .zip archives, reported as archive.zip::entry (default: false). Archives inside archives are not opened"),
+		),
+		mcp.WithBoolean("classify",
+			mcp.Description("Tag each matching regular file as text or binary by checking its first 4KB for NUL bytes (default: false)"),
+		),
+		respectGitignore,
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleSearchFiles))
 
 	s.AddTool(mcp.NewTool(
 		"get_file_info",
-		mcp.WithDescription("Retrieve detailed metadata about a file or directory."),
+		readOnly,
+		mcp.WithDescription("Retrieve detailed metadata about a file or directory: type, size, timestamps, mode and, on Unix, owner and group."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file or directory"),
 			mcp.Required(),
 		),
+		mcp.WithBoolean("follow",
+			mcp.Description("Follow a symbolic link and describe its target; when false the link itself is described, with type 'symlink' and its target (default: true)"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("For a directory, add a summary of everything below it: file, directory and symlink counts, total bytes, newest and oldest file and a breakdown by extension (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
 	), h.HandleGetFileInfo)
 
 	s.AddTool(mcp.NewTool(
 		"list_allowed_directories",
-		mcp.WithDescription("Returns the list of directories that this server is allowed to access."),
+		readOnly,
+		mcp.WithDescription("Returns the list of directories that this server is allowed to access, resolved to absolute paths, with the read-only or read-write mode of each. Call this first to learn where tools may operate."),
+		callTimeout,
+		relativePaths,
 	), h.HandleListAllowedDirectories)
 
+	s.AddTool(mcp.NewTool(
+		"get_server_info",
+		readOnly,
+		mcp.WithDescription("Report the server name and version, its configuration and read cache hit/miss counters."),
+		callTimeout,
+		relativePaths,
+	), h.HandleGetServerInfo)
+
+	s.AddTool(mcp.NewTool(
+		"ping",
+		readOnly,
+		mcp.WithDescription("Liveness probe. Reports the current time, the server uptime and whether each allowed directory can currently be accessed."),
+		callTimeout,
+		relativePaths,
+	), h.HandlePing)
+
+	s.AddTool(mcp.NewTool(
+		"reload_config",
+		toolHints(false, false, true),
+		mcp.WithDescription("Re-read the server configuration file and switch to its allowed directories and limits without a restart. Calls in progress finish with the old configuration. Reports the allowed directories that were added and removed and the tools that were turned on or off."),
+		callTimeout,
+		relativePaths,
+	), h.HandleReloadConfig)
+
+	s.AddTool(mcp.NewTool(
+		"resolve_path",
+		readOnly,
+		mcp.WithDescription("Resolve a path the same way the server does (make absolute, clean, evaluate symlinks) and report the resulting real path and the allowed directory it falls under, or why it is rejected."),
+		mcp.WithString("path",
+			mcp.Description("Path to resolve"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleResolvePath)
+
+	s.AddTool(mcp.NewTool(
+		"check_access",
+		readOnly,
+		mcp.WithDescription("Check whether reading, writing or deleting a path would be allowed, applying the allowed directories, the directory's access mode and the server's access policy, without performing the operation. Use it to find out up front which steps of a plan would be rejected."),
+		mcp.WithString("path",
+			mcp.Description("Path to check"),
+			mcp.Required(),
+		),
+		mcp.WithString("operation",
+			mcp.Description("Intended operation: 'read' (default), 'write' or 'delete'"),
+			mcp.Enum("read", "write", "delete"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleCheckAccess)
+
+	s.AddTool(mcp.NewTool(
+		"read_structured",
+		readOnly,
+		mcp.WithDescription("Parse a JSON, YAML or TOML file and return the decoded value as JSON, or a parse error with its line and column. Use this to validate configuration files before acting on them."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file to parse"),
+			mcp.Required(),
+		),
+		mcp.WithString("format",
+			mcp.Description("File format (default: detected from the extension: .json, .yaml/.yml, .toml)"),
+			mcp.Enum("json", "yaml", "toml"),
+		),
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleReadStructured))
+
+	s.AddTool(mcp.NewTool(
+		"extract_text",
+		readOnly,
+		mcp.WithDescription("Extract the plain text of a PDF (text layer) or DOCX document. Page boundaries (PDF) or paragraph numbers (DOCX) are included so passages can be cited. Other formats are rejected."),
+		mcp.WithString("path",
+			mcp.Description("Path to the PDF or DOCX document"),
+			mcp.Required(),
+		),
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleExtractText))
+
 	s.AddTool(mcp.NewTool(
 		"read_multiple_files",
+		readOnly,
 		mcp.WithDescription("Read the contents of multiple files in a single operation."),
 		mcp.WithArray("paths",
 			mcp.Description("List of file paths to read"),
 			mcp.Required(),
 			mcp.Items(map[string]any{"type": "string"}),
 		),
-	), h.HandleReadMultipleFiles)
+		mcp.WithNumber("max_total_bytes",
+			mcp.Description("Maximum combined size of the files read; files beyond the budget are skipped (default: 20MB)"),
+		),
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleReadMultipleFiles))
+
+	s.AddTool(mcp.NewTool(
+		"read_glob",
+		readOnly,
+		mcp.WithDescription("Read every text file below a directory that matches a glob pattern in one call. Returns a JSON array of {path, content, error}; once the combined size would exceed the budget, the remaining matches are marked skipped."),
+		mcp.WithString("path",
+			mcp.Description("Directory to search recursively"),
+			mcp.Required(),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Glob pattern, e.g. '*.md'; patterns without '/' match file names, others the path relative to the directory, e.g. 'docs/**/*.md'"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("max_total_bytes",
+			mcp.Description("Maximum combined size of the files read; matches beyond the budget are skipped (default: 20MB)"),
+		),
+		skipBinary,
+		skipLargerThan,
+		respectGitignore,
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleReadGlob))
+
+	s.AddTool(mcp.NewTool(
+		"stat_multiple",
+		readOnly,
+		mcp.WithDescription("Get metadata for multiple paths in a single operation. Returns a JSON array with exists, type, size, mtime and error for each path; missing or invalid paths do not fail the batch."),
+		mcp.WithArray("paths",
+			mcp.Description("List of paths to stat"),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleStatMultiple)
 
 	s.AddTool(mcp.NewTool(
 		"tree",
-		mcp.WithDescription("Returns a hierarchical JSON representation of a directory structure."),
+		readOnly,
+		mcp.WithDescription("Returns a hierarchical JSON representation of a directory structure, or an ASCII tree like the tree command."),
 		mcp.WithString("path",
 			mcp.Description("Path of the directory to traverse"),
 			mcp.Required(),
@@ -145,10 +717,19 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("follow_symlinks",
 			mcp.Description("Whether to follow symbolic links (default: false)"),
 		),
-	), h.HandleTree)
+		mcp.WithString("format",
+			mcp.Description("Output format: json for nested nodes or ascii for an indented ├──/└── listing (default: json)"),
+			mcp.Enum("json", "ascii"),
+		),
+		respectGitignore,
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleTree))
 
 	s.AddTool(mcp.NewTool(
 		"delete_file",
+		overwriting,
 		mcp.WithDescription("Delete a file or directory from the file system."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file or directory to delete"),
@@ -157,10 +738,50 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("recursive",
 			mcp.Description("Whether to recursively delete directories (default: false)"),
 		),
-	), h.HandleDeleteFile)
+		confirmationToken,
+		callTimeout,
+		relativePaths,
+	), mutating(h.RequireConfirmation(h.HandleDeleteFile)))
+
+	s.AddTool(mcp.NewTool(
+		"move_to_trash",
+		additive,
+		mcp.WithDescription("Move a file or directory into the configured trash directory instead of deleting it. The item is kept under a timestamped directory at its original path and can be restored with restore_from_trash."),
+		mcp.WithString("path",
+			mcp.Description("Path to the file or directory to move to the trash"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleMoveToTrash))
+
+	s.AddTool(mcp.NewTool(
+		"restore_from_trash",
+		additive,
+		mcp.WithDescription("Move an item from the trash back to its original location. Fails if the original location exists again."),
+		mcp.WithString("path",
+			mcp.Description("Path of the item in the trash, as returned by move_to_trash"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleRestoreFromTrash))
+
+	s.AddTool(mcp.NewTool(
+		"empty_trash",
+		overwriting,
+		mcp.WithDescription("Permanently delete the items in the trash, optionally only those trashed before a given age."),
+		mcp.WithString("older_than",
+			mcp.Description("Only delete items trashed longer ago than this duration, e.g. \"168h\" (default: delete everything)"),
+		),
+		confirmationToken,
+		callTimeout,
+		relativePaths,
+	), mutating(h.RequireConfirmation(h.HandleEmptyTrash)))
 
 	s.AddTool(mcp.NewTool(
 		"modify_file",
+		destructive,
 		mcp.WithDescription("Update file by finding and replacing text. Provides a simple pattern matching interface without needing exact character positions."),
 		mcp.WithString("path",
 			mcp.Description("Path to the file to modify"),
@@ -180,18 +801,172 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithBoolean("regex",
 			mcp.Description("Treat the find pattern as a regular expression (default: false)"),
 		),
-	), h.HandleModifyFile)
+		mcp.WithString("if_match_sha256",
+			mcp.Description("Only modify the file if its current contents have this hex SHA-256 digest; otherwise a conflict error with the current digest is returned"),
+		),
+		mcp.WithBoolean("backup",
+			mcp.Description("Copy an existing file to path~, or to the configured backup directory with a timestamp, before modifying it; the backup path is returned (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleModifyFile))
+
+	s.AddTool(mcp.NewTool(
+		"patch_json",
+		destructive,
+		mcp.WithDescription("Apply RFC 6902 JSON Patch operations to a JSON file and write it back atomically, keeping its key order, indentation and line endings. Nothing is written if the file is not valid JSON or any operation fails, e.g. because a path does not resolve."),
+		mcp.WithString("path",
+			mcp.Description("Path to the JSON file to patch"),
+			mcp.Required(),
+		),
+		mcp.WithArray("operations",
+			mcp.Description(`JSON Patch operations applied in order, e.g. [{"op": "replace", "path": "/server/port", "value": 8080}]. op is add, remove, replace, move, copy or test; move and copy take a from pointer`),
+			mcp.Required(),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Check that the operations apply and return the patched document without writing it (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandlePatchJSON))
+
+	s.AddTool(mcp.NewTool(
+		"search_and_replace_preview",
+		readOnly,
+		mcp.WithDescription("Preview a find and replace without modifying anything. Uses the same matching rules as modify_file and reports, per affected file, the changed line numbers with their text before and after. Accepts a single file, or a directory together with a file name pattern; files that are too large or not text are skipped."),
+		mcp.WithString("path",
+			mcp.Description("File to preview, or directory to search recursively"),
+			mcp.Required(),
+		),
+		mcp.WithString("find",
+			mcp.Description("Text to search for (exact match or regex pattern)"),
+			mcp.Required(),
+		),
+		mcp.WithString("replace",
+			mcp.Description("Text to replace with"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("all_occurrences",
+			mcp.Description("Replace all occurrences of the matching text (default: true)"),
+		),
+		mcp.WithBoolean("regex",
+			mcp.Description("Treat the find pattern as a regular expression (default: false)"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Glob pattern matched against file names when path is a directory (default: '*')"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleSearchAndReplacePreview)
+
+	s.AddTool(mcp.NewTool(
+		"normalize_line_endings",
+		overwriting,
+		mcp.WithDescription("Rewrite text files to use LF or CRLF line endings. Accepts a single file, or a directory together with a file name pattern. Files are rewritten atomically; binary files (containing NUL bytes) are skipped."),
+		mcp.WithString("path",
+			mcp.Description("File to normalize, or directory to search recursively"),
+			mcp.Required(),
+		),
+		mcp.WithString("target",
+			mcp.Description("Line ending to convert to"),
+			mcp.Required(),
+			mcp.Enum("lf", "crlf"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Glob pattern matched against file names when path is a directory (default: '*')"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report which files would change without rewriting them (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleNormalizeLineEndings))
+
+	s.AddTool(mcp.NewTool(
+		"create_symlink",
+		additive,
+		mcp.WithDescription("Create a symbolic link at link_path pointing to target. Relative targets are resolved from the link's directory. The target must resolve within the allowed directories."),
+		mcp.WithString("target",
+			mcp.Description("Path the link points to, absolute or relative to the link's directory"),
+			mcp.Required(),
+		),
+		mcp.WithString("link_path",
+			mcp.Description("Path of the symbolic link to create"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleCreateSymlink))
+
+	s.AddTool(mcp.NewTool(
+		"create_hardlink",
+		additive,
+		mcp.WithDescription("Create a hard link at link_path to an existing regular file, giving the same data a second name. Both paths must be within the allowed directories and on the same filesystem; directories cannot be hard linked."),
+		mcp.WithString("target",
+			mcp.Description("Existing file to link to"),
+			mcp.Required(),
+		),
+		mcp.WithString("link_path",
+			mcp.Description("Path of the hard link to create; must not exist"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleCreateHardlink))
+
+	s.AddTool(mcp.NewTool(
+		"set_permissions_recursive",
+		overwriting,
+		mcp.WithDescription("Set the permission bits of everything below a directory: file_mode is applied to regular files and dir_mode to directories, including path itself. Symbolic links and special files are never changed. Not supported on Windows."),
+		mcp.WithString("path",
+			mcp.Description("Root of the tree to change"),
+			mcp.Required(),
+		),
+		mcp.WithString("file_mode",
+			mcp.Description("Octal mode for regular files, e.g. '0644'; files are left alone when omitted"),
+		),
+		mcp.WithString("dir_mode",
+			mcp.Description("Octal mode for directories, e.g. '0755'; directories are left alone when omitted"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Glob pattern limiting the change to matching entries, matched against the name or, if it contains '/', the path relative to path"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report which entries would change without changing them (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), mutating(h.HandleSetPermissionsRecursive))
+
+	s.AddTool(mcp.NewTool(
+		"read_symlink",
+		readOnly,
+		mcp.WithDescription("Show the target of a symbolic link without following it, along with where the target resolves to."),
+		mcp.WithString("path",
+			mcp.Description("Path to the symbolic link"),
+			mcp.Required(),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleReadSymlink)
 
 	s.AddTool(mcp.NewTool(
 		"search_within_files",
-		mcp.WithDescription("Search for text within file contents. Unlike search_files which only searches file names, this tool scans the actual contents of text files for matching substrings. Binary files are automatically excluded from the search. Reports file paths and line numbers where matches are found."),
+		readOnly,
+		mcp.WithDescription("Search for text within file contents, like grep. Unlike search_files which only searches file names, this tool scans the actual contents of text files for a literal substring or a regular expression. Binary files are automatically excluded from the search. Reports file paths, line numbers and the matched lines, and lists every match with its context as an object under matches in _meta."),
 		mcp.WithString("path",
-			mcp.Description("Starting path for the search (must be a directory)"),
+			mcp.Description("Directory to search recursively, or a single file to search"),
 			mcp.Required(),
 		),
 		mcp.WithString("substring",
-			mcp.Description("Text to search for within file contents"),
-			mcp.Required(),
+			mcp.Description("Literal text to search for within file contents; give either substring or pattern"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("RE2 regular expression to search for instead of substring, e.g. func \\w+Handler\\("),
+		),
+		mcp.WithBoolean("case_insensitive",
+			mcp.Description("Ignore case when matching substring or pattern (default: false)"),
 		),
 		mcp.WithNumber("depth",
 			mcp.Description("Maximum directory depth to search (default: unlimited)"),
@@ -199,7 +974,124 @@ func NewFilesystemServer(allowedDirs []string) (*server.MCPServer, error) {
 		mcp.WithNumber("max_results",
 			mcp.Description("Maximum number of results to return (default: 1000)"),
 		),
-	), h.HandleSearchWithinFiles)
+		mcp.WithNumber("context_before",
+			mcp.Description("Lines of context to show before each match, like grep -B (default: 0, maximum: 50)"),
+		),
+		mcp.WithNumber("context_after",
+			mcp.Description("Lines of context to show after each match, like grep -A (default: 0, maximum: 50). Overlapping contexts are merged and matched lines are marked with '>'"),
+		),
+		mcp.WithBoolean("search_archives",
+			mcp.Description("Also search the text entries of .zip archives, reported as archive.zip::entry (default: false). Entries over 10MB and archives inside archives are skipped, and at most 100MB is decompressed per archive"),
+		),
+		mcp.WithString("include",
+			mcp.Description("Only search files matching this glob, e.g. *.go; patterns containing '/' match the path relative to the search directory, others the name ('**' crosses directories)"),
+		),
+		mcp.WithString("exclude",
+			mcp.Description("Skip files and directories matching this glob, e.g. vendor, without descending into excluded directories"),
+		),
+		mcp.WithBoolean("use_index",
+			mcp.Description("Take the files to search from the server's content index, when it keeps one, instead of walking the tree (default: false). The index serves case-sensitive searches for text of at least 3 characters; files created or changed since its last refresh may be missed"),
+		),
+		skipBinary,
+		skipLargerThan,
+		respectGitignore,
+		acceptEncoding,
+		callTimeout,
+		relativePaths,
+	), h.CompressResults(h.HandleSearchWithinFiles))
+
+	s.AddTool(mcp.NewTool(
+		"find_duplicates",
+		readOnly,
+		mcp.WithDescription("Find files with identical contents below a directory. Files are grouped by size and only same-size files are hashed (SHA-256). Symbolic links are not followed."),
+		mcp.WithString("path",
+			mcp.Description("Directory to search recursively"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("min_size",
+			mcp.Description("Ignore files smaller than this many bytes (default: 1, which skips empty files)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleFindDuplicates)
+
+	s.AddTool(mcp.NewTool(
+		"directory_manifest",
+		readOnly,
+		mcp.WithDescription("List every file below a directory with its relative path, size and SHA-256, sorted by path, as JSON. Identical trees give byte-for-byte identical manifests. Symbolic links are followed to files within the allowed directories; other links and files over the hashing size limit are listed as skipped."),
+		mcp.WithString("path",
+			mcp.Description("Directory to describe recursively"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("aggregate",
+			mcp.Description("Add aggregate_sha256, a single hash of the whole manifest for quick comparison (default: false)"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleDirectoryManifest)
+
+	s.AddTool(mcp.NewTool(
+		"compare_directories",
+		readOnly,
+		mcp.WithDescription("Compare two directory trees and list the paths only in left, only in right, and present in both but different. Files are compared by size and modification time, or by SHA-256 of their contents with compare_content. Symbolic links are not followed."),
+		mcp.WithString("left",
+			mcp.Description("First directory to compare"),
+			mcp.Required(),
+		),
+		mcp.WithString("right",
+			mcp.Description("Second directory to compare"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("compare_content",
+			mcp.Description("Hash files of equal size to decide whether they differ, instead of comparing modification times (default: false)"),
+		),
+		mcp.WithString("exclude",
+			mcp.Description("Glob pattern of paths to ignore on both sides; patterns without '/' match names, others the relative path"),
+		),
+		callTimeout,
+		relativePaths,
+	), h.HandleCompareDirectories)
+
+	s.AddTool(mcp.NewTool(
+		"sync_directories",
+		overwriting,
+		mcp.WithDescription("Make destination match source: copy new and changed files (each written atomically, keeping its mode and modification time) and, with delete, remove paths that exist only in destination. Files are compared by size and modification time, or by SHA-256 with compare_content. Symbolic links are not followed."),
+		mcp.WithString("source",
+			mcp.Description("Directory to copy from"),
+			mcp.Required(),
+		),
+		mcp.WithString("destination",
+			mcp.Description("Directory to update; created if it does not exist"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("delete",
+			mcp.Description("Delete files and directories that exist only in destination (default: false)"),
+		),
+		mcp.WithBoolean("compare_content",
+			mcp.Description("Hash files of equal size to decide whether they changed, instead of comparing modification times (default: false)"),
+		),
+		mcp.WithString("exclude",
+			mcp.Description("Glob pattern of paths to leave alone on both sides, e.g. '{.git,*.tmp}'; patterns without '/' match names, others the relative path"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report the planned copies and deletions without changing anything (default: false)"),
+		),
+		confirmationToken,
+		callTimeout,
+		relativePaths,
+	), mutating(h.RequireConfirmation(h.HandleSyncDirectories)))
 
-	return s, nil
+	return &FilesystemServer{MCPServer: s, handler: h}, nil
+}
+
+// toolHints sets the MCP annotations that describe a tool's effect on the
+// filesystem. The filesystem is a closed world, so the open-world hint is
+// always false.
+func toolHints(readOnly, destructive, idempotent bool) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithReadOnlyHintAnnotation(readOnly)(tool)
+		mcp.WithDestructiveHintAnnotation(destructive)(tool)
+		mcp.WithIdempotentHintAnnotation(idempotent)(tool)
+		mcp.WithOpenWorldHintAnnotation(false)(tool)
+	}
 }
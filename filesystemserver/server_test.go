@@ -26,3 +26,25 @@ func TestReadMultipleFilesSchema(t *testing.T) {
 	_, ok = pathsMap["items"]
 	assert.True(t, ok)
 }
+
+func TestToolAnnotations(t *testing.T) {
+	fsserver, err := filesystemserver.NewFilesystemServer([]string{t.TempDir()})
+	require.NoError(t, err)
+
+	mcpClient := startTestClient(t, fsserver)
+
+	for name, want := range map[string]struct{ readOnly, destructive bool }{
+		"read_file":        {readOnly: true},
+		"create_directory": {},
+		"delete_file":      {destructive: true},
+		"modify_file":      {destructive: true},
+	} {
+		tool := getTool(t, mcpClient, name)
+		require.NotNil(t, tool, name)
+		require.NotNil(t, tool.Annotations.ReadOnlyHint, name)
+		require.NotNil(t, tool.Annotations.DestructiveHint, name)
+		assert.Equal(t, want.readOnly, *tool.Annotations.ReadOnlyHint, name)
+		assert.Equal(t, want.destructive, *tool.Annotations.DestructiveHint, name)
+		assert.False(t, *tool.Annotations.OpenWorldHint, name)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// stringList is a flag that can be given several times, collecting every
+// value in order
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// configOverrides holds the settings given on the command line. They take
+// precedence over config.toml, at startup as well as on every reload, so
+// that the server can be launched from an MCP client configuration without
+// a config file.
+type configOverrides struct {
+	configPath  string
+	allowedDirs stringList
+	logLevel    string
+	logFormat   string
+	transport   string
+	address     string
+	basePath    string
+}
+
+// registerFlags defines the override flags on flags
+func (o *configOverrides) registerFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.configPath, "config", "", "Path of the config file (default: config.toml next to the executable)")
+	flags.Var(&o.allowedDirs, "allowed-dir", "Directory the server may access; repeat for several (replaces directories.allowed)")
+	flags.StringVar(&o.logLevel, "log-level", "", "Log level: debug, info, warn or error (overrides logging.level)")
+	flags.StringVar(&o.logFormat, "log-format", "", "Log format: json, text or logfmt (overrides logging.format)")
+	flags.StringVar(&o.transport, "transport", "", "Transport to serve over: stdio, http or sse (overrides server.transport)")
+	flags.StringVar(&o.address, "address", "", "Listen address (host:port) of the http and sse transports (overrides server.address)")
+	flags.StringVar(&o.basePath, "base-path", "", "Endpoint path of the http transport, or prefix of the sse endpoints (overrides server.base_path)")
+}
+
+// apply replaces the settings of config that were given on the command line
+func (o configOverrides) apply(config *Config) {
+	if len(o.allowedDirs) > 0 {
+		config.Directories.Allowed = append([]string(nil), o.allowedDirs...)
+	}
+	if o.logLevel != "" {
+		config.Logging.Level = o.logLevel
+	}
+	if o.logFormat != "" {
+		config.Logging.Format = o.logFormat
+	}
+	if o.transport != "" {
+		config.Server.Transport = o.transport
+	}
+	if o.address != "" {
+		config.Server.Address = o.address
+	}
+	if o.basePath != "" {
+		config.Server.BasePath = o.basePath
+	}
+}
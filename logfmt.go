@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// logfmtHandler is a slog.Handler that writes records as logfmt lines:
+// space-separated key=value pairs, with values quoted only when needed.
+// Attributes in groups are written with dotted keys, e.g. request.id=42.
+type logfmtHandler struct {
+	opts   slog.HandlerOptions
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string // pre-rendered attributes from WithAttrs
+	group  string // dotted key prefix from WithGroup
+}
+
+func newLogfmtHandler(w io.Writer, opts *slog.HandlerOptions) *logfmtHandler {
+	h := &logfmtHandler{mu: &sync.Mutex{}, w: w}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *logfmtHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *logfmtHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if !r.Time.IsZero() {
+		writePair(&b, slog.TimeKey, r.Time.Format(time.RFC3339Nano))
+	}
+	writePair(&b, slog.LevelKey, r.Level.String())
+	writePair(&b, slog.MessageKey, r.Message)
+	b.WriteString(h.prefix)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.group, a)
+		return true
+	})
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String()[1:]) // Without the leading space
+	return err
+}
+
+func (h *logfmtHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.group, a)
+	}
+	clone := *h
+	clone.prefix += b.String()
+	return &clone
+}
+
+func (h *logfmtHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group += name + "."
+	return &clone
+}
+
+// writeAttr writes an attribute, flattening groups into dotted keys
+func writeAttr(b *strings.Builder, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, group, ga)
+		}
+		return
+	}
+
+	var value string
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		value = a.Value.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			value = err.Error()
+		} else {
+			value = fmt.Sprint(a.Value.Any())
+		}
+	default:
+		value = a.Value.String()
+	}
+	writePair(b, group+a.Key, value)
+}
+
+// writePair writes " key=value". Characters that would break the key are
+// replaced with underscores.
+func writePair(b *strings.Builder, key, value string) {
+	b.WriteString(" ")
+	b.WriteString(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar {
+			return '_'
+		}
+		return r
+	}, key))
+	b.WriteString("=")
+	if needsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// needsQuoting reports whether a logfmt value must be quoted
+func needsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
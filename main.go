@@ -9,18 +9,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/bobmcallan/mcp-filesystem-server/filesystemserver"
+	"github.com/bobmcallan/mcp-filesystem-server/filesystemserver/handler"
 	"github.com/common-nighthawk/go-figure"
-	"github.com/mark3labs/mcp-go/server"
 )
 
 // LogConfig represents logging configuration
@@ -33,51 +40,158 @@ type LogConfig struct {
 
 // DirectoriesConfig represents directories configuration
 type DirectoriesConfig struct {
-	Allowed []string `toml:"allowed"`
+	Allowed           []string          `toml:"allowed"`
+	RootRelativePaths bool              `toml:"root_relative_paths"`
+	RelativePaths     bool              `toml:"relative_paths"`
+	ReadOnly          bool              `toml:"read_only"`
+	RespectGitignore  bool              `toml:"respect_gitignore"`
+	Denied            []string          `toml:"denied"`
+	SymlinkPolicy     string            `toml:"symlink_policy"`
+	Templates         string            `toml:"templates"`
+	Trash             string            `toml:"trash"`
+	Backups           string            `toml:"backups"`
+	Aliases           map[string]string `toml:"aliases"`
+	Quotas            map[string]int64  `toml:"quotas"`
+	Access            map[string]string `toml:"access"`
+}
+
+// CacheConfig represents the read cache configuration
+type CacheConfig struct {
+	MaxBytes int64 `toml:"max_bytes"`
+}
+
+// CompressionConfig represents the compression of large tool results
+type CompressionConfig struct {
+	MinBytes int `toml:"min_bytes"`
+}
+
+// LimitsConfig represents the write rate limits and the permissions of
+// created files
+type LimitsConfig struct {
+	WritesPerMinute     int64  `toml:"writes_per_minute"`
+	WriteBytesPerMinute int64  `toml:"write_bytes_per_minute"`
+	FileMode            string `toml:"file_mode"`
+	DirMode             string `toml:"dir_mode"`
+	OperationTimeout    string `toml:"operation_timeout"`
+	MaxCallTimeout      string `toml:"max_call_timeout"`
+	ConfirmDestructive  bool   `toml:"confirm_destructive"`
+	ConfirmationTTL     string `toml:"confirmation_ttl"`
+	MaxWalkDepth        int    `toml:"max_walk_depth"`
+	MaxWalkEntries      int    `toml:"max_walk_entries"`
+	MaxConcurrency      int    `toml:"max_concurrency"`
+	MaxListEntries      int    `toml:"max_list_entries"`
+	ResourceBudget      int    `toml:"resource_budget"`
+}
+
+// AuditConfig represents the audit log configuration
+type AuditConfig struct {
+	FilePath string `toml:"file_path"`
+	Format   string `toml:"format"`
+}
+
+// HealthConfig represents the periodic check of the allowed directories
+type HealthConfig struct {
+	CheckInterval string `toml:"check_interval"`
+}
+
+// IndexConfig represents the background content index of search_within_files
+type IndexConfig struct {
+	FilePath        string `toml:"file_path"`
+	RefreshInterval string `toml:"refresh_interval"`
+}
+
+// ToolsConfig represents the tools that are turned off
+type ToolsConfig struct {
+	Disabled []string `toml:"disabled"`
+}
+
+// ReloadConfig represents the automatic reloading of the config file
+type ReloadConfig struct {
+	WatchInterval string `toml:"watch_interval"`
 }
 
 // Config represents the application configuration
 type Config struct {
 	Directories DirectoriesConfig `toml:"directories"`
 	Logging     LogConfig         `toml:"logging"`
+	Cache       CacheConfig       `toml:"cache"`
+	Compression CompressionConfig `toml:"compression"`
+	Limits      LimitsConfig      `toml:"limits"`
+	Audit       AuditConfig       `toml:"audit"`
+	Health      HealthConfig      `toml:"health"`
+	Index       IndexConfig       `toml:"index"`
+	Server      ServerConfig      `toml:"server"`
+	Tools       ToolsConfig       `toml:"tools"`
+	Reload      ReloadConfig      `toml:"reload"`
 }
 
-func loadConfig() (Config, error) {
+// configFilePath returns the path of the config file given with --config,
+// or else of config.toml next to the executable
+func configFilePath(overrides configOverrides) (string, error) {
+	if overrides.configPath != "" {
+		return filepath.Abs(overrides.configPath)
+	}
+
 	// Get the directory of the executable
 	execPath, err := os.Executable()
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to get executable path: %w", err)
+		return "", fmt.Errorf("failed to get executable path: %w", err)
 	}
 
 	execDir := filepath.Dir(execPath)
-	configPath := filepath.Join(execDir, "config.toml")
+	return filepath.Join(execDir, "config.toml"), nil
+}
+
+// defaultConfig returns the built-in configuration used when no config file exists
+func defaultConfig() Config {
+	return Config{
+		Directories: DirectoriesConfig{
+			Allowed: []string{"."},
+		},
+		Logging: LogConfig{
+			Level:    "info",
+			Format:   "json",
+			Output:   "file",
+			FilePath: "mcp-filesystem-server.log", // This will be replaced with executable name
+		},
+	}
+}
+
+// loadConfig reads config.toml from the executable directory, or the file
+// given with --config, and applies the command-line overrides to it. A
+// missing config.toml falls back to the built-in defaults, but a file that
+// exists and cannot be parsed is an error so that a typo never silently
+// replaces the configured allow-list. So is a missing --config file.
+func loadConfig(overrides configOverrides) (Config, error) {
+	configPath, err := configFilePath(overrides)
+	if err != nil {
+		return Config{}, err
+	}
 
 	// Try to read and parse TOML config file
 	var config Config
 	if _, err := toml.DecodeFile(configPath, &config); err != nil {
-		// Return default configuration if config file doesn't exist or can't be parsed
-		config = Config{
-			Directories: DirectoriesConfig{
-				Allowed: []string{"."},
-			},
-			Logging: LogConfig{
-				Level:    "info",
-				Format:   "json",
-				Output:   "file",
-				FilePath: "mcp-filesystem-server.log", // This will be replaced with executable name
-			},
+		if !errors.Is(err, fs.ErrNotExist) {
+			return Config{}, fmt.Errorf("failed to parse %s: %w", configPath, err)
+		}
+		if overrides.configPath != "" {
+			return Config{}, fmt.Errorf("config file %s does not exist", configPath)
 		}
+		// Return default configuration if config file doesn't exist
+		config = defaultConfig()
 	}
 
+	overrides.apply(&config)
 	return config, nil
 }
 
-func setupLogger(config Config) *slog.Logger {
+// logFilePath returns the path of the log file used for file output,
+// relative to the executable directory
+func logFilePath(config Config) (string, error) {
 	// Get the directory of the executable
 	execPath, err := os.Executable()
 	if err != nil {
-		// Fallback to disabled logging if we can't determine executable path
-		return slog.New(slog.NewJSONHandler(io.Discard, nil))
+		return "", fmt.Errorf("failed to get executable path: %w", err)
 	}
 	execDir := filepath.Dir(execPath)
 	execName := filepath.Base(execPath)
@@ -88,63 +202,211 @@ func setupLogger(config Config) *slog.Logger {
 	}
 	logFileName += ".log"
 
-	// Parse log level
-	var logLevel slog.Level
-	switch config.Logging.Level {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
-	}
-
-	handlerOpts := &slog.HandlerOptions{Level: logLevel}
-
-	// If configured for file logging, use file output
+	// If configured for file logging, use the configured file path, but if it
+	// matches the default, use the executable name
 	if config.Logging.Output == "file" && config.Logging.FilePath != "" {
-		// Use configured file path, but if it matches default, use executable name
 		logPath := config.Logging.FilePath
 		if logPath == "mcp-filesystem-server.log" {
 			logPath = logFileName
 		}
-		logFilePath := filepath.Join(execDir, logPath)
+		return filepath.Join(execDir, logPath), nil
+	}
 
-		// Open log file for writing (create if not exists, append if exists)
-		logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			// Don't write to stderr as it interferes with MCP protocol
-			// Fallback to a temp file or disable logging
-			return slog.New(slog.NewJSONHandler(io.Discard, handlerOpts))
-		}
+	// Default to a log file in the executable directory named after the executable
+	return filepath.Join(execDir, logFileName), nil
+}
 
-		// Use only file for logging to avoid stderr interference with MCP protocol
-		// Create handler based on format
-		if config.Logging.Format == "text" {
-			return slog.New(slog.NewTextHandler(logFile, handlerOpts))
-		} else {
-			return slog.New(slog.NewJSONHandler(logFile, handlerOpts))
+// auditFilePath returns the path of the audit log, relative to the
+// executable directory, or "" when auditing is disabled
+func auditFilePath(config Config) (string, error) {
+	if config.Audit.FilePath == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(config.Audit.FilePath) {
+		return config.Audit.FilePath, nil
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	return filepath.Join(filepath.Dir(execPath), config.Audit.FilePath), nil
+}
+
+// indexFilePath returns the absolute path of the content index, relative
+// paths being taken from the executable directory, or "" if the index is
+// disabled
+func indexFilePath(config Config) (string, error) {
+	if config.Index.FilePath == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(config.Index.FilePath) {
+		return config.Index.FilePath, nil
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	return filepath.Join(filepath.Dir(execPath), config.Index.FilePath), nil
+}
+
+// parseFileMode parses an octal permission such as "0640". An empty string
+// yields 0, which keeps the server's default mode.
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q (expected an octal permission such as \"0644\")", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// handlerOptions converts the configuration into filesystem handler options.
+// The logger and the audit log are left to the caller.
+func handlerOptions(config Config) ([]handler.Option, error) {
+	var opts []handler.Option
+	if config.Directories.RootRelativePaths {
+		opts = append(opts, handler.WithRootRelativePaths())
+	}
+	if config.Directories.RelativePaths {
+		opts = append(opts, handler.WithRelativeOutputPaths())
+	}
+	if config.Directories.ReadOnly {
+		opts = append(opts, handler.WithReadOnly())
+	}
+	if config.Directories.RespectGitignore {
+		opts = append(opts, handler.WithRespectGitignore())
+	}
+	if len(config.Directories.Denied) > 0 {
+		opts = append(opts, handler.WithDeniedPaths(config.Directories.Denied))
+	}
+	if config.Directories.SymlinkPolicy != "" {
+		opts = append(opts, handler.WithSymlinkPolicy(handler.SymlinkPolicy(config.Directories.SymlinkPolicy)))
+	}
+	if len(config.Directories.Aliases) > 0 {
+		opts = append(opts, handler.WithRootAliases(config.Directories.Aliases))
+	}
+	if len(config.Directories.Quotas) > 0 {
+		opts = append(opts, handler.WithRootQuotas(config.Directories.Quotas))
+	}
+	if len(config.Directories.Access) > 0 {
+		opts = append(opts, handler.WithRootAccess(config.Directories.Access))
+	}
+	if config.Directories.Templates != "" {
+		opts = append(opts, handler.WithTemplatesDir(config.Directories.Templates))
+	}
+	if config.Directories.Trash != "" {
+		opts = append(opts, handler.WithTrashDir(config.Directories.Trash))
+	}
+	if config.Directories.Backups != "" {
+		opts = append(opts, handler.WithBackupDir(config.Directories.Backups))
+	}
+	if config.Cache.MaxBytes > 0 {
+		opts = append(opts, handler.WithReadCache(config.Cache.MaxBytes))
+	}
+	if config.Compression.MinBytes > 0 {
+		opts = append(opts, handler.WithResultCompression(config.Compression.MinBytes))
+	}
+	if config.Limits.WritesPerMinute > 0 || config.Limits.WriteBytesPerMinute > 0 {
+		opts = append(opts, handler.WithWriteRateLimit(config.Limits.WritesPerMinute, config.Limits.WriteBytesPerMinute))
+	}
+	fileMode, err := parseFileMode(config.Limits.FileMode)
+	if err != nil {
+		return nil, fmt.Errorf("limits.file_mode: %w", err)
+	}
+	dirMode, err := parseFileMode(config.Limits.DirMode)
+	if err != nil {
+		return nil, fmt.Errorf("limits.dir_mode: %w", err)
+	}
+	opts = append(opts, handler.WithDefaultModes(fileMode, dirMode))
+	opts = append(opts, handler.WithWalkLimits(config.Limits.MaxWalkDepth, config.Limits.MaxWalkEntries))
+	opts = append(opts, handler.WithMaxConcurrency(config.Limits.MaxConcurrency))
+	opts = append(opts, handler.WithMaxListEntries(config.Limits.MaxListEntries))
+	opts = append(opts, handler.WithResourceBudget(config.Limits.ResourceBudget))
+	if config.Limits.OperationTimeout != "" {
+		timeout, err := time.ParseDuration(config.Limits.OperationTimeout)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("limits.operation_timeout: invalid duration %q", config.Limits.OperationTimeout)
 		}
+		opts = append(opts, handler.WithOperationTimeout(timeout))
+	}
+	if config.Limits.MaxCallTimeout != "" {
+		limit, err := time.ParseDuration(config.Limits.MaxCallTimeout)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("limits.max_call_timeout: invalid duration %q", config.Limits.MaxCallTimeout)
+		}
+		opts = append(opts, handler.WithMaxCallTimeout(limit))
+	}
+	if len(config.Tools.Disabled) > 0 {
+		opts = append(opts, handler.WithDisabledTools(config.Tools.Disabled))
+	}
+	if config.Limits.ConfirmDestructive {
+		var ttl time.Duration
+		if config.Limits.ConfirmationTTL != "" {
+			ttl, err = time.ParseDuration(config.Limits.ConfirmationTTL)
+			if err != nil || ttl < 0 {
+				return nil, fmt.Errorf("limits.confirmation_ttl: invalid duration %q", config.Limits.ConfirmationTTL)
+			}
+		}
+		opts = append(opts, handler.WithConfirmation(ttl))
+	}
+	return opts, nil
+}
+
+// parseLogLevel converts a configured log level; unknown levels are info
+func parseLogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// setupLogger creates the application logger, filtering by level, which is
+// set to the configured level and may be changed later. The returned log
+// file, if any, must be synced and closed by the caller on shutdown. An
+// unknown log format is an error.
+func setupLogger(config Config, level *slog.LevelVar) (*slog.Logger, *os.File, error) {
+	level.Set(parseLogLevel(config.Logging.Level))
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	// Create handler based on format
+	var newHandler func(w io.Writer) slog.Handler
+	switch config.Logging.Format {
+	case "json":
+		newHandler = func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, handlerOpts) }
+	case "text":
+		newHandler = func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, handlerOpts) }
+	case "logfmt":
+		newHandler = func(w io.Writer) slog.Handler { return newLogfmtHandler(w, handlerOpts) }
+	default:
+		return nil, nil, fmt.Errorf("unknown log format %q (expected json, text or logfmt)", config.Logging.Format)
 	}
 
-	// Default to file logging to avoid stderr interference with MCP protocol
-	// Create default log file in executable directory using executable name
-	defaultLogPath := filepath.Join(execDir, logFileName)
-	logFile, err := os.OpenFile(defaultLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	// Always log to a file to avoid stderr interference with MCP protocol
+	logPath, err := logFilePath(config)
 	if err != nil {
-		// If we can't create log file, disable logging entirely to avoid protocol interference
-		return slog.New(slog.NewJSONHandler(io.Discard, handlerOpts))
+		// Fallback to disabled logging if we can't determine executable path
+		return slog.New(newHandler(io.Discard)), nil, nil
 	}
 
-	if config.Logging.Format == "text" {
-		return slog.New(slog.NewTextHandler(logFile, handlerOpts))
-	} else {
-		return slog.New(slog.NewJSONHandler(logFile, handlerOpts))
+	// Open log file for writing (create if not exists, append if exists)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		// Don't write to stderr as it interferes with MCP protocol
+		// If we can't create log file, disable logging entirely
+		return slog.New(newHandler(io.Discard)), nil, nil
 	}
+
+	return slog.New(newHandler(logFile)), logFile, nil
 }
 
 func showSplashScreen(config Config) {
@@ -167,7 +429,7 @@ func showSplashScreen(config Config) {
 	fmt.Println(ColorDarkBlue + appSeparator + ColorReset)
 	fmt.Println(ColorDarkBlue + appSeparator + ColorReset)
 	fmt.Println()
-	fmt.Printf(ColorGreen + "Version: 1.0.0.07241752\n\n" + ColorReset)
+	fmt.Printf(ColorGreen+"Version: %s\n\n"+ColorReset, filesystemserver.Version)
 	fmt.Println(ColorGreen + "» Filesystem MCP Server «" + ColorReset)
 	fmt.Println()
 	fmt.Println(ColorGreen + "Configuration:" + ColorReset)
@@ -190,21 +452,49 @@ func showSplashScreen(config Config) {
 }
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "Validate config.toml, print a report and exit")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	var overrides configOverrides
+	overrides.registerFlags(flag.CommandLine)
+	flag.Parse()
+	overrides = overrides.over(overridesFromEnv(os.Getenv))
+
+	if *showVersion {
+		fmt.Println(filesystemserver.Version)
+		return
+	}
+
+	// Validate the configuration without starting the server
+	if *checkConfig {
+		os.Exit(runConfigCheck(os.Stdout, overrides))
+	}
+
 	// Load configuration from config.toml
-	config, err := loadConfig()
+	config, err := loadConfig(overrides)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
+	config.Server = withTransportDefaults(config.Server)
+	if err := validateTransport(config.Server); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid server configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize structured logger with file logging support
+	logLevel := new(slog.LevelVar)
+	logger, logFile, err := setupLogger(config, logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid logging configuration: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Show splash screen
 	showSplashScreen(config)
-
-	// Initialize structured logger with file logging support
-	logger := setupLogger(config)
+	defer closeLogFile(logFile)
 
 	// Log startup message
-	logger.Info("Starting application", "name", "Filesystem Server MCP", "version", "1.0.0.07241752", "pid", os.Getpid())
+	logger.Info("Starting application", "name", "Filesystem Server MCP", "version", filesystemserver.Version, "pid", os.Getpid())
 
 	// Validate that we have allowed directories from config
 	if len(config.Directories.Allowed) == 0 {
@@ -216,18 +506,176 @@ func main() {
 	logger.Info("Configuration loaded", "directories", config.Directories.Allowed)
 
 	// Create and start the server
-	fss, err := filesystemserver.NewFilesystemServer(config.Directories.Allowed)
+	opts, err := handlerOptions(config)
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		closeLogFile(logFile)
+		os.Exit(1)
+	}
+	opts = append(opts, handler.WithLogger(logger))
+
+	// reload_config, SIGHUP and the config file watch re-read config.toml,
+	// applying the command-line overrides again; the log level follows it,
+	// but the rest of the logger and the audit log keep their startup
+	// settings
+	opts = append(opts, handler.WithConfigReload(func() ([]string, []handler.Option, error) {
+		config, err := loadConfig(overrides)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(config.Directories.Allowed) == 0 {
+			return nil, nil, fmt.Errorf("no allowed directories configured in config.toml")
+		}
+		opts, err := handlerOptions(config)
+		opts = append(opts, handler.WithLogLevel(logLevel, parseLogLevel(config.Logging.Level)))
+		return config.Directories.Allowed, opts, err
+	}))
+
+	// The audit log is kept apart from the application log and is written
+	// regardless of the log level
+	auditPath, err := auditFilePath(config)
+	if err != nil {
+		logger.Error("Failed to resolve audit log path", "error", err)
+		closeLogFile(logFile)
+		os.Exit(1)
+	}
+	var auditFile *os.File
+	if auditPath != "" {
+		if config.Audit.Format != "" && config.Audit.Format != "jsonl" {
+			logger.Error("Unsupported audit log format", "format", config.Audit.Format)
+			closeLogFile(logFile)
+			os.Exit(1)
+		}
+		auditFile, err = os.OpenFile(auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			logger.Error("Failed to open audit log", "path", auditPath, "error", err)
+			closeLogFile(logFile)
+			os.Exit(1)
+		}
+		defer closeLogFile(auditFile)
+		opts = append(opts, handler.WithAuditLog(auditFile))
+		logger.Info("Audit log enabled", "path", auditPath)
+	}
+
+	// The root health check runs for the lifetime of the process and keeps
+	// its startup interval across reloads
+	if config.Health.CheckInterval != "" {
+		interval, err := time.ParseDuration(config.Health.CheckInterval)
+		if err != nil || interval <= 0 {
+			logger.Error("Invalid health check interval", "check_interval", config.Health.CheckInterval)
+			closeLogFile(auditFile)
+			closeLogFile(logFile)
+			os.Exit(1)
+		}
+		opts = append(opts, handler.WithRootHealthCheck(interval))
+		logger.Info("Root health check enabled", "interval", interval)
+	}
+
+	// The content index, like the health check, is kept for the lifetime of
+	// the process; a reload changing the allowed directories is picked up by
+	// its next refresh
+	indexPath, err := indexFilePath(config)
+	if err != nil {
+		logger.Error("Failed to resolve content index path", "error", err)
+		closeLogFile(auditFile)
+		closeLogFile(logFile)
+		os.Exit(1)
+	}
+	if indexPath != "" {
+		var interval time.Duration
+		if config.Index.RefreshInterval != "" {
+			interval, err = time.ParseDuration(config.Index.RefreshInterval)
+			if err != nil || interval <= 0 {
+				logger.Error("Invalid content index refresh interval", "refresh_interval", config.Index.RefreshInterval)
+				closeLogFile(auditFile)
+				closeLogFile(logFile)
+				os.Exit(1)
+			}
+		}
+		opts = append(opts, handler.WithContentIndex(indexPath, interval))
+		logger.Info("Content index enabled", "path", indexPath, "refresh_interval", config.Index.RefreshInterval)
+	}
+
+	// Watching the config file is set up at startup only
+	var watchInterval time.Duration
+	if config.Reload.WatchInterval != "" {
+		watchInterval, err = time.ParseDuration(config.Reload.WatchInterval)
+		if err != nil || watchInterval <= 0 {
+			logger.Error("Invalid config watch interval", "watch_interval", config.Reload.WatchInterval)
+			closeLogFile(auditFile)
+			closeLogFile(logFile)
+			os.Exit(1)
+		}
+	}
+
+	fss, err := filesystemserver.New(config.Directories.Allowed, opts...)
 	if err != nil {
 		logger.Error("Failed to create server", "error", err)
+		closeLogFile(auditFile)
+		closeLogFile(logFile)
 		os.Exit(1)
 	}
 
+	// Stop serving on SIGINT/SIGTERM so that watchers and logs are cleaned up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Reload config.toml on SIGHUP, like the reload_config tool
+	reload := func(trigger string) {
+		if _, err := fss.Reload(); err != nil {
+			logger.Error("Failed to reload configuration", "trigger", trigger, "error", err)
+		}
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	go func() {
+		for range hangup {
+			reload("SIGHUP")
+		}
+	}()
+
+	// and, if configured, whenever the file changes
+	if watchInterval > 0 {
+		configPath, err := configFilePath(overrides)
+		if err != nil {
+			logger.Error("Failed to resolve config file path", "error", err)
+		} else {
+			go watchConfigFile(ctx, configPath, watchInterval, func() { reload("file change") })
+			logger.Info("Watching config file for changes", "path", configPath, "interval", watchInterval)
+		}
+	}
+
 	// Log server start
-	logger.Info("Starting MCP server", "name", "Filesystem Server MCP", "version", "1.0.0.07241752")
+	logger.Info("Starting MCP server", "name", "Filesystem Server MCP", "version", filesystemserver.Version, "transport", config.Server.Transport)
 
 	// Serve requests
-	if err := server.ServeStdio(fss); err != nil {
-		logger.Error("Server error", "error", err)
+	serveErr := serve(ctx, fss.MCPServer, config.Server, logger)
+
+	if ctx.Err() != nil {
+		logger.Info("Shutdown signal received, stopping MCP server")
+	}
+
+	// Release file watchers and other handler resources
+	if err := fss.Close(); err != nil {
+		logger.Error("Failed to release server resources", "error", err)
+	}
+
+	if serveErr != nil {
+		logger.Error("Server error", "error", serveErr)
+		closeLogFile(auditFile)
+		closeLogFile(logFile)
 		os.Exit(1)
 	}
+
+	logger.Info("Server stopped")
+}
+
+// closeLogFile flushes and closes a log file, if one is open
+func closeLogFile(logFile *os.File) {
+	if logFile == nil {
+		return
+	}
+	logFile.Sync()
+	logFile.Close()
 }
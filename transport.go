@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// DEFAULT_HTTP_ADDRESS is where the HTTP and SSE transports listen
+	// unless configured otherwise; only local clients can reach it
+	DEFAULT_HTTP_ADDRESS = "127.0.0.1:8080"
+	// DEFAULT_HTTP_BASE_PATH is the endpoint of the HTTP transport and the
+	// prefix of the SSE transport's endpoints
+	DEFAULT_HTTP_BASE_PATH = "/mcp"
+	// HTTP_SHUTDOWN_TIMEOUT bounds how long open HTTP requests may run after
+	// a shutdown signal
+	HTTP_SHUTDOWN_TIMEOUT = 10 * time.Second
+)
+
+// ServerConfig represents the transport the MCP server is served over
+type ServerConfig struct {
+	Transport      string   `toml:"transport"`
+	Address        string   `toml:"address"`
+	BasePath       string   `toml:"base_path"`
+	AllowedOrigins []string `toml:"allowed_origins"`
+}
+
+// withTransportDefaults fills in the defaults of the unset transport settings
+func withTransportDefaults(config ServerConfig) ServerConfig {
+	if config.Transport == "" {
+		config.Transport = "stdio"
+	}
+	if config.Address == "" {
+		config.Address = DEFAULT_HTTP_ADDRESS
+	}
+	if config.BasePath == "" {
+		config.BasePath = DEFAULT_HTTP_BASE_PATH
+	}
+	return config
+}
+
+// validateTransport checks settings already completed by withTransportDefaults
+func validateTransport(config ServerConfig) error {
+	switch config.Transport {
+	case "stdio", "http", "sse":
+	default:
+		return fmt.Errorf("server.transport: unknown transport %q (expected stdio, http or sse)", config.Transport)
+	}
+	if !strings.HasPrefix(config.BasePath, "/") {
+		return fmt.Errorf("server.base_path: %q must start with /", config.BasePath)
+	}
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return fmt.Errorf("server.allowed_origins: %q is not an origin such as https://example.com", origin)
+		}
+	}
+	return nil
+}
+
+// isLoopbackAddress reports whether a listen address only accepts local
+// clients
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	return err == nil && isLoopbackHost(host)
+}
+
+// isLoopbackHost reports whether host names the local machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkOrigin wraps the handler of the HTTP transports to refuse requests
+// sent by web pages. A browser lets any page post to a server on the local
+// machine, so requests with an Origin that is neither local nor in
+// allowedOrigins ("*" allows every origin) are refused. When the server
+// listens on a loopback address, requests whose Host is not a loopback name
+// are refused too, so that a page cannot reach the server through DNS
+// rebinding.
+func checkOrigin(handler http.Handler, config ServerConfig, logger *slog.Logger) http.Handler {
+	loopback := isLoopbackAddress(config.Address)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loopback {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+			if !isLoopbackHost(host) {
+				logger.Warn("Refusing HTTP request for a non-local host", "host", r.Host, "remote", r.RemoteAddr)
+				http.Error(w, "Forbidden: host not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !originAllowed(origin, config.AllowedOrigins) {
+			logger.Warn("Refusing HTTP request from a disallowed origin", "origin", origin, "remote", r.RemoteAddr)
+			http.Error(w, "Forbidden: origin not allowed", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether requests from a web page at origin may be
+// served
+func originAllowed(origin string, allowedOrigins []string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && isLoopbackHost(u.Hostname())
+}
+
+// serve serves the MCP server over the configured transport until ctx is
+// cancelled or the transport fails. Stopping because ctx was cancelled is
+// not an error.
+func serve(ctx context.Context, mcpServer *server.MCPServer, config ServerConfig, logger *slog.Logger) error {
+	switch config.Transport {
+	case "http":
+		mux := http.NewServeMux()
+		mux.Handle(config.BasePath, server.NewStreamableHTTPServer(mcpServer, server.WithEndpointPath(config.BasePath)))
+		httpServer := &http.Server{Addr: config.Address, Handler: checkOrigin(mux, config, logger)}
+		logger = logger.With("transport", "http", "endpoint", config.BasePath)
+		return serveHTTP(ctx, httpServer, httpServer.Shutdown, logger)
+	case "sse":
+		// The SSE server shuts the HTTP server down itself, after ending the
+		// event streams of its sessions
+		httpServer := &http.Server{Addr: config.Address}
+		sseServer := server.NewSSEServer(mcpServer, server.WithStaticBasePath(config.BasePath), server.WithHTTPServer(httpServer))
+		httpServer.Handler = checkOrigin(sseServer, config, logger)
+		logger = logger.With("transport", "sse", "sse_endpoint", sseServer.CompleteSsePath(), "message_endpoint", sseServer.CompleteMessagePath())
+		return serveHTTP(ctx, httpServer, sseServer.Shutdown, logger)
+	default:
+		err := server.NewStdioServer(mcpServer).Listen(ctx, os.Stdin, os.Stdout)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+}
+
+// serveHTTP runs httpServer until ctx is cancelled, then stops it with
+// shutdown. The requests in progress get HTTP_SHUTDOWN_TIMEOUT to finish.
+func serveHTTP(ctx context.Context, httpServer *http.Server, shutdown func(context.Context) error, logger *slog.Logger) error {
+	// Listen before serving so that an unusable address fails at startup
+	listener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("Listening for MCP clients", "address", listener.Addr().String())
+	if !isLoopbackAddress(httpServer.Addr) {
+		logger.Warn("Remote clients can reach the server and every allowed directory; the transport has no authentication of its own", "address", httpServer.Addr)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), HTTP_SHUTDOWN_TIMEOUT)
+	defer cancel()
+	if err := shutdown(shutdownCtx); err != nil {
+		// Listening streams stay open until the client leaves, so whatever
+		// outlives the timeout is cut off
+		logger.Warn("Closing HTTP connections still open after shutdown timeout", "timeout", HTTP_SHUTDOWN_TIMEOUT)
+		httpServer.Close()
+	}
+	<-errs
+	return nil
+}